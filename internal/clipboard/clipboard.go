@@ -0,0 +1,111 @@
+// Package clipboard copies text to the system clipboard, using native tools
+// where available and the OSC52 terminal escape sequence otherwise, which
+// also works over SSH. It can also read the clipboard with the native
+// tools.
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrUnavailable is returned when no clipboard mechanism could be used.
+var ErrUnavailable = errors.New("no clipboard mechanism available (tried OSC52, pbcopy, wl-copy, xclip, xsel)")
+
+// ErrPasteUnavailable is returned when no tool to read the clipboard is
+// installed.
+var ErrPasteUnavailable = errors.New("no tool to read the clipboard available (tried pbpaste, wl-paste, xclip, xsel)")
+
+// tool is a native clipboard command and the environment it needs.
+type tool struct {
+	name  string
+	args  []string
+	paste []string // command and arguments reading the clipboard
+	env   string   // required environment variable, if any
+}
+
+var tools = []tool{
+	{name: "pbcopy", paste: []string{"pbpaste"}},
+	{name: "wl-copy", paste: []string{"wl-paste", "--no-newline"}, env: "WAYLAND_DISPLAY"},
+	{name: "xclip", args: []string{"-selection", "clipboard"}, paste: []string{"xclip", "-selection", "clipboard", "-o"}, env: "DISPLAY"},
+	{name: "xsel", args: []string{"--clipboard", "--input"}, paste: []string{"xsel", "--clipboard", "--output"}, env: "DISPLAY"},
+}
+
+// Copy puts text on the clipboard. Over SSH the OSC52 sequence is written to
+// the controlling terminal so the text lands on the local machine; otherwise
+// a native tool is preferred, with OSC52 as the fallback.
+func Copy(text string) error {
+	if os.Getenv("SSH_TTY") == "" && os.Getenv("SSH_CONNECTION") == "" {
+		if t, ok := findTool(exec.LookPath, os.Getenv); ok {
+			return runTool(t, text)
+		}
+	}
+	return writeOSC52(text)
+}
+
+// Paste returns the text on the clipboard. Only the native tools can read
+// it; OSC52 reads are answered by too few terminals to rely on.
+func Paste() (string, error) {
+	t, ok := findTool(exec.LookPath, os.Getenv)
+	if !ok {
+		return "", ErrPasteUnavailable
+	}
+	cmd := exec.Command(t.paste[0], t.paste[1:]...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", t.paste[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// findTool returns the first native tool that is installed and usable in
+// the current environment.
+func findTool(lookPath func(string) (string, error), getenv func(string) string) (tool, bool) {
+	for _, t := range tools {
+		if t.env != "" && getenv(t.env) == "" {
+			continue
+		}
+		if _, err := lookPath(t.name); err == nil {
+			return t, true
+		}
+	}
+	return tool{}, false
+}
+
+func runTool(t tool, text string) error {
+	cmd := exec.Command(t.name, t.args...)
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", t.name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// writeOSC52 sends the sequence to /dev/tty rather than stdout so it reaches
+// the terminal even when the command itself is being piped.
+func writeOSC52(text string) error {
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return ErrUnavailable
+	}
+	defer tty.Close()
+
+	_, err = tty.WriteString(osc52Sequence(text))
+	return err
+}
+
+// osc52Sequence builds the escape sequence that sets the clipboard selection.
+// Inside tmux the sequence is wrapped in a DCS passthrough.
+func osc52Sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
+	}
+	return seq
+}
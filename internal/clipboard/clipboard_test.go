@@ -0,0 +1,76 @@
+package clipboard
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestOSC52Sequence tests the escape sequence encoding
+func TestOSC52Sequence(t *testing.T) {
+	tests := []struct {
+		name     string
+		tmux     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "plain terminal",
+			text:     "ls -la",
+			expected: "\x1b]52;c;bHMgLWxh\a",
+		},
+		{
+			name:     "inside tmux",
+			tmux:     "/tmp/tmux-1000/default,1,0",
+			text:     "ls -la",
+			expected: "\x1bPtmux;\x1b\x1b]52;c;bHMgLWxh\a\x1b\\",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", tt.tmux)
+			if got := osc52Sequence(tt.text); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestFindTool tests native clipboard tool detection
+func TestFindTool(t *testing.T) {
+	tests := []struct {
+		name      string
+		installed []string
+		env       map[string]string
+		expected  string
+	}{
+		{name: "pbcopy", installed: []string{"pbcopy", "xclip"}, env: map[string]string{"DISPLAY": ":0"}, expected: "pbcopy"},
+		{name: "wayland", installed: []string{"wl-copy", "xclip"}, env: map[string]string{"WAYLAND_DISPLAY": "wayland-0", "DISPLAY": ":0"}, expected: "wl-copy"},
+		{name: "wl-copy needs wayland", installed: []string{"wl-copy", "xclip"}, env: map[string]string{"DISPLAY": ":0"}, expected: "xclip"},
+		{name: "xsel", installed: []string{"xsel"}, env: map[string]string{"DISPLAY": ":0"}, expected: "xsel"},
+		{name: "x11 tools need a display", installed: []string{"xclip", "xsel"}},
+		{name: "nothing installed", env: map[string]string{"DISPLAY": ":0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lookPath := func(name string) (string, error) {
+				for _, installed := range tt.installed {
+					if installed == name {
+						return "/usr/bin/" + name, nil
+					}
+				}
+				return "", errors.New("not found")
+			}
+			getenv := func(key string) string { return tt.env[key] }
+
+			got, ok := findTool(lookPath, getenv)
+			if ok != (tt.expected != "") {
+				t.Fatalf("Expected found=%v, got %v (%q)", tt.expected != "", ok, got.name)
+			}
+			if got.name != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got.name)
+			}
+		})
+	}
+}
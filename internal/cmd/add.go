@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"maps"
+	"os"
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
+	"github.com/samling/command-snippets/internal/clipboard"
 	"github.com/samling/command-snippets/internal/models"
 
 	"github.com/AlecAivazis/survey/v2"
@@ -23,37 +27,84 @@ All variables must be explicitly defined in the template. You can use:
 - Inline transforms for simple transformations
 - Transform templates for reusable transformation logic
 
+With --from, the command prompt starts out with an existing command line,
+and you pick which of its words become <placeholders> first; each one's
+original text is offered as the variable's default. --from-clipboard does
+the same with the command on the clipboard.
+
 Examples:
-  cs add                         # Interactive template creation`,
+  cs add                         # Interactive template creation
+  cs add --from 'kubectl get pods -n kube-system -o wide'
+  cs add --from-clipboard        # Start from the copied command`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAdd()
+			from, _ := cmd.Flags().GetString("from")
+			if fromClipboard, _ := cmd.Flags().GetBool("from-clipboard"); fromClipboard {
+				text, err := clipboard.Paste()
+				if err != nil {
+					return fmt.Errorf("failed to read the clipboard: %w", err)
+				}
+				if from = strings.TrimSpace(text); from == "" {
+					return fmt.Errorf("the clipboard is empty")
+				}
+			}
+			return runAdd(from)
 		},
 	}
 
+	cmd.Flags().String("from", "", "Start from an existing command line")
+	cmd.Flags().Bool("from-clipboard", false, "Start from the command on the clipboard")
+	cmd.MarkFlagsMutuallyExclusive("from", "from-clipboard")
+
 	return cmd
 }
 
-func runAdd() error {
-	snippet, err := promptForSnippet()
+func runAdd(from string) error {
+	name, err := addSnippet(from)
 	if err != nil {
-		return fmt.Errorf("failed to create template: %w", err)
+		return err
 	}
 
+	fmt.Printf("✅ Command template '%s' added successfully!\n", name)
+	return nil
+}
+
+// addSnippet prompts for a new snippet, adds it to the config file, and
+// returns its name. A non-empty from is an existing command line to start
+// the command from. opts are passed to every prompt.
+func addSnippet(from string, opts ...survey.AskOpt) (string, error) {
+	snippet, err := promptForSnippet(from, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to create template: %w", err)
+	}
+
+	now := time.Now()
+	snippet.CreatedAt, snippet.UpdatedAt = now, now
+	snippet.Source, snippet.SourceFile = models.SourceGlobal, cfgFile
+
 	// Add to config
+	if config.Snippets == nil {
+		config.Snippets = make(map[string]models.Snippet)
+	}
+	previous := snippetFiles(snippet.Name)
 	config.Snippets[snippet.Name] = *snippet
 
-	// Save config
-	if err := saveConfig(config, cfgFile); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	if err := saveSnippets(previous, snippet.Name); err != nil {
+		return "", err
 	}
-
-	fmt.Printf("✅ Command template '%s' added successfully!\n", snippet.Name)
-	return nil
+	return snippet.Name, nil
 }
 
-func promptForSnippet() (*models.Snippet, error) {
+func promptForSnippet(from string, opts ...survey.AskOpt) (*models.Snippet, error) {
 	snippet := &models.Snippet{}
 
+	command, defaults := from, map[string]string(nil)
+	if from != "" {
+		var err error
+		if command, defaults, err = promptForPlaceholders(from, opts...); err != nil {
+			return nil, err
+		}
+	}
+
 	// Prompt for basic information
 	questions := []*survey.Question{
 		{
@@ -67,7 +118,7 @@ func promptForSnippet() (*models.Snippet, error) {
 		},
 		{
 			Name:     "command",
-			Prompt:   &survey.Input{Message: "Command template (use <variable> syntax):"},
+			Prompt:   &survey.Input{Message: "Command template (use <variable> syntax):", Default: command},
 			Validate: survey.Required,
 		},
 		{
@@ -83,7 +134,7 @@ func promptForSnippet() (*models.Snippet, error) {
 		Tags        string
 	}{}
 
-	if err := survey.Ask(questions, &answers); err != nil {
+	if err := survey.Ask(questions, &answers, opts...); err != nil {
 		return nil, err
 	}
 
@@ -104,7 +155,7 @@ func promptForSnippet() (*models.Snippet, error) {
 
 	// Prompt for variable configuration (all variables must be explicitly defined)
 	for _, varName := range variables {
-		variable, err := promptForVariable(varName)
+		variable, err := promptForVariable(varName, defaults[varName], opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -114,22 +165,191 @@ func promptForSnippet() (*models.Snippet, error) {
 	return snippet, nil
 }
 
-var varTokenPattern = regexp.MustCompile(`<([A-Za-z_][A-Za-z0-9_]*)>`)
+// varTokenPattern matches <name> placeholders; an escaped <<name>> matches
+// with an empty name so it is skipped.
+var varTokenPattern = regexp.MustCompile(`<<[A-Za-z_][A-Za-z0-9_]*>>|<([A-Za-z_][A-Za-z0-9_]*)>`)
 
 func extractVariablesFromCommand(command string) []string {
 	matches := varTokenPattern.FindAllStringSubmatch(command, -1)
 	variables := make([]string, 0, len(matches))
 	for _, m := range matches {
 		name := m[1]
-		if !slices.Contains(variables, name) {
+		if name != "" && !slices.Contains(variables, name) {
 			variables = append(variables, name)
 		}
 	}
 	return variables
 }
 
-func promptForVariable(varName string) (*models.Variable, error) {
-	fmt.Printf("\nConfiguring variable: %s\n", varName)
+// promptForPlaceholders lets the user pick the words of command to turn
+// into placeholders and name them. It returns the resulting template and
+// each new variable's default, the word it replaced.
+func promptForPlaceholders(command string, opts ...survey.AskOpt) (string, map[string]string, error) {
+	tokens := commandTokens(command)
+	if len(tokens) == 0 {
+		return command, nil, nil
+	}
+	options := make([]string, len(tokens))
+	for i, t := range tokens {
+		options[i] = fmt.Sprintf("%d: %s", i+1, t.text)
+	}
+
+	var selected []int
+	if err := survey.AskOne(&survey.MultiSelect{
+		Message:  "Select words to turn into <placeholders>:",
+		Options:  options,
+		PageSize: 15,
+	}, &selected, opts...); err != nil {
+		return "", nil, err
+	}
+
+	names := make(map[int]string, len(selected))
+	defaults := make(map[string]string, len(selected))
+	for _, i := range selected {
+		value := tokens[i].value()
+		var name string
+		if err := survey.AskOne(&survey.Input{
+			Message: fmt.Sprintf("Variable name for '%s':", value),
+			Default: suggestVariableName(tokens, i, defaults),
+		}, &name, append(slices.Clip(opts), survey.WithValidator(func(ans any) error {
+			name, _ := ans.(string)
+			if !variableNamePattern.MatchString(name) {
+				return fmt.Errorf("use letters, digits, and underscores, not starting with a digit")
+			}
+			if _, ok := defaults[name]; ok {
+				return fmt.Errorf("'%s' is already used", name)
+			}
+			return nil
+		}))...); err != nil {
+			return "", nil, err
+		}
+		names[i] = name
+		defaults[name] = value
+	}
+	return parameterizeCommand(command, tokens, names), defaults, nil
+}
+
+// commandToken is a shell word of a command line; start and end are its
+// byte offsets in the line.
+type commandToken struct {
+	text       string
+	start, end int
+}
+
+// commandTokens splits command into shell words. Quotes and backslashes
+// are kept in each word's text but stop whitespace from splitting it.
+func commandTokens(command string) []commandToken {
+	var tokens []commandToken
+	start := -1
+	var quote byte
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+			continue
+		case c == ' ' || c == '\t' || c == '\n':
+			if start >= 0 {
+				tokens = append(tokens, commandToken{command[start:i], start, i})
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		switch c {
+		case '\'', '"':
+			quote = c
+		case '\\':
+			i++
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, commandToken{command[start:], start, len(command)})
+	}
+	return tokens
+}
+
+// valueOffset returns where the part of t a placeholder replaces starts:
+// after the = of a --flag=value word, otherwise at the start of the word.
+func (t commandToken) valueOffset() int {
+	if strings.HasPrefix(t.text, "-") {
+		if i := strings.IndexByte(t.text, '='); i > 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// value returns the part of t a placeholder replaces, with one layer of
+// surrounding quotes removed.
+func (t commandToken) value() string {
+	v := t.text[t.valueOffset():]
+	if len(v) >= 2 && (v[0] == '\'' || v[0] == '"') && v[len(v)-1] == v[0] {
+		v = v[1 : len(v)-1]
+	}
+	return v
+}
+
+// variableNamePattern matches valid variable names.
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// suggestVariableName proposes a name for a placeholder replacing
+// tokens[i]: the long flag it is the value of, else the word itself made
+// into a valid name, else arg<N>. Names in used get a number appended.
+func suggestVariableName(tokens []commandToken, i int, used map[string]string) string {
+	t := tokens[i]
+	var name string
+	switch {
+	case t.valueOffset() > 0:
+		name = t.text[:t.valueOffset()-1]
+	case i > 0 && strings.HasPrefix(tokens[i-1].text, "--") && !strings.Contains(tokens[i-1].text, "="):
+		name = tokens[i-1].text
+	default:
+		name = t.value()
+	}
+	name = strings.Trim(nonNameChars.ReplaceAllString(strings.TrimLeft(name, "-"), "_"), "_")
+	if !variableNamePattern.MatchString(name) {
+		name = fmt.Sprintf("arg%d", i)
+	}
+	suggestion := name
+	for n := 2; ; n++ {
+		if _, ok := used[suggestion]; !ok {
+			return suggestion
+		}
+		suggestion = fmt.Sprintf("%s%d", name, n)
+	}
+}
+
+// nonNameChars matches runs of characters not allowed in variable names.
+var nonNameChars = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// parameterizeCommand replaces the tokens of command named in names, by
+// index, with placeholders, leaving everything else as it was.
+func parameterizeCommand(command string, tokens []commandToken, names map[int]string) string {
+	var b strings.Builder
+	last := 0
+	for i, t := range tokens {
+		name, ok := names[i]
+		if !ok {
+			continue
+		}
+		start := t.start + t.valueOffset()
+		b.WriteString(command[last:start])
+		b.WriteString("<" + name + ">")
+		last = t.end
+	}
+	b.WriteString(command[last:])
+	return b.String()
+}
+
+func promptForVariable(varName, defaultValue string, opts ...survey.AskOpt) (*models.Variable, error) {
+	fmt.Fprintf(promptOutput(opts), "\nConfiguring variable: %s\n", varName)
 
 	variable := &models.Variable{
 		Name: varName,
@@ -142,7 +362,7 @@ func promptForVariable(varName string) (*models.Variable, error) {
 		},
 		{
 			Name:   "default",
-			Prompt: &survey.Input{Message: "Default value:"},
+			Prompt: &survey.Input{Message: "Default value:", Default: defaultValue},
 		},
 		{
 			Name:   "required",
@@ -156,7 +376,7 @@ func promptForVariable(varName string) (*models.Variable, error) {
 		Required    bool
 	}{}
 
-	if err := survey.Ask(questions, &answers); err != nil {
+	if err := survey.Ask(questions, &answers, opts...); err != nil {
 		return nil, err
 	}
 
@@ -171,7 +391,7 @@ func promptForVariable(varName string) (*models.Variable, error) {
 		Message: "Transformation type:",
 		Options: transformOptions,
 		Default: "None",
-	}, &transformChoice); err != nil {
+	}, &transformChoice, opts...); err != nil {
 		return nil, err
 	}
 
@@ -179,8 +399,8 @@ func promptForVariable(varName string) (*models.Variable, error) {
 	case "Transform template":
 		// Show available transform templates
 		if len(config.TransformTemplates) == 0 {
-			fmt.Println("No transform templates available. Creating inline transform instead.")
-			t, err := promptForInlineTransform()
+			fmt.Fprintln(promptOutput(opts), "No transform templates available. Creating inline transform instead.")
+			t, err := promptForInlineTransform(opts...)
 			if err != nil {
 				return nil, err
 			}
@@ -192,14 +412,14 @@ func promptForVariable(varName string) (*models.Variable, error) {
 			if err := survey.AskOne(&survey.Select{
 				Message: "Select transform template:",
 				Options: templates,
-			}, &selectedTemplate); err != nil {
+			}, &selectedTemplate, opts...); err != nil {
 				return nil, err
 			}
 			variable.TransformTemplate = selectedTemplate
 		}
 
 	case "Inline transform":
-		t, err := promptForInlineTransform()
+		t, err := promptForInlineTransform(opts...)
 		if err != nil {
 			return nil, err
 		}
@@ -209,7 +429,7 @@ func promptForVariable(varName string) (*models.Variable, error) {
 	return variable, nil
 }
 
-func promptForInlineTransform() (*models.Transform, error) {
+func promptForInlineTransform(opts ...survey.AskOpt) (*models.Transform, error) {
 	transform := &models.Transform{}
 
 	transformQuestions := []*survey.Question{
@@ -228,7 +448,7 @@ func promptForInlineTransform() (*models.Transform, error) {
 		ValuePattern string
 	}{}
 
-	if err := survey.Ask(transformQuestions, &transformAnswers); err != nil {
+	if err := survey.Ask(transformQuestions, &transformAnswers, opts...); err != nil {
 		return nil, err
 	}
 
@@ -237,3 +457,16 @@ func promptForInlineTransform() (*models.Transform, error) {
 
 	return transform, nil
 }
+
+// promptOutput returns the writer opts direct survey's prompts to, so that
+// messages between prompts go to the same place.
+func promptOutput(opts []survey.AskOpt) io.Writer {
+	options := survey.AskOptions{}
+	for _, opt := range opts {
+		_ = opt(&options)
+	}
+	if options.Stdio.Out == nil {
+		return os.Stdout
+	}
+	return options.Stdio.Out
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParameterizeCommand tests turning words of an existing command line
+// into placeholders for cs add --from
+func TestParameterizeCommand(t *testing.T) {
+	command := `kubectl get pods -n kube-system  --selector="app=web server" -o 'wide' --all-namespaces`
+	tokens := commandTokens(command)
+	var words []string
+	for _, tok := range tokens {
+		words = append(words, tok.text)
+	}
+	want := []string{"kubectl", "get", "pods", "-n", "kube-system", `--selector="app=web server"`, "-o", "'wide'", "--all-namespaces"}
+	if !reflect.DeepEqual(words, want) {
+		t.Fatalf("Expected words %q, got %q", want, words)
+	}
+	if got := commandTokens(`echo a\ b "say \"hi\""`); len(got) != 3 || got[1].text != `a\ b` || got[2].text != `"say \"hi\""` {
+		t.Errorf("Expected escapes to keep words together, got %+v", got)
+	}
+
+	if got := tokens[5].value(); got != "app=web server" {
+		t.Errorf("Expected the flag value without quotes, got %q", got)
+	}
+	if got := tokens[7].value(); got != "wide" {
+		t.Errorf("Expected the word without quotes, got %q", got)
+	}
+
+	used := map[string]string{}
+	for _, tc := range []struct {
+		index int
+		want  string
+	}{
+		{4, "kube_system"},
+		{5, "selector"},
+		{7, "wide"},
+		{2, "pods"},
+		{3, "n"},
+	} {
+		if got := suggestVariableName(tokens, tc.index, used); got != tc.want {
+			t.Errorf("suggestVariableName(%d) = %q, want %q", tc.index, got, tc.want)
+		}
+	}
+	if got := suggestVariableName(commandTokens("sleep 10"), 1, nil); got != "arg1" {
+		t.Errorf("Expected a positional name for a number, got %q", got)
+	}
+	used["pods"] = "pods"
+	if got := suggestVariableName(tokens, 2, used); got != "pods2" {
+		t.Errorf("Expected a taken name to get a number, got %q", got)
+	}
+
+	got := parameterizeCommand(command, tokens, map[int]string{4: "namespace", 5: "selector", 7: "output"})
+	wantCommand := `kubectl get pods -n <namespace>  --selector=<selector> -o <output> --all-namespaces`
+	if got != wantCommand {
+		t.Errorf("Expected %q, got %q", wantCommand, got)
+	}
+	if vars := extractVariablesFromCommand(got); !reflect.DeepEqual(vars, []string{"namespace", "selector", "output"}) {
+		t.Errorf("Expected the placeholders to be found again, got %v", vars)
+	}
+}
@@ -1,14 +1,78 @@
 package cmd
 
 import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"maps"
+	"os"
 	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/state"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// Sort orders accepted by `cs list --sort`, `cs exec --sort`, and the
+// selector settings. "alpha" is accepted as a synonym for "name".
+const (
+	sortByName    = "name"
+	sortByUsage   = "usage"
+	sortByRecent  = "recent"
+	sortByCreated = "created"
+	sortByUpdated = "updated"
 )
 
+// parseSortMode validates a user-supplied sort order.
+func parseSortMode(s string) (string, error) {
+	switch s {
+	case sortByName, "alpha", "":
+		return sortByName, nil
+	case sortByUsage, sortByRecent, sortByCreated, sortByUpdated:
+		return s, nil
+	}
+	return "", fmt.Errorf("invalid sort order '%s' (expected name, usage, recent, created, or updated)", s)
+}
+
+// resolveSnippetName returns the snippet name that name, possibly an
+// alias, refers to; unknown names are returned unchanged for getSnippet to
+// report.
+func resolveSnippetName(name string) string {
+	if resolved, ok := config.ResolveSnippetName(name); ok {
+		return resolved
+	}
+	return name
+}
+
+// completeSnippetNames offers snippet names and aliases for the first
+// argument of commands that take a snippet.
+func completeSnippetNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || config == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for name, snippet := range config.Snippets {
+		for _, candidate := range append([]string{name}, snippet.Aliases...) {
+			if strings.HasPrefix(candidate, toComplete) && !slices.Contains(names, candidate) {
+				names = append(names, candidate)
+			}
+		}
+	}
+	slices.Sort(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
 // getSnippet looks up a snippet by name in the loaded config.
 func getSnippet(name string) (models.Snippet, error) {
 	snippet, exists := config.Snippets[name]
@@ -18,11 +82,74 @@ func getSnippet(name string) (models.Snippet, error) {
 	return snippet, nil
 }
 
+// findSnippet looks up a snippet by name or alias as given on the command
+// line, returning its name. When there is none, similar names and aliases
+// are suggested: in a terminal the user is asked whether to use the best
+// one, otherwise they are listed in the error.
+func findSnippet(name string) (string, models.Snippet, error) {
+	resolved := resolveSnippetName(name)
+	if snippet, ok := config.Snippets[resolved]; ok {
+		return resolved, snippet, nil
+	}
+
+	var candidates []string
+	for name, snippet := range config.Snippets {
+		candidates = append(candidates, name)
+		candidates = append(candidates, snippet.Aliases...)
+	}
+	suggestions := similarNames(name, candidates, maxNameSuggestions)
+	if len(suggestions) == 0 {
+		return "", models.Snippet{}, fmt.Errorf("template '%s' not found", name)
+	}
+
+	if canConfirm() {
+		fmt.Fprintf(os.Stderr, "Template '%s' not found.\n", name)
+		proceed, err := askConfirm(fmt.Sprintf("Did you mean '%s'?", suggestions[0]), false)
+		if err != nil {
+			return "", models.Snippet{}, err
+		}
+		if proceed {
+			resolved = resolveSnippetName(suggestions[0])
+			return resolved, config.Snippets[resolved], nil
+		}
+	}
+	return "", models.Snippet{}, fmt.Errorf("template '%s' not found (did you mean: %s?)", name, strings.Join(suggestions, ", "))
+}
+
+// canConfirm reports whether askConfirm can ask: stdin and stderr are both
+// terminals.
+func canConfirm() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// askConfirm asks a yes/no question on stderr, keeping stdout clean for
+// command output. Ctrl+C is a UserCancellationError.
+func askConfirm(message string, def bool) (bool, error) {
+	var answer bool
+	prompt := &survey.Confirm{Message: message, Default: def}
+	if err := survey.AskOne(prompt, &answer, survey.WithStdio(os.Stdin, os.Stderr, os.Stderr)); err != nil {
+		if errors.Is(err, terminal.InterruptErr) {
+			return false, &UserCancellationError{"user cancelled"}
+		}
+		return false, err
+	}
+	return answer, nil
+}
+
+// favoriteMarker prefixes favorite snippets in summaries.
+const favoriteMarker = "★ "
+
+// localBadge follows snippets from .csnippets in the selectors.
+const localBadge = " [local]"
+
 // snippetSummary renders "name - description [tag1, tag2]" suitable for
 // list output, search results, and selector menus. Description and tags
-// are omitted when empty.
+// are omitted when empty; favorites are prefixed with a star.
 func snippetSummary(name string, s *models.Snippet) string {
 	var b strings.Builder
+	if s.Favorite {
+		b.WriteString(favoriteMarker)
+	}
 	b.WriteString(name)
 	if s.Description != "" {
 		b.WriteString(" - ")
@@ -36,16 +163,417 @@ func snippetSummary(name string, s *models.Snippet) string {
 	return b.String()
 }
 
-// buildSnippetOptions returns the snippet display strings (alphabetical) and
-// the reverse lookup from display string back to snippet name. Used by both
-// the external (fzf) and internal selectors.
-func buildSnippetOptions(snippets map[string]*models.Snippet) (options []string, byDisplay map[string]string) {
+// buildSnippetOptions returns the snippet display strings, ordered by the
+// given sort mode, and the reverse lookup from display string back to
+// snippet name. Used by both the external (fzf) and internal selectors.
+// Local snippets are marked with a badge.
+func buildSnippetOptions(snippets map[string]*models.Snippet, sortBy string) (options []string, byDisplay map[string]string) {
 	byDisplay = make(map[string]string, len(snippets))
 	options = make([]string, 0, len(snippets))
-	for _, name := range slices.Sorted(maps.Keys(snippets)) {
+	for _, name := range sortSnippetNames(slices.Collect(maps.Keys(snippets)), sortBy) {
 		display := snippetSummary(name, snippets[name])
+		if snippets[name].Source == models.SourceLocal {
+			display += localBadge
+		}
 		options = append(options, display)
 		byDisplay[display] = name
 	}
 	return options, byDisplay
 }
+
+// snippetOutput is the structured form of a snippet for list and search
+// --output.
+type snippetOutput struct {
+	ID          string           `yaml:"id"`
+	Name        string           `yaml:"name"`
+	Description string           `yaml:"description"`
+	Command     string           `yaml:"command"`
+	Tags        []string         `yaml:"tags"`
+	Aliases     []string         `yaml:"aliases,omitempty"`
+	Source      string           `yaml:"source"`
+	Favorite    bool             `yaml:"favorite,omitempty"`
+	Variables   []variableOutput `yaml:"variables"`
+	CreatedAt   time.Time        `yaml:"created_at,omitempty"`
+	UpdatedAt   time.Time        `yaml:"updated_at,omitempty"`
+	Matched     string           `yaml:"matched,omitempty"` // search only
+	Score       int              `yaml:"score,omitempty"`   // search only
+}
+
+// variableOutput summarizes a variable in snippetOutput.
+type variableOutput struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+	Type        string `yaml:"type,omitempty"`
+	Default     string `yaml:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty"`
+	Computed    bool   `yaml:"computed,omitempty"`
+}
+
+// newSnippetOutput returns the structured form of the snippet with key id.
+func newSnippetOutput(id string, s *models.Snippet) snippetOutput {
+	out := snippetOutput{
+		ID:          id,
+		Name:        cmp.Or(s.Name, id),
+		Description: s.Description,
+		Command:     strings.Join(s.Steps(), models.StepSeparator(config)),
+		Tags:        append([]string{}, s.Tags...),
+		Aliases:     s.Aliases,
+		Source:      string(cmp.Or(s.Source, models.SourceGlobal)),
+		Favorite:    s.Favorite,
+		Variables:   make([]variableOutput, len(s.Variables)),
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	for i, v := range s.Variables {
+		out.Variables[i] = variableOutput{
+			Name:        v.Name,
+			Description: v.Description,
+			Type:        v.Type,
+			Default:     v.DefaultValue,
+			Required:    v.Required,
+			Computed:    v.Computed,
+		}
+	}
+	return out
+}
+
+// writeSnippetOutputs writes snippets to w in format, sorted by id so the
+// output is stable.
+func writeSnippetOutputs(w io.Writer, snippets []snippetOutput, format string) error {
+	slices.SortFunc(snippets, func(a, b snippetOutput) int { return cmp.Compare(a.ID, b.ID) })
+	if snippets == nil {
+		snippets = []snippetOutput{}
+	}
+	data, err := marshalOutput(snippets, format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// checkListOutput reports an --output value list and search don't accept.
+func checkListOutput(output string) error {
+	switch output {
+	case outputText, outputJSON, outputYAML:
+		return nil
+	}
+	return usageErrorf("invalid --output value '%s' (expected text, json, or yaml)", output)
+}
+
+// marshalOutput encodes v as "yaml" or "json". JSON is derived from the YAML
+// encoding so both formats share the same field names and omitempty rules.
+func marshalOutput(v any, format string) ([]byte, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal output: %w", err)
+	}
+
+	switch format {
+	case "yaml":
+		return data, nil
+	case "json":
+		var generic any
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, fmt.Errorf("failed to marshal output: %w", err)
+		}
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // keep <placeholders> readable
+		if err := enc.Encode(generic); err != nil {
+			return nil, fmt.Errorf("failed to marshal output: %w", err)
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format '%s' (expected yaml or json)", format)
+	}
+}
+
+// sortSnippetNames sorts names in place by the given mode, as
+// sortNamedSnippets does, and returns them.
+func sortSnippetNames(names []string, sortBy string) []string {
+	snippets := make([]namedSnippet, len(names))
+	for i, name := range names {
+		snippets[i] = namedSnippet{name, config.Snippets[name]}
+	}
+	sortNamedSnippets(snippets, sortBy, false)
+	for i, s := range snippets {
+		names[i] = s.name
+	}
+	return names
+}
+
+// namedSnippet is a snippet with its key in Config.Snippets.
+type namedSnippet struct {
+	name    string
+	snippet models.Snippet
+}
+
+// sortNamedSnippets sorts snippets by the given mode. Favorites always come
+// first. Within that, "usage" puts the most-executed snippets first,
+// "recent" the most recently executed, and "created" and "updated" the
+// newest by those timestamps, with snippets that have none last; ties,
+// never-used snippets, and every other mode fall back to alphabetical
+// order. reverse turns the order around, except that favorites and
+// snippets without a timestamp stay where they are.
+func sortNamedSnippets(snippets []namedSnippet, sortBy string, reverse bool) {
+	var usage *state.Usage
+	if sortBy == sortByUsage || sortBy == sortByRecent {
+		usage = loadUsage()
+	}
+
+	// compareTimes puts the later of a and b first and zero times last,
+	// reporting whether exactly one was zero.
+	compareTimes := func(a, b time.Time) (int, bool) {
+		if a.IsZero() != b.IsZero() {
+			if a.IsZero() {
+				return 1, true
+			}
+			return -1, true
+		}
+		return b.Compare(a), false
+	}
+
+	slices.SortFunc(snippets, func(a, b namedSnippet) int {
+		if a.snippet.Favorite != b.snippet.Favorite {
+			if a.snippet.Favorite {
+				return -1
+			}
+			return 1
+		}
+		var c int
+		switch sortBy {
+		case sortByUsage:
+			c = cmp.Compare(usage.Snippets[b.name].Count, usage.Snippets[a.name].Count)
+		case sortByRecent:
+			c = usage.Snippets[b.name].LastUsed.Compare(usage.Snippets[a.name].LastUsed)
+		case sortByCreated, sortByUpdated:
+			ta, tb := a.snippet.CreatedAt, b.snippet.CreatedAt
+			if sortBy == sortByUpdated {
+				ta, tb = a.snippet.UpdatedAt, b.snippet.UpdatedAt
+			}
+			var undated bool
+			if c, undated = compareTimes(ta, tb); undated {
+				return c
+			}
+		}
+		c = cmp.Or(c, cmp.Compare(a.name, b.name))
+		if reverse {
+			return -c
+		}
+		return c
+	})
+}
+
+// formatTimeAgo renders the time elapsed since t compactly, e.g. "2d ago".
+func formatTimeAgo(t, now time.Time) string {
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd ago", int(d/(24*time.Hour)))
+	}
+}
+
+// loadUsage reads the usage state file. Problems are reported on stderr and
+// result in empty usage rather than an error, since usage is best-effort.
+func loadUsage() *state.Usage {
+	usage := &state.Usage{Snippets: make(map[string]state.UsageEntry)}
+	path, err := state.UsagePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not locate usage file: %v\n", err)
+		return usage
+	}
+	loaded, err := state.LoadUsage(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return loaded
+}
+
+// recordUsage counts one execution of the named snippet.
+func recordUsage(name string) {
+	path, err := state.UsagePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not locate usage file: %v\n", err)
+		return
+	}
+	usage := loadUsage()
+	usage.Record(name, time.Now())
+	if err := usage.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not save usage: %v\n", err)
+	}
+}
+
+// renameUsage moves the usage recorded for a renamed snippet to its new
+// name. Failures are reported on stderr, like recordUsage's.
+func renameUsage(oldName, newName string) {
+	path, err := state.UsagePath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not locate usage file: %v\n", err)
+		return
+	}
+	usage := loadUsage()
+	if !usage.Rename(oldName, newName) {
+		return
+	}
+	if err := usage.Save(path); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not save usage: %v\n", err)
+	}
+}
+
+// closestName returns the candidate nearest to name by edit distance, or ""
+// when none is close enough to be a likely typo: within a third of name's
+// length in characters, and at least 2.
+func closestName(name string, candidates []string) string {
+	best, bestDist := "", max(2, utf8.RuneCountInString(name)/3)+1
+	for _, c := range candidates {
+		if d := levenshtein(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// maxNameSuggestions caps the names offered when a snippet is not found.
+const maxNameSuggestions = 3
+
+// similarNames returns up to limit candidates that name may have been meant
+// as, best first: those name is a prefix of (or that are a prefix of
+// name), then those containing it, then those within closestName's edit
+// distance. Case is ignored.
+func similarNames(name string, candidates []string, limit int) []string {
+	type match struct {
+		name       string
+		rank, dist int
+	}
+	lower := strings.ToLower(name)
+	var matches []match
+	for _, c := range candidates {
+		lc := strings.ToLower(c)
+		dist := levenshtein(lower, lc)
+		switch {
+		case lc == lower:
+			continue
+		case strings.HasPrefix(lc, lower) || strings.HasPrefix(lower, lc):
+			matches = append(matches, match{c, 0, dist})
+		case strings.Contains(lc, lower):
+			matches = append(matches, match{c, 1, dist})
+		case dist <= max(2, utf8.RuneCountInString(name)/3):
+			matches = append(matches, match{c, 2, dist})
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(cmp.Compare(a.rank, b.rank), cmp.Compare(a.dist, b.dist), cmp.Compare(a.name, b.name))
+	})
+
+	var names []string
+	for _, m := range matches[:min(limit, len(matches))] {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// levenshtein returns the number of single-character insertions, deletions,
+// and substitutions needed to turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// hasAnyTag checks if any of the filterTags exist in the snippet tags (case-insensitive).
+func hasAnyTag(snippetTags, filterTags []string) bool {
+	for _, filterTag := range filterTags {
+		if slices.ContainsFunc(snippetTags, func(t string) bool { return strings.EqualFold(t, filterTag) }) {
+			return true
+		}
+	}
+	return false
+}
+
+// tagNames maps each tag in tagLists, lowercased, to the spelling shown for
+// it: the one used most, or the first in sort order on a tie. Tags are
+// compared ignoring case everywhere, so "Docker" and "docker" are one tag.
+func tagNames(tagLists ...[]string) map[string]string {
+	uses := make(map[string]int)
+	for _, tags := range tagLists {
+		for _, tag := range tags {
+			uses[tag]++
+		}
+	}
+	names := make(map[string]string)
+	for _, tag := range slices.Sorted(maps.Keys(uses)) {
+		key := strings.ToLower(tag)
+		if name, ok := names[key]; !ok || uses[tag] > uses[name] {
+			names[key] = tag
+		}
+	}
+	return names
+}
+
+// tagFilter selects snippets by tag, from the --tags and --all-tags flags:
+// those with any of tags, or with all of them when all is set. An empty
+// filter selects every snippet.
+type tagFilter struct {
+	tags []string
+	all  bool
+}
+
+// addFlags adds --tags and --all-tags to cmd, setting f.
+func (f *tagFilter) addFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVarP(&f.tags, "tags", "t", []string{}, "Filter by tags")
+	cmd.Flags().BoolVar(&f.all, "all-tags", false, "Require all of --tags instead of any")
+}
+
+// empty reports whether the filter selects every snippet.
+func (f tagFilter) empty() bool {
+	return len(f.tags) == 0
+}
+
+// matches reports whether a snippet with snippetTags passes the filter,
+// comparing tags case-insensitively.
+func (f tagFilter) matches(snippetTags []string) bool {
+	if f.empty() {
+		return true
+	}
+	if !f.all {
+		return hasAnyTag(snippetTags, f.tags)
+	}
+	for _, tag := range f.tags {
+		if !hasAnyTag(snippetTags, []string{tag}) {
+			return false
+		}
+	}
+	return true
+}
+
+// String describes the filter for messages, e.g. "tags: k8s, prod" or
+// "all tags: k8s, prod".
+func (f tagFilter) String() string {
+	if f.all {
+		return "all tags: " + strings.Join(f.tags, ", ")
+	}
+	return "tags: " + strings.Join(f.tags, ", ")
+}
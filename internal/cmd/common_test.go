@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/state"
+	"gopkg.in/yaml.v3"
+)
+
+// TestCompleteSnippetNames tests that completion offers names and aliases
+func TestCompleteSnippetNames(t *testing.T) {
+	config = &models.Config{Snippets: map[string]models.Snippet{
+		"kubectl-get-pods": {Aliases: []string{"kgp"}},
+		"kubectl-logs":     {},
+		"docker-run":       {Aliases: []string{"dr"}},
+	}}
+	t.Cleanup(func() { config = nil })
+
+	got, _ := completeSnippetNames(nil, nil, "k")
+	if expected := []string{"kgp", "kubectl-get-pods", "kubectl-logs"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got, _ := completeSnippetNames(nil, []string{"kgp"}, ""); len(got) != 0 {
+		t.Errorf("Expected no completions after the first argument, got %v", got)
+	}
+}
+
+// loadFixtureSnippets sets config to the snippets in testdata/test_snippets.yaml.
+func loadFixtureSnippets(t *testing.T) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "test_snippets.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read test snippets: %v", err)
+	}
+	config = &models.Config{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		t.Fatalf("Failed to parse test snippets: %v", err)
+	}
+	t.Cleanup(func() { config = nil })
+}
+
+func TestWriteSnippetOutputs(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	config = &models.Config{Snippets: map[string]models.Snippet{
+		"b-pods": {Description: "List pods", Command: "kubectl get pods -n <ns>", Tags: []string{"k8s"}, CreatedAt: created, Source: models.SourceLocal,
+			Variables: []models.Variable{{Name: "ns", Description: "Namespace", DefaultValue: "default", Required: true}}},
+		"a-echo": {Name: "Echo", Command: "echo hi"},
+	}}
+	t.Cleanup(func() { config = nil })
+
+	var outputs []snippetOutput
+	for name, snippet := range config.Snippets {
+		outputs = append(outputs, newSnippetOutput(name, &snippet))
+	}
+	var b strings.Builder
+	if err := writeSnippetOutputs(&b, outputs, outputJSON); err != nil {
+		t.Fatal(err)
+	}
+	expected := `[
+  {
+    "command": "echo hi",
+    "description": "",
+    "id": "a-echo",
+    "name": "Echo",
+    "source": "global",
+    "tags": [],
+    "variables": []
+  },
+  {
+    "command": "kubectl get pods -n <ns>",
+    "created_at": "2024-05-01T12:00:00Z",
+    "description": "List pods",
+    "id": "b-pods",
+    "name": "b-pods",
+    "source": "local",
+    "tags": [
+      "k8s"
+    ],
+    "variables": [
+      {
+        "default": "default",
+        "description": "Namespace",
+        "name": "ns",
+        "required": true
+      }
+    ]
+  }
+]
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected JSON:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := writeSnippetOutputs(&b, nil, outputJSON); err != nil || b.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q (%v)", b.String(), err)
+	}
+}
+
+// TestSortNamedSnippets tests sorting by name and timestamps, with
+// favorites first and undated snippets last
+func TestSortNamedSnippets(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	snippets := []namedSnippet{
+		{"old", models.Snippet{CreatedAt: day(1), UpdatedAt: day(9)}},
+		{"undated", models.Snippet{}},
+		{"new", models.Snippet{CreatedAt: day(5), UpdatedAt: day(6)}},
+		{"pinned", models.Snippet{Favorite: true}},
+		{"also-undated", models.Snippet{}},
+	}
+
+	tests := []struct {
+		sortBy   string
+		reverse  bool
+		expected []string
+	}{
+		{sortByName, false, []string{"pinned", "also-undated", "new", "old", "undated"}},
+		{sortByName, true, []string{"pinned", "undated", "old", "new", "also-undated"}},
+		{sortByCreated, false, []string{"pinned", "new", "old", "also-undated", "undated"}},
+		{sortByCreated, true, []string{"pinned", "old", "new", "undated", "also-undated"}},
+		{sortByUpdated, false, []string{"pinned", "old", "new", "also-undated", "undated"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s reverse=%v", tt.sortBy, tt.reverse), func(t *testing.T) {
+			sorted := slices.Clone(snippets)
+			sortNamedSnippets(sorted, tt.sortBy, tt.reverse)
+			var got []string
+			for _, s := range sorted {
+				got = append(got, s.name)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestSortNamedSnippets_Usage tests sorting by execution count and last
+// use, with favorites first, ties by name, and never-used snippets last
+func TestSortNamedSnippets_Usage(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path, err := state.UsagePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	usage := &state.Usage{Snippets: map[string]state.UsageEntry{
+		"busy":    {Count: 5, LastUsed: day(1)},
+		"tied-b":  {Count: 2, LastUsed: day(3)},
+		"tied-a":  {Count: 2, LastUsed: day(3)},
+		"recent":  {Count: 1, LastUsed: day(9)},
+		"starred": {Count: 1, LastUsed: day(2)},
+	}}
+	if err := usage.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	snippets := []namedSnippet{
+		{"never", models.Snippet{}},
+		{"busy", models.Snippet{}},
+		{"tied-b", models.Snippet{}},
+		{"also-never", models.Snippet{}},
+		{"recent", models.Snippet{}},
+		{"starred", models.Snippet{Favorite: true}},
+		{"tied-a", models.Snippet{}},
+		{"unused-star", models.Snippet{Favorite: true}},
+	}
+	tests := []struct {
+		sortBy   string
+		reverse  bool
+		expected []string
+	}{
+		{sortByUsage, false, []string{"starred", "unused-star", "busy", "tied-a", "tied-b", "recent", "also-never", "never"}},
+		{sortByUsage, true, []string{"unused-star", "starred", "never", "also-never", "recent", "tied-b", "tied-a", "busy"}},
+		{sortByRecent, false, []string{"starred", "unused-star", "recent", "tied-a", "tied-b", "busy", "also-never", "never"}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s reverse=%v", tt.sortBy, tt.reverse), func(t *testing.T) {
+			sorted := slices.Clone(snippets)
+			sortNamedSnippets(sorted, tt.sortBy, tt.reverse)
+			var got []string
+			for _, s := range sorted {
+				got = append(got, s.name)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestSimilarNames tests the names suggested for a snippet that isn't found
+func TestSimilarNames(t *testing.T) {
+	names := []string{"kubectl-get-pods", "kubectl-logs", "docker-run", "docker-ps", "git-log", "kgp", "デプロイする", "デプロイ確認"}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"kubect-get-pods", []string{"kubectl-get-pods"}},
+		{"kubectl", []string{"kubectl-logs", "kubectl-get-pods"}},
+		{"docker", []string{"docker-ps", "docker-run"}},
+		{"log", []string{"git-log", "kubectl-logs"}},
+		{"Docker-Rn", []string{"docker-run", "docker-ps"}},
+		{"terraform-plan", nil},
+		// Distances count characters, and so do the thresholds
+		{"デプロイすう", []string{"デプロイする", "デプロイ確認"}},
+		{"ビルドする", nil},
+	}
+	for _, tt := range tests {
+		if got := similarNames(tt.name, names, maxNameSuggestions); !slices.Equal(got, tt.expected) {
+			t.Errorf("similarNames(%q) = %v, expected %v", tt.name, got, tt.expected)
+		}
+	}
+}
+
+// TestClosestName tests that only a likely typo is offered, measuring
+// multibyte names in characters rather than bytes
+func TestClosestName(t *testing.T) {
+	names := []string{"kubectl-get-pods", "デプロイする"}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"kubect-get-pod", "kubectl-get-pods"},
+		{"kgp", ""},
+		{"デプロイすう", "デプロイする"},
+		{"ビルドする", ""},
+	}
+	for _, tt := range tests {
+		if got := closestName(tt.name, names); got != tt.expected {
+			t.Errorf("closestName(%q) = %q, expected %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+// TestFindSnippet tests that a missing snippet is reported with suggestions
+// when not in a terminal
+func TestFindSnippet(t *testing.T) {
+	loadFixtureSnippets(t)
+
+	if name, _, err := findSnippet("simple-no-vars"); err != nil || name != "simple-no-vars" {
+		t.Errorf("Expected simple-no-vars, got %q (%v)", name, err)
+	}
+	_, _, err := findSnippet("simple-no-var")
+	if err == nil || err.Error() != "template 'simple-no-var' not found (did you mean: simple-no-vars?)" {
+		t.Errorf("Expected a suggestion, got %v", err)
+	}
+	_, _, err = findSnippet("zzzzzzzzzz")
+	if err == nil || err.Error() != "template 'zzzzzzzzzz' not found" {
+		t.Errorf("Expected a plain not found error, got %v", err)
+	}
+}
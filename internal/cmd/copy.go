@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func newCopyCmd() *cobra.Command {
+	var overwrite bool
+	var edit bool
+
+	cmd := &cobra.Command{
+		Use:   "copy <source-name> <dest-name>",
+		Short: "Duplicate an existing command template",
+		Long: `Duplicate a command template, including its variables, transforms, and tags.
+
+Examples:
+  cs copy kubectl-get-pods kubectl-get-deployments         # Copy a template
+  cs copy kubectl-get-pods kubectl-get-deployments --edit  # Copy and open in editor`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCopy(args[0], args[1], overwrite, edit)
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace an existing template with the destination name")
+	cmd.Flags().BoolVar(&edit, "edit", false, "Open the copied template in your editor before saving")
+
+	return cmd
+}
+
+func runCopy(srcName, destName string, overwrite bool, edit bool) error {
+	srcName, src, err := findSnippet(srcName)
+	if err != nil {
+		return err
+	}
+
+	if srcName == destName {
+		return fmt.Errorf("template '%s' already has that name", srcName)
+	}
+	if _, exists := config.Snippets[destName]; exists && !overwrite {
+		return fmt.Errorf("template '%s' already exists (use --overwrite to replace it)", destName)
+	}
+
+	dest, err := cloneSnippet(&src)
+	if err != nil {
+		return fmt.Errorf("failed to copy template: %w", err)
+	}
+	now := time.Now()
+	dest.Name = destName
+	dest.CreatedAt = now
+	dest.UpdatedAt = now
+	dest.Source = models.SourceGlobal
+	dest.SourceFile = cfgFile
+	// Aliases and the favorite mark belong to the original; the copy
+	// starts without them.
+	dest.Aliases = nil
+	dest.Favorite = false
+
+	if edit {
+		edited, err := openSnippetInEditor(destName, dest)
+		if err != nil {
+			return err
+		}
+		keepEditedIdentity(destName, dest, edited, now)
+		dest = edited
+	}
+
+	previous := snippetFiles(destName)
+	config.Snippets[destName] = *dest
+
+	if err := saveSnippets(previous, destName); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Command template '%s' copied to '%s'\n", srcName, destName)
+	return nil
+}
+
+// cloneSnippet returns a deep copy of s by round-tripping it through YAML,
+// so nested variables, transforms, and validation rules are never shared.
+// Fields that aren't persisted (Source, SourceFile) are left zero.
+func cloneSnippet(s *models.Snippet) (*models.Snippet, error) {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var clone models.Snippet
+	if err := yaml.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
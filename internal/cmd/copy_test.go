@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// TestCloneSnippet tests that a clone shares no variables, transforms,
+// validation rules, or tags with the original
+func TestCloneSnippet(t *testing.T) {
+	original := &models.Snippet{
+		Command: "kubectl get pods -n <ns>",
+		Tags:    []string{"k8s", "read"},
+		Variables: []models.Variable{{
+			Name:       "ns",
+			Transform:  &models.Transform{ValuePattern: "-n {{.Value}}"},
+			Validation: &models.Validation{Enum: []string{"dev", "prod"}},
+		}},
+		Source:     models.SourceLocal,
+		SourceFile: ".csnippets",
+	}
+
+	clone, err := cloneSnippet(original)
+	if err != nil {
+		t.Fatalf("cloneSnippet failed: %v", err)
+	}
+	if clone.Source != "" || clone.SourceFile != "" {
+		t.Errorf("Expected the source to be left zero, got %q and %q", clone.Source, clone.SourceFile)
+	}
+
+	clone.Tags[0] = "changed"
+	clone.Tags = append(clone.Tags, "added")
+	clone.Variables[0].Name = "changed"
+	clone.Variables[0].Transform.ValuePattern = "changed"
+	clone.Variables[0].Validation.Enum[0] = "changed"
+	clone.Variables = append(clone.Variables, models.Variable{Name: "added"})
+
+	if !slices.Equal(original.Tags, []string{"k8s", "read"}) {
+		t.Errorf("Expected the original tags to be unchanged, got %v", original.Tags)
+	}
+	v := original.Variables
+	if len(v) != 1 || v[0].Name != "ns" || v[0].Transform.ValuePattern != "-n {{.Value}}" || v[0].Validation.Enum[0] != "dev" {
+		t.Errorf("Expected the original variables to be unchanged, got %+v", v)
+	}
+}
+
+// TestRunCopy tests copying a snippet to a new name, refusing to replace an
+// existing one unless asked or to copy it onto itself, and saving the copy
+// on its own without the original's aliases or favorite mark
+func TestRunCopy(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": "snippets:\n" +
+		"  pods:\n    command: kubectl get pods -n <ns>\n    tags: [k8s]\n    variables:\n      - name: ns\n    aliases: [p]\n    favorite: true\n" +
+		"  taken:\n    command: echo taken\n"})
+
+	err := runCopy("pods", "taken", false, false)
+	if err == nil || !strings.Contains(err.Error(), "use --overwrite") {
+		t.Fatalf("Expected a collision error, got %v", err)
+	}
+	if err := runCopy("missing", "new", false, false); err == nil {
+		t.Error("Expected an error copying a missing template")
+	}
+	err = runCopy("p", "pods", true, false)
+	if err == nil || !strings.Contains(err.Error(), "already has that name") {
+		t.Errorf("Expected copying pods onto itself to be rejected, got %v", err)
+	}
+
+	if err := runCopy("pods", "deployments", false, false); err != nil {
+		t.Fatalf("runCopy failed: %v", err)
+	}
+	if err := runCopy("pods", "taken", true, false); err != nil {
+		t.Fatalf("runCopy with overwrite failed: %v", err)
+	}
+
+	// Changing a copy leaves the original alone
+	copied := config.Snippets["deployments"]
+	copied.Tags[0] = "changed"
+	copied.Variables[0].Name = "changed"
+
+	reloaded, err := loadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	for _, name := range []string{"pods", "deployments", "taken"} {
+		snippet := reloaded.Snippets[name]
+		if snippet.Command != "kubectl get pods -n <ns>" || !slices.Equal(snippet.Tags, []string{"k8s"}) || len(snippet.Variables) != 1 || snippet.Variables[0].Name != "ns" {
+			t.Errorf("Expected %s to be saved as a copy of pods, got %+v", name, snippet)
+		}
+	}
+	if pods := config.Snippets["pods"]; pods.Tags[0] != "k8s" || pods.Variables[0].Name != "ns" {
+		t.Errorf("Expected pods to be unaffected by changes to its copy, got %+v", pods)
+	}
+	for _, name := range []string{"deployments", "taken"} {
+		if snippet := reloaded.Snippets[name]; len(snippet.Aliases) > 0 || snippet.Favorite {
+			t.Errorf("Expected %s to have no aliases and not be a favorite, got %v and %v", name, snippet.Aliases, snippet.Favorite)
+		}
+	}
+	if pods := reloaded.Snippets["pods"]; !slices.Equal(pods.Aliases, []string{"p"}) || !pods.Favorite {
+		t.Errorf("Expected pods to keep its alias and favorite mark, got %v and %v", pods.Aliases, pods.Favorite)
+	}
+	if created := reloaded.Snippets["deployments"].CreatedAt; created.IsZero() {
+		t.Error("Expected the copy to record when it was created")
+	}
+}
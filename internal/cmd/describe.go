@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strings"
 
 	"github.com/samling/command-snippets/internal/models"
@@ -19,24 +22,62 @@ This command displays:
 - All variables with their types, validation rules, and defaults
 - Tags for organization
 - Transform templates used
+- The command rendered with example values, and with optional values empty
+
+With --plain only the description, command, and a line per variable are
+printed, compact enough for a selector's preview pane.
+
+With --output yaml or json the template is printed as a config fragment,
+together with the transform templates and variable types it references,
+ready for cs import. --resolve inlines those instead: global variables,
+type defaults and validation, and transform templates are copied into the
+template's own variables.
 
 Examples:
   cs describe kubectl-get-pods     # Show details for specific template
-  cs describe docker-run          # Show variables and validation rules`,
-		Args: cobra.ExactArgs(1),
-		RunE: runDescribe,
+  cs describe docker-run          # Show variables and validation rules
+  cs describe docker-run --plain  # Short summary, as in the fzf preview
+  cs describe docker-run -o yaml  # Definition as YAML, for cs import
+  cs describe docker-run -o json --resolve  # Self-contained definition as JSON`,
+		Args:              cobra.ExactArgs(1),
+		RunE:              runDescribe,
+		ValidArgsFunction: completeSnippetNames,
 	}
 
+	cmd.Flags().Bool("plain", false, "Print a compact summary without validation and transform details")
+	cmd.Flags().StringP("output", "o", outputText, "Output format: text, json, or yaml")
+	cmd.Flags().Bool("resolve", false, "With --output, inline global variables, type defaults, and transform templates")
+
 	return cmd
 }
 
 func runDescribe(cmd *cobra.Command, args []string) error {
-	snippetName := args[0]
+	output, _ := cmd.Flags().GetString("output")
+	resolve, _ := cmd.Flags().GetBool("resolve")
+	if err := checkListOutput(output); err != nil {
+		return err
+	}
+	if resolve && output == outputText {
+		return usageErrorf("--resolve requires --output json or yaml")
+	}
 
-	snippet, err := getSnippet(snippetName)
+	snippetName, snippet, err := findSnippet(args[0])
 	if err != nil {
 		return err
 	}
+	if output != outputText {
+		return describeStructured(os.Stdout, snippetName, snippet, output, resolve)
+	}
+	local := make(map[string]bool, len(snippet.Variables))
+	for _, v := range snippet.Variables {
+		local[v.Name] = true
+	}
+	snippet = snippet.WithGlobalVariables(config)
+
+	if plain, _ := cmd.Flags().GetBool("plain"); plain {
+		describePlain(os.Stdout, snippetName, snippet, local)
+		return nil
+	}
 
 	// Display snippet information
 	fmt.Printf("Name: %s\n", snippetName)
@@ -44,9 +85,33 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 	if snippet.Description != "" {
 		fmt.Printf("Description: %s\n", snippet.Description)
 	}
+	if len(snippet.Aliases) > 0 {
+		fmt.Printf("Aliases: %s\n", strings.Join(snippet.Aliases, ", "))
+	}
 
 	fmt.Printf("\nCommand Template:\n")
-	fmt.Printf("  %s\n", snippet.Command)
+	for _, step := range snippet.Steps() {
+		fmt.Printf("  %s\n", step)
+	}
+	if len(snippet.Commands) > 0 && snippet.ContinueOnError {
+		fmt.Printf("  (continues after a failed step)\n")
+	}
+
+	if snippet.Workdir != "" {
+		fmt.Printf("\nWorking Directory: %s\n", snippet.Workdir)
+	}
+	if snippet.ExecMode != "" {
+		fmt.Printf("\nExec Mode: %s\n", snippet.ExecMode)
+	}
+	if snippet.Dangerous {
+		fmt.Printf("\nDangerous: always asks for confirmation before running\n")
+	}
+	if snippet.PreCommand != "" {
+		fmt.Printf("\nPre Command: %s\n", snippet.PreCommand)
+	}
+	if snippet.PostCommand != "" {
+		fmt.Printf("\nPost Command: %s\n", snippet.PostCommand)
+	}
 
 	// Show tags if present
 	if len(snippet.Tags) > 0 {
@@ -56,18 +121,179 @@ func runDescribe(cmd *cobra.Command, args []string) error {
 	// Show variables
 	if len(snippet.Variables) > 0 {
 		fmt.Printf("\nVariables:\n")
-		for _, variable := range snippet.Variables {
-			displayVariable(variable)
+		// Same order and sections as the form
+		variables, err := snippet.OrderedVariables(config)
+		if err != nil {
+			variables = snippet.Variables
+		}
+		for _, group := range models.GroupVariables(variables) {
+			if group.Name != "" {
+				fmt.Printf("\n  [%s]\n", group.Name)
+			}
+			for _, variable := range group.Variables {
+				displayVariable(variable, !local[variable.Name])
+			}
 		}
 	} else {
 		fmt.Printf("\nNo variables defined.\n")
 	}
 
+	if len(snippet.Variables) > 0 {
+		describeExamples(os.Stdout, snippet)
+	}
+
 	return nil
 }
 
-func displayVariable(variable models.Variable) {
-	fmt.Printf("\n  %s:\n", variable.Name)
+// describeExamples writes the command rendered with example values for
+// every variable and, when some are optional, again with those left empty
+// to show their empty branches. A failure to render is written as a note.
+func describeExamples(w io.Writer, snippet models.Snippet) {
+	examples := []struct {
+		heading       string
+		emptyOptional bool
+	}{{"Example", false}, {"Example (optional values empty)", true}}
+	if !slices.ContainsFunc(snippet.Variables, isOptionalInput) {
+		examples = examples[:1]
+	}
+
+	for _, example := range examples {
+		fmt.Fprintf(w, "\n%s:\n", example.heading)
+		command, err := snippet.ProcessTemplate(exampleValues(snippet, example.emptyOptional), config)
+		if err != nil {
+			fmt.Fprintf(w, "  (could not render: %v)\n", err)
+			continue
+		}
+		for _, line := range strings.Split(command, "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// exampleValues returns a value for each input variable of snippet: its
+// default, its type's default, the first allowed value, or else <name>.
+// With emptyOptional, variables that are not required are left empty.
+func exampleValues(snippet models.Snippet, emptyOptional bool) map[string]string {
+	values := make(map[string]string, len(snippet.Variables))
+	for _, v := range snippet.Variables {
+		if v.Computed || (emptyOptional && isOptionalInput(v)) {
+			continue
+		}
+		values[v.Name] = exampleValue(v)
+	}
+	return values
+}
+
+// exampleValue picks the value exampleValues shows for v.
+func exampleValue(v models.Variable) string {
+	var varType models.VariableType
+	if config != nil && v.Type != "" {
+		varType = config.VariableTypes[v.Type]
+	}
+	if v.DefaultValue != "" {
+		return v.DefaultValue
+	}
+	if varType.Default != "" {
+		return varType.Default
+	}
+	validation := v.WithMapEnum(config).Validation
+	if validation == nil {
+		validation = varType.Validation
+	}
+	if validation != nil && len(validation.Enum) > 0 {
+		return validation.Enum[0]
+	}
+	return "<" + v.Name + ">"
+}
+
+// isOptionalInput reports whether v is a variable the user may leave empty.
+func isOptionalInput(v models.Variable) bool {
+	return !v.Computed && !v.Required
+}
+
+// describeStructured writes the snippet as a config fragment in format.
+// Unless resolve is set the fragment also holds the transform templates and
+// variable types the snippet references, so cs import recreates it as is;
+// with resolve the snippet is self-contained and the fragment holds only it.
+func describeStructured(w io.Writer, name string, snippet models.Snippet, format string, resolve bool) error {
+	var fragment configFragment
+	if resolve {
+		resolved, err := snippet.Resolved(config)
+		if err != nil {
+			return fmt.Errorf("failed to resolve template '%s': %w", name, err)
+		}
+		fragment.Snippets = map[string]models.Snippet{name: resolved}
+	} else {
+		fragment = buildConfigFragment(map[string]models.Snippet{name: snippet})
+	}
+
+	data, err := marshalOutput(fragment, format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// describePlain writes a compact summary of a snippet, as shown in the fzf
+// preview: its description, command, and one line per variable in form
+// order. local names the snippet's own variables; the rest are global.
+func describePlain(w io.Writer, name string, snippet models.Snippet, local map[string]bool) {
+	fmt.Fprintln(w, name)
+	if snippet.Description != "" {
+		fmt.Fprintln(w, snippet.Description)
+	}
+	fmt.Fprintln(w)
+	for _, step := range snippet.Steps() {
+		fmt.Fprintln(w, step)
+	}
+	if len(snippet.Variables) == 0 {
+		return
+	}
+
+	variables, err := snippet.OrderedVariables(config)
+	if err != nil {
+		variables = snippet.Variables
+	}
+	width := 0
+	for _, v := range variables {
+		width = max(width, len(v.Name))
+	}
+	fmt.Fprintf(w, "\nVariables:\n")
+	for _, v := range variables {
+		var details []string
+		if v.Type != "" {
+			details = append(details, v.Type)
+		}
+		if v.DefaultValue != "" {
+			details = append(details, "default: "+v.DefaultValue)
+		}
+		if v.Required {
+			details = append(details, "required")
+		}
+		if v.Computed {
+			details = append(details, "computed")
+		}
+		if !local[v.Name] {
+			details = append(details, "global")
+		}
+
+		line := strings.TrimRight(fmt.Sprintf("  %-*s  %s", width, v.Name, v.Description), " ")
+		if len(details) > 0 {
+			line += " (" + strings.Join(details, ", ") + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// displayVariable prints a variable's definition; global marks one taken
+// from global_variables.
+func displayVariable(variable models.Variable, global bool) {
+	if global {
+		fmt.Printf("\n  %s (global):\n", variable.Name)
+	} else {
+		fmt.Printf("\n  %s:\n", variable.Name)
+	}
 
 	if variable.Description != "" {
 		fmt.Printf("    Description: %s\n", variable.Description)
@@ -75,12 +301,24 @@ func displayVariable(variable models.Variable) {
 	if variable.Type != "" {
 		fmt.Printf("    Type: %s\n", variable.Type)
 	}
+	if variable.DefaultFromEnv != "" {
+		fmt.Printf("    Default From Env: $%s\n", variable.DefaultFromEnv)
+	}
+	if variable.DefaultFromCommand != "" {
+		fmt.Printf("    Default From Command: %s\n", variable.DefaultFromCommand)
+	}
 	if variable.DefaultValue != "" {
 		fmt.Printf("    Default: %s\n", variable.DefaultValue)
 	}
 	if variable.Required {
 		fmt.Printf("    Required: true\n")
 	}
+	if variable.RequiredIf != nil {
+		fmt.Printf("    Required If: %s\n", variable.RequiredIf)
+	}
+	if variable.When != nil {
+		fmt.Printf("    When: %s\n", variable.When)
+	}
 	if variable.Computed {
 		fmt.Printf("    Computed: true\n")
 	}
@@ -112,6 +350,9 @@ func displayVariable(variable models.Variable) {
 			if varType.Description != "" {
 				fmt.Printf("    Type Description: %s\n", varType.Description)
 			}
+			if varType.DefaultFromEnv != "" && variable.DefaultValue == "" {
+				fmt.Printf("    Type Default From Env: $%s\n", varType.DefaultFromEnv)
+			}
 			if varType.Default != "" && variable.DefaultValue == "" {
 				fmt.Printf("    Type Default: %s\n", varType.Default)
 			}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// TestDescribePlain tests the compact description used for previews
+func TestDescribePlain(t *testing.T) {
+	config = &models.Config{}
+	t.Cleanup(func() { config = nil })
+	snippet := models.Snippet{
+		Description: "List pods",
+		Command:     "kubectl get pods -n <namespace> <wide>",
+		Variables: []models.Variable{
+			{Name: "namespace", Description: "Namespace", DefaultValue: "default", Required: true},
+			{Name: "wide", Type: models.VarTypeBoolean},
+		},
+	}
+
+	var b strings.Builder
+	describePlain(&b, "kubectl-get-pods", snippet, map[string]bool{"namespace": true})
+	expected := `kubectl-get-pods
+List pods
+
+kubectl get pods -n <namespace> <wide>
+
+Variables:
+  namespace  Namespace (default: default, required)
+  wide (boolean, global)
+`
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+// TestDescribeStructured tests that cs describe --output round-trips
+// through the config parser cs import uses, with and without --resolve
+func TestDescribeStructured(t *testing.T) {
+	config = &models.Config{
+		TransformTemplates: map[string]models.TransformTemplate{
+			"ns-flag": {Transform: &models.Transform{ValuePattern: "-n {{.Value}}"}},
+		},
+		VariableTypes: map[string]models.VariableType{
+			"port": {Default: "8080"},
+		},
+	}
+	t.Cleanup(func() { config = nil })
+	snippet := models.Snippet{
+		Description: "Forward a port",
+		Command:     "kubectl port-forward <ns> svc/app <port>",
+		Tags:        []string{"k8s"},
+		Variables: []models.Variable{
+			{Name: "ns", TransformTemplate: "ns-flag"},
+			{Name: "port", Type: "port"},
+		},
+	}
+
+	for _, format := range []string{outputYAML, outputJSON} {
+		for _, resolve := range []bool{false, true} {
+			var b strings.Builder
+			if err := describeStructured(&b, "forward", snippet, format, resolve); err != nil {
+				t.Fatalf("%s (resolve=%v): %v", format, resolve, err)
+			}
+			parsed, err := parseConfig([]byte(b.String()))
+			if err != nil {
+				t.Fatalf("%s (resolve=%v): output does not parse: %v\n%s", format, resolve, err, b.String())
+			}
+
+			want := snippet
+			if resolve {
+				if want, err = snippet.Resolved(config); err != nil {
+					t.Fatal(err)
+				}
+				if len(parsed.TransformTemplates) != 0 || len(parsed.VariableTypes) != 0 {
+					t.Errorf("%s: expected a resolved fragment to hold only the template, got %+v", format, parsed)
+				}
+			} else if _, ok := parsed.TransformTemplates["ns-flag"]; !ok || parsed.VariableTypes["port"].Default != "8080" {
+				t.Errorf("%s: expected the referenced template and type, got %+v", format, parsed)
+			}
+
+			got, _ := yaml.Marshal(parsed.Snippets["forward"])
+			expected, _ := yaml.Marshal(want)
+			if string(got) != string(expected) {
+				t.Errorf("%s (resolve=%v): expected\n%s\ngot\n%s", format, resolve, expected, got)
+			}
+		}
+	}
+}
+
+// TestDescribeExamples tests the example commands cs describe renders
+func TestDescribeExamples(t *testing.T) {
+	config = &models.Config{VariableTypes: map[string]models.VariableType{
+		"port": {Default: "8080"},
+	}}
+	t.Cleanup(func() { config = nil })
+
+	snippet := models.Snippet{
+		Command: "app <env> <port> <format> <name> <verbose>",
+		Variables: []models.Variable{
+			{Name: "env", Required: true, Validation: &models.Validation{Enum: []string{"dev", "prod"}}},
+			{Name: "port", Type: "port", Transform: &models.Transform{ValuePattern: "--port {{.Value}}"}},
+			{Name: "format", DefaultValue: "json", Transform: &models.Transform{ValuePattern: "-o {{.Value}}", EmptyValue: "-o text"}},
+			{Name: "name", Required: true},
+			{Name: "verbose", Type: models.VarTypeBoolean, DefaultValue: "true", Transform: &models.Transform{TrueValue: "-v"}},
+		},
+	}
+	var b strings.Builder
+	describeExamples(&b, snippet)
+	// An empty port still takes its type's default when rendered
+	expected := "\nExample:\n  app dev --port 8080 -o json <name> -v\n" +
+		"\nExample (optional values empty):\n  app dev 8080 -o text <name> \n"
+	if b.String() != expected {
+		t.Errorf("Expected:\n%q\ngot:\n%q", expected, b.String())
+	}
+
+	snippet.Variables[0].TransformTemplate = "missing"
+	b.Reset()
+	describeExamples(&b, snippet)
+	if !strings.Contains(b.String(), "(could not render: processing variable env: transform template 'missing' not found)") {
+		t.Errorf("Expected a note for the failed render, got:\n%s", b.String())
+	}
+}
@@ -3,12 +3,17 @@ package cmd
 import (
 	"cmp"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/samling/command-snippets/internal/models"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	"gopkg.in/yaml.v3"
 )
 
@@ -18,13 +23,28 @@ func newEditCmd() *cobra.Command {
 		Short: "Edit an existing command template or open config file",
 		Long: `Edit a command template or configuration file in your default editor.
 
+With --transform or --type a single transform template or variable type is
+edited instead, and saved back to the file it was loaded from.
+
 Examples:
   cs edit kubectl-get-pods       # Edit specific template
+  cs edit --transform k8s-namespace  # Edit a transform template
+  cs edit --type port            # Edit a variable type
   cs edit --config               # Edit configuration file`,
-		RunE: runEdit,
+		RunE:              runEdit,
+		ValidArgsFunction: completeSnippetNames,
 	}
 
 	cmd.Flags().Bool("config", false, "Edit the configuration file")
+	cmd.Flags().String("transform", "", "Edit the named transform template")
+	cmd.Flags().String("type", "", "Edit the named variable type")
+	cmd.MarkFlagsMutuallyExclusive("config", "transform", "type")
+	_ = cmd.RegisterFlagCompletionFunc("transform", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return slices.Sorted(maps.Keys(config.TransformTemplates)), cobra.ShellCompDirectiveNoFileComp
+	})
+	_ = cmd.RegisterFlagCompletionFunc("type", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return slices.Sorted(maps.Keys(config.VariableTypes)), cobra.ShellCompDirectiveNoFileComp
+	})
 
 	return cmd
 }
@@ -36,12 +56,23 @@ func runEdit(cmd *cobra.Command, args []string) error {
 		return editConfigFile()
 	}
 
+	transformName, _ := cmd.Flags().GetString("transform")
+	typeName, _ := cmd.Flags().GetString("type")
+	if (transformName != "" || typeName != "") && len(args) > 0 {
+		return usageErrorf("--transform and --type don't take a template name")
+	}
+	if transformName != "" {
+		return editTransformTemplate(transformName)
+	}
+	if typeName != "" {
+		return editVariableType(typeName)
+	}
+
 	if len(args) == 0 {
 		return fmt.Errorf("please specify a template name to edit, or use --config to edit the configuration file")
 	}
 
-	snippetName := args[0]
-	snippet, err := getSnippet(snippetName)
+	snippetName, snippet, err := findSnippet(args[0])
 	if err != nil {
 		return err
 	}
@@ -59,17 +90,73 @@ func editConfigFile() error {
 }
 
 func editSnippet(name string, snippet *models.Snippet) error {
-	// Create a temporary file with the snippet YAML
-	tempFile, err := os.CreateTemp("", fmt.Sprintf("cs-edit-%s-*.yaml", name))
+	editedSnippet, err := openSnippetInEditor(name, snippet)
+	if err != nil {
+		return err
+	}
+
+	// Update the snippet in config, keeping it in the file it came from
+	previous := snippetFiles(name)
+	keepEditedIdentity(name, snippet, editedSnippet, time.Now())
+	config.Snippets[name] = *editedSnippet
+
+	if err := saveSnippets(previous, name); err != nil {
+		return err
+	}
+
+	fmt.Printf("✅ Command template '%s' updated successfully!\n", name)
+	return nil
+}
+
+// keepEditedIdentity carries over to edited, the snippet called key after
+// editing, what the editor must not change: its name, which has to stay
+// consistent with key, its creation time when the edited copy has none,
+// and the file it belongs to. UpdatedAt is set to now. A name changed in
+// the editor is reported on stderr and reverted.
+func keepEditedIdentity(key string, original, edited *models.Snippet, now time.Time) {
+	if edited.Name != original.Name && edited.Name != key {
+		fmt.Fprintf(os.Stderr, "Warning: The name can't be changed in the editor; use 'cs rename %s <new-name>'\n", key)
+		edited.Name = original.Name
+	}
+	if edited.CreatedAt.IsZero() {
+		edited.CreatedAt = original.CreatedAt
+	}
+	edited.UpdatedAt = now
+	edited.Source, edited.SourceFile = original.Source, original.SourceFile
+}
+
+// openSnippetInEditor opens snippet in the user's editor and returns the
+// parsed result. The config is not modified.
+func openSnippetInEditor(name string, snippet *models.Snippet) (*models.Snippet, error) {
+	var edited *models.Snippet
+	err := editInEditor("cs-edit-"+name+"-*.yaml", snippet, "template", func(data []byte) (err error) {
+		edited, err = parseEditedSnippet(name, data)
+		return err
+	})
+	return edited, err
+}
+
+// editInEditor writes v as YAML to a temp file named after pattern, opens
+// it in the user's editor, and passes the result to parse. When parse
+// fails, the user is asked whether to fix it in the editor; when they
+// don't, the temp file is kept and its path is returned in the error, so
+// the edits aren't lost. what names v in that error.
+func editInEditor(pattern string, v any, what string, parse func([]byte) error) error {
+	// Create a temporary file with the YAML
+	tempFile, err := os.CreateTemp("", pattern)
 	if err != nil {
 		return fmt.Errorf("failed to create temp file: %w", err)
 	}
-	defer os.Remove(tempFile.Name())
+	keep := false
+	defer func() {
+		if !keep {
+			os.Remove(tempFile.Name())
+		}
+	}()
 
-	// Write current snippet to temp file
-	data, err := yaml.Marshal(snippet)
+	data, err := yaml.Marshal(v)
 	if err != nil {
-		return fmt.Errorf("failed to marshal template: %w", err)
+		return fmt.Errorf("failed to marshal %s: %w", what, err)
 	}
 
 	if _, err := tempFile.Write(data); err != nil {
@@ -77,41 +164,204 @@ func editSnippet(name string, snippet *models.Snippet) error {
 	}
 	tempFile.Close()
 
-	// Open editor
-	editor := getEditor()
-	cmd := exec.Command(editor, tempFile.Name())
+	for {
+		// Open editor
+		editor := getEditor()
+		cmd := exec.Command(editor, tempFile.Name())
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("editor failed: %w", err)
+		}
+
+		// Read back the edited content
+		editedData, err := os.ReadFile(tempFile.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read edited file: %w", err)
+		}
+
+		err = parse(editedData)
+		if err == nil {
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		reopen := false
+		if canConfirm() {
+			reopen, _ = askConfirm("Re-open the editor?", true)
+		}
+		if !reopen {
+			keep = true
+			return fmt.Errorf("%s not saved; your edits are kept in %s", what, tempFile.Name())
+		}
+	}
+}
+
+// parseEditedSnippet parses the YAML of the snippet called name after
+// editing and checks it for the errors cs validate would report, such as a
+// placeholder without a variable or an unknown transform template.
+func parseEditedSnippet(name string, data []byte) (*models.Snippet, error) {
+	var editedSnippet models.Snippet
+	if err := yaml.Unmarshal(data, &editedSnippet); err != nil {
+		return nil, fmt.Errorf("invalid YAML in edited template: %w", err)
+	}
+
+	if err := lintErrors(editedSnippet.Lint(name, config), "template"); err != nil {
+		return nil, err
+	}
+	return &editedSnippet, nil
+}
+
+// editCommandInEditor opens command in the user's editor and returns the
+// edited text without its trailing newline. An empty file keeps command as
+// it was. The editor draws on the terminal even when stdout is captured, as
+// it is from shell widgets.
+func editCommandInEditor(command string) (string, error) {
+	tempFile, err := os.CreateTemp("", "cs-command-*.sh")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.WriteString(command + "\n"); err != nil {
+		tempFile.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	tempFile.Close()
+
+	cmd := exec.Command(getEditor(), tempFile.Name())
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		if tty, err := os.Open("/dev/tty"); err == nil {
+			defer tty.Close()
+			cmd.Stdin = tty
+		}
+	}
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("editor failed: %w", err)
+		return "", fmt.Errorf("editor failed: %w", err)
 	}
 
-	// Read back the edited content
-	editedData, err := os.ReadFile(tempFile.Name())
+	data, err := os.ReadFile(tempFile.Name())
 	if err != nil {
-		return fmt.Errorf("failed to read edited file: %w", err)
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	edited := strings.TrimRight(string(data), "\r\n")
+	if strings.TrimSpace(edited) == "" {
+		return command, nil
 	}
+	return edited, nil
+}
 
-	// Parse the edited YAML
-	var editedSnippet models.Snippet
-	if err := yaml.Unmarshal(editedData, &editedSnippet); err != nil {
-		return fmt.Errorf("invalid YAML in edited template: %w", err)
+func getEditor() string {
+	return cmp.Or(os.Getenv("EDITOR"), "vi")
+}
+
+// editTransformTemplate edits the named transform template and saves it
+// back to the file it was loaded from.
+func editTransformTemplate(name string) error {
+	template, ok := config.TransformTemplates[name]
+	if !ok {
+		return unknownDefinitionError(usageTransform, name, config.TransformTemplates)
 	}
 
-	// Update the snippet in config
-	config.Snippets[name] = editedSnippet
+	var edited models.TransformTemplate
+	err := editInEditor("cs-transform-"+name+"-*.yaml", template, usageTransform, func(data []byte) (err error) {
+		edited, err = parseEditedTransformTemplate(name, data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
 
-	// Save config
-	if err := saveConfig(config, cfgFile); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+	edited.SourceFile = cmp.Or(template.SourceFile, cfgFile)
+	config.TransformTemplates[name] = edited
+	edits := make(configEdits)
+	edits.set("transform_templates", name, edited)
+	if err := applyConfigEdits(edited.SourceFile, edits); err != nil {
+		return fmt.Errorf("failed to save %s: %w", edited.SourceFile, err)
 	}
 
-	fmt.Printf("✅ Command template '%s' updated successfully!\n", name)
+	fmt.Printf("✅ Transform template '%s' updated successfully!\n", name)
 	return nil
 }
 
-func getEditor() string {
-	return cmp.Or(os.Getenv("EDITOR"), "vi")
+// editVariableType edits the named variable type and saves it back to the
+// file it was loaded from.
+func editVariableType(name string) error {
+	varType, ok := config.VariableTypes[name]
+	if !ok {
+		return unknownDefinitionError(usageType, name, config.VariableTypes)
+	}
+
+	var edited models.VariableType
+	err := editInEditor("cs-type-"+name+"-*.yaml", varType, usageType, func(data []byte) (err error) {
+		edited, err = parseEditedVariableType(name, data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	edited.SourceFile = cmp.Or(varType.SourceFile, cfgFile)
+	config.VariableTypes[name] = edited
+	edits := make(configEdits)
+	edits.set("variable_types", name, edited)
+	if err := applyConfigEdits(edited.SourceFile, edits); err != nil {
+		return fmt.Errorf("failed to save %s: %w", edited.SourceFile, err)
+	}
+
+	fmt.Printf("✅ Variable type '%s' updated successfully!\n", name)
+	return nil
+}
+
+// parseEditedTransformTemplate parses an edited transform template and
+// checks it as cs validate would: it needs a transform, and its templates
+// and patterns must parse.
+func parseEditedTransformTemplate(name string, data []byte) (models.TransformTemplate, error) {
+	var edited models.TransformTemplate
+	if err := yaml.Unmarshal(data, &edited); err != nil {
+		return edited, fmt.Errorf("invalid YAML in edited %s: %w", usageTransform, err)
+	}
+	fragment := models.Config{TransformTemplates: map[string]models.TransformTemplate{name: edited}}
+	return edited, lintErrors(fragment.Lint(), usageTransform)
+}
+
+// parseEditedVariableType parses an edited variable type and checks it as
+// cs validate would, including that its default is one of its enum values.
+func parseEditedVariableType(name string, data []byte) (models.VariableType, error) {
+	var edited models.VariableType
+	if err := yaml.Unmarshal(data, &edited); err != nil {
+		return edited, fmt.Errorf("invalid YAML in edited %s: %w", usageType, err)
+	}
+	fragment := models.Config{VariableTypes: map[string]models.VariableType{name: edited}}
+	return edited, lintErrors(fragment.Lint(), usageType)
+}
+
+// lintErrors combines the errors among issues into one naming what was
+// edited, or returns nil when there are none; warnings are ignored.
+func lintErrors(issues []models.Issue, what string) error {
+	var problems []string
+	for _, issue := range issues {
+		if issue.Severity == models.SeverityError {
+			problems = append(problems, issue.Message)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("edited %s has errors:\n  %s", what, strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// unknownDefinitionError reports a transform template or variable type
+// that doesn't exist, listing those that do.
+func unknownDefinitionError[T any](kind, name string, defined map[string]T) error {
+	if len(defined) == 0 {
+		return fmt.Errorf("%s '%s' not found (none are defined)", kind, name)
+	}
+	return fmt.Errorf("%s '%s' not found (available: %s)", kind, name, strings.Join(slices.Sorted(maps.Keys(defined)), ", "))
 }
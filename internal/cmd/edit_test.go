@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// TestEditCommandInEditor tests editing the rendered command in $EDITOR
+func TestEditCommandInEditor(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected string
+		wantErr  bool
+	}{
+		{name: "edited", script: `printf 'kubectl get pods -n prod\n' > "$1"`, expected: "kubectl get pods -n prod"},
+		{name: "unchanged", script: `true`, expected: "kubectl get pods"},
+		{name: "emptied keeps command", script: `: > "$1"`, expected: "kubectl get pods"},
+		{name: "editor failure", script: `exit 1`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor := filepath.Join(t.TempDir(), "editor.sh")
+			if err := os.WriteFile(editor, []byte("#!/bin/sh\n"+tt.script+"\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("EDITOR", editor)
+
+			edited, err := editCommandInEditor("kubectl get pods")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %q", edited)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("editCommandInEditor failed: %v", err)
+			}
+			if edited != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, edited)
+			}
+		})
+	}
+}
+
+// TestKeepEditedIdentity tests what cs edit carries over from the snippet
+// as it was before editing
+func TestKeepEditedIdentity(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	original := models.Snippet{Name: "pods", Command: "kubectl get pods", CreatedAt: created, UpdatedAt: created, SourceFile: "k8s.yaml"}
+
+	edited := models.Snippet{Name: "pods-renamed", Command: "kubectl get pods -A"}
+	keepEditedIdentity("pods", &original, &edited, now)
+	expected := models.Snippet{Name: "pods", Command: "kubectl get pods -A", CreatedAt: created, UpdatedAt: now, SourceFile: "k8s.yaml"}
+	if !reflect.DeepEqual(edited, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, edited)
+	}
+
+	later := created.Add(time.Hour)
+	edited = models.Snippet{Name: "pods", CreatedAt: later, UpdatedAt: created}
+	keepEditedIdentity("pods", &original, &edited, now)
+	if !edited.CreatedAt.Equal(later) || !edited.UpdatedAt.Equal(now) {
+		t.Errorf("Expected an edited created_at to be kept and updated_at bumped, got %v and %v", edited.CreatedAt, edited.UpdatedAt)
+	}
+
+	data, err := yaml.Marshal(models.Snippet{Name: "fresh", Command: "echo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "_at") {
+		t.Errorf("Expected zero timestamps to be omitted, got:\n%s", data)
+	}
+}
+
+// TestParseEditedSnippet tests which edits cs edit sends back to the editor
+func TestParseEditedSnippet(t *testing.T) {
+	config = &models.Config{TransformTemplates: map[string]models.TransformTemplate{
+		"ns-flag": {Transform: &models.Transform{ValuePattern: "-n {{.Value}}"}},
+	}}
+	t.Cleanup(func() { config = nil })
+
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"valid", "command: kubectl get pods <ns>\nvariables:\n  - name: ns\n    transform_template: ns-flag\n", ""},
+		{"unused variable is only a warning", "command: echo\nvariables:\n  - name: unused\n", ""},
+		{"bad yaml", "command: [unclosed\n", "invalid YAML"},
+		{"missing variable", "command: echo <message>\n", "placeholder <message> has no matching variable"},
+		{"unknown transform template", "command: echo <ns>\nvariables:\n  - name: ns\n    transform_template: nope\n", "unknown transform template 'nope'"},
+		{"invalid compose", "command: echo <x>\nvariables:\n  - name: x\n    computed: true\n    transform:\n      compose: '{{.y'\n", "invalid compose template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, err := parseEditedSnippet("test", []byte(tt.yaml))
+			if tt.wantErr == "" {
+				if err != nil || snippet == nil {
+					t.Errorf("Expected the edit to be accepted, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestParseEditedDefinitions tests the checks on transform templates and
+// variable types edited with cs edit --transform and --type
+func TestParseEditedDefinitions(t *testing.T) {
+	if _, err := parseEditedTransformTemplate("ns", []byte("transform:\n  value_pattern: '-n {{.Value}}'\n")); err != nil {
+		t.Errorf("Expected a valid transform template, got %v", err)
+	}
+	if _, err := parseEditedTransformTemplate("ns", []byte("description: no transform\n")); err == nil || !strings.Contains(err.Error(), "no transform defined") {
+		t.Errorf("Expected a missing transform error, got %v", err)
+	}
+	if _, err := parseEditedTransformTemplate("ns", []byte("transform:\n  compose: '{{.x'\n")); err == nil || !strings.Contains(err.Error(), "invalid compose template") {
+		t.Errorf("Expected an invalid compose error, got %v", err)
+	}
+
+	if _, err := parseEditedVariableType("env", []byte("default: dev\nvalidation:\n  enum: [dev, prod]\n")); err != nil {
+		t.Errorf("Expected a valid variable type, got %v", err)
+	}
+	if _, err := parseEditedVariableType("env", []byte("default: qa\nvalidation:\n  enum: [dev, prod]\n")); err == nil || !strings.Contains(err.Error(), "default 'qa' is not one of the allowed values") {
+		t.Errorf("Expected a default outside the enum to be rejected, got %v", err)
+	}
+	if _, err := parseEditedVariableType("env", []byte("default: [\n")); err == nil || !strings.Contains(err.Error(), "invalid YAML") {
+		t.Errorf("Expected a YAML error, got %v", err)
+	}
+
+	err := unknownDefinitionError(usageType, "prot", map[string]models.VariableType{"port": {}, "env": {}})
+	if err == nil || err.Error() != "variable type 'prot' not found (available: env, port)" {
+		t.Errorf("Expected the available types to be listed, got %v", err)
+	}
+}
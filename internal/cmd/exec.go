@@ -2,17 +2,31 @@ package cmd
 
 import (
 	"bytes"
+	"cmp"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
+	"github.com/samling/command-snippets/internal/clipboard"
 	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/state"
 	"github.com/samling/command-snippets/internal/template"
 
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
 )
 
 func newExecCmd() *cobra.Command {
@@ -30,147 +44,803 @@ Examples:
   cs exec kubectl-get-pods --run        # Execute automatically
   cs exec kubectl-get-pods --prompt     # Prompt before executing
   cs exec kubectl-get-pods --set namespace=kube-system  # Pre-set variables
-  cs exec docker-run --set port=8080 --set image=nginx  # Multiple variables`,
-		RunE: runExec,
+  cs exec docker-run --set port=8080 --set image=nginx  # Multiple variables
+  cs exec --no-selector --sort recent   # Most recently used snippets first
+  cs exec --tags k8s                    # Only offer k8s-tagged snippets
+  cs exec --multi --run                 # Pick several snippets and run them in turn
+  cs exec kubectl-get-pods --copy       # Also copy the command to the clipboard
+  cs exec kubectl-get-pods --dry-run    # Show resolved values, print the command
+  cs exec docker-run --values-file values.yaml --set port=9090  # File values, --set wins
+  cs exec kubectl-apply --set file=app.yaml --non-interactive   # Never open the form
+  cs exec kubectl-get-pods --set namespace=default -o json      # Structured output for tooling
+  cs exec kubectl-get-pods --edit-command --run                 # Tweak the command before running it
+  cs exec --last                        # Repeat the previous invocation, form pre-filled
+  cs exec --last=2 --run                # Re-run the second most recent invocation as is
+
+Exit status:
+  With --run or --prompt, a command that fails makes cs exit with the
+  command's own status. Otherwise cs exits 0 on success, 1 on errors such
+  as an invalid config, 2 for invalid flags or arguments, and 130 when the
+  selector is cancelled. Cancelling the variable form returns to the
+  selector when it was opened from there, and exits with 130 otherwise.`,
+		RunE:              runExec,
+		ValidArgsFunction: completeSnippetNames,
 	}
 
 	// Add execution mode flags
-	cmd.Flags().Bool("run", false, "Automatically execute the command without prompting")
-	cmd.Flags().Bool("prompt", false, "Prompt before executing the command")
-	cmd.Flags().Bool("no-selector", false, "Use internal selector instead of configured external selector")
-	cmd.Flags().Bool("no-color", false, "Disable colored output in the TUI")
-	cmd.Flags().StringArray("set", []string{}, "Set variable values (format: key=value)")
+	addExecModeFlags(cmd)
+	cmd.Flags().Bool("ignore-unknown-set", false, "Ignore --set keys that don't match a variable instead of failing")
+	cmd.Flags().String("values-file", "", "Read variable values from a YAML or JSON file ('-' for stdin)")
+	cmd.Flags().Bool("non-interactive", false, "Never show the form; values must come from --set, --values-file, or defaults (implied when stdin or stderr is not a terminal)")
+	cmd.Flags().Bool("dry-run", false, "Show how each variable resolved and print the command without executing it")
+	cmd.Flags().StringP("output", "o", outputText, "Output format (text|json|yaml); json and yaml include the resolved values and mode")
+	cmd.Flags().Bool("edit-command", false, "Open the rendered command in $EDITOR before printing or running it")
+	cmd.Flags().Bool("copy", false, "Copy the rendered command to the clipboard (default from settings.interactive.copy_to_clipboard)")
+	cmd.Flags().String("sort", "", "Selector sort order for this invocation (alpha|recent|usage)")
+	cmd.Flags().StringSliceP("tags", "t", nil, "Only offer templates with any of these tags in the selector")
+	cmd.Flags().Bool("multi", false, "Select several templates and execute them one after another")
+	cmd.Flags().Int("last", 0, "Repeat the nth most recent invocation from history (--last=n, default 1); --run skips the form")
+	cmd.Flags().Lookup("last").NoOptDefVal = "1"
 
 	return cmd
 }
 
 func runExec(cmd *cobra.Command, args []string) error {
-	processor := template.NewProcessor(config)
-
 	var snippetName string
 
-	// If snippet name provided as argument
-	if len(args) > 0 {
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+	runFlag, _ := cmd.Flags().GetBool("run")
+
+	// --last takes the snippet and values from a history entry.
+	var historyValues map[string]string
+	rerunLast := false
+	if cmd.Flags().Changed("last") {
+		if len(args) > 0 {
+			return usageErrorf("--last cannot be combined with a snippet name (use --last=n to pick an older entry)")
+		}
+		n, _ := cmd.Flags().GetInt("last")
+		entry, err := lastInvocation(n)
+		if err != nil {
+			return err
+		}
+		snippetName = entry.Snippet
+		historyValues = historyPresets(entry)
+		// Running the previous invocation again needs no form.
+		rerunLast = runFlag
+	}
+
+	for _, flag := range []string{"tags", "multi"} {
+		if cmd.Flags().Changed(flag) && (snippetName != "" || len(args) > 0) {
+			return usageErrorf("--%s applies to the selector and cannot be combined with a snippet name or --last", flag)
+		}
+	}
+
+	switch {
+	case snippetName != "":
+		// Taken from history by --last.
+	case len(args) > 0:
+		// If snippet name provided as argument
 		snippetName = args[0]
-	} else {
+	case nonInteractive:
+		return usageErrorf("a snippet name is required with --non-interactive")
+	default:
 		// Interactive snippet selection
-		noSelector, _ := cmd.Flags().GetBool("no-selector")
-		noColor, _ := cmd.Flags().GetBool("no-color")
-		var err error
-		snippetName, err = selectSnippet(noSelector, noColor)
+		var opts selectOptions
+		opts.forceInternal, _ = cmd.Flags().GetBool("no-selector")
+		opts.noColor, _ = cmd.Flags().GetBool("no-color")
+		opts.sortBy, _ = cmd.Flags().GetString("sort")
+		opts.tags, _ = cmd.Flags().GetStringSlice("tags")
+		if opts.sortBy != "" {
+			if _, err := parseSortMode(opts.sortBy); err != nil {
+				return &usageError{err}
+			}
+		}
+		opts.multi, _ = cmd.Flags().GetBool("multi")
+		return selectAndExec(cmd, opts)
+	}
+
+	return execNamedSnippet(cmd, snippetName, historyValues, rerunLast, "", nil)
+}
+
+// addExecModeFlags adds the flags that pick how a snippet chosen in the
+// selector is executed, shared by exec and search --interactive.
+func addExecModeFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("run", false, "Automatically execute the command without prompting")
+	cmd.Flags().Bool("prompt", false, "Prompt before executing the command")
+	cmd.Flags().Bool("no-selector", false, "Use internal selector instead of configured external selector")
+	cmd.Flags().Bool("no-color", false, "Disable colored output in the TUI")
+	cmd.Flags().StringArray("set", []string{}, "Set variable values (format: key=value)")
+}
+
+// selectAndExec shows the selector and executes the snippets picked in it
+// according to the flags of cmd. Cancelling the form goes back to the
+// selector, on the snippet that was picked, so a wrong pick doesn't end
+// the invocation.
+func selectAndExec(cmd *cobra.Command, opts selectOptions) error {
+	for {
+		names, err := selectSnippet(opts)
+		if errors.Is(err, errAddSnippet) {
+			names, err = addSnippetFromSelector()
+		}
 		if err != nil {
-			// Handle user cancellation silently
 			if isUserCancellation(err) {
-				os.Exit(0)
+				return err
 			}
 			return fmt.Errorf("failed to select template: %w", err)
 		}
+		if opts.initial, err = execSelected(cmd, names); opts.initial == "" {
+			return err
+		}
 	}
+}
 
-	snippet, err := getSnippet(snippetName)
+// addSnippetFromSelector prompts for a new snippet on stderr, leaving
+// stdout to the command it prints, and returns its name so that it can be
+// executed next.
+func addSnippetFromSelector() ([]string, error) {
+	name, err := addSnippet("", survey.WithStdio(os.Stdin, os.Stderr, os.Stderr))
+	if errors.Is(err, terminal.InterruptErr) {
+		return nil, &UserCancellationError{"user cancelled adding a template"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Command template '%s' added successfully!\n\n", name)
+	return []string{name}, nil
+}
+
+// execSelected executes the snippets picked in the selector in order,
+// stopping at the first error or cancelled form; in print mode their
+// commands are printed one per line. Structured output of several snippets
+// is printed as one array once they have all been handled, or once one
+// fails. When the form of the first snippet is cancelled, before anything
+// ran, it returns that snippet's name so the selector can be shown again.
+func execSelected(cmd *cobra.Command, names []string) (reselect string, err error) {
+	var outputs *[]execOutput
+	if output, _ := cmd.Flags().GetString("output"); len(names) > 1 && output != "" && output != outputText {
+		outputs = new([]execOutput)
+		defer func() {
+			if len(*outputs) == 0 {
+				return
+			}
+			data, marshalErr := marshalOutput(*outputs, output)
+			if marshalErr == nil {
+				_, marshalErr = os.Stdout.Write(data)
+			}
+			err = cmp.Or(err, marshalErr)
+		}()
+	}
+
+	for i, name := range names {
+		separator := ""
+		if i > 0 {
+			separator = "\n"
+		}
+		err := execNamedSnippet(cmd, name, nil, false, separator, outputs)
+		if i == 0 && errors.Is(err, template.ErrUserCancelled) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// execNamedSnippet executes the snippet called snippetName according to
+// the flags of cmd. historyValues fill in variables not otherwise given,
+// rerunLast skips the form, and separator is printed before the command in
+// print mode. Structured output is appended to outputs instead of printed
+// when it is not nil.
+func execNamedSnippet(cmd *cobra.Command, snippetName string, historyValues map[string]string, rerunLast bool, separator string, outputs *[]execOutput) error {
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+	runFlag, _ := cmd.Flags().GetBool("run")
+
+	snippetName, snippet, err := findSnippet(snippetName)
 	if err != nil {
 		return err
 	}
+	snippet = snippet.WithGlobalVariables(config)
 
 	// Get execution mode flags
-	runFlag, _ := cmd.Flags().GetBool("run")
 	promptFlag, _ := cmd.Flags().GetBool("prompt")
 
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	// Commands other than exec have no --output
+	output, _ := cmd.Flags().GetString("output")
+	output = cmp.Or(output, outputText)
+	switch output {
+	case outputText, outputJSON, outputYAML:
+	default:
+		return usageErrorf("invalid --output value '%s' (expected text, json, or yaml)", output)
+	}
+
 	// Validate flags (mutually exclusive)
 	if runFlag && promptFlag {
-		return fmt.Errorf("--run and --prompt flags are mutually exclusive")
+		return usageErrorf("--run and --prompt flags are mutually exclusive")
+	}
+	if nonInteractive && promptFlag {
+		return usageErrorf("--prompt cannot be combined with --non-interactive")
+	}
+	if dryRun && (runFlag || promptFlag) {
+		return usageErrorf("--dry-run cannot be combined with --run or --prompt")
+	}
+	editCommand, _ := cmd.Flags().GetBool("edit-command")
+	if editCommand && (dryRun || nonInteractive) {
+		return usageErrorf("--edit-command cannot be combined with --dry-run or --non-interactive")
 	}
 
-	// Parse --set values
-	setValues, _ := cmd.Flags().GetStringArray("set")
+	presetValues, presetSources, err := collectPresets(cmd, snippetName, &snippet, historyValues)
+	if err != nil {
+		return err
+	}
+
+	opts := newExecOptions(presetValues)
+	opts.noColor, _ = cmd.Flags().GetBool("no-color")
+	opts.nonInteractive = opts.nonInteractive || nonInteractive || rerunLast
+	opts.noConfirm = opts.noConfirm || nonInteractive
+	if cmd.Flags().Changed("copy") {
+		opts.copyCommand, _ = cmd.Flags().GetBool("copy")
+	}
+	opts.output = output
+	opts.editCommand = editCommand
+	opts.separator = separator
+	opts.outputs = outputs
+
+	if dryRun {
+		return dryRunSnippet(snippetName, &snippet, opts, presetSources)
+	}
+
+	opts.mode, err = resolveExecMode(runFlag, promptFlag, opts.nonInteractive, &snippet, config.Settings.Interactive)
+	if err != nil {
+		return err
+	}
+
+	return executeSnippet(snippetName, &snippet, opts)
+}
 
+// collectPresets gathers the values given for the snippet's variables from
+// --set, then --values-file, then historyValues, each filling in only what
+// the ones before left unset. It also returns where each value came from,
+// for --dry-run.
+func collectPresets(cmd *cobra.Command, snippetName string, snippet *models.Snippet, historyValues map[string]string) (map[string]string, map[string]string, error) {
+	setValues, _ := cmd.Flags().GetStringArray("set")
 	presetValues, err := parseSetValues(setValues)
 	if err != nil {
-		return fmt.Errorf("invalid --set format: %w", err)
+		return nil, nil, usageErrorf("invalid --set format: %w", err)
 	}
 
+	presetSources := make(map[string]string, len(presetValues))
+	for k := range presetValues {
+		presetSources[k] = "--set"
+	}
+
+	// Computed variables are never read from input, wherever it comes from.
 	known := make(map[string]bool, len(snippet.Variables))
 	for _, v := range snippet.Variables {
-		known[v.Name] = true
+		known[v.Name] = !v.Computed
 	}
-	for k := range presetValues {
-		if !known[k] {
-			return fmt.Errorf("--set %s: snippet %q has no variable named %q", k, snippetName, k)
+	ignoreUnknown, _ := cmd.Flags().GetBool("ignore-unknown-set")
+	for _, k := range slices.Sorted(maps.Keys(presetValues)) {
+		err := checkSetKey(snippetName, snippet, k)
+		if err == nil {
+			continue
+		}
+		if ignoreUnknown {
+			delete(presetValues, k)
+			delete(presetSources, k)
+			continue
 		}
+		return nil, nil, usageErrorf("--set %s: %w (use --ignore-unknown-set to skip it)", k, err)
 	}
 
-	// Get no-color flag and pass it to the processor
-	noColor, _ := cmd.Flags().GetBool("no-color")
-	processor.NoColor = noColor
+	// Multiline variables accept \n escapes on the command line.
+	for _, v := range snippet.Variables {
+		if value, ok := presetValues[v.Name]; ok && v.Type == models.VarTypeMultiline {
+			presetValues[v.Name] = unescapeMultiline(value)
+		}
+	}
 
-	// Determine execution mode
-	var execMode template.ExecutionMode
+	// Values from --values-file fill in anything not given with --set.
+	if valuesFile, _ := cmd.Flags().GetString("values-file"); valuesFile != "" {
+		fileValues, err := loadValuesFile(valuesFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		var unknown []string
+		for k, v := range fileValues {
+			if !known[k] {
+				unknown = append(unknown, k)
+				continue
+			}
+			if _, ok := presetValues[k]; !ok {
+				presetValues[k] = v
+				presetSources[k] = "values-file"
+			}
+		}
+		if len(unknown) > 0 {
+			slices.Sort(unknown)
+			valid := make([]string, 0, len(snippet.Variables))
+			for _, v := range snippet.Variables {
+				if known[v.Name] {
+					valid = append(valid, v.Name)
+				}
+			}
+			fmt.Fprintf(os.Stderr, "Warning: ignoring unknown variables in %s: %s (valid: %s)\n",
+				valuesFile, strings.Join(unknown, ", "), strings.Join(valid, ", "))
+		}
+	}
+
+	// Values recorded in history fill in anything still unset.
+	for k, v := range historyValues {
+		if _, ok := presetValues[k]; !ok && known[k] {
+			presetValues[k] = v
+			presetSources[k] = "history"
+		}
+	}
+
+	return presetValues, presetSources, nil
+}
+
+// checkSetKey reports why key cannot be given with --set: the snippet has
+// no variable of that name, in which case the closest name is suggested, or
+// the variable is computed and never read from input.
+func checkSetKey(snippetName string, snippet *models.Snippet, key string) error {
+	var names []string
+	for _, v := range snippet.Variables {
+		if v.Name == key {
+			if v.Computed {
+				return fmt.Errorf("variable %q is computed and cannot be set", key)
+			}
+			return nil
+		}
+		if !v.Computed {
+			names = append(names, v.Name)
+		}
+	}
+	if suggestion := closestName(key, names); suggestion != "" {
+		return fmt.Errorf("snippet %q has no variable named %q; did you mean %q?", snippetName, key, suggestion)
+	}
+	return fmt.Errorf("snippet %q has no variable named %q", snippetName, key)
+}
+
+// lastInvocation returns the nth most recent history entry for --last,
+// explaining how to enable history when it is off.
+func lastInvocation(n int) (state.HistoryEntry, error) {
+	if n < 1 {
+		return state.HistoryEntry{}, usageErrorf("invalid --last value %d: expected a positive number", n)
+	}
+	if !config.Settings.History.Enabled {
+		return state.HistoryEntry{}, fmt.Errorf("--last needs execution history, which is disabled; set settings.history.enabled: true to record it")
+	}
+	entry, err := nthHistoryEntry(n)
+	if err != nil {
+		return state.HistoryEntry{}, fmt.Errorf("--last: %w", err)
+	}
+	return entry, nil
+}
+
+// resolveExecMode picks the execution mode: --run and --prompt win, then
+// the snippet's exec_mode, then settings.interactive.confirm_before_execute,
+// then print only. The setting, and an exec_mode of prompt, are ignored in
+// non-interactive mode, where no confirmation can be asked. Dangerous
+// snippets are handled later by guardDangerous.
+func resolveExecMode(runFlag, promptFlag, nonInteractive bool, snippet *models.Snippet, settings models.InteractiveConfig) (template.ExecutionMode, error) {
 	switch {
 	case runFlag:
-		execMode = template.AutoExecute
+		return template.AutoExecute, nil
 	case promptFlag:
-		execMode = template.PromptExecute
+		return template.PromptExecute, nil
+	case snippet.ExecMode != "":
+		mode, err := template.ParseExecutionMode(snippet.ExecMode)
+		if err != nil {
+			return template.PrintOnly, fmt.Errorf("snippet exec_mode: %w", err)
+		}
+		if mode == template.PromptExecute && nonInteractive {
+			return template.PrintOnly, nil
+		}
+		return mode, nil
+	case settings.ConfirmBeforeExecute && !nonInteractive:
+		return template.PromptExecute, nil
 	default:
-		execMode = template.PrintOnly
+		return template.PrintOnly, nil
 	}
+}
 
-	// Execute with specified mode
-	if err := processor.ExecuteWithModeAndPresets(&snippet, execMode, presetValues); err != nil {
-		if isUserCancellation(err) {
-			return nil
+// guardDangerous turns running a dangerous snippet into prompting for it,
+// however the run was asked for. When no confirmation can be asked
+// (noConfirm), running it is refused instead.
+func guardDangerous(mode template.ExecutionMode, snippetName string, snippet *models.Snippet, noConfirm bool) (template.ExecutionMode, error) {
+	if !snippet.Dangerous || mode == template.PrintOnly {
+		return mode, nil
+	}
+	if noConfirm {
+		return mode, usageErrorf("'%s' is marked dangerous and needs confirmation, which can't be asked without a terminal or with --non-interactive; rerun it interactively", snippetName)
+	}
+	return template.PromptExecute, nil
+}
+
+// execOptions holds the per-invocation settings shared by `cs exec` and
+// `cs history rerun`.
+type execOptions struct {
+	mode           template.ExecutionMode
+	presets        map[string]string
+	noColor        bool
+	copyCommand    bool
+	nonInteractive bool
+	noConfirm      bool          // no confirmation can be asked: no terminal, or --non-interactive
+	output         string        // outputText, outputJSON, or outputYAML
+	editCommand    bool          // open the rendered command in the editor first
+	separator      string        // printed before a text command, between the commands of a batch
+	outputs        *[]execOutput // collects structured output of a batch instead of printing it
+}
+
+// newExecOptions returns options seeded from settings. The form is skipped
+// automatically when it could not be shown.
+func newExecOptions(presets map[string]string) execOptions {
+	noTerminal := !canShowForm()
+	return execOptions{
+		presets:        presets,
+		copyCommand:    config.Settings.Interactive.CopyToClipboard,
+		nonInteractive: noTerminal,
+		noConfirm:      noTerminal,
+	}
+}
+
+// canShowForm reports whether the form has a terminal to draw on (stderr)
+// and read keys from. When stdin is not a terminal the form falls back to
+// /dev/tty, which is how it works from shell widgets whose stdin is
+// /dev/null, so stdin only disqualifies it if /dev/tty is unavailable too.
+func canShowForm() bool {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return false
+	}
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return true
+	}
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false
+	}
+	tty.Close()
+	return true
+}
+
+// newProcessor creates a template processor configured from opts.
+func (o execOptions) newProcessor() *template.Processor {
+	processor := template.NewProcessor(config)
+	processor.NoColor = o.noColor
+	processor.NonInteractive = o.nonInteractive
+	processor.HideCommand = !config.Settings.Interactive.ShowsFinalCommand()
+	return processor
+}
+
+// executeSnippet prompts for the snippet's variables, outputs the rendered
+// command (after editing it when opts.editCommand is set), and handles it
+// according to opts. The invocation is recorded in history before the
+// command runs, so a failed run can be re-run after fixing the cause, and
+// counted in usage only once the command has actually run.
+func executeSnippet(snippetName string, snippet *models.Snippet, opts execOptions) error {
+	mode, err := guardDangerous(opts.mode, snippetName, snippet, opts.noConfirm)
+	if err != nil {
+		return err
+	}
+
+	processor := opts.newProcessor()
+	if !opts.nonInteractive {
+		processor.Suggestions = valueSuggestions(snippetName, snippet)
+	}
+
+	result, err := processor.Render(snippet, opts.presets)
+	if err != nil {
+		return err
+	}
+	result.Mode = mode
+	result.Dangerous = snippet.Dangerous
+
+	if opts.editCommand {
+		edited, err := editCommandInEditor(result.Command)
+		if err != nil {
+			return err
 		}
+		if edited != result.Command {
+			// The edited text replaces all steps of a multi-step snippet.
+			result.Command = edited
+			result.Steps = nil
+		}
+	}
+
+	if err := opts.printResult(snippetName, result); err != nil {
+		return err
+	}
+	if opts.copyCommand {
+		copyToClipboard(result.PrintableCommand())
+	}
+	recordHistory(snippetName, snippet, result)
+
+	err = processor.Execute(result)
+	if result.Executed {
+		recordUsage(snippetName)
+	}
+	return err
+}
+
+// Output formats accepted by `cs exec --output`.
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// execOutput is the structured form of a rendered command for --output.
+type execOutput struct {
+	Snippet string            `yaml:"snippet"`
+	Command string            `yaml:"command"`
+	Workdir string            `yaml:"workdir,omitempty"`
+	Values  map[string]string `yaml:"values"`
+	Mode    string            `yaml:"mode"`
+}
+
+// printResult writes the rendered command to stdout in o.output. Text output
+// prints the command in print mode only, exactly as it would be pasted
+// (including any `cd <workdir> && ` prefix) after o.separator; structured
+// output is always printed, before the command is run in other modes, or
+// appended to o.outputs when that is set.
+func (o execOptions) printResult(snippetName string, result *template.Result) error {
+	if o.output == "" || o.output == outputText {
+		if result.Mode == template.PrintOnly {
+			fmt.Print(o.separator + result.PrintableCommand())
+		}
+		return nil
+	}
+
+	values := result.Values
+	if values == nil {
+		values = map[string]string{}
+	}
+	out := execOutput{
+		Snippet: snippetName,
+		Command: result.Command,
+		Workdir: result.Workdir,
+		Values:  values,
+		Mode:    result.Mode.String(),
+	}
+	if o.outputs != nil {
+		*o.outputs = append(*o.outputs, out)
+		return nil
+	}
+	data, err := marshalOutput(out, o.output)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// copyToClipboard copies command, warning on stderr if that is not possible.
+func copyToClipboard(command string) {
+	if err := clipboard.Copy(command); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not copy command to clipboard: %v\n", err)
+	}
+}
+
+// dryRunSnippet prompts for the snippet's variables like a normal exec, then
+// writes a table of how each variable resolved to stderr and the command to
+// stdout, with secret values redacted in both. Nothing is executed or
+// recorded. presetSources names the origin of each preset value.
+func dryRunSnippet(snippetName string, snippet *models.Snippet, opts execOptions, presetSources map[string]string) error {
+	result, err := opts.newProcessor().Render(snippet, opts.presets)
+	if err != nil {
+		return err
+	}
+
+	// Rendering from masked values keeps secrets out of both the table and
+	// the command.
+	masked := snippet.MaskSecrets(result.Values, redactedValue)
+	command, resolved, err := snippet.ProcessTemplateDetailed(masked, config)
+	if err != nil {
 		return err
 	}
+
+	variables := make(map[string]models.Variable, len(snippet.Variables))
+	for _, v := range snippet.Variables {
+		variables[v.Name] = v
+	}
+
+	if len(resolved) > 0 {
+		w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VARIABLE\tRAW\tTRANSFORMED\tSOURCE")
+		for _, r := range resolved {
+			v := variables[r.Name]
+			raw, source := strconv.Quote(r.Raw), valueSource(v, r.Raw, presetSources)
+			if v.Type == models.VarTypeSecret {
+				raw, source = redactedValue, valueSource(v, result.Values[r.Name], presetSources)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Name, raw, strconv.Quote(r.Transformed), source)
+		}
+		w.Flush()
+		fmt.Fprintln(os.Stderr)
+	}
+
+	result.Command, result.Values = command, masked
+	if err := opts.printResult(snippetName, result); err != nil {
+		return err
+	}
+	if opts.copyCommand {
+		copyToClipboard(result.PrintableCommand())
+	}
 	return nil
 }
 
-// selectSnippet shows an interactive snippet selector
-func selectSnippet(forceInternal bool, noColor bool) (string, error) {
-	if len(config.Snippets) == 0 {
-		return "", fmt.Errorf("no templates found")
+// valueSource reports where a variable's raw value most likely came from.
+// Values left at a default in the form are indistinguishable from typed
+// ones, so a value equal to the default is attributed to the default.
+func valueSource(v models.Variable, raw string, presetSources map[string]string) string {
+	if v.Computed {
+		return "computed"
+	}
+	if source, ok := presetSources[v.Name]; ok {
+		return source
+	}
+	if env := v.DefaultFromEnv; env != "" && raw != "" && raw == os.Getenv(env) {
+		return "$" + env
+	}
+	if v.DefaultValue != "" && (raw == "" || raw == v.DefaultValue) {
+		return "default"
+	}
+	varType, ok := config.VariableTypes[v.Type]
+	if ok && v.DefaultValue == "" && varType.DefaultFromEnv != "" && raw != "" && raw == os.Getenv(varType.DefaultFromEnv) {
+		return "$" + varType.DefaultFromEnv
+	}
+	if ok && v.DefaultValue == "" && varType.Default != "" && raw == varType.Default {
+		return "type default"
 	}
+	return "form"
+}
+
+// selectOptions controls the snippet selector.
+type selectOptions struct {
+	forceInternal bool     // Use the built-in selector even when an external one is configured
+	noColor       bool     // Disable colors in the built-in selector
+	sortBy        string   // Order of the options, overriding the configured one when set
+	tags          []string // Only offer snippets with any of these tags
+	only          []string // Only offer these snippets, when set
+	query         string   // Initial filter text, dropped when it matches no option
+	multi         bool     // Several snippets can be selected
+	initial       string   // Snippet the built-in selector starts on
+}
 
+// selectSnippet shows an interactive snippet selector. A non-empty sortBy
+// overrides the configured order for both the external and built-in selector;
+// otherwise the built-in selector uses settings.selector.internal_sort,
+// falling back to settings.selector.sort. With multi, several snippets can
+// be selected, and are returned in the order they were picked. When no
+// snippet is left to offer, it returns errAddSnippet if the user asks to
+// add one.
+func selectSnippet(opts selectOptions) ([]string, error) {
+	tags, noColor := opts.tags, opts.noColor
 	snippetsMap := make(map[string]*models.Snippet, len(config.Snippets))
+	snippetTags := make(map[string][]string, len(config.Snippets))
 	for name, snippet := range config.Snippets {
+		if len(tags) > 0 && !hasAnyTag(snippet.Tags, tags) {
+			continue
+		}
+		if opts.only != nil && !slices.Contains(opts.only, name) {
+			continue
+		}
 		snippetsMap[name] = &snippet
+		snippetTags[name] = snippet.Tags
+	}
+	// With nothing to offer, the built-in selector offers to add a snippet
+	switch {
+	case len(config.Snippets) == 0:
+		return selectNothing("No templates yet.", noColor)
+	case len(snippetsMap) == 0:
+		return selectNothing(fmt.Sprintf("No templates found matching tags: %s.", strings.Join(tags, ", ")), noColor)
 	}
-	options, byDisplay := buildSnippetOptions(snippetsMap)
+	selector := config.Settings.Selector
 
-	if !forceInternal {
-		selected, err := tryExternalSelector(options, byDisplay)
+	if !opts.forceInternal {
+		options, byDisplay := buildSnippetOptions(snippetsMap, cmp.Or(opts.sortBy, selector.Sort))
+		selected, err := tryExternalSelector(options, byDisplay, opts.multi, initialQuery(options, opts.query))
 		if err == nil {
 			return selected, nil
 		}
 		if isUserCancellation(err) {
-			return "", err
+			return nil, err
 		}
 		// fall through to bubbletea selector
 	}
 
-	return selectSnippetWithBubbleTea(options, byDisplay, noColor)
+	internalSort, err := parseSortMode(cmp.Or(opts.sortBy, selector.InternalSort, selector.Sort))
+	if err != nil {
+		return nil, fmt.Errorf("settings.selector: %w", err)
+	}
+	options, byDisplay := buildSnippetOptions(snippetsMap, internalSort)
+
+	var suffixes map[string]string
+	if internalSort == sortByRecent {
+		suffixes = lastUsedSuffixes(byDisplay, time.Now())
+	}
+
+	opts.query = initialQuery(options, opts.query)
+	return selectSnippetWithBubbleTea(options, byDisplay, suffixes, snippetTags, opts)
+}
+
+// initialQuery returns query if one of options contains its letters in
+// order, ignoring case, and "" otherwise, so that the selector doesn't
+// start out hiding everything.
+func initialQuery(options []string, query string) string {
+	pattern := []rune(strings.ToLower(query))
+	for _, option := range options {
+		if fuzzyPositions([]rune(strings.ToLower(option)), pattern) != nil {
+			return query
+		}
+	}
+	return ""
+}
+
+// lastUsedSuffixes maps each display option to a "last used 2d ago" note,
+// omitting snippets that have never been executed.
+func lastUsedSuffixes(byDisplay map[string]string, now time.Time) map[string]string {
+	usage := loadUsage()
+	suffixes := make(map[string]string, len(byDisplay))
+	for display, name := range byDisplay {
+		if entry, ok := usage.Snippets[name]; ok && !entry.LastUsed.IsZero() {
+			suffixes[display] = "last used " + formatTimeAgo(entry.LastUsed, now)
+		}
+	}
+	return suffixes
 }
 
-// tryExternalSelector attempts to use configured external selector (like fzf)
-func tryExternalSelector(options []string, snippetMap map[string]string) (string, error) {
+// tryExternalSelector attempts to use configured external selector (like fzf).
+// With multi, fzf is passed --multi so several snippets can be selected;
+// other selectors return one. fzf is given each snippet's name before a tab
+// and shows only what follows, so the selection maps back to the snippet
+// even when display strings collide, and the name is available to the
+// preview command as {1}; other selectors are matched by the display string.
+// A non-empty query is given to fzf as its initial --query.
+func tryExternalSelector(options []string, snippetMap map[string]string, multi bool, query string) ([]string, error) {
 	// Check if external selector is configured
 	selectorCmd := config.Settings.Selector.Command
 	if selectorCmd == "" {
-		return "", fmt.Errorf("no external selector configured")
+		return nil, fmt.Errorf("no external selector configured")
 	}
 
 	// Check if selector command is available
 	if _, err := exec.LookPath(selectorCmd); err != nil {
-		return "", fmt.Errorf("selector command '%s' not found: %w", selectorCmd, err)
+		return nil, fmt.Errorf("selector command '%s' not found: %w", selectorCmd, err)
 	}
 
+	isFzf := filepath.Base(selectorCmd) == "fzf"
+
 	// Prepare input for selector (one option per line)
-	input := strings.Join(options, "\n")
+	lines := options
+	if isFzf {
+		lines = make([]string, len(options))
+		for i, option := range options {
+			lines[i] = snippetMap[option] + "\t" + option
+		}
+	}
+	input := strings.Join(lines, "\n")
 
-	// Build command with options
+	// Build command with options. fzf's own come first so the configured
+	// options can override them.
 	var cmdArgs []string
+	if isFzf {
+		cmdArgs = append(cmdArgs, "--delimiter", "\t", "--with-nth", "2..",
+			"--preview", cmp.Or(config.Settings.Selector.Preview, models.DefaultSelectorPreview))
+	}
 	if config.Settings.Selector.Options != "" {
 		// Parse options string into individual arguments
-		cmdArgs = strings.Fields(config.Settings.Selector.Options)
+		cmdArgs = append(cmdArgs, strings.Fields(config.Settings.Selector.Options)...)
+	}
+	if isFzf && multi {
+		cmdArgs = append(cmdArgs, "--multi")
+	}
+	if isFzf && query != "" {
+		cmdArgs = append(cmdArgs, "--query", query)
 	}
 
 	// Create and run the selector command
@@ -190,27 +860,39 @@ func tryExternalSelector(options []string, snippetMap map[string]string) (string
 				// 130 = Ctrl+C (SIGINT)
 				// 1 = general cancellation in many tools
 				if exitCode == 130 || exitCode == 1 {
-					return "", &UserCancellationError{"user cancelled selection"}
+					return nil, &UserCancellationError{"user cancelled selection"}
 				}
 			}
 		}
-		return "", fmt.Errorf("selector command failed: %w", err)
+		return nil, fmt.Errorf("selector command failed: %w", err)
 	}
 
-	// Parse the selected option
+	// Parse the selected options, one per line
 	selected := strings.TrimSpace(output.String())
 	if selected == "" {
-		return "", &UserCancellationError{"no selection made"}
+		return nil, &UserCancellationError{"no selection made"}
 	}
 
-	// Look up the actual snippet name
-	if snippetName, exists := snippetMap[selected]; exists {
-		return snippetName, nil
+	// Look up the actual snippet names
+	var names []string
+	for _, line := range strings.Split(selected, "\n") {
+		snippetName, exists := snippetMap[strings.TrimSpace(line)]
+		if isFzf {
+			snippetName, _, _ = strings.Cut(line, "\t")
+			_, exists = config.Snippets[snippetName]
+		}
+		if !exists {
+			return nil, fmt.Errorf("selected option not found: %s", line)
+		}
+		names = append(names, snippetName)
 	}
-
-	return "", fmt.Errorf("selected option not found: %s", selected)
+	return names, nil
 }
 
+// errAddSnippet is returned by the selector when there were no snippets to
+// select and the user asked to add one.
+var errAddSnippet = errors.New("no templates to select; add one")
+
 // UserCancellationError indicates the user cancelled the operation
 type UserCancellationError struct {
 	Message string
@@ -221,12 +903,13 @@ func (e *UserCancellationError) Error() string {
 }
 
 // isUserCancellation checks if an error represents user cancellation
-// from any of the snippet selectors or the variable form.
+// from any of the snippet selectors, the variable form, or the confirmation
+// before executing.
 func isUserCancellation(err error) bool {
 	if err == nil {
 		return false
 	}
-	if errors.Is(err, template.ErrUserCancelled) {
+	if errors.Is(err, template.ErrUserCancelled) || errors.Is(err, template.ErrConfirmCancelled) {
 		return true
 	}
 	var uce *UserCancellationError
@@ -249,6 +932,52 @@ func parseSetValues(setValues []string) (map[string]string, error) {
 	return result, nil
 }
 
+// multilineEscapes turns the \n and \\ escapes accepted by --set for
+// multiline variables into a newline and a backslash.
+var multilineEscapes = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+
+// unescapeMultiline expands the escapes in a --set value for a multiline
+// variable.
+func unescapeMultiline(value string) string {
+	return multilineEscapes.Replace(value)
+}
+
+// loadValuesFile reads a flat map of variable values from a YAML or JSON
+// document; path "-" reads from stdin. Scalars are taken verbatim, so 08 or
+// 1.0 keep their spelling.
+func loadValuesFile(path string) (map[string]string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	var nodes map[string]yaml.Node
+	if err := yaml.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(nodes))
+	for name, node := range nodes {
+		if node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("values file %s: value for '%s' must be a scalar", path, name)
+		}
+		if node.Tag == "!!null" {
+			values[name] = ""
+			continue
+		}
+		values[name] = node.Value
+	}
+	return values, nil
+}
+
 // parseKeyValue parses a key=value string
 func parseKeyValue(input string) (string, string, error) {
 	rawKey, rawValue, ok := strings.Cut(input, "=")
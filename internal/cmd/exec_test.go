@@ -0,0 +1,608 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/template"
+	"gopkg.in/yaml.v3"
+)
+
+// TestResolveExecMode tests how flags and settings pick the execution mode
+func TestResolveExecMode(t *testing.T) {
+	tests := []struct {
+		name           string
+		runFlag        bool
+		promptFlag     bool
+		nonInteractive bool
+		confirm        bool
+		execMode       string
+		expected       template.ExecutionMode
+	}{
+		{name: "no flags, no confirm", expected: template.PrintOnly},
+		{name: "no flags, confirm", confirm: true, expected: template.PromptExecute},
+		{name: "run, no confirm", runFlag: true, expected: template.AutoExecute},
+		{name: "run overrides confirm", runFlag: true, confirm: true, expected: template.AutoExecute},
+		{name: "prompt, no confirm", promptFlag: true, expected: template.PromptExecute},
+		{name: "prompt, confirm", promptFlag: true, confirm: true, expected: template.PromptExecute},
+		{name: "confirm ignored when non-interactive", confirm: true, nonInteractive: true, expected: template.PrintOnly},
+		{name: "run when non-interactive", runFlag: true, confirm: true, nonInteractive: true, expected: template.AutoExecute},
+		{name: "exec_mode run", execMode: "run", expected: template.AutoExecute},
+		{name: "exec_mode overrides confirm", execMode: "print", confirm: true, expected: template.PrintOnly},
+		{name: "prompt flag overrides exec_mode", promptFlag: true, execMode: "run", expected: template.PromptExecute},
+		{name: "run flag overrides exec_mode", runFlag: true, execMode: "prompt", expected: template.AutoExecute},
+		{name: "exec_mode prompt ignored when non-interactive", execMode: "prompt", nonInteractive: true, expected: template.PrintOnly},
+		{name: "exec_mode run when non-interactive", execMode: "run", nonInteractive: true, expected: template.AutoExecute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := models.InteractiveConfig{ConfirmBeforeExecute: tt.confirm}
+			snippet := &models.Snippet{ExecMode: tt.execMode}
+			got, err := resolveExecMode(tt.runFlag, tt.promptFlag, tt.nonInteractive, snippet, settings)
+			if err != nil {
+				t.Fatalf("resolveExecMode failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := resolveExecMode(false, false, false, &models.Snippet{ExecMode: "always"}, models.InteractiveConfig{}); err == nil {
+		t.Error("Expected an error for an unknown exec_mode")
+	}
+}
+
+// TestGuardDangerous tests that dangerous snippets are never run without
+// confirmation, and are refused when none can be asked
+func TestGuardDangerous(t *testing.T) {
+	tests := []struct {
+		mode      template.ExecutionMode
+		dangerous bool
+		noConfirm bool
+		expected  template.ExecutionMode
+		wantErr   bool
+	}{
+		{template.AutoExecute, false, false, template.AutoExecute, false},
+		{template.AutoExecute, true, false, template.PromptExecute, false},
+		{template.PromptExecute, true, false, template.PromptExecute, false},
+		{template.PrintOnly, true, false, template.PrintOnly, false},
+		{template.AutoExecute, false, true, template.AutoExecute, false},
+		{template.AutoExecute, true, true, template.AutoExecute, true},
+		{template.PromptExecute, true, true, template.PromptExecute, true},
+		{template.PrintOnly, true, true, template.PrintOnly, false},
+	}
+
+	for _, tt := range tests {
+		got, err := guardDangerous(tt.mode, "rm-all", &models.Snippet{Dangerous: tt.dangerous}, tt.noConfirm)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("guardDangerous(%v, dangerous=%v, noConfirm=%v) error = %v, expected error: %v", tt.mode, tt.dangerous, tt.noConfirm, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != tt.expected {
+			t.Errorf("guardDangerous(%v, dangerous=%v, noConfirm=%v) = %v, expected %v", tt.mode, tt.dangerous, tt.noConfirm, got, tt.expected)
+		}
+	}
+}
+
+// TestExecNamedSnippet_DangerousNonInteractive tests that running a
+// dangerous snippet with --run --non-interactive fails with a usage error
+// before anything runs, while printing it still works
+func TestExecNamedSnippet_DangerousNonInteractive(t *testing.T) {
+	dir := useConfigDir(t, map[string]string{"config.yaml": "settings:\n  execution:\n    shell: sh\n" +
+		"snippets:\n  wipe:\n    command: touch ran\n    dangerous: true\n"})
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	cmd := newExecCmd()
+	if err := cmd.ParseFlags([]string{"--run", "--non-interactive"}); err != nil {
+		t.Fatal(err)
+	}
+	err := execNamedSnippet(cmd, "wipe", nil, false, "", nil)
+	var usageErr *usageError
+	if !errors.As(err, &usageErr) || !strings.Contains(err.Error(), "'wipe' is marked dangerous") {
+		t.Errorf("Expected a usage error about the dangerous snippet, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err == nil {
+		t.Error("Expected the dangerous command not to run")
+	}
+
+	cmd = newExecCmd()
+	if err := cmd.ParseFlags([]string{"--non-interactive"}); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		if err := execNamedSnippet(cmd, "wipe", nil, false, "", nil); err != nil {
+			t.Errorf("Expected printing a dangerous snippet to work, got %v", err)
+		}
+	})
+	if out != "touch ran" {
+		t.Errorf("Expected the command to be printed, got %q", out)
+	}
+}
+
+// TestExecProcessorHideCommand tests that show_final_command controls the command echo
+func TestExecProcessorHideCommand(t *testing.T) {
+	show, hide := true, false
+
+	tests := []struct {
+		name     string
+		setting  *bool
+		expected bool
+	}{
+		{name: "unset shows command", setting: nil, expected: false},
+		{name: "true shows command", setting: &show, expected: false},
+		{name: "false hides command", setting: &hide, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = &models.Config{Settings: models.Settings{
+				Interactive: models.InteractiveConfig{ShowFinalCommand: tt.setting},
+			}}
+			t.Cleanup(func() { config = nil })
+
+			processor := execOptions{}.newProcessor()
+			if processor.HideCommand != tt.expected {
+				t.Errorf("Expected HideCommand=%v, got %v", tt.expected, processor.HideCommand)
+			}
+		})
+	}
+}
+
+// TestLastInvocation tests the errors for --last without usable history
+func TestLastInvocation(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		n        int
+		contains string
+	}{
+		{name: "history disabled", n: 1, contains: "settings.history.enabled"},
+		{name: "no history yet", enabled: true, n: 1, contains: "no history recorded"},
+		{name: "invalid n", enabled: true, n: 0, contains: "expected a positive number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config = &models.Config{Settings: models.Settings{
+				History: models.HistoryConfig{Enabled: tt.enabled},
+			}}
+			t.Cleanup(func() { config = nil })
+
+			_, err := lastInvocation(tt.n)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+// TestUnescapeMultiline tests the escapes --set accepts for multiline variables
+func TestUnescapeMultiline(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"one line", "one line"},
+		{`first\nsecond`, "first\nsecond"},
+		{`trailing\n`, "trailing\n"},
+		{`C:\\new`, `C:\new`},
+		{`literal\\n`, `literal\n`},
+	}
+
+	for _, tt := range tests {
+		if got := unescapeMultiline(tt.input); got != tt.expected {
+			t.Errorf("unescapeMultiline(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestCheckSetKey tests which --set keys are accepted and the suggestions
+// given for the rest
+func TestCheckSetKey(t *testing.T) {
+	snippet := &models.Snippet{
+		Variables: []models.Variable{
+			{Name: "namespace"},
+			{Name: "pod"},
+			{Name: "target", Computed: true},
+		},
+	}
+
+	tests := []struct {
+		key      string
+		contains string // empty when the key is accepted
+	}{
+		{"namespace", ""},
+		{"namepsace", `did you mean "namespace"?`},
+		{"pdo", `did you mean "pod"?`},
+		{"image", `has no variable named "image"`},
+		{"target", "is computed"},
+		{"targte", `has no variable named "targte"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := checkSetKey("k", snippet, tt.key)
+			if tt.contains == "" {
+				if err != nil {
+					t.Errorf("Expected %s to be accepted, got %v", tt.key, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected an error containing %q, got %v", tt.contains, err)
+			}
+			if strings.Contains(tt.contains, "has no variable") && strings.Contains(err.Error(), "did you mean") {
+				t.Errorf("Expected no suggestion, got %v", err)
+			}
+		})
+	}
+}
+
+// TestLoadValuesFile tests reading values from YAML and JSON files, and
+// rejecting malformed ones
+func TestLoadValuesFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected map[string]string
+		contains string // empty when the file loads
+	}{
+		{"yaml", "namespace: prod\nport: 08\nratio: 1.0\nempty:\n", map[string]string{"namespace": "prod", "port": "08", "ratio": "1.0", "empty": ""}, ""},
+		{"json", `{"namespace": "prod", "replicas": 3, "debug": true}`, map[string]string{"namespace": "prod", "replicas": "3", "debug": "true"}, ""},
+		{"empty", "", map[string]string{}, ""},
+		{"malformed", "namespace: [prod\n", nil, "failed to parse values file"},
+		{"not a map", "- prod\n", nil, "failed to parse values file"},
+		{"nested value", "labels:\n  app: web\n", nil, "value for 'labels' must be a scalar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "values.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			values, err := loadValuesFile(path)
+			if tt.contains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.contains) {
+					t.Errorf("Expected an error containing %q, got %v", tt.contains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadValuesFile failed: %v", err)
+			}
+			if !maps.Equal(values, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, values)
+			}
+		})
+	}
+
+	if _, err := loadValuesFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "failed to read values file") {
+		t.Errorf("Expected a read error for a missing file, got %v", err)
+	}
+}
+
+// TestCollectPresets tests that --set wins over --values-file, which wins
+// over history, that unknown --set keys are rejected unless ignored, and
+// that computed variables are never preset
+func TestCollectPresets(t *testing.T) {
+	snippet := &models.Snippet{Variables: []models.Variable{
+		{Name: "namespace"}, {Name: "pod"}, {Name: "container"}, {Name: "script", Type: models.VarTypeMultiline},
+		{Name: "target", Computed: true},
+	}}
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("namespace: from-file\npod: from-file\ntarget: x\nunknown: x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	history := map[string]string{"namespace": "from-history", "pod": "from-history", "container": "from-history", "target": "x", "gone": "x"}
+
+	tests := []struct {
+		name     string
+		args     []string
+		values   map[string]string
+		sources  map[string]string
+		contains string // empty when the flags are accepted
+	}{
+		{
+			name:    "precedence",
+			args:    []string{"--set", "namespace=from-set", "--set", `script=a\nb`, "--values-file", valuesFile},
+			values:  map[string]string{"namespace": "from-set", "pod": "from-file", "container": "from-history", "script": "a\nb"},
+			sources: map[string]string{"namespace": "--set", "pod": "values-file", "container": "history", "script": "--set"},
+		},
+		{
+			name:    "history only",
+			values:  map[string]string{"namespace": "from-history", "pod": "from-history", "container": "from-history"},
+			sources: map[string]string{"namespace": "history", "pod": "history", "container": "history"},
+		},
+		{name: "unknown key", args: []string{"--set", "namepsace=x"}, contains: `did you mean "namespace"?`},
+		{name: "computed key", args: []string{"--set", "target=x"}, contains: "is computed"},
+		{name: "malformed --set", args: []string{"--set", "namespace"}, contains: "invalid --set format"},
+		{
+			name:    "unknown key ignored",
+			args:    []string{"--set", "namepsace=x", "--set", "target=x", "--ignore-unknown-set"},
+			values:  map[string]string{"namespace": "from-history", "pod": "from-history", "container": "from-history"},
+			sources: map[string]string{"namespace": "history", "pod": "history", "container": "history"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newExecCmd()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			values, sources, err := collectPresets(cmd, "k", snippet, history)
+			if tt.contains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.contains) {
+					t.Errorf("Expected an error containing %q, got %v", tt.contains, err)
+				}
+				var usageErr *usageError
+				if !errors.As(err, &usageErr) {
+					t.Errorf("Expected a usage error, got %T", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("collectPresets failed: %v", err)
+			}
+			if !maps.Equal(values, tt.values) {
+				t.Errorf("Expected values %v, got %v", tt.values, values)
+			}
+			if !maps.Equal(sources, tt.sources) {
+				t.Errorf("Expected sources %v, got %v", tt.sources, sources)
+			}
+		})
+	}
+
+	cmd := newExecCmd()
+	if err := cmd.ParseFlags([]string{"--values-file", filepath.Join(t.TempDir(), "missing.yaml")}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := collectPresets(cmd, "k", snippet, nil); err == nil {
+		t.Error("Expected an error for a missing values file")
+	}
+}
+
+// TestExecNamedSnippet_NonInteractiveInvalid tests that values failing
+// validation are reported in non-interactive mode instead of being used
+func TestExecNamedSnippet_NonInteractiveInvalid(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": "snippets:\n  serve:\n    command: serve --port <port>\n" +
+		"    variables:\n      - name: port\n        default: \"0\"\n        validation:\n          range: [1, 65535]\n"})
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("port: 70000\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"--non-interactive", "--set", "port=0"},
+		{"--non-interactive", "--values-file", valuesFile},
+		{"--non-interactive", "--dry-run", "--set", "port=http"},
+		{"--non-interactive"}, // the default
+	} {
+		cmd := newExecCmd()
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		err := execNamedSnippet(cmd, "serve", nil, false, "", nil)
+		if err == nil || !strings.Contains(err.Error(), "variable port must be") {
+			t.Errorf("%v: expected a validation error, got %v", args, err)
+		}
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	old := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = old }()
+	fn()
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestExecNamedSnippet_Usage tests that usage counts only commands that
+// ran, not printed ones or dry runs
+func TestExecNamedSnippet_Usage(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": "settings:\n  execution:\n    shell: sh\n" +
+		"snippets:\n  hello:\n    command: echo hello > /dev/null\n"})
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	for _, tt := range []struct {
+		args     []string
+		expected int
+	}{
+		{[]string{"--non-interactive"}, 0},
+		{[]string{"--non-interactive", "--dry-run"}, 0},
+		{[]string{"--non-interactive", "-o", "json"}, 0},
+		{[]string{"--non-interactive", "--run"}, 1},
+		{[]string{"--non-interactive", "--run"}, 2},
+	} {
+		cmd := newExecCmd()
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		captureStdout(t, func() {
+			if err := execNamedSnippet(cmd, "hello", nil, false, "", nil); err != nil {
+				t.Fatalf("%v: execNamedSnippet failed: %v", tt.args, err)
+			}
+		})
+		if got := loadUsage().Snippets["hello"].Count; got != tt.expected {
+			t.Errorf("%v: expected a usage count of %d, got %d", tt.args, tt.expected, got)
+		}
+	}
+}
+
+// TestExecSelected_Output tests that structured output of several selected
+// snippets is printed as one array, and of a single one as an object
+func TestExecSelected_Output(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": "snippets:\n" +
+		"  pods:\n    command: kubectl get pods -n <ns>\n    variables:\n      - name: ns\n        default: dev\n" +
+		"  nodes:\n    command: kubectl get nodes\n"})
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	tests := []struct {
+		name  string
+		args  []string
+		names []string
+		check func(t *testing.T, out string)
+	}{
+		{
+			name:  "json array",
+			args:  []string{"--non-interactive", "-o", "json"},
+			names: []string{"nodes", "pods"},
+			check: func(t *testing.T, out string) {
+				var outputs []execOutput
+				if err := json.Unmarshal([]byte(out), &outputs); err != nil {
+					t.Fatalf("Expected a single JSON array, got %v:\n%s", err, out)
+				}
+				if len(outputs) != 2 || outputs[0].Snippet != "nodes" || outputs[1].Command != "kubectl get pods -n dev" {
+					t.Errorf("Expected nodes then pods, got %+v", outputs)
+				}
+			},
+		},
+		{
+			name:  "yaml sequence",
+			args:  []string{"--non-interactive", "-o", "yaml", "--dry-run"},
+			names: []string{"pods", "nodes"},
+			check: func(t *testing.T, out string) {
+				var outputs []execOutput
+				if err := yaml.Unmarshal([]byte(out), &outputs); err != nil {
+					t.Fatalf("Expected a single YAML sequence, got %v:\n%s", err, out)
+				}
+				if len(outputs) != 2 || outputs[0].Snippet != "pods" || outputs[1].Snippet != "nodes" {
+					t.Errorf("Expected pods then nodes, got %+v", outputs)
+				}
+			},
+		},
+		{
+			name:  "single selection",
+			args:  []string{"--non-interactive", "-o", "json"},
+			names: []string{"pods"},
+			check: func(t *testing.T, out string) {
+				var output execOutput
+				if err := json.Unmarshal([]byte(out), &output); err != nil || output.Snippet != "pods" {
+					t.Errorf("Expected a single object, got %v:\n%s", err, out)
+				}
+			},
+		},
+		{
+			name:  "text",
+			args:  []string{"--non-interactive"},
+			names: []string{"nodes", "pods"},
+			check: func(t *testing.T, out string) {
+				if out != "kubectl get nodes\nkubectl get pods -n dev" {
+					t.Errorf("Expected one command per line, got %q", out)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newExecCmd()
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			out := captureStdout(t, func() {
+				if reselect, err := execSelected(cmd, tt.names); err != nil || reselect != "" {
+					t.Errorf("execSelected failed: %v (reselect %q)", err, reselect)
+				}
+			})
+			tt.check(t, out)
+		})
+	}
+
+	// What ran before a failure is still printed
+	cmd := newExecCmd()
+	if err := cmd.ParseFlags([]string{"--non-interactive", "-o", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	out := captureStdout(t, func() {
+		if _, err := execSelected(cmd, []string{"nodes", "missing"}); err == nil {
+			t.Error("Expected an error for a missing template")
+		}
+	})
+	var outputs []execOutput
+	if err := json.Unmarshal([]byte(out), &outputs); err != nil || len(outputs) != 1 {
+		t.Errorf("Expected the output of nodes, got %v:\n%s", err, out)
+	}
+}
+
+// TestTryExternalSelector_Fzf tests that fzf selections map back to the
+// snippet by name, even when display strings collide
+func TestTryExternalSelector_Fzf(t *testing.T) {
+	config = &models.Config{
+		Settings: models.Settings{Selector: models.SelectorConfig{Command: "fzf"}},
+		Snippets: map[string]models.Snippet{
+			"a - b": {},
+			"a":     {Description: "b", Source: models.SourceLocal},
+		},
+	}
+	t.Cleanup(func() { config = nil })
+	snippets := make(map[string]*models.Snippet)
+	for name, snippet := range config.Snippets {
+		snippets[name] = &snippet
+	}
+	options, byDisplay := buildSnippetOptions(snippets, "")
+	if !slices.Equal(options, []string{"a - b [local]", "a - b"}) {
+		t.Fatalf("Expected the local snippet badged, got %q", options)
+	}
+
+	// A stand-in fzf that picks its second line and records its arguments
+	dir := t.TempDir()
+	script := `echo "$@" > "` + filepath.Join(dir, "args") + `"; sed -n 2p`
+	if err := os.WriteFile(filepath.Join(dir, "fzf"), []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	names, err := tryExternalSelector(options, byDisplay, true, "")
+	if err != nil {
+		t.Fatalf("tryExternalSelector failed: %v", err)
+	}
+	if !slices.Equal(names, []string{"a - b"}) {
+		t.Errorf("Expected the second snippet, got %q", names)
+	}
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "--delimiter \t --with-nth 2.. --preview cs describe --plain {1} --multi" {
+		t.Errorf("Expected fzf to hide the name, preview, and allow several picks, got %q", got)
+	}
+
+	config.Settings.Selector.Preview = "bat {}"
+	config.Settings.Selector.Options = "--preview-window right"
+	if _, err := tryExternalSelector(options, byDisplay, false, "get"); err != nil {
+		t.Fatalf("tryExternalSelector failed: %v", err)
+	}
+	args, _ = os.ReadFile(filepath.Join(dir, "args"))
+	if got := strings.TrimSpace(string(args)); got != "--delimiter \t --with-nth 2.. --preview bat {} --preview-window right --query get" {
+		t.Errorf("Expected the configured preview passed as is, before the options, and the query, got %q", got)
+	}
+}
+
+func TestInitialQuery(t *testing.T) {
+	options := []string{"kubectl-get-pods - List pods", "git-pull"}
+	if got := initialQuery(options, "KGP"); got != "KGP" {
+		t.Errorf("Expected a query matching an option to be kept, got %q", got)
+	}
+	// Matched by the command, which the selector doesn't show
+	if got := initialQuery(options, "rebase"); got != "" {
+		t.Errorf("Expected a query matching no option to be dropped, got %q", got)
+	}
+}
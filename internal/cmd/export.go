@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/samling/command-snippets/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+// configFragment is the shareable subset of a config written by export and
+// read by import. Settings are deliberately omitted.
+type configFragment struct {
+	TransformTemplates map[string]models.TransformTemplate `yaml:"transform_templates,omitempty"`
+	VariableTypes      map[string]models.VariableType      `yaml:"variable_types,omitempty"`
+	GlobalVariables    map[string]models.Variable          `yaml:"global_variables,omitempty"`
+	Snippets           map[string]models.Snippet           `yaml:"snippets"`
+}
+
+func newExportCmd() *cobra.Command {
+	var tags []string
+	var all bool
+	var output string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "export [template-name...]",
+		Short: "Export command templates as a shareable config file",
+		Long: `Export selected command templates, together with the global variables,
+transform templates, and variable types they use, as a self-contained config
+fragment.
+
+Examples:
+  cs export kubectl-get-pods                        # Export one template to stdout
+  cs export --tags k8s --output team-k8s.yaml       # Export templates tagged 'k8s'
+  cs export --all --format json --output all.json   # Export everything as JSON`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runExport(args, tags, all, output, format)
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&tags, "tags", "t", []string{}, "Export templates with any of these tags")
+	cmd.Flags().BoolVar(&all, "all", false, "Export all templates")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Write to file instead of stdout")
+	cmd.Flags().StringVar(&format, "format", "yaml", "Output format (yaml|json)")
+
+	return cmd
+}
+
+func runExport(names []string, tags []string, all bool, output string, format string) error {
+	if !all && len(names) == 0 && len(tags) == 0 {
+		return fmt.Errorf("specify template names, --tags, or --all")
+	}
+
+	selected := make(map[string]models.Snippet)
+	for _, name := range names {
+		snippet, err := getSnippet(name)
+		if err != nil {
+			return err
+		}
+		selected[name] = snippet
+	}
+	for name, snippet := range config.Snippets {
+		if all || (len(tags) > 0 && hasAnyTag(snippet.Tags, tags)) {
+			selected[name] = snippet
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no templates matched")
+	}
+
+	fragment := buildConfigFragment(selected)
+
+	data, err := marshalOutput(fragment, format)
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Printf("✅ Exported %d template(s) to %s\n", len(fragment.Snippets), output)
+	return nil
+}
+
+// buildConfigFragment collects the given snippets plus the global variables
+// they use and every transform template and variable type their variables,
+// global ones included, reference. References that don't resolve in the
+// loaded config are reported on stderr and skipped.
+func buildConfigFragment(snippets map[string]models.Snippet) configFragment {
+	fragment := configFragment{
+		TransformTemplates: make(map[string]models.TransformTemplate),
+		VariableTypes:      make(map[string]models.VariableType),
+		GlobalVariables:    make(map[string]models.Variable),
+		Snippets:           snippets,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(snippets)) {
+		snippet := snippets[name]
+		merged := snippet.WithGlobalVariables(config)
+		for _, global := range merged.Variables[len(snippet.Variables):] {
+			fragment.GlobalVariables[global.Name] = global
+		}
+		for _, variable := range merged.Variables {
+			if tmplName := variable.TransformTemplate; tmplName != "" {
+				if tmpl, ok := config.TransformTemplates[tmplName]; ok {
+					fragment.TransformTemplates[tmplName] = tmpl
+				} else {
+					fmt.Fprintf(os.Stderr, "Warning: Template '%s' references missing transform template '%s'\n", name, tmplName)
+				}
+			}
+			if typeName := variable.Type; typeName != "" {
+				if varType, ok := config.VariableTypes[typeName]; ok {
+					fragment.VariableTypes[typeName] = varType
+				}
+			}
+		}
+	}
+
+	return fragment
+}
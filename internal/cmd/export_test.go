@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestExportImportRoundTrip tests that snippets exported by tag, with the
+// global variables, transform templates, and variable types they use, come
+// back unchanged when the file is imported into an empty config
+func TestExportImportRoundTrip(t *testing.T) {
+	for _, format := range []string{outputYAML, outputJSON} {
+		t.Run(format, func(t *testing.T) {
+			dir := useConfigDir(t, map[string]string{"config.yaml": "transform_templates:\n" +
+				"  ns-flag:\n    description: Namespace flag\n    transform:\n      value_pattern: '-n {{.Value}}'\n" +
+				"  unused:\n    transform:\n      empty_value: none\n" +
+				"  image-flag:\n    transform:\n      value_pattern: '--image {{.Value}}'\n" +
+				"variable_types:\n  port:\n    default: \"8080\"\n    validation:\n      range: [1, 65535]\n" +
+				"global_variables:\n  image:\n    default: nginx\n    transform_template: image-flag\n  unused:\n    default: x\n" +
+				"snippets:\n" +
+				"  pods:\n    description: List pods\n    command: kubectl get pods <ns>\n    tags: [k8s]\n    variables:\n      - name: ns\n        transform_template: ns-flag\n" +
+				"  forward:\n    command: kubectl debug <pod> <image> -- <port>\n    tags: [k8s, net]\n    variables:\n      - name: pod\n        required: true\n      - name: port\n        type: port\n" +
+				"  other:\n    command: echo <x>\n    tags: [misc]\n    variables:\n      - name: x\n        transform_template: unused\n",
+			})
+			exported := filepath.Join(dir, "team-k8s."+format)
+			if err := runExport(nil, []string{"k8s"}, false, exported, format); err != nil {
+				t.Fatalf("runExport failed: %v", err)
+			}
+			original := config
+
+			useConfigDir(t, map[string]string{"config.yaml": ""})
+			if err := runImport(io.Discard, exported, conflictSkip); err != nil {
+				t.Fatalf("runImport failed: %v", err)
+			}
+			imported, err := loadConfig(cfgFile)
+			if err != nil {
+				t.Fatalf("loadConfig failed: %v", err)
+			}
+
+			if got := slices.Sorted(maps.Keys(imported.Snippets)); !slices.Equal(got, []string{"forward", "pods"}) {
+				t.Errorf("Expected the k8s snippets, got %v", got)
+			}
+			if got := slices.Sorted(maps.Keys(imported.TransformTemplates)); !slices.Equal(got, []string{"image-flag", "ns-flag"}) {
+				t.Errorf("Expected the transform templates the snippets and globals reference, got %v", got)
+			}
+			if got := slices.Sorted(maps.Keys(imported.GlobalVariables)); !slices.Equal(got, []string{"image"}) {
+				t.Errorf("Expected the global variable the snippets use, got %v", got)
+			}
+			if got := slices.Sorted(maps.Keys(imported.VariableTypes)); !slices.Equal(got, []string{"port"}) {
+				t.Errorf("Expected the referenced variable type, got %v", got)
+			}
+			for name, snippet := range imported.Snippets {
+				want := original.Snippets[name]
+				if !sameDefinition(snippet, want) {
+					t.Errorf("Expected %s to round-trip unchanged", name)
+				}
+				wantResolved, err := want.Resolved(original)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotResolved, err := snippet.Resolved(imported)
+				if err != nil {
+					t.Fatalf("Resolving imported %s failed: %v", name, err)
+				}
+				if !sameDefinition(gotResolved, wantResolved) {
+					t.Errorf("Expected %s to resolve the same after import", name)
+				}
+			}
+			if !sameDefinition(imported.TransformTemplates["ns-flag"], original.TransformTemplates["ns-flag"]) {
+				t.Error("Expected the transform template to round-trip unchanged")
+			}
+			if !sameDefinition(imported.VariableTypes["port"], original.VariableTypes["port"]) {
+				t.Error("Expected the variable type to round-trip unchanged")
+			}
+			if issues := imported.Lint(); len(issues) > 0 {
+				t.Errorf("Expected the imported config to lint clean, got %v", issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newFavoriteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "favorite <template-name>",
+		Short: "Pin a command template so it is listed first",
+		Long: `Mark a command template as a favorite. Favorites are shown with a ★ and
+sorted before other templates in 'cs list' and the snippet selector.
+
+Examples:
+  cs favorite kubectl-get-pods     # Pin a template
+  cs unfavorite kubectl-get-pods   # Unpin it again`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setFavorite(args[0], true)
+		},
+	}
+}
+
+func newUnfavoriteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "unfavorite <template-name>",
+		Short: "Unpin a favorite command template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return setFavorite(args[0], false)
+		},
+	}
+}
+
+func setFavorite(name string, favorite bool) error {
+	name, snippet, err := findSnippet(name)
+	if err != nil {
+		return err
+	}
+
+	if snippet.Favorite == favorite {
+		if favorite {
+			fmt.Printf("Command template '%s' is already a favorite\n", name)
+		} else {
+			fmt.Printf("Command template '%s' is not a favorite\n", name)
+		}
+		return nil
+	}
+
+	previous := snippetFiles(name)
+	snippet.Favorite = favorite
+	config.Snippets[name] = snippet
+
+	if err := saveSnippets(previous, name); err != nil {
+		return err
+	}
+
+	if favorite {
+		fmt.Printf("★ Command template '%s' added to favorites\n", name)
+	} else {
+		fmt.Printf("Command template '%s' removed from favorites\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import "testing"
+
+// TestSetFavorite tests that favoriting and unfavoriting a snippet is saved,
+// and that repeating either leaves it as it is
+func TestSetFavorite(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": "snippets:\n  pods:\n    command: kubectl get pods\n  logs:\n    command: kubectl logs\n"})
+
+	favorite := func(name string) bool {
+		t.Helper()
+		reloaded, err := loadConfig(cfgFile)
+		if err != nil {
+			t.Fatalf("loadConfig failed: %v", err)
+		}
+		return reloaded.Snippets[name].Favorite
+	}
+
+	for _, want := range []bool{true, true, false, false, true} {
+		if err := setFavorite("pods", want); err != nil {
+			t.Fatalf("setFavorite(%v) failed: %v", want, err)
+		}
+		if got := favorite("pods"); got != want {
+			t.Errorf("After setFavorite(%v), expected the saved flag to be %v, got %v", want, want, got)
+		}
+		if config.Snippets["pods"].Favorite != want {
+			t.Errorf("After setFavorite(%v), expected the loaded flag to be %v", want, want)
+		}
+		if favorite("logs") {
+			t.Error("Expected logs to be left alone")
+		}
+	}
+
+	if err := setFavorite("missing", true); err == nil {
+		t.Error("Expected an error for a missing template")
+	}
+}
@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/state"
+	"github.com/samling/command-snippets/internal/template"
+
+	"github.com/spf13/cobra"
+)
+
+// redactedValue replaces secret values in history entries.
+const redactedValue = "********"
+
+func newHistoryCmd() *cobra.Command {
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show recently executed command templates",
+		Long: `Show, inspect, and re-run recent template executions.
+
+History is recorded when settings.history.enabled is true. Entries are
+numbered from 1 (most recent). Values of secret variables are redacted.
+
+Examples:
+  cs history                  # List recent executions
+  cs history show 1           # Show the full record for the latest execution
+  cs history rerun 2          # Re-run the second most recent execution
+  cs history rerun 1 --run    # Re-run and execute without prompting`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistoryList(limit)
+		},
+	}
+	cmd.Flags().IntVarP(&limit, "limit", "n", 20, "Number of entries to show (0 for all)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <n>",
+		Short: "Show the full record of a history entry",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHistoryShow(args[0])
+		},
+	})
+
+	rerunCmd := &cobra.Command{
+		Use:   "rerun <n>",
+		Short: "Re-run a history entry with its recorded values",
+		Long: `Re-run a history entry with its recorded values pre-filled. The recorded
+execution mode is reused unless --run or --prompt is given.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runHistoryRerun,
+	}
+	rerunCmd.Flags().Bool("run", false, "Automatically execute the command without prompting")
+	rerunCmd.Flags().Bool("prompt", false, "Prompt before executing the command")
+	rerunCmd.Flags().Bool("no-color", false, "Disable colored output in the TUI")
+	cmd.AddCommand(rerunCmd)
+
+	return cmd
+}
+
+func runHistoryList(limit int) error {
+	entries, err := loadHistoryNewestFirst()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No history recorded.")
+		return nil
+	}
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	width := len(strconv.Itoa(len(entries)))
+	for i, entry := range entries {
+		fmt.Printf("%*d  %s  %s  %s\n", width, i+1, entry.Time.Local().Format("2006-01-02 15:04"), entry.Snippet, entry.Command)
+	}
+	return nil
+}
+
+func runHistoryShow(arg string) error {
+	entry, err := historyEntry(arg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Template: %s\n", entry.Snippet)
+	fmt.Printf("Time: %s\n", entry.Time.Local().Format(time.RFC3339))
+	fmt.Printf("Mode: %s\n", entry.Mode)
+	fmt.Printf("\nCommand:\n  %s\n", entry.Command)
+	if len(entry.Values) > 0 {
+		fmt.Printf("\nValues:\n")
+		for _, name := range slices.Sorted(maps.Keys(entry.Values)) {
+			fmt.Printf("  %s: %s\n", name, entry.Values[name])
+		}
+	}
+	return nil
+}
+
+func runHistoryRerun(cmd *cobra.Command, args []string) error {
+	entry, err := historyEntry(args[0])
+	if err != nil {
+		return err
+	}
+
+	snippet, err := getSnippet(entry.Snippet)
+	if err != nil {
+		return err
+	}
+	snippet = snippet.WithGlobalVariables(config)
+
+	runFlag, _ := cmd.Flags().GetBool("run")
+	promptFlag, _ := cmd.Flags().GetBool("prompt")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	if runFlag && promptFlag {
+		return usageErrorf("--run and --prompt flags are mutually exclusive")
+	}
+
+	var mode template.ExecutionMode
+	switch {
+	case runFlag:
+		mode = template.AutoExecute
+	case promptFlag:
+		mode = template.PromptExecute
+	default:
+		mode, err = template.ParseExecutionMode(entry.Mode)
+		if err != nil {
+			return err
+		}
+	}
+
+	opts := newExecOptions(historyPresets(entry))
+	opts.mode = mode
+	opts.noColor = noColor
+	return executeSnippet(entry.Snippet, &snippet, opts)
+}
+
+// historyPresets returns the recorded values of entry for use as presets.
+// Redacted secrets are dropped so the form asks for them again.
+func historyPresets(entry state.HistoryEntry) map[string]string {
+	presets := make(map[string]string, len(entry.Values))
+	for name, value := range entry.Values {
+		if value != redactedValue {
+			presets[name] = value
+		}
+	}
+	return presets
+}
+
+// historyEntry returns the entry numbered by arg (1 = most recent).
+func historyEntry(arg string) (state.HistoryEntry, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return state.HistoryEntry{}, fmt.Errorf("invalid history entry '%s': expected a positive number", arg)
+	}
+	return nthHistoryEntry(n)
+}
+
+// nthHistoryEntry returns the entry numbered n (1 = most recent).
+func nthHistoryEntry(n int) (state.HistoryEntry, error) {
+	entries, err := loadHistoryNewestFirst()
+	if err != nil {
+		return state.HistoryEntry{}, err
+	}
+	if len(entries) == 0 {
+		return state.HistoryEntry{}, fmt.Errorf("no history recorded")
+	}
+	if n > len(entries) {
+		return state.HistoryEntry{}, fmt.Errorf("history entry %d not found (%d entries recorded)", n, len(entries))
+	}
+	return entries[n-1], nil
+}
+
+// loadHistoryNewestFirst reads the history file with the latest entry first.
+func loadHistoryNewestFirst() ([]state.HistoryEntry, error) {
+	path, err := state.HistoryPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := state.LoadHistory(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	slices.Reverse(entries)
+	return entries, nil
+}
+
+// maxSuggestions caps the previous values the form offers per variable.
+const maxSuggestions = 5
+
+// valueSuggestions returns the values recently entered for each of the
+// snippet's variables, most recent first, for the form to offer. Secrets
+// are never suggested. Nothing is suggested when history is disabled or
+// cannot be read.
+func valueSuggestions(snippetName string, snippet *models.Snippet) map[string][]string {
+	if !config.Settings.History.Enabled {
+		return nil
+	}
+	path, err := state.HistoryPath()
+	if err != nil {
+		return nil
+	}
+	entries, err := state.LoadHistory(path)
+	if err != nil {
+		return nil
+	}
+	suggestions := state.RecentValues(entries, snippetName, maxSuggestions)
+	for _, v := range snippet.Variables {
+		if v.Type == models.VarTypeSecret {
+			delete(suggestions, v.Name)
+		}
+	}
+	for name, values := range suggestions {
+		suggestions[name] = slices.DeleteFunc(values, func(value string) bool { return value == redactedValue })
+	}
+	return suggestions
+}
+
+// recordHistory appends the result to the history file when history is
+// enabled. Failures are reported on stderr and never fail the execution.
+func recordHistory(snippetName string, snippet *models.Snippet, result *template.Result) {
+	if !config.Settings.History.Enabled {
+		return
+	}
+
+	path, err := state.HistoryPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not locate history file: %v\n", err)
+		return
+	}
+
+	command, values := redactSecrets(snippet, result.Command, result.Values)
+	entry := state.HistoryEntry{
+		Snippet: snippetName,
+		Command: command,
+		Values:  values,
+		Mode:    result.Mode.String(),
+		Time:    time.Now(),
+	}
+	maxEntries := cmp.Or(config.Settings.History.MaxEntries, models.DefaultHistoryMaxEntries)
+	if err := state.AppendHistory(path, entry, maxEntries); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not save history: %v\n", err)
+	}
+}
+
+// renameHistory moves the history of a renamed snippet to its new name, so
+// --last and the form's suggestions keep finding it. It runs whether or not
+// history is enabled, as a file recorded earlier may still be read later.
+func renameHistory(oldName, newName string) {
+	path, err := state.HistoryPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not locate history file: %v\n", err)
+		return
+	}
+	if err := state.RenameHistory(path, oldName, newName); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not update history: %v\n", err)
+	}
+}
+
+// redactSecrets returns copies of command and values with the values of
+// secret-typed variables masked. When there is a secret, the command is
+// rendered again from the masked values rather than searched for it: a
+// short secret would match unrelated text, and a transformed one might not
+// appear as typed. A command edited with --edit-command is replaced by the
+// rendering too.
+func redactSecrets(snippet *models.Snippet, command string, values map[string]string) (string, map[string]string) {
+	masked := snippet.MaskSecrets(values, redactedValue)
+	if maps.Equal(masked, values) {
+		return command, masked
+	}
+	redacted, err := snippet.ProcessTemplate(masked, config)
+	if err != nil {
+		// Leaving the command out is safer than keeping the secret in it.
+		return "", masked
+	}
+	return redacted, masked
+}
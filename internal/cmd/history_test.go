@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/template"
+)
+
+// TestValueSuggestions tests that suggestions come from history and leave
+// out secrets
+func TestValueSuggestions(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	config = &models.Config{Settings: models.Settings{History: models.HistoryConfig{Enabled: true}}}
+	t.Cleanup(func() { config = nil })
+
+	snippet := &models.Snippet{
+		Command:   "login <user> <token>",
+		Variables: []models.Variable{{Name: "user"}, {Name: "token", Type: models.VarTypeSecret}},
+	}
+	for _, user := range []string{"alice", "bob", "alice"} {
+		recordHistory("login", snippet, &template.Result{Command: "login", Values: map[string]string{"user": user, "token": "s3cret"}})
+	}
+
+	suggestions := valueSuggestions("login", snippet)
+	if !slices.Equal(suggestions["user"], []string{"alice", "bob"}) {
+		t.Errorf("Expected the users most recent first, got %v", suggestions["user"])
+	}
+	if _, ok := suggestions["token"]; ok {
+		t.Errorf("Expected no suggestions for a secret, got %v", suggestions["token"])
+	}
+
+	config.Settings.History.Enabled = false
+	if suggestions := valueSuggestions("login", snippet); suggestions != nil {
+		t.Errorf("Expected no suggestions with history disabled, got %v", suggestions)
+	}
+}
+
+// TestRedactSecrets tests that secrets are masked where their placeholders
+// render, leaving text that happens to match a short one alone
+func TestRedactSecrets(t *testing.T) {
+	config = &models.Config{}
+	t.Cleanup(func() { config = nil })
+
+	snippet := &models.Snippet{
+		Command: "curl --retry 1 -u admin:<token> <url>",
+		Variables: []models.Variable{
+			{Name: "token", Type: models.VarTypeSecret, Transform: &models.Transform{ValuePattern: "'{{.Value}}'"}},
+			{Name: "url"},
+		},
+	}
+	values := map[string]string{"token": "1", "url": "https://example.com/1"}
+
+	command, redacted := redactSecrets(snippet, "curl --retry 1 -u admin:'1' https://example.com/1", values)
+	if want := "curl --retry 1 -u admin:'" + redactedValue + "' https://example.com/1"; command != want {
+		t.Errorf("Expected %q, got %q", want, command)
+	}
+	if redacted["token"] != redactedValue || redacted["url"] != values["url"] {
+		t.Errorf("Expected only the token masked, got %v", redacted)
+	}
+	if values["token"] != "1" {
+		t.Error("Expected the values passed in to be left alone")
+	}
+
+	if command, _ := redactSecrets(snippet, "edited", map[string]string{"url": "x"}); command != "edited" {
+		t.Errorf("Expected a command without secrets to be kept as is, got %q", command)
+	}
+}
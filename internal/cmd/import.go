@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/samling/command-snippets/internal/models"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict strategies accepted by --on-conflict.
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+)
+
+// importCounts tallies the outcome for one kind of definition.
+type importCounts struct {
+	imported int
+	skipped  int
+	renamed  int
+	written  []string // Names the definitions were written under
+}
+
+func (c importCounts) String() string {
+	return fmt.Sprintf("%d imported, %d skipped, %d renamed", c.imported, c.skipped, c.renamed)
+}
+
+func newImportCmd() *cobra.Command {
+	var onConflict string
+
+	cmd := &cobra.Command{
+		Use:   "import <file|->",
+		Short: "Merge command templates from a file or stdin",
+		Long: `Merge snippets, transform templates, and variable types from a config
+fragment (such as one written by 'cs export') into your configuration.
+
+Definitions identical to existing ones are left alone. Other name
+conflicts are resolved with --on-conflict:
+  skip       keep the existing definition (default)
+  overwrite  replace the existing definition
+  rename     import under a new name (name-2, name-3, ...)
+
+Examples:
+  cs import team-k8s.yaml                        # Import, skipping conflicts
+  cs import team-k8s.yaml --on-conflict rename   # Keep both on conflict
+  cat shared.yaml | cs import -                  # Import from stdin`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runImport(os.Stdout, args[0], onConflict)
+		},
+	}
+
+	cmd.Flags().StringVar(&onConflict, "on-conflict", conflictSkip, "Conflict strategy (skip|overwrite|rename)")
+
+	return cmd
+}
+
+// runImport merges the config fragment at path, or stdin for "-", into the
+// config and writes a summary of what was imported to w.
+func runImport(w io.Writer, path string, onConflict string) error {
+	switch onConflict {
+	case conflictSkip, conflictOverwrite, conflictRename:
+	default:
+		return fmt.Errorf("invalid --on-conflict value '%s' (expected skip, overwrite, or rename)", onConflict)
+	}
+
+	var (
+		src models.Config
+		err error
+	)
+	if path == "-" {
+		var data []byte
+		data, err = io.ReadAll(os.Stdin)
+		if err == nil {
+			src, err = parseConfig(data)
+		}
+	} else {
+		src, err = readConfigFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if len(src.Snippets) == 0 && len(src.TransformTemplates) == 0 && len(src.VariableTypes) == 0 && len(src.GlobalVariables) == 0 {
+		return fmt.Errorf("%s contains no snippets, transform templates, variable types, or global variables", path)
+	}
+
+	previous := snippetFiles(slices.Collect(maps.Keys(src.Snippets))...)
+	counts := importConfig(config, &src, onConflict)
+
+	// Every imported snippet and global variable must resolve its transform
+	// templates against the merged config before anything is written.
+	check := func(what string, variables []models.Variable) error {
+		for _, variable := range variables {
+			if variable.TransformTemplate == "" {
+				continue
+			}
+			if _, ok := config.TransformTemplates[variable.TransformTemplate]; !ok {
+				return fmt.Errorf("%s variable '%s' references unknown transform template '%s'; nothing was imported", what, variable.Name, variable.TransformTemplate)
+			}
+		}
+		return nil
+	}
+	for _, name := range counts.globals.written {
+		if err := check("global", []models.Variable{config.GlobalVariables[name]}); err != nil {
+			return err
+		}
+	}
+	for _, name := range counts.snippets.written {
+		if err := check(fmt.Sprintf("template '%s'", name), config.Snippets[name].Variables); err != nil {
+			return err
+		}
+	}
+
+	// Only the imported definitions are written, each to the file of the
+	// definition it replaces, or else to the main config.
+	edits := make(fileEdits)
+	for _, name := range counts.templates.written {
+		template := config.TransformTemplates[name]
+		edits.set(template.SourceFile, "transform_templates", name, template)
+	}
+	for _, name := range counts.types.written {
+		varType := config.VariableTypes[name]
+		edits.set(varType.SourceFile, "variable_types", name, varType)
+	}
+	for _, name := range counts.globals.written {
+		global := config.GlobalVariables[name]
+		edits.set(global.SourceFile, "global_variables", name, global)
+	}
+	if err := edits.apply(); err != nil {
+		return err
+	}
+	if err := saveSnippets(previous, counts.snippets.written...); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "✅ Import from %s complete\n", path)
+	fmt.Fprintf(w, "  Command templates: %s\n", counts.snippets)
+	if len(src.TransformTemplates) > 0 {
+		fmt.Fprintf(w, "  Transform templates: %s\n", counts.templates)
+	}
+	if len(src.VariableTypes) > 0 {
+		fmt.Fprintf(w, "  Variable types: %s\n", counts.types)
+	}
+	if len(src.GlobalVariables) > 0 {
+		fmt.Fprintf(w, "  Global variables: %s\n", counts.globals)
+	}
+	return nil
+}
+
+// importSummary holds the importCounts for each kind of definition.
+type importSummary struct {
+	templates, types, globals, snippets importCounts
+}
+
+// importConfig merges src into dst using the given conflict strategy.
+// Transform templates and variable types are merged first so that renames
+// can be propagated into the references held by src's global variables
+// and snippets, which are rewritten in place. An overwritten definition
+// keeps the file it was loaded from: written anywhere else, the old copy
+// would win again when the files are merged on the next load.
+//
+// Snippets refer to a global variable by its placeholder, so one can't be
+// imported under another name; instead, rename copies it into each
+// imported snippet that uses it as a local variable, which shadows the
+// existing global.
+func importConfig(dst, src *models.Config, strategy string) importSummary {
+	if dst.TransformTemplates == nil {
+		dst.TransformTemplates = make(map[string]models.TransformTemplate)
+	}
+	if dst.VariableTypes == nil {
+		dst.VariableTypes = make(map[string]models.VariableType)
+	}
+	if dst.GlobalVariables == nil {
+		dst.GlobalVariables = make(map[string]models.Variable)
+	}
+	if dst.Snippets == nil {
+		dst.Snippets = make(map[string]models.Snippet)
+	}
+	var counts importSummary
+
+	tmplRenames := make(map[string]string)
+	for _, name := range slices.Sorted(maps.Keys(src.TransformTemplates)) {
+		target, ok := resolveImportName(dst.TransformTemplates, name, src.TransformTemplates[name], strategy, &counts.templates)
+		if !ok {
+			continue
+		}
+		if target != name {
+			tmplRenames[name] = target
+		}
+		template := src.TransformTemplates[name]
+		template.SourceFile = cmp.Or(dst.TransformTemplates[target].SourceFile, cfgFile)
+		dst.TransformTemplates[target] = template
+		counts.templates.written = append(counts.templates.written, target)
+	}
+
+	typeRenames := make(map[string]string)
+	for _, name := range slices.Sorted(maps.Keys(src.VariableTypes)) {
+		target, ok := resolveImportName(dst.VariableTypes, name, src.VariableTypes[name], strategy, &counts.types)
+		if !ok {
+			continue
+		}
+		if target != name {
+			typeRenames[name] = target
+		}
+		varType := src.VariableTypes[name]
+		varType.SourceFile = cmp.Or(dst.VariableTypes[target].SourceFile, cfgFile)
+		dst.VariableTypes[target] = varType
+		counts.types.written = append(counts.types.written, target)
+	}
+
+	renameReferences := func(v *models.Variable) {
+		if renamed, ok := tmplRenames[v.TransformTemplate]; ok {
+			v.TransformTemplate = renamed
+		}
+		if renamed, ok := typeRenames[v.Type]; ok {
+			v.Type = renamed
+		}
+	}
+
+	shadowed := &models.Config{GlobalVariables: make(map[string]models.Variable), TransformTemplates: dst.TransformTemplates}
+	for _, name := range slices.Sorted(maps.Keys(src.GlobalVariables)) {
+		global := src.GlobalVariables[name]
+		global.Name = name
+		renameReferences(&global)
+		target, ok := resolveImportName(dst.GlobalVariables, name, global, strategy, &counts.globals)
+		if !ok {
+			continue
+		}
+		if target != name {
+			shadowed.GlobalVariables[name] = global
+			continue
+		}
+		global.SourceFile = cmp.Or(dst.GlobalVariables[name].SourceFile, cfgFile)
+		dst.GlobalVariables[name] = global
+		counts.globals.written = append(counts.globals.written, name)
+	}
+
+	for name, snippet := range src.Snippets {
+		snippet.Variables = slices.Clone(snippet.Variables)
+		for i := range snippet.Variables {
+			renameReferences(&snippet.Variables[i])
+		}
+		snippet = snippet.WithGlobalVariables(shadowed)
+		src.Snippets[name] = snippet
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(src.Snippets)) {
+		snippet := src.Snippets[name]
+		target, ok := resolveImportName(dst.Snippets, name, snippet, strategy, &counts.snippets)
+		if !ok {
+			continue
+		}
+		if target != name {
+			snippet.Name = target
+		}
+		snippet.Source = cmp.Or(dst.Snippets[target].Source, models.SourceGlobal)
+		snippet.SourceFile = cmp.Or(dst.Snippets[target].SourceFile, cfgFile)
+		dst.Snippets[target] = snippet
+		counts.snippets.written = append(counts.snippets.written, target)
+	}
+
+	return counts
+}
+
+// resolveImportName decides where an incoming definition should land in
+// existing. It returns false when the definition should not be written,
+// either because an identical one is already present or the strategy is
+// skip.
+func resolveImportName[T any](existing map[string]T, name string, incoming T, strategy string, counts *importCounts) (string, bool) {
+	current, exists := existing[name]
+	if !exists {
+		counts.imported++
+		return name, true
+	}
+	if sameDefinition(current, incoming) {
+		counts.skipped++
+		return "", false
+	}
+
+	switch strategy {
+	case conflictOverwrite:
+		counts.imported++
+		return name, true
+	case conflictRename:
+		counts.renamed++
+		for i := 2; ; i++ {
+			candidate := name + "-" + strconv.Itoa(i)
+			if _, taken := existing[candidate]; !taken {
+				return candidate, true
+			}
+		}
+	default:
+		counts.skipped++
+		return "", false
+	}
+}
+
+// sameDefinition reports whether a and b serialize to the same YAML, which
+// ignores load-time metadata and cached compiled patterns.
+func sameDefinition(a, b any) bool {
+	ay, errA := yaml.Marshal(a)
+	by, errB := yaml.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ay, by)
+}
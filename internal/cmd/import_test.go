@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// useConfigDir writes files, by name, to a temporary directory, makes it
+// the working directory, and loads its config.yaml as the config.
+func useConfigDir(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	main := filepath.Join(dir, "config.yaml")
+	var err error
+	if config, err = loadConfig(main); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	oldCfgFile := cfgFile
+	cfgFile = main
+	t.Cleanup(func() { config, cfgFile = nil, oldCfgFile })
+	return dir
+}
+
+// TestRunImport tests each conflict strategy of cs import, the summary it
+// prints, and that renamed transform templates and variable types are
+// followed by the imported snippets
+func TestRunImport(t *testing.T) {
+	existing := "transform_templates:\n  ns-flag:\n    transform:\n      value_pattern: '-n {{.Value}}'\n" +
+		"variable_types:\n  port:\n    description: Port\n" +
+		"snippets:\n  pods:\n    command: kubectl get pods\n  same:\n    command: echo same\n"
+	fragment := "transform_templates:\n  ns-flag:\n    transform:\n      value_pattern: '--namespace {{.Value}}'\n" +
+		"variable_types:\n  port:\n    description: TCP port\n" +
+		"snippets:\n" +
+		"  pods:\n    command: kubectl get pods <ns> <port>\n    variables:\n      - name: ns\n        transform_template: ns-flag\n      - name: port\n        type: port\n" +
+		"  same:\n    command: echo same\n" +
+		"  logs:\n    command: kubectl logs <ns>\n    variables:\n      - name: ns\n        transform_template: ns-flag\n"
+
+	tests := []struct {
+		strategy  string
+		summary   []string
+		snippets  []string
+		templates []string
+		types     []string
+		refs      map[string][2]string // snippet to the template and type its variables use
+	}{
+		{
+			strategy:  conflictSkip,
+			summary:   []string{"Command templates: 1 imported, 2 skipped, 0 renamed", "Transform templates: 0 imported, 1 skipped, 0 renamed", "Variable types: 0 imported, 1 skipped, 0 renamed"},
+			snippets:  []string{"logs", "pods", "same"},
+			templates: []string{"ns-flag"},
+			types:     []string{"port"},
+			refs:      map[string][2]string{"logs": {"ns-flag", ""}},
+		},
+		{
+			strategy:  conflictOverwrite,
+			summary:   []string{"Command templates: 2 imported, 1 skipped, 0 renamed", "Transform templates: 1 imported, 0 skipped, 0 renamed", "Variable types: 1 imported, 0 skipped, 0 renamed"},
+			snippets:  []string{"logs", "pods", "same"},
+			templates: []string{"ns-flag"},
+			types:     []string{"port"},
+			refs:      map[string][2]string{"logs": {"ns-flag", ""}, "pods": {"ns-flag", "port"}},
+		},
+		{
+			strategy:  conflictRename,
+			summary:   []string{"Command templates: 1 imported, 1 skipped, 1 renamed", "Transform templates: 0 imported, 0 skipped, 1 renamed", "Variable types: 0 imported, 0 skipped, 1 renamed"},
+			snippets:  []string{"logs", "pods", "pods-2", "same"},
+			templates: []string{"ns-flag", "ns-flag-2"},
+			types:     []string{"port", "port-2"},
+			refs:      map[string][2]string{"logs": {"ns-flag-2", ""}, "pods-2": {"ns-flag-2", "port-2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dir := useConfigDir(t, map[string]string{"config.yaml": existing, "fragment.yaml": fragment})
+
+			var out bytes.Buffer
+			if err := runImport(&out, filepath.Join(dir, "fragment.yaml"), tt.strategy); err != nil {
+				t.Fatalf("runImport failed: %v", err)
+			}
+			for _, line := range tt.summary {
+				if !strings.Contains(out.String(), line) {
+					t.Errorf("Expected %q in the summary, got:\n%s", line, out.String())
+				}
+			}
+
+			saved, err := readConfigFile(cfgFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := slices.Sorted(maps.Keys(saved.Snippets)); !slices.Equal(got, tt.snippets) {
+				t.Errorf("Expected snippets %v, got %v", tt.snippets, got)
+			}
+			if got := slices.Sorted(maps.Keys(saved.TransformTemplates)); !slices.Equal(got, tt.templates) {
+				t.Errorf("Expected transform templates %v, got %v", tt.templates, got)
+			}
+			if got := slices.Sorted(maps.Keys(saved.VariableTypes)); !slices.Equal(got, tt.types) {
+				t.Errorf("Expected variable types %v, got %v", tt.types, got)
+			}
+			for name, want := range tt.refs {
+				var got [2]string
+				for _, v := range saved.Snippets[name].Variables {
+					got[0] = cmp.Or(got[0], v.TransformTemplate)
+					got[1] = cmp.Or(got[1], v.Type)
+				}
+				if got != want {
+					t.Errorf("Expected %s to reference %v, got %v", name, want, got)
+				}
+			}
+			if tt.strategy == conflictOverwrite && !strings.Contains(saved.TransformTemplates["ns-flag"].Transform.ValuePattern, "--namespace") {
+				t.Errorf("Expected the transform template to be overwritten")
+			}
+		})
+	}
+}
+
+// TestRunImport_NothingWritten tests that a fragment that can't be parsed,
+// or whose snippets reference a transform template that doesn't exist
+// after the merge, leaves the config file as it was
+func TestRunImport_NothingWritten(t *testing.T) {
+	existing := "snippets:\n  pods:\n    command: kubectl get pods\n"
+	tests := []struct {
+		name     string
+		fragment string
+		contains string
+	}{
+		{name: "invalid YAML", fragment: "snippets: [\n", contains: "failed to read"},
+		{name: "empty", fragment: "settings: {}\n", contains: "contains no snippets"},
+		{
+			name:     "unknown transform template",
+			fragment: "snippets:\n  logs:\n    command: kubectl logs <ns>\n    variables:\n      - name: ns\n        transform_template: missing\n",
+			contains: "unknown transform template 'missing'; nothing was imported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := useConfigDir(t, map[string]string{"config.yaml": existing, "fragment.yaml": tt.fragment})
+
+			err := runImport(io.Discard, filepath.Join(dir, "fragment.yaml"), conflictSkip)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected an error containing %q, got %v", tt.contains, err)
+			}
+			if data, _ := os.ReadFile(cfgFile); string(data) != existing {
+				t.Errorf("Expected the config to be left alone, got:\n%s", data)
+			}
+		})
+	}
+
+	useConfigDir(t, map[string]string{"config.yaml": existing})
+	if err := runImport(io.Discard, "fragment.yaml", "merge"); err == nil || !strings.Contains(err.Error(), "invalid --on-conflict value") {
+		t.Errorf("Expected an unknown strategy to be rejected, got %v", err)
+	}
+}
+
+// TestRunImport_SkippedSnippets tests that snippets left out by the conflict
+// strategy aren't checked against the merged config, so one referencing an
+// unknown transform template doesn't block the rest of the import
+func TestRunImport_SkippedSnippets(t *testing.T) {
+	dir := useConfigDir(t, map[string]string{
+		"config.yaml": "snippets:\n  pods:\n    command: kubectl get pods\n",
+		"fragment.yaml": "snippets:\n  pods:\n    command: kubectl get pods -n <ns>\n    variables:\n      - name: ns\n        transform_template: missing\n" +
+			"  logs:\n    command: kubectl logs\n",
+	})
+
+	if err := runImport(io.Discard, filepath.Join(dir, "fragment.yaml"), conflictSkip); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+	reloaded, err := loadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if got := reloaded.Snippets["pods"].Command; got != "kubectl get pods" {
+		t.Errorf("Expected pods to be skipped, got command %q", got)
+	}
+	if _, ok := reloaded.Snippets["logs"]; !ok {
+		t.Error("Expected logs to be imported")
+	}
+}
+
+// TestRunImport_GlobalVariables tests how each conflict strategy treats a
+// global variable; rename keeps the existing one and gives the imported
+// snippets that use it their own copy
+func TestRunImport_GlobalVariables(t *testing.T) {
+	existing := "global_variables:\n  registry:\n    default: ghcr.io\n"
+	fragment := "global_variables:\n  registry:\n    default: docker.io\n  tag:\n    default: latest\n" +
+		"snippets:\n  push:\n    command: docker push <registry>/app:<tag>\n"
+
+	tests := []struct {
+		strategy string
+		summary  string
+		global   string   // default of the registry global afterwards
+		local    []string // variables push defines itself
+		uses     string   // registry default push ends up with
+	}{
+		{strategy: conflictSkip, summary: "Global variables: 1 imported, 1 skipped, 0 renamed", global: "ghcr.io", uses: "ghcr.io"},
+		{strategy: conflictOverwrite, summary: "Global variables: 2 imported, 0 skipped, 0 renamed", global: "docker.io", uses: "docker.io"},
+		{strategy: conflictRename, summary: "Global variables: 1 imported, 0 skipped, 1 renamed", global: "ghcr.io", local: []string{"registry"}, uses: "docker.io"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dir := useConfigDir(t, map[string]string{"config.yaml": existing, "fragment.yaml": fragment})
+
+			var out bytes.Buffer
+			if err := runImport(&out, filepath.Join(dir, "fragment.yaml"), tt.strategy); err != nil {
+				t.Fatalf("runImport failed: %v", err)
+			}
+			if !strings.Contains(out.String(), tt.summary) {
+				t.Errorf("Expected %q in the summary, got:\n%s", tt.summary, out.String())
+			}
+
+			saved, err := loadConfig(cfgFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := saved.GlobalVariables["registry"].DefaultValue; got != tt.global {
+				t.Errorf("Expected the registry global to default to %s, got %s", tt.global, got)
+			}
+			if _, ok := saved.GlobalVariables["tag"]; !ok {
+				t.Error("Expected the tag global to be imported")
+			}
+			push := saved.Snippets["push"]
+			var local []string
+			for _, v := range push.Variables {
+				local = append(local, v.Name)
+			}
+			if !slices.Equal(local, tt.local) {
+				t.Errorf("Expected push to define %v itself, got %v", tt.local, local)
+			}
+			merged := push.WithGlobalVariables(saved)
+			if i := slices.IndexFunc(merged.Variables, func(v models.Variable) bool { return v.Name == "registry" }); i < 0 || merged.Variables[i].DefaultValue != tt.uses {
+				t.Errorf("Expected push to use the registry defaulting to %s, got %+v", tt.uses, merged.Variables)
+			}
+		})
+	}
+}
+
+// TestImportOverwriteInPlace tests that an overwritten transform template,
+// variable type, global variable, or snippet is written to the file it was
+// loaded from, so the imported version still wins after the next load
+func TestImportOverwriteInPlace(t *testing.T) {
+	dir := useConfigDir(t, map[string]string{
+		"config.yaml": "settings:\n  additional_configs: [extra.yaml]\n",
+		"extra.yaml": "transform_templates:\n  ns-flag:\n    description: old\n    transform:\n      value_pattern: '-n {{.Value}}'\nvariable_types:\n  port:\n    description: old\nglobal_variables:\n  registry:\n    default: ghcr.io\n" +
+			"snippets:\n  pods:\n    command: kubectl get pods\n",
+		"fragment.yaml": "transform_templates:\n  ns-flag:\n    description: new\n    transform:\n      value_pattern: '--namespace {{.Value}}'\n" +
+			"variable_types:\n  port:\n    description: new\n  env:\n    description: new\n" +
+			"global_variables:\n  registry:\n    default: docker.io\n" +
+			"snippets:\n  pods:\n    command: kubectl get pods -A\n",
+	})
+	main, extra := filepath.Join(dir, "config.yaml"), filepath.Join(dir, "extra.yaml")
+
+	if err := runImport(io.Discard, filepath.Join(dir, "fragment.yaml"), conflictOverwrite); err != nil {
+		t.Fatalf("runImport failed: %v", err)
+	}
+
+	reloaded, err := loadConfig(main)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if got := reloaded.TransformTemplates["ns-flag"]; got.Description != "new" || got.SourceFile != extra {
+		t.Errorf("Expected the new transform template in %s, got %q from %s", extra, got.Description, got.SourceFile)
+	}
+	if got := reloaded.VariableTypes["port"]; got.Description != "new" || got.SourceFile != extra {
+		t.Errorf("Expected the new port type in %s, got %q from %s", extra, got.Description, got.SourceFile)
+	}
+	if got := reloaded.GlobalVariables["registry"]; got.DefaultValue != "docker.io" || got.SourceFile != extra {
+		t.Errorf("Expected the new registry global in %s, got %q from %s", extra, got.DefaultValue, got.SourceFile)
+	}
+	if got := reloaded.Snippets["pods"]; got.Command != "kubectl get pods -A" || got.SourceFile != extra {
+		t.Errorf("Expected the new pods snippet in %s, got %q from %s", extra, got.Command, got.SourceFile)
+	}
+	if got := reloaded.VariableTypes["env"]; got.SourceFile != main {
+		t.Errorf("Expected a new type to go to the main config, got %s", got.SourceFile)
+	}
+	saved, err := readConfigFile(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved.TransformTemplates["ns-flag"]; ok {
+		t.Error("Expected no copy of the overwritten template in the main config")
+	}
+	if _, ok := saved.Snippets["pods"]; ok {
+		t.Error("Expected no copy of the overwritten snippet in the main config")
+	}
+}
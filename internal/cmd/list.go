@@ -1,20 +1,34 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
+	"io"
 	"maps"
+	"os"
 	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
 
 	"github.com/samling/command-snippets/internal/models"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func newListCmd() *cobra.Command {
-	var tags []string
+	var tags tagFilter
 	var verbose bool
 	var showLocal bool
 	var showGlobal bool
+	var sortBy string
+	var reverse bool
+	var groupBy string
+	var format string
+	var noHeaders bool
+	var quiet bool
+	var output string
 
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -26,22 +40,63 @@ Examples:
   cs list --local            # Show only local (project-specific) templates
   cs list --global           # Show only global templates
   cs list --tags k8s         # List templates with 'k8s' tag
-  cs list --verbose          # Show detailed information`,
+  cs list --tags k8s,prod --all-tags  # Only templates with both tags
+  cs list --group-by tag     # A section per tag
+  cs list --sort usage       # Most frequently executed first
+  cs list --sort updated     # Most recently edited first
+  cs list --sort created --reverse  # Oldest first
+  cs list --verbose          # Show detailed information
+  cs list --format table     # Aligned columns with source and variable count
+  cs exec $(cs list -q | fzf)  # Bare names for your own pipelines
+  cs list -o json            # Machine-readable, sorted by name`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runList(tags, verbose, showLocal, showGlobal)
+			if format != formatList && format != formatTable {
+				return usageErrorf("invalid --format value '%s' (expected list or table)", format)
+			}
+			if quiet {
+				if output != outputText || format != formatList || cmd.Flags().Changed("group-by") || verbose {
+					return usageErrorf("--quiet cannot be combined with --output, --format, --group-by, or --verbose")
+				}
+				return runListQuiet(tags, showLocal, showGlobal, sortBy, reverse)
+			}
+			if format == formatTable {
+				if output != outputText || cmd.Flags().Changed("group-by") || verbose {
+					return usageErrorf("--format table cannot be combined with --output, --group-by, or --verbose")
+				}
+				return runListTable(tags, showLocal, showGlobal, sortBy, reverse, noHeaders)
+			}
+			return runList(tags, verbose, showLocal, showGlobal, sortBy, reverse, groupBy, output)
 		},
 	}
 
-	cmd.Flags().StringSliceVarP(&tags, "tags", "t", []string{}, "Filter by tags")
+	tags.addFlags(cmd)
 	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed information")
 	cmd.Flags().BoolVar(&showLocal, "local", false, "Show only local (project-specific) templates")
 	cmd.Flags().BoolVar(&showGlobal, "global", false, "Show only global templates")
+	cmd.Flags().StringVar(&sortBy, "sort", sortByName, "Sort order (name|usage|recent|created|updated)")
+	cmd.Flags().BoolVar(&reverse, "reverse", false, "Reverse the sort order")
+	cmd.Flags().StringVar(&groupBy, "group-by", groupBySource, "Group templates by source (local, then global) or tag")
+	cmd.Flags().StringVar(&format, "format", formatList, "Layout of the text output (list|table)")
+	cmd.Flags().BoolVar(&noHeaders, "no-headers", false, "Leave out the header row of --format table")
+	cmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "Print only template names, one per line")
+	cmd.Flags().StringVarP(&output, "output", "o", outputText, "Output format (text|json|yaml); json and yaml are always sorted by name")
 
 	return cmd
 }
 
-func runList(filterTags []string, verbose bool, showLocal bool, showGlobal bool) error {
-	if len(config.Snippets) == 0 {
+func runList(filterTags tagFilter, verbose bool, showLocal bool, showGlobal bool, sortBy string, reverse bool, groupBy string, output string) error {
+	sortBy, err := parseSortMode(sortBy)
+	if err != nil {
+		return err
+	}
+	if groupBy != groupBySource && groupBy != groupByTag {
+		return usageErrorf("invalid --group-by value '%s' (expected source or tag)", groupBy)
+	}
+	if err := checkListOutput(output); err != nil {
+		return err
+	}
+
+	if len(config.Snippets) == 0 && output == outputText {
 		fmt.Println("No command templates found. Use 'cs add' to create your first template.")
 		return nil
 	}
@@ -58,7 +113,7 @@ func runList(filterTags []string, verbose bool, showLocal bool, showGlobal bool)
 
 	for name, snippet := range config.Snippets {
 		// Filter by tags if specified
-		if len(filterTags) > 0 && !hasAnyTag(snippet.Tags, filterTags) {
+		if !filterTags.matches(snippet.Tags) {
 			continue
 		}
 
@@ -77,6 +132,16 @@ func runList(filterTags []string, verbose bool, showLocal bool, showGlobal bool)
 		}
 	}
 
+	if output != outputText {
+		var outputs []snippetOutput
+		for _, group := range []map[string]models.Snippet{localSnippets, globalSnippets} {
+			for name, snippet := range group {
+				outputs = append(outputs, newSnippetOutput(name, &snippet))
+			}
+		}
+		return writeSnippetOutputs(os.Stdout, outputs, output)
+	}
+
 	// Check if we have any snippets to show
 	totalSnippets := len(localSnippets) + len(globalSnippets)
 	if totalSnippets == 0 {
@@ -84,21 +149,35 @@ func runList(filterTags []string, verbose bool, showLocal bool, showGlobal bool)
 			fmt.Println("No local (project-specific) templates found.")
 		} else if showGlobal {
 			fmt.Println("No global templates found.")
-		} else if len(filterTags) > 0 {
-			fmt.Printf("No templates found matching tags: %s\n", strings.Join(filterTags, ", "))
+		} else if !filterTags.empty() {
+			fmt.Printf("No templates found matching %s\n", filterTags)
 		} else {
 			fmt.Println("No templates found.")
 		}
 		return nil
 	}
 
+	if groupBy == groupByTag {
+		all := make(map[string]models.Snippet, totalSnippets)
+		maps.Copy(all, globalSnippets)
+		maps.Copy(all, localSnippets)
+		for i, group := range groupSnippetsByTag(all, filterTags) {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n\n", group.tag)
+			displaySnippetGroup(group.snippets, verbose, sortBy, reverse)
+		}
+		return nil
+	}
+
 	// Display local snippets first if any exist and we're not filtering for global only
 	if len(localSnippets) > 0 && !showGlobal {
 		if !showLocal {
 			// Only show section header if we're showing both types
 			fmt.Printf("Local (project-specific) templates:\n\n")
 		}
-		displaySnippetGroup(localSnippets, verbose)
+		displaySnippetGroup(localSnippets, verbose, sortBy, reverse)
 	}
 
 	// Display global snippets if any exist and we're not filtering for local only
@@ -111,20 +190,85 @@ func runList(filterTags []string, verbose bool, showLocal bool, showGlobal bool)
 			// Only show section header if we're showing both types
 			fmt.Printf("Global templates:\n\n")
 		}
-		displaySnippetGroup(globalSnippets, verbose)
+		displaySnippetGroup(globalSnippets, verbose, sortBy, reverse)
 	}
 
 	return nil
 }
 
-func displaySnippetGroup(snippets map[string]models.Snippet, verbose bool) {
-	for _, name := range slices.Sorted(maps.Keys(snippets)) {
-		snippet := snippets[name]
+// Groupings accepted by `cs list --group-by`.
+const (
+	groupBySource = "source"
+	groupByTag    = "tag"
+)
+
+// untaggedSection heads the snippets without tags in `cs list --group-by tag`.
+const untaggedSection = "(untagged)"
+
+// tagSection is the snippets listed under one tag.
+type tagSection struct {
+	tag      string
+	snippets map[string]models.Snippet
+}
+
+// groupSnippetsByTag returns a section per tag, in tag order, holding the
+// snippets with that tag; a snippet with several tags is in each of their
+// sections. Snippets without tags come last, under untaggedSection. With a
+// filter, only the sections of its tags are returned.
+func groupSnippetsByTag(snippets map[string]models.Snippet, filter tagFilter) []tagSection {
+	var tagLists [][]string
+	for _, snippet := range snippets {
+		tagLists = append(tagLists, snippet.Tags)
+	}
+	names := tagNames(tagLists...)
+
+	byTag := make(map[string]map[string]models.Snippet)
+	add := func(tag, name string, snippet models.Snippet) {
+		if byTag[tag] == nil {
+			byTag[tag] = make(map[string]models.Snippet)
+		}
+		byTag[tag][name] = snippet
+	}
+	for name, snippet := range snippets {
+		if len(snippet.Tags) == 0 {
+			add(untaggedSection, name, snippet)
+		}
+		for _, tag := range snippet.Tags {
+			if filter.empty() || hasAnyTag([]string{tag}, filter.tags) {
+				add(names[strings.ToLower(tag)], name, snippet)
+			}
+		}
+	}
+
+	var sections []tagSection
+	for _, tag := range slices.Sorted(maps.Keys(byTag)) {
+		if tag != untaggedSection {
+			sections = append(sections, tagSection{tag, byTag[tag]})
+		}
+	}
+	if untagged, ok := byTag[untaggedSection]; ok {
+		sections = append(sections, tagSection{untaggedSection, untagged})
+	}
+	return sections
+}
+
+func displaySnippetGroup(snippets map[string]models.Snippet, verbose bool, sortBy string, reverse bool) {
+	sorted := make([]namedSnippet, 0, len(snippets))
+	for name, snippet := range snippets {
+		sorted = append(sorted, namedSnippet{name, snippet})
+	}
+	sortNamedSnippets(sorted, sortBy, reverse)
+
+	for _, s := range sorted {
+		name, snippet := s.name, s.snippet
 		fmt.Printf("• %s\n", snippetSummary(name, &snippet))
 
 		// Verbose mode shows more details
 		if verbose {
-			fmt.Printf("  Command: %s\n", snippet.Command)
+			fmt.Printf("  Command: %s\n", strings.Join(snippet.Steps(), models.StepSeparator(config)))
+			if len(snippet.Aliases) > 0 {
+				fmt.Printf("  Aliases: %s\n", strings.Join(snippet.Aliases, ", "))
+			}
 
 			if len(snippet.Variables) > 0 {
 				fmt.Printf("  Variables:\n")
@@ -152,12 +296,128 @@ func displaySnippetGroup(snippets map[string]models.Snippet, verbose bool) {
 	}
 }
 
-// hasAnyTag checks if any of the filterTags exist in the snippet tags (case-insensitive).
-func hasAnyTag(snippetTags, filterTags []string) bool {
-	for _, filterTag := range filterTags {
-		if slices.ContainsFunc(snippetTags, func(t string) bool { return strings.EqualFold(t, filterTag) }) {
-			return true
+// Layouts accepted by `cs list --format`.
+const (
+	formatList  = "list"
+	formatTable = "table"
+)
+
+// filteredSnippets returns the snippets passing the list filters, sorted.
+// Setting both showLocal and showGlobal is the same as setting neither.
+func filteredSnippets(filterTags tagFilter, showLocal bool, showGlobal bool, sortBy string, reverse bool) ([]namedSnippet, error) {
+	sortBy, err := parseSortMode(sortBy)
+	if err != nil {
+		return nil, err
+	}
+	if showLocal && showGlobal {
+		showLocal, showGlobal = false, false
+	}
+
+	var snippets []namedSnippet
+	for name, snippet := range config.Snippets {
+		if !filterTags.matches(snippet.Tags) ||
+			showLocal && snippet.Source != models.SourceLocal ||
+			showGlobal && snippet.Source == models.SourceLocal {
+			continue
 		}
+		snippets = append(snippets, namedSnippet{name, snippet})
+	}
+	sortNamedSnippets(snippets, sortBy, reverse)
+	return snippets, nil
+}
+
+// runListQuiet prints the names of the snippets passing the filters, one
+// per line, with nothing else on stdout.
+func runListQuiet(filterTags tagFilter, showLocal bool, showGlobal bool, sortBy string, reverse bool) error {
+	snippets, err := filteredSnippets(filterTags, showLocal, showGlobal, sortBy, reverse)
+	if err != nil {
+		return err
+	}
+	for _, s := range snippets {
+		fmt.Println(s.name)
+	}
+	return nil
+}
+
+// runListTable lists the snippets passing the filters in one table, fitted
+// to the terminal width when stdout is a terminal.
+func runListTable(filterTags tagFilter, showLocal bool, showGlobal bool, sortBy string, reverse bool, noHeaders bool) error {
+	snippets, err := filteredSnippets(filterTags, showLocal, showGlobal, sortBy, reverse)
+	if err != nil {
+		return err
+	}
+
+	width, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		width = 0
+	}
+	return writeSnippetTable(os.Stdout, snippets, width, !noHeaders)
+}
+
+// minDescriptionWidth is the narrowest the description column of the
+// table gets, however little room the terminal leaves.
+const minDescriptionWidth = 10
+
+// writeSnippetTable writes snippets to w as aligned NAME, DESCRIPTION, TAGS,
+// VARS, and SOURCE columns, with a header row when headers is set.
+// Descriptions are cut short with an ellipsis so the rows fit in width;
+// a width of 0 leaves them whole.
+func writeSnippetTable(w io.Writer, snippets []namedSnippet, width int, headers bool) error {
+	type row struct{ name, description, tags, vars, source string }
+	rows := make([]row, 0, len(snippets)+1)
+	if headers {
+		rows = append(rows, row{"NAME", "DESCRIPTION", "TAGS", "VARS", "SOURCE"})
+	}
+	for _, s := range snippets {
+		name := s.name
+		if s.snippet.Favorite {
+			name = favoriteMarker + name
+		}
+		rows = append(rows, row{
+			name:        name,
+			description: s.snippet.Description,
+			tags:        strings.Join(s.snippet.Tags, ","),
+			vars:        strconv.Itoa(len(s.snippet.Variables)),
+			source:      string(cmp.Or(s.snippet.Source, models.SourceGlobal)),
+		})
+	}
+
+	// The description gets whatever the other columns and their padding
+	// leave.
+	const padding = 2
+	if width > 0 {
+		others := 4 * padding
+		for _, column := range []func(row) string{
+			func(r row) string { return r.name },
+			func(r row) string { return r.tags },
+			func(r row) string { return r.vars },
+			func(r row) string { return r.source },
+		} {
+			widest := 0
+			for _, r := range rows {
+				widest = max(widest, utf8.RuneCountInString(column(r)))
+			}
+			others += widest
+		}
+		limit := max(width-others, minDescriptionWidth)
+		for i := range rows {
+			rows[i].description = truncate(rows[i].description, limit)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+	for _, r := range rows {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.name, r.description, r.tags, r.vars, r.source)
+	}
+	return tw.Flush()
+}
+
+// truncate shortens s to at most limit runes, ending it with an ellipsis
+// when anything was cut.
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
 	}
-	return false
+	return string(runes[:limit-1]) + "…"
 }
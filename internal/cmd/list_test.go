@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+func TestGroupSnippetsByTag(t *testing.T) {
+	snippets := map[string]models.Snippet{
+		"pods":    {Tags: []string{"k8s", "read"}},
+		"deploy":  {Tags: []string{"k8s"}},
+		"disk":    {Tags: []string{"read"}},
+		"scratch": {},
+	}
+	summarize := func(sections []tagSection) []string {
+		var got []string
+		for _, section := range sections {
+			got = append(got, section.tag+": "+strings.Join(slices.Sorted(maps.Keys(section.snippets)), ","))
+		}
+		return got
+	}
+
+	got := summarize(groupSnippetsByTag(snippets, tagFilter{}))
+	expected := []string{"k8s: deploy,pods", "read: disk,pods", "(untagged): scratch"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// Only the filtered tags get sections; runList has already dropped the
+	// snippets without them
+	got = summarize(groupSnippetsByTag(map[string]models.Snippet{"pods": snippets["pods"], "deploy": snippets["deploy"]}, tagFilter{tags: []string{"K8S"}}))
+	if !slices.Equal(got, []string{"k8s: deploy,pods"}) {
+		t.Errorf("Expected only the k8s section, got %v", got)
+	}
+
+	// Spellings differing only in case share one section, named after the
+	// most used spelling
+	snippets["pods"] = models.Snippet{Tags: []string{"K8s", "read"}}
+	snippets["disk"] = models.Snippet{Tags: []string{"read", "k8s"}}
+	got = summarize(groupSnippetsByTag(snippets, tagFilter{}))
+	expected = []string{"k8s: deploy,disk,pods", "read: disk,pods", "(untagged): scratch"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestWriteSnippetTable(t *testing.T) {
+	snippets := []namedSnippet{
+		{"pods", models.Snippet{Description: "List the pods in a namespace", Tags: []string{"k8s", "read"}, Favorite: true,
+			Variables: []models.Variable{{Name: "ns"}, {Name: "wide"}}}},
+		{"up", models.Snippet{Description: "Start", Source: models.SourceLocal}},
+	}
+
+	var b strings.Builder
+	if err := writeSnippetTable(&b, snippets, 0, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := `NAME    DESCRIPTION                   TAGS      VARS  SOURCE
+★ pods  List the pods in a namespace  k8s,read  2     global
+up      Start                                   0     local
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected table:\n%s", b.String())
+	}
+
+	// Without headers, NAME, TAGS, VARS, and SOURCE take 6+8+1+6 columns
+	// plus 8 of padding, leaving 15 of 44 for the description
+	b.Reset()
+	if err := writeSnippetTable(&b, snippets, 44, false); err != nil {
+		t.Fatal(err)
+	}
+	expected = `★ pods  List the pods …  k8s,read  2  global
+up      Start                      0  local
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected table:\n%s", b.String())
+	}
+}
+
+func TestFilteredSnippets(t *testing.T) {
+	config = &models.Config{Snippets: map[string]models.Snippet{
+		"b-pods":  {Tags: []string{"k8s"}, Source: models.SourceLocal},
+		"a-nodes": {Tags: []string{"k8s"}},
+		"c-disk":  {},
+	}}
+	t.Cleanup(func() { config = nil })
+
+	tests := []struct {
+		name                  string
+		filter                tagFilter
+		showLocal, showGlobal bool
+		expected              []string
+	}{
+		{name: "all", expected: []string{"a-nodes", "b-pods", "c-disk"}},
+		{name: "tags", filter: tagFilter{tags: []string{"k8s"}}, expected: []string{"a-nodes", "b-pods"}},
+		{name: "local", showLocal: true, expected: []string{"b-pods"}},
+		{name: "global with tags", filter: tagFilter{tags: []string{"k8s"}}, showGlobal: true, expected: []string{"a-nodes"}},
+		{name: "local and global", showLocal: true, showGlobal: true, expected: []string{"a-nodes", "b-pods", "c-disk"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippets, err := filteredSnippets(tt.filter, tt.showLocal, tt.showGlobal, sortByName, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range snippets {
+				got = append(got, s.name)
+			}
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
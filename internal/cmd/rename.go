@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newRenameCmd() *cobra.Command {
+	var overwrite bool
+
+	cmd := &cobra.Command{
+		Use:   "rename <old-name> <new-name>",
+		Short: "Rename a command template",
+		Long: `Rename a command template, keeping its name field in sync with the new key.
+
+Examples:
+  cs rename kgp kubectl-get-pods              # Rename a template
+  cs rename kgp kubectl-get-pods --overwrite  # Replace an existing template`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRename(args[0], args[1], overwrite)
+		},
+	}
+
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace an existing template with the new name")
+
+	return cmd
+}
+
+func runRename(oldName, newName string, overwrite bool) error {
+	oldName, snippet, err := findSnippet(oldName)
+	if err != nil {
+		return err
+	}
+
+	if oldName == newName {
+		return fmt.Errorf("template '%s' already has that name", oldName)
+	}
+	if _, exists := config.Snippets[newName]; exists && !overwrite {
+		return fmt.Errorf("template '%s' already exists (use --overwrite to replace it)", newName)
+	}
+
+	// The renamed snippet stays in the file it was loaded from; one it
+	// replaces is removed from its own file.
+	previous := snippetFiles(oldName, newName)
+	delete(config.Snippets, oldName)
+	snippet.Name = newName
+	snippet.UpdatedAt = time.Now()
+	config.Snippets[newName] = snippet
+
+	if err := saveSnippets(previous, oldName, newName); err != nil {
+		return err
+	}
+	renameUsage(oldName, newName)
+	renameHistory(oldName, newName)
+
+	fmt.Printf("✅ Command template '%s' renamed to '%s'\n", oldName, newName)
+	return nil
+}
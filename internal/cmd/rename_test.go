@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/samling/command-snippets/internal/state"
+)
+
+// TestRunRename tests renaming a snippet, refusing to replace another one
+// unless asked, and that its usage and history follow it
+func TestRunRename(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": "snippets:\n" +
+		"  kgp:\n    command: kubectl get pods\n    favorite: true\n" +
+		"  pods:\n    command: kubectl get pods -A\n"})
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	usagePath, err := state.UsagePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	historyPath, err := state.HistoryPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	usage := &state.Usage{Snippets: map[string]state.UsageEntry{"kgp": {Count: 3, LastUsed: at}, "pods": {Count: 1, LastUsed: at}}}
+	if err := usage.Save(usagePath); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range []state.HistoryEntry{
+		{Snippet: "kgp", Command: "kubectl get pods", Time: at},
+		{Snippet: "pods", Command: "kubectl get pods -A", Time: at},
+	} {
+		if err := state.AppendHistory(historyPath, entry, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = runRename("kgp", "pods", false)
+	if err == nil || !strings.Contains(err.Error(), "use --overwrite") {
+		t.Fatalf("Expected a collision error, got %v", err)
+	}
+	if _, ok := config.Snippets["kgp"]; !ok {
+		t.Error("Expected kgp to be kept after the collision")
+	}
+	if err := runRename("kgp", "kgp", false); err == nil {
+		t.Error("Expected an error renaming to the same name")
+	}
+
+	if err := runRename("kgp", "pods", true); err != nil {
+		t.Fatalf("runRename with overwrite failed: %v", err)
+	}
+	reloaded, err := loadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	if _, ok := reloaded.Snippets["kgp"]; ok {
+		t.Error("Expected kgp to be gone")
+	}
+	if pods := reloaded.Snippets["pods"]; pods.Command != "kubectl get pods" || !pods.Favorite {
+		t.Errorf("Expected pods to be the renamed kgp, got %+v", pods)
+	}
+
+	if usage, err = state.LoadUsage(usagePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := usage.Snippets["kgp"]; ok || usage.Snippets["pods"].Count != 3 {
+		t.Errorf("Expected kgp's usage under pods, got %v", usage.Snippets)
+	}
+	history, err := state.LoadHistory(historyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Snippet != "pods" || history[0].Command != "kubectl get pods" {
+		t.Errorf("Expected only kgp's history, under pods, got %+v", history)
+	}
+}
@@ -1,13 +1,18 @@
 package cmd
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/template"
 
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
@@ -36,11 +41,17 @@ Features:
 - Interactive template execution
 - Reusable transformation patterns
 - Tag-based organization
-- Complex variable composition`,
+- Complex variable composition
+
+Exit status:
+  0    success
+  1    cs error, such as an invalid config or snippet
+  2    invalid flags or arguments
+  130  the selector or variable form was cancelled
+  When an executed command fails, cs exits with that command's status.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if generateConfig {
-			defaultConfig := createDefaultConfig()
-			data, err := yaml.Marshal(defaultConfig)
+			data, err := marshalDefaultConfig(createDefaultConfig())
 			if err != nil {
 				return fmt.Errorf("failed to marshal config: %w", err)
 			}
@@ -52,10 +63,74 @@ Features:
 	},
 }
 
+// Exit statuses for failures in cs itself. A failed executed command exits
+// with its own status instead.
+const (
+	exitError     = 1
+	exitUsage     = 2
+	exitCancelled = 130
+)
+
+// usageError marks an error caused by invalid flags or arguments.
+type usageError struct {
+	err error
+}
+
+func (e *usageError) Error() string { return e.err.Error() }
+func (e *usageError) Unwrap() error { return e.err }
+
+// usageErrorf is fmt.Errorf for errors caused by invalid flags or arguments.
+func usageErrorf(format string, args ...any) error {
+	return &usageError{fmt.Errorf(format, args...)}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() error {
-	return rootCmd.Execute()
+// Errors are printed here and mapped to the returned exit status.
+func Execute() int {
+	cmd, err := rootCmd.ExecuteC()
+	return exitStatus(cmd, err)
+}
+
+// exitStatus prints err, returned by cmd, unless it needs no message, and
+// maps it to the exit status of cs.
+func exitStatus(cmd *cobra.Command, err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *template.ExitError
+	var usageErr *usageError
+	switch {
+	case errors.As(err, &exitErr):
+		// The command already wrote its own diagnostics.
+		return exitErr.Code
+	case isUserCancellation(err):
+		return exitCancelled
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", cmd.CommandPath())
+		return exitUsage
+	default:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+}
+
+// markArgErrors wraps the positional argument validators of cmd and its
+// subcommands so their failures are reported as usage errors.
+func markArgErrors(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return &usageError{err}
+			}
+			return nil
+		}
+	}
+	for _, sub := range cmd.Commands() {
+		markArgErrors(sub)
+	}
 }
 
 func init() {
@@ -73,6 +148,23 @@ func init() {
 	rootCmd.AddCommand(newEditCmd())
 	rootCmd.AddCommand(newDescribeCmd())
 	rootCmd.AddCommand(newShowCmd())
+	rootCmd.AddCommand(newRenameCmd())
+	rootCmd.AddCommand(newCopyCmd())
+	rootCmd.AddCommand(newExportCmd())
+	rootCmd.AddCommand(newImportCmd())
+	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newTagsCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+	rootCmd.AddCommand(newFavoriteCmd())
+	rootCmd.AddCommand(newUnfavoriteCmd())
+
+	// Errors are printed by Execute, which also picks the exit status.
+	rootCmd.SilenceErrors = true
+	rootCmd.SilenceUsage = true
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &usageError{err}
+	})
+	markArgErrors(rootCmd)
 }
 
 // initConfig reads in config file and ENV variables.
@@ -95,11 +187,11 @@ func initConfig() {
 		// Create default config if file doesn't exist
 		if os.IsNotExist(err) {
 			config = createDefaultConfig()
-			if err := saveConfig(config, cfgFile); err != nil {
-				fmt.Printf("Warning: Could not save default config: %v\n", err)
+			if err := saveDefaultConfig(config, cfgFile); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Could not save default config: %v\n", err)
 			}
 		} else {
-			fmt.Printf("Error loading config: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 			os.Exit(1)
 		}
 	}
@@ -126,8 +218,29 @@ func loadConfig(filename string) (*models.Config, error) {
 	// Mark all snippets from main config as global
 	for name, snippet := range cfg.Snippets {
 		snippet.Source = models.SourceGlobal
+		snippet.SourceFile = filename
 		cfg.Snippets[name] = snippet
 	}
+	for name, template := range cfg.TransformTemplates {
+		template.SourceFile = filename
+		cfg.TransformTemplates[name] = template
+	}
+	for name, varType := range cfg.VariableTypes {
+		varType.SourceFile = filename
+		cfg.VariableTypes[name] = varType
+	}
+	for name, global := range cfg.GlobalVariables {
+		global.Name, global.SourceFile = name, filename
+		cfg.GlobalVariables[name] = global
+	}
+	cfg.Files = []models.ConfigFile{{
+		Path:               filename,
+		Kind:               "main",
+		Snippets:           len(cfg.Snippets),
+		TransformTemplates: len(cfg.TransformTemplates),
+		VariableTypes:      len(cfg.VariableTypes),
+		GlobalVariables:    len(cfg.GlobalVariables),
+	}}
 
 	// Load additional configuration files if specified
 	if err := loadAdditionalConfigs(&cfg, filename); err != nil {
@@ -139,6 +252,10 @@ func loadConfig(filename string) (*models.Config, error) {
 		return nil, fmt.Errorf("loading local snippets: %w", err)
 	}
 
+	for _, conflict := range cfg.AliasConflicts() {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", conflict)
+	}
+
 	return &cfg, nil
 }
 
@@ -186,7 +303,7 @@ func loadAdditionalConfigs(cfg *models.Config, configDir string) error {
 	for _, r := range results {
 		if r.err != nil {
 			if os.IsNotExist(r.err) {
-				fmt.Printf("Warning: Additional config file not found: %s\n", r.path)
+				fmt.Fprintf(os.Stderr, "Warning: Additional config file not found: %s\n", r.path)
 				continue
 			}
 			return fmt.Errorf("loading additional config file %s: %w", r.path, r.err)
@@ -198,11 +315,16 @@ func loadAdditionalConfigs(cfg *models.Config, configDir string) error {
 
 // readConfigFile reads and parses a YAML config file without merging.
 func readConfigFile(filename string) (models.Config, error) {
-	var c models.Config
 	data, err := os.ReadFile(filename)
 	if err != nil {
-		return c, err
+		return models.Config{}, err
 	}
+	return parseConfig(data)
+}
+
+// parseConfig parses YAML (or JSON) config data without merging.
+func parseConfig(data []byte) (models.Config, error) {
+	var c models.Config
 	if err := yaml.Unmarshal(data, &c); err != nil {
 		return c, err
 	}
@@ -220,7 +342,8 @@ func loadConfigFileWithSource(cfg *models.Config, filename string, source models
 	return nil
 }
 
-// mergeConfig merges src into dst. Snippets gain the given source label.
+// mergeConfig merges src into dst. Snippets gain the given source label,
+// and the file is added to dst.Files along with what it overwrote.
 func mergeConfig(dst, src *models.Config, filename string, source models.SnippetSource) {
 	if dst.TransformTemplates == nil {
 		dst.TransformTemplates = make(map[string]models.TransformTemplate)
@@ -228,29 +351,63 @@ func mergeConfig(dst, src *models.Config, filename string, source models.Snippet
 	if dst.VariableTypes == nil {
 		dst.VariableTypes = make(map[string]models.VariableType)
 	}
+	if dst.GlobalVariables == nil {
+		dst.GlobalVariables = make(map[string]models.Variable)
+	}
 	if dst.Snippets == nil {
 		dst.Snippets = make(map[string]models.Snippet)
 	}
 
-	for name, template := range src.TransformTemplates {
+	file := models.ConfigFile{
+		Path:               filename,
+		Kind:               "additional",
+		Snippets:           len(src.Snippets),
+		TransformTemplates: len(src.TransformTemplates),
+		VariableTypes:      len(src.VariableTypes),
+		GlobalVariables:    len(src.GlobalVariables),
+	}
+	if source == models.SourceLocal {
+		file.Kind = "local"
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(src.TransformTemplates)) {
 		if _, exists := dst.TransformTemplates[name]; exists {
-			fmt.Printf("Warning: Transform template '%s' from %s overwrites existing template\n", name, filename)
+			fmt.Fprintf(os.Stderr, "Warning: Transform template '%s' from %s overwrites existing template\n", name, filename)
+			file.Overwritten = append(file.Overwritten, fmt.Sprintf("transform template '%s'", name))
 		}
+		template := src.TransformTemplates[name]
+		template.SourceFile = filename
 		dst.TransformTemplates[name] = template
 	}
-	for name, varType := range src.VariableTypes {
+	for _, name := range slices.Sorted(maps.Keys(src.VariableTypes)) {
 		if _, exists := dst.VariableTypes[name]; exists {
-			fmt.Printf("Warning: Variable type '%s' from %s overwrites existing type\n", name, filename)
+			fmt.Fprintf(os.Stderr, "Warning: Variable type '%s' from %s overwrites existing type\n", name, filename)
+			file.Overwritten = append(file.Overwritten, fmt.Sprintf("variable type '%s'", name))
 		}
+		varType := src.VariableTypes[name]
+		varType.SourceFile = filename
 		dst.VariableTypes[name] = varType
 	}
-	for name, snippet := range src.Snippets {
-		if _, exists := dst.Snippets[name]; exists {
-			fmt.Printf("Warning: Snippet '%s' from %s overwrites existing snippet\n", name, filename)
+	for _, name := range slices.Sorted(maps.Keys(src.GlobalVariables)) {
+		if _, exists := dst.GlobalVariables[name]; exists {
+			fmt.Fprintf(os.Stderr, "Warning: Global variable '%s' from %s overwrites existing variable\n", name, filename)
+			file.Overwritten = append(file.Overwritten, fmt.Sprintf("global variable '%s'", name))
+		}
+		global := src.GlobalVariables[name]
+		global.Name, global.SourceFile = name, filename
+		dst.GlobalVariables[name] = global
+	}
+	for _, name := range slices.Sorted(maps.Keys(src.Snippets)) {
+		if existing, exists := dst.Snippets[name]; exists {
+			fmt.Fprintf(os.Stderr, "Warning: Snippet '%s' from %s overwrites existing snippet\n", name, filename)
+			file.Overwritten = append(file.Overwritten, fmt.Sprintf("snippet '%s' (from %s)", name, existing.SourceFile))
 		}
+		snippet := src.Snippets[name]
 		snippet.Source = source
+		snippet.SourceFile = filename
 		dst.Snippets[name] = snippet
 	}
+	dst.Files = append(dst.Files, file)
 }
 
 // loadLocalSnippets loads snippets from a local .csnippets file in the current directory
@@ -279,24 +436,210 @@ func expandPath(path string) string {
 	return path
 }
 
-// saveConfig saves configuration to YAML file
-func saveConfig(cfg *models.Config, filename string) error {
-	// Create directory if it doesn't exist
-	dir := filepath.Dir(filename)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+// snippetFile returns the file a snippet is saved to: the one it was
+// loaded from, or the main config for a new snippet.
+func snippetFile(s models.Snippet) string {
+	return cmp.Or(s.SourceFile, cfgFile)
+}
+
+// snippetFiles returns the file each of the named snippets currently
+// belongs to, skipping names that don't exist. Commands take it before
+// changing snippets and pass it to saveSnippets afterwards.
+func snippetFiles(names ...string) map[string]string {
+	files := make(map[string]string, len(names))
+	for _, name := range names {
+		if s, ok := config.Snippets[name]; ok {
+			files[name] = snippetFile(s)
+		}
+	}
+	return files
+}
+
+// saveSnippets writes the named snippets to the files they belong to.
+// previous holds the files they belonged to before the change, as
+// returned by snippetFiles: a snippet that was deleted, renamed away, or
+// replaced by one from another file is removed from there. Only these
+// entries are rewritten; in particular, snippets loaded from other files
+// never end up in the main config.
+func saveSnippets(previous map[string]string, names ...string) error {
+	edits := make(fileEdits)
+	for _, name := range names {
+		file := ""
+		if s, ok := config.Snippets[name]; ok {
+			file = snippetFile(s)
+			edits.set(file, "snippets", name, s)
+		}
+		if old, ok := previous[name]; ok && old != file {
+			edits.set(old, "snippets", name, nil)
+		}
+	}
+	return edits.apply()
+}
+
+// fileEdits holds the configEdits for each of several files.
+type fileEdits map[string]configEdits
+
+func (e fileEdits) set(file, section, name string, value any) {
+	if e[file] == nil {
+		e[file] = make(configEdits)
+	}
+	e[file].set(section, name, value)
+}
+
+// apply writes the edits to each file with applyConfigEdits.
+func (e fileEdits) apply() error {
+	for _, file := range slices.Sorted(maps.Keys(e)) {
+		if err := applyConfigEdits(file, e[file]); err != nil {
+			return fmt.Errorf("failed to save %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// configEdits maps a section of a config file ("snippets",
+// "transform_templates", "variable_types", or "global_variables") to the entries to write
+// there by name; a nil entry is removed.
+type configEdits map[string]map[string]any
+
+func (e configEdits) set(section, name string, value any) {
+	if e[section] == nil {
+		e[section] = make(map[string]any)
+	}
+	e[section][name] = value
+}
+
+// applyConfigEdits writes edits to filename, leaving the rest of the file,
+// comments included, as it is on disk. A missing file is created.
+func applyConfigEdits(filename string, edits configEdits) error {
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("top level is not a mapping")
+	}
+
+	for _, section := range slices.Sorted(maps.Keys(edits)) {
+		entries := mappingSection(root, section)
+		for _, name := range slices.Sorted(maps.Keys(edits[section])) {
+			i := mappingKeyIndex(entries, name)
+			value := edits[section][name]
+			if value == nil {
+				if i >= 0 {
+					entries.Content = slices.Delete(entries.Content, i, i+2)
+				}
+				continue
+			}
+
+			var node yaml.Node
+			if err := node.Encode(value); err != nil {
+				return err
+			}
+			if i >= 0 {
+				entries.Content[i+1] = &node
+			} else {
+				key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}
+				entries.Content = append(entries.Content, key, &node)
+			}
+		}
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
 		return err
 	}
+	return writeConfigFile(filename, out)
+}
 
-	data, err := yaml.Marshal(cfg)
+// mappingKeyIndex returns the index in mapping.Content of key, or -1.
+func mappingKeyIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingSection returns the mapping under key in root, adding an empty
+// one when the key is missing or null.
+func mappingSection(root *yaml.Node, key string) *yaml.Node {
+	if i := mappingKeyIndex(root, key); i >= 0 {
+		value := root.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			*value = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	return value
+}
+
+// saveDefaultConfig writes a newly created default config, including the
+// commented-out optional settings from marshalDefaultConfig.
+func saveDefaultConfig(cfg *models.Config, filename string) error {
+	data, err := marshalDefaultConfig(cfg)
 	if err != nil {
 		return err
 	}
+	return writeConfigFile(filename, data)
+}
 
+// writeConfigFile writes data to filename, creating its directory if needed.
+func writeConfigFile(filename string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
 	return os.WriteFile(filename, data, 0644)
 }
 
+// defaultExecutionComment documents settings.execution in generated
+// configs, where the section is otherwise empty and omitted.
+const defaultExecutionComment = `# execution:
+#   shell: powershell              # default: $SHELL, then sh; cmd on Windows
+#   shell_args: ["-Command"]       # default: -c; /C for cmd; -NoProfile -Command for powershell/pwsh
+#   allow_dynamic_defaults: true   # let variables run their default_from_command
+#   trusted_dirs: ["~/src/infra"]  # local .csnippets directories that may run them too`
+
+// marshalDefaultConfig renders cfg as YAML with the optional execution
+// settings included as comments under settings, so they can be discovered
+// and uncommented.
+func marshalDefaultConfig(cfg *models.Config) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, err
+	}
+
+	// The comment goes above interactive, where execution would appear.
+	if _, settings := mappingEntry(&doc, "settings"); settings != nil {
+		if key, _ := mappingEntry(settings, "interactive"); key != nil {
+			key.HeadComment = defaultExecutionComment
+		}
+	}
+	return yaml.Marshal(&doc)
+}
+
+// mappingEntry returns the key and value nodes for key in mapping node n,
+// or nils when it is absent.
+func mappingEntry(n *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if i := mappingKeyIndex(n, key); i >= 0 {
+		return n.Content[i], n.Content[i+1]
+	}
+	return nil, nil
+}
+
 // createDefaultConfig creates a minimal stub configuration
 func createDefaultConfig() *models.Config {
+	showFinalCommand := true
 	return &models.Config{
 		TransformTemplates: make(map[string]models.TransformTemplate),
 		VariableTypes:      make(map[string]models.VariableType),
@@ -309,6 +652,14 @@ func createDefaultConfig() *models.Config {
 				Command: "fzf",
 				Options: "--height 40% --reverse --border --sort",
 			},
+			History: models.HistoryConfig{
+				Enabled:    true,
+				MaxEntries: models.DefaultHistoryMaxEntries,
+			},
+			Interactive: models.InteractiveConfig{
+				ConfirmBeforeExecute: false,
+				ShowFinalCommand:     &showFinalCommand,
+			},
 		},
 	}
 }
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/template"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// TestDefaultConfigInteractiveSettings tests the interactive defaults written for new users
+func TestDefaultConfigInteractiveSettings(t *testing.T) {
+	interactive := createDefaultConfig().Settings.Interactive
+	if interactive.ConfirmBeforeExecute {
+		t.Error("Expected confirm_before_execute to default to false")
+	}
+	if interactive.ShowFinalCommand == nil || !*interactive.ShowFinalCommand {
+		t.Error("Expected show_final_command to be written as true")
+	}
+	if createDefaultConfig().Settings.Interactive.ShowFinalCommand == interactive.ShowFinalCommand {
+		t.Error("Expected each default config to get its own show_final_command")
+	}
+}
+
+// TestMarshalDefaultConfig tests that generated configs document the shell settings
+func TestMarshalDefaultConfig(t *testing.T) {
+	data, err := marshalDefaultConfig(createDefaultConfig())
+	if err != nil {
+		t.Fatalf("marshalDefaultConfig failed: %v", err)
+	}
+	for _, want := range []string{"    # execution:", "#   shell: powershell", "#   shell_args:"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected generated config to contain %q, got:\n%s", want, data)
+		}
+	}
+
+	var cfg models.Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("generated config does not parse: %v", err)
+	}
+	if cfg.Settings.Execution.Shell != "" {
+		t.Errorf("Expected the shell to stay commented out, got %q", cfg.Settings.Execution.Shell)
+	}
+}
+
+// TestConfigProvenance tests the files loadConfig records and how
+// cs show config --paths lists them
+func TestConfigProvenance(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	main := filepath.Join(dir, "config.yaml")
+	files := map[string]string{
+		main: "settings:\n  additional_configs: [extra.yaml]\n" +
+			"transform_templates:\n  ns-flag: {}\nsnippets:\n  pods:\n    command: kubectl get pods\n",
+		filepath.Join(dir, "extra.yaml"): "transform_templates:\n  ns-flag: {}\n" +
+			"snippets:\n  pods:\n    command: kubectl get pods -A\n  logs:\n    command: kubectl logs\n",
+		filepath.Join(dir, ".csnippets"): "",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := loadConfig(main)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	var b strings.Builder
+	showConfigPaths(&b, cfg.Files)
+	expected := fmt.Sprintf(`Config files (in load order):
+
+1. %s (main)
+   1 snippet(s), 1 transform template(s)
+
+2. %s (additional)
+   2 snippet(s), 1 transform template(s)
+   Overwrites transform template 'ns-flag'
+   Overwrites snippet 'pods' (from %s)
+
+3. .csnippets (local)
+   no definitions
+`, main, filepath.Join(dir, "extra.yaml"), main)
+	if b.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+// TestSaveSnippets tests that changed snippets are written back to the
+// file they were loaded from, leaving other entries and files alone
+func TestSaveSnippets(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "config.yaml")
+	extra := filepath.Join(dir, "extra.yaml")
+	mainContent := "# main config\nsettings:\n  additional_configs: [extra.yaml]\nsnippets:\n  main-one:\n    command: echo main\n"
+	for path, content := range map[string]string{
+		main:  mainContent,
+		extra: "# k8s\ntransform_templates:\n  ns-flag: {}\nsnippets:\n  logs:\n    command: kubectl logs\n  pods:\n    command: kubectl get pods\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	var err error
+	if config, err = loadConfig(main); err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	oldCfgFile := cfgFile
+	cfgFile = main
+	t.Cleanup(func() { config, cfgFile = nil, oldCfgFile })
+
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	if err := setFavorite("logs", true); err != nil {
+		t.Fatal(err)
+	}
+	if err := runRename("pods", "pods-all", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(main); string(data) != mainContent {
+		t.Errorf("Expected the main config to be left alone, got:\n%s", data)
+	}
+	saved, err := readConfigFile(extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := slices.Sorted(maps.Keys(saved.Snippets)); !slices.Equal(names, []string{"logs", "pods-all"}) {
+		t.Errorf("Expected logs and pods-all in %s, got %v", extra, names)
+	}
+	if !saved.Snippets["logs"].Favorite {
+		t.Error("Expected logs to be saved as a favorite")
+	}
+	if _, ok := saved.TransformTemplates["ns-flag"]; !ok {
+		t.Error("Expected the transform template to be kept")
+	}
+	if data, _ := os.ReadFile(extra); !strings.HasPrefix(string(data), "# k8s\n") {
+		t.Errorf("Expected the comment to be kept, got:\n%s", data)
+	}
+}
+
+// TestExitStatus tests that errors are mapped to the documented exit codes,
+// including a cancelled confirmation before executing
+func TestExitStatus(t *testing.T) {
+	cmd := &cobra.Command{Use: "cs"}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, 0},
+		{"command failed", fmt.Errorf("run: %w", &template.ExitError{Code: 3}), 3},
+		{"form cancelled", fmt.Errorf("form: %w", template.ErrUserCancelled), exitCancelled},
+		{"confirmation cancelled", fmt.Errorf("confirm: %w", template.ErrConfirmCancelled), exitCancelled},
+		{"usage", usageErrorf("bad flag"), exitUsage},
+		{"other", errors.New("broken config"), exitError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitStatus(cmd, tt.err); got != tt.want {
+				t.Errorf("Expected exit status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,90 +1,345 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
+	"os"
+	"regexp"
+	"slices"
 	"strings"
+	"unicode"
 
+	"github.com/samling/command-snippets/internal/models"
+	"github.com/samling/command-snippets/internal/template"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func newSearchCmd() *cobra.Command {
+	var tags tagFilter
+
 	cmd := &cobra.Command{
 		Use:   "search [query]",
 		Short: "Search command templates by name, description, or command",
 		Long: `Search through your command templates using a query string.
 
-The search looks through template names, descriptions, commands, and tags.
+The search looks through template names, descriptions, commands, and tags,
+and the names, descriptions, defaults, and transform templates of their
+variables; each result says which one matched.
+A template matches when one of them contains the letters of the query in
+order, so "kgp" finds kubectl-get-pods. Results are ranked, best first:
+letters at the start of words and next to each other score higher, and so
+do matches in the name. --exact only matches the query as written.
+
+--regex matches the query as a Go regular expression against everything
+but tags, highlighting the matches in commands on a terminal. --fields (or
+--in) limits any search to some of name, description, command, tags, and
+variables.
+
+--tags only searches templates with any of the given tags, or with
+--all-tags, all of them. With tags the query may be left out.
+
+--interactive opens the selector on the matching templates, filtered by
+the query, and executes the one picked as cs exec would; --run, --prompt,
+and --set apply to it. With no matches, cs exits with status 1.
 
 Examples:
-  cs search kubectl              # Find templates containing "kubectl"
-  cs search "get pods"           # Find templates with "get pods"
+  cs search kubectl              # Find templates matching "kubectl"
+  cs search kgp                  # Finds kubectl-get-pods
+  cs search --exact "get pods"   # Only templates containing "get pods"
+  cs search --regex -- '--context(\s|=)'  # Commands passing --context
+  cs search --in tags k8s        # Only search tags
+  cs search --fields variables jsonpath  # Templates with a jsonpath variable
+  cs search --tags k8s pods      # Templates tagged k8s matching "pods"
+  cs search --tags k8s,prod --all-tags  # Templates with both tags
+  cs search -i pods --run        # Pick one of the matches and run it
+  cs search -o json pods         # Machine-readable, sorted by name
   cs search                      # Interactive search`,
-		RunE: runSearch,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSearch(cmd, args, tags)
+		},
 	}
 
+	cmd.Flags().Bool("exact", false, "Match the query as a substring instead of fuzzily")
+	cmd.Flags().Bool("regex", false, "Match the query as a regular expression")
+	cmd.Flags().StringSlice("fields", nil, "Only search these fields: name, description, command, tags, variables")
+	cmd.Flags().StringSlice("in", nil, "Same as --fields")
+	tags.addFlags(cmd)
+	cmd.Flags().BoolP("interactive", "i", false, "Select one of the matching templates and execute it")
+	cmd.Flags().StringP("output", "o", outputText, "Output format (text|json|yaml); json and yaml are sorted by name and include each match's score")
+	addExecModeFlags(cmd)
+
 	return cmd
 }
 
-func runSearch(cmd *cobra.Command, args []string) error {
+func runSearch(cmd *cobra.Command, args []string, tags tagFilter) error {
 	query := ""
 	if len(args) > 0 {
 		query = strings.Join(args, " ")
 	}
 
-	if query == "" {
+	if query == "" && tags.empty() {
 		// Interactive search could be implemented here
 		fmt.Println("Usage: cs search <query>")
 		return nil
 	}
 
-	matches := searchSnippets(query)
+	interactive, _ := cmd.Flags().GetBool("interactive")
+	output, _ := cmd.Flags().GetString("output")
+	if err := checkListOutput(output); err != nil {
+		return err
+	}
+	if interactive && output != outputText {
+		return usageErrorf("--output cannot be combined with --interactive")
+	}
+	if !interactive {
+		for _, flag := range []string{"run", "prompt", "set", "no-selector", "no-color"} {
+			if cmd.Flags().Changed(flag) {
+				return usageErrorf("--%s only applies with --interactive", flag)
+			}
+		}
+	}
+
+	opts := searchOptions{tags: tags}
+	opts.exact, _ = cmd.Flags().GetBool("exact")
+	for _, flag := range []string{"fields", "in"} {
+		fields, _ := cmd.Flags().GetStringSlice(flag)
+		for _, field := range fields {
+			if !slices.Contains(searchFields, field) {
+				return usageErrorf("invalid --%s value '%s' (expected %s)", flag, field, strings.Join(searchFields, ", "))
+			}
+		}
+		opts.in = append(opts.in, fields...)
+	}
+	if useRegex, _ := cmd.Flags().GetBool("regex"); useRegex {
+		if opts.exact {
+			return usageErrorf("--regex and --exact are mutually exclusive")
+		}
+		pattern, err := regexp.Compile(query)
+		if err != nil {
+			return usageErrorf("invalid --regex pattern: %w", err)
+		}
+		opts.pattern = pattern
+	}
+	matches := searchSnippets(query, opts)
 
+	if output != outputText {
+		outputs := make([]snippetOutput, len(matches))
+		for i, match := range matches {
+			snippet := config.Snippets[match.name]
+			outputs[i] = newSnippetOutput(match.name, &snippet)
+			if query != "" {
+				outputs[i].Matched, outputs[i].Score = match.field, match.score
+			}
+		}
+		return writeSnippetOutputs(os.Stdout, outputs, output)
+	}
+
+	// The header names the active filters
+	var filters []string
+	if query != "" {
+		filters = append(filters, fmt.Sprintf("matching '%s'", query))
+	}
+	if len(opts.in) > 0 {
+		filters = append(filters, "in "+strings.Join(opts.in, ", "))
+	}
+	if !tags.empty() {
+		filters = append(filters, "with "+tags.String())
+	}
 	if len(matches) == 0 {
-		fmt.Printf("No command templates found matching '%s'\n", query)
+		fmt.Printf("No command templates found %s\n", strings.Join(filters, " "))
+		if interactive {
+			return &template.ExitError{Code: exitError}
+		}
 		return nil
 	}
 
-	fmt.Printf("Found %d template(s) matching '%s':\n\n", len(matches), query)
+	if interactive {
+		selectOpts := selectOptions{only: make([]string, len(matches))}
+		for i, match := range matches {
+			selectOpts.only[i] = match.name
+		}
+		// A regex isn't a useful selector filter
+		if opts.pattern == nil {
+			selectOpts.query = query
+		}
+		selectOpts.forceInternal, _ = cmd.Flags().GetBool("no-selector")
+		selectOpts.noColor, _ = cmd.Flags().GetBool("no-color")
+		return selectAndExec(cmd, selectOpts)
+	}
+
+	fmt.Printf("Found %d template(s) %s:\n\n", len(matches), strings.Join(filters, " "))
 
-	for _, name := range matches {
-		snippet := config.Snippets[name]
-		fmt.Printf("• %s\n  Command: %s\n\n", snippetSummary(name, &snippet), snippet.Command)
+	// Regex matches are highlighted in commands on a terminal
+	highlight := opts.pattern != nil && term.IsTerminal(int(os.Stdout.Fd()))
+	for _, match := range matches {
+		snippet := config.Snippets[match.name]
+		steps := snippet.Steps()
+		if highlight {
+			steps = slices.Clone(steps)
+			for i, step := range steps {
+				steps[i] = highlightPattern(step, opts.pattern)
+			}
+		}
+		fmt.Printf("• %s\n  Command: %s\n", snippetSummary(match.name, &snippet), strings.Join(steps, models.StepSeparator(config)))
+		if query != "" {
+			fmt.Printf("  Matched: %s\n", match.field)
+		}
+		fmt.Println()
 	}
 
 	return nil
 }
 
-func searchSnippets(query string) []string {
-	var matches []string
-	queryLower := strings.ToLower(query)
+// searchMatch is a snippet found by searchSnippets, with the field that
+// matched best, e.g. "name" or "tag k8s", and its score.
+type searchMatch struct {
+	name  string
+	field string
+	score int
+}
+
+// searchFields are the fields --fields can limit a search to.
+var searchFields = []string{"name", "description", "command", "tags", "variables"}
+
+// searchOptions controls how searchSnippets matches.
+type searchOptions struct {
+	exact   bool           // Match the query as a substring
+	pattern *regexp.Regexp // Match this instead of the query, when set
+	in      []string       // Fields to search, from searchFields; all when empty, or all but tags with pattern
+	tags    tagFilter      // Only snippets passing this are searched
+}
+
+// searchField is a text searchSnippets looks in, with the label reported
+// when it matches and a bonus added to its score.
+type searchField struct {
+	kind, label, text string
+	bonus             int
+}
+
+// searchSnippets returns the snippets passing opts.tags with a name,
+// description, command, tag, or variable matching query, ignoring case,
+// best match first; ties are in name order. A field matches when it contains the letters of query in order,
+// with opts.exact, when it contains query itself, and with opts.pattern,
+// when the pattern matches it; every pattern match scores the same.
+func searchSnippets(query string, opts searchOptions) []searchMatch {
+	queryRunes := []rune(strings.ToLower(query))
+	in := opts.in
+	if len(in) == 0 && opts.pattern != nil {
+		in = []string{"name", "description", "command", "variables"}
+	}
+	var matches []searchMatch
 
 	for name, snippet := range config.Snippets {
-		// Search in name
-		if strings.Contains(strings.ToLower(name), queryLower) {
-			matches = append(matches, name)
+		if !opts.tags.matches(snippet.Tags) {
 			continue
 		}
 
-		// Search in description
-		if strings.Contains(strings.ToLower(snippet.Description), queryLower) {
-			matches = append(matches, name)
-			continue
+		// Fields are listed by priority: a match in the name counts most,
+		// and on equal scores the earlier field is reported.
+		fields := []searchField{{"name", "name", name, 3}}
+		for _, tag := range snippet.Tags {
+			fields = append(fields, searchField{"tags", "tag " + tag, tag, 2})
 		}
+		fields = append(fields,
+			searchField{"description", "description", snippet.Description, 1},
+			searchField{"command", "command", strings.Join(snippet.Steps(), "\n"), 0})
+		for _, v := range snippet.Variables {
+			label := "variable " + v.Name
+			fields = append(fields,
+				searchField{"variables", label, v.Name, 0},
+				searchField{"variables", label + " description", v.Description, 0},
+				searchField{"variables", label + " default", v.DefaultValue, 0},
+				searchField{"variables", label + " transform template", v.TransformTemplate, 0})
+		}
+
+		best := searchMatch{name: name, score: -1}
+		for _, field := range fields {
+			if len(in) > 0 && !slices.Contains(in, field.kind) {
+				continue
+			}
+			var score int
+			var ok bool
+			if opts.pattern != nil {
+				ok = opts.pattern.MatchString(field.text)
+			} else {
+				score, ok = matchScore([]rune(strings.ToLower(field.text)), queryRunes, opts.exact)
+			}
+			if ok && score+field.bonus > best.score {
+				best.field, best.score = field.label, score+field.bonus
+			}
+		}
+		if best.score >= 0 {
+			matches = append(matches, best)
+		}
+	}
 
-		// Search in command
-		if strings.Contains(strings.ToLower(snippet.Command), queryLower) {
-			matches = append(matches, name)
+	slices.SortFunc(matches, func(a, b searchMatch) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.name, b.name))
+	})
+	return matches
+}
+
+// matchScore reports whether text contains the runes of query in order, or
+// with exact, contiguously, and scores the best such match: each matched
+// rune scores a point, plus two when it follows the previous one and three
+// at the start of a word.
+func matchScore(text, query []rune, exact bool) (int, bool) {
+	if len(query) == 0 {
+		return 0, true
+	}
+	best, found := 0, false
+	for start := range text {
+		if text[start] != query[0] {
 			continue
 		}
+		var positions []int
+		if exact {
+			if start+len(query) > len(text) || !slices.Equal(text[start:start+len(query)], query) {
+				continue
+			}
+			for i := range query {
+				positions = append(positions, start+i)
+			}
+		} else if positions = fuzzyPositions(text[start:], query); positions == nil {
+			// Starting later can't find the rest either
+			break
+		} else {
+			for i := range positions {
+				positions[i] += start
+			}
+		}
 
-		// Search in tags
-		for _, tag := range snippet.Tags {
-			if strings.Contains(strings.ToLower(tag), queryLower) {
-				matches = append(matches, name)
-				break
+		score := 0
+		for i, p := range positions {
+			score++
+			if i > 0 && positions[i-1] == p-1 {
+				score += 2
+			}
+			if p == 0 || !unicode.IsLetter(text[p-1]) && !unicode.IsDigit(text[p-1]) {
+				score += 3
 			}
 		}
+		if !found || score > best {
+			best, found = score, true
+		}
 	}
+	return best, found
+}
 
-	return matches
+// highlightPattern renders the parts of s that pattern matches in
+// matchStyle.
+func highlightPattern(s string, pattern *regexp.Regexp) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range pattern.FindAllStringIndex(s, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(matchStyle.Render(s[loc[0]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
 }
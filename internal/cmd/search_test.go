@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+func TestSearchSnippets(t *testing.T) {
+	config = &models.Config{Snippets: map[string]models.Snippet{
+		"kubectl-get-pods": {Command: "kubectl get pods -n <ns>", Tags: []string{"k8s"}},
+		"kill-port":        {Description: "Kill the process on a port", Command: "fuser -k <port>/tcp"},
+		"git-pull":         {Description: "Pull the current branch", Command: "git pull --rebase"},
+		"zz-pods":          {Command: "kubectl get pods"},
+		"jq-filter": {Command: "jq <expr>", Variables: []models.Variable{
+			{Name: "expr", Description: "JSONPath to extract", DefaultValue: ".items", TransformTemplate: "quote"},
+		}},
+	}}
+	t.Cleanup(func() { config = nil })
+
+	names := func(matches []searchMatch) []string {
+		var names []string
+		for _, m := range matches {
+			names = append(names, m.name)
+		}
+		return names
+	}
+
+	matches := searchSnippets("kgp", searchOptions{})
+	if got := names(matches); !slices.Equal(got, []string{"kubectl-get-pods", "zz-pods"}) {
+		t.Errorf("Expected word-boundary name match first, got %v", got)
+	}
+	if matches[0].field != "name" || matches[1].field != "command" {
+		t.Errorf("Expected matched fields name and command, got %q and %q", matches[0].field, matches[1].field)
+	}
+
+	if got := searchSnippets("K8S", searchOptions{}); len(got) != 1 || got[0].field != "tag k8s" {
+		t.Errorf("Expected a case-insensitive tag match, got %v", got)
+	}
+
+	// Equal scores are in name order
+	if got := names(searchSnippets("get pods", searchOptions{})); !slices.Equal(got, []string{"kubectl-get-pods", "zz-pods"}) {
+		t.Errorf("Expected ties by name, got %v", got)
+	}
+
+	if got := names(searchSnippets("kgp", searchOptions{exact: true})); got != nil {
+		t.Errorf("Expected --exact to need a substring, got %v", got)
+	}
+	if got := names(searchSnippets("port", searchOptions{exact: true})); !slices.Equal(got, []string{"kill-port"}) {
+		t.Errorf("Expected kill-port, got %v", got)
+	}
+
+	if got := names(searchSnippets("", searchOptions{in: []string{"tags"}, pattern: regexp.MustCompile("pods")})); got != nil {
+		t.Errorf("Expected --in tags to skip names and commands, got %v", got)
+	}
+	matches = searchSnippets("", searchOptions{pattern: regexp.MustCompile(`-(n|k)\s`)})
+	if got := names(matches); !slices.Equal(got, []string{"kill-port", "kubectl-get-pods"}) || matches[0].field != "command" {
+		t.Errorf("Expected regex command matches, got %v", matches)
+	}
+	if got := names(searchSnippets("", searchOptions{pattern: regexp.MustCompile("k8s")})); got != nil {
+		t.Errorf("Expected --regex to skip tags unless asked, got %v", got)
+	}
+
+	for query, field := range map[string]string{
+		"jsonpath": "variable expr description",
+		".items":   "variable expr default",
+		"quote":    "variable expr transform template",
+	} {
+		if got := searchSnippets(query, searchOptions{exact: true}); len(got) != 1 || got[0].field != field {
+			t.Errorf("Expected %q to match %s, got %v", query, field, got)
+		}
+	}
+	if got := searchSnippets("jsonpath", searchOptions{exact: true, in: []string{"name", "command"}}); got != nil {
+		t.Errorf("Expected --fields to skip variables, got %v", got)
+	}
+
+	if got := highlightPattern("a -n b -n", regexp.MustCompile("-n")); strings.Count(got, "-n") != 2 || !strings.HasPrefix(got, "a ") {
+		t.Errorf("Unexpected highlighting %q", got)
+	}
+}
+
+func TestTagFilter(t *testing.T) {
+	loadFixtureSnippets(t)
+
+	tests := []struct {
+		name     string
+		filter   tagFilter
+		query    string
+		expected []string
+	}{
+		{
+			name:     "any tag",
+			filter:   tagFilter{tags: []string{"validation", "pattern"}},
+			expected: []string{"snippet-with-enum", "snippet-with-inline-pattern", "snippet-with-pattern", "snippet-with-range", "snippet-with-regex-type", "snippet-with-value-pattern"},
+		},
+		{
+			name:     "all tags",
+			filter:   tagFilter{tags: []string{"validation", "pattern"}, all: true},
+			expected: []string{"snippet-with-inline-pattern", "snippet-with-pattern"},
+		},
+		{
+			name:     "all tags ignores case",
+			filter:   tagFilter{tags: []string{"Computed", "COMPOSE"}, all: true},
+			expected: []string{"snippet-with-computed-simple"},
+		},
+		{
+			name:     "tags then query",
+			filter:   tagFilter{tags: []string{"validation"}},
+			query:    "inline",
+			expected: []string{"snippet-with-inline-pattern"},
+		},
+		{
+			name:   "no snippet has every tag",
+			filter: tagFilter{tags: []string{"boolean", "enum"}, all: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, match := range searchSnippets(tt.query, searchOptions{exact: true, tags: tt.filter}) {
+				got = append(got, match.name)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if !(tagFilter{}).matches(nil) {
+		t.Errorf("Expected an empty filter to match every snippet")
+	}
+	if got := (tagFilter{tags: []string{"a", "b"}, all: true}).String(); got != "all tags: a, b" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
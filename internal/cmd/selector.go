@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -30,24 +33,168 @@ var (
 
 	helpTextStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("241"))
+
+	badgeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("86")) // Cyan for the local badge
+
+	matchStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214")). // Orange for characters matching the filter
+			Bold(true)
 )
 
+// selectorWindowSize is the number of options the selector shows at once
+// until it knows the terminal height.
+const selectorWindowSize = 10
+
+// selectorChrome is the number of lines the selector shows besides the
+// options: the title, filter, and a blank line above them, a scroll
+// indicator on either side, and a blank line and the help below.
+const selectorChrome = 7
+
 // selectorModel represents a snippet selector
 type selectorModel struct {
 	options    []string
-	snippetMap map[string]string // maps display name to snippet name
-	cursor     int
-	selected   string
+	snippetMap map[string]string   // maps display name to snippet name
+	suffixes   map[string]string   // optional dimmed text shown after a display name
+	tags       map[string][]string // snippet tags by snippet name
+	allTags    []string            // Every tag of the options, sorted, for Ctrl+T to cycle through
+	tag        string              // Only options with this tag are shown, when set
+	query      string              // Text typed to filter the options
+	matches    []optionMatch       // Options matching query, best first
+	cursor     int                 // Index into matches
+	height     int                 // Terminal height, 0 until known
+	numbered   bool                // Digits pick the visible options while the filter is empty
+	multi      bool                // Space toggles options so several can be selected
+	chosen     []int               // Options toggled in multi mode, in the order they were
+	empty      string              // Why there are no options, shown with the offer to add one
+	addNew     bool                // n was pressed to add a template when there were no options
+	selected   []string
 	cancelled  bool
 	done       bool
 }
 
+// optionMatch is an option that matches the selector's query and the rune
+// positions in it of the matched characters.
+type optionMatch struct {
+	index     int
+	positions []int
+}
+
 // newSelectorModel creates a new selector model from prebuilt display options.
-func newSelectorModel(options []string, snippetMap map[string]string) selectorModel {
-	return selectorModel{
+// suffixes and tags may be nil.
+func newSelectorModel(options []string, snippetMap map[string]string, suffixes map[string]string, tags map[string][]string) selectorModel {
+	m := selectorModel{
 		options:    options,
 		snippetMap: snippetMap,
+		suffixes:   suffixes,
+		tags:       tags,
+	}
+	var tagLists [][]string
+	for _, option := range options {
+		tagLists = append(tagLists, tags[snippetMap[option]])
+	}
+	m.allTags = slices.Sorted(maps.Values(tagNames(tagLists...)))
+	m.filter()
+	return m
+}
+
+// filter narrows the options to those with the selected tag that match the
+// query, ignoring case: options containing it come first, then those
+// containing its characters in order. Each group keeps the options' order.
+// The cursor moves to the best match.
+func (m *selectorModel) filter() {
+	query := []rune(strings.ToLower(m.query))
+	var contains, fuzzy []optionMatch
+	for i, option := range m.options {
+		if m.tag != "" && !hasAnyTag(m.tags[m.snippetMap[option]], []string{m.tag}) {
+			continue
+		}
+		text := []rune(strings.ToLower(option))
+		if at := runeIndex(text, query); at >= 0 {
+			positions := make([]int, len(query))
+			for j := range positions {
+				positions[j] = at + j
+			}
+			contains = append(contains, optionMatch{i, positions})
+		} else if positions := fuzzyPositions(text, query); positions != nil {
+			fuzzy = append(fuzzy, optionMatch{i, positions})
+		}
+	}
+	m.matches = append(contains, fuzzy...)
+	m.cursor = 0
+}
+
+// runeIndex returns the index in s of the first occurrence of sub, or -1.
+func runeIndex(s, sub []rune) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		if slices.Equal(s[i:i+len(sub)], sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fuzzyPositions returns the positions in s of the runes of pattern, taken
+// in order at their earliest, or nil when they don't all appear.
+func fuzzyPositions(s, pattern []rune) []int {
+	positions := make([]int, 0, len(pattern))
+	for i := 0; i < len(s) && len(positions) < len(pattern); i++ {
+		if s[i] == pattern[len(positions)] {
+			positions = append(positions, i)
+		}
+	}
+	if len(positions) < len(pattern) {
+		return nil
+	}
+	return positions
+}
+
+// focus moves the cursor to the option for the snippet named name, if
+// there is one.
+func (m *selectorModel) focus(name string) {
+	for i, match := range m.matches {
+		if m.snippetMap[m.options[match.index]] == name {
+			m.cursor = i
+			return
+		}
+	}
+}
+
+// nextTag selects the tag after the current one, then no tag again after
+// the last.
+func (m *selectorModel) nextTag() {
+	i := slices.Index(m.allTags, m.tag) + 1
+	if i < len(m.allTags) {
+		m.tag = m.allTags[i]
+	} else {
+		m.tag = ""
+	}
+	m.filter()
+}
+
+// toggle adds the option under the cursor to the chosen ones, or removes
+// it.
+func (m *selectorModel) toggle() {
+	index := m.matches[m.cursor].index
+	if i := slices.Index(m.chosen, index); i >= 0 {
+		m.chosen = slices.Delete(m.chosen, i, i+1)
+	} else {
+		m.chosen = append(m.chosen, index)
+	}
+}
+
+// choose selects the options toggled in multi mode, in the order they were
+// toggled, or else the option under the cursor.
+func (m selectorModel) choose() (tea.Model, tea.Cmd) {
+	if len(m.chosen) > 0 {
+		for _, index := range m.chosen {
+			m.selected = append(m.selected, m.snippetMap[m.options[index]])
+		}
+	} else {
+		m.selected = []string{m.snippetMap[m.options[m.matches[m.cursor].index]]}
 	}
+	m.done = true
+	return m, tea.Quit
 }
 
 // Init initializes the model
@@ -55,106 +202,367 @@ func (m selectorModel) Init() tea.Cmd {
 	return nil
 }
 
-// Update handles messages and updates the model
+// Update handles messages and updates the model. Typing filters the
+// options; Esc clears the filter, or cancels when there is none. In multi
+// mode Space toggles the option under the cursor.
 func (m selectorModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if len(m.options) == 0 {
+		return m.updateEmpty(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.multi && msg.Type == tea.KeySpace {
+			if len(m.matches) > 0 {
+				m.toggle()
+			}
+			return m, nil
+		}
+		if i, ok := m.numberedOption(msg); ok {
+			if i < 0 {
+				return m, nil
+			}
+			m.cursor = i
+			if m.multi {
+				m.toggle()
+				return m, nil
+			}
+			return m.choose()
+		}
+		switch msg.Type {
+		case tea.KeyRunes, tea.KeySpace:
+			if !msg.Alt {
+				m.query += string(msg.Runes)
+				m.filter()
+			}
+			return m, nil
+		}
+
 		switch msg.String() {
-		case "ctrl+c", "q", "esc":
+		case "ctrl+c":
 			m.cancelled = true
 			return m, tea.Quit
 
-		case "up", "k":
-			if m.cursor > 0 {
-				m.cursor--
+		case "esc":
+			if m.query != "" {
+				m.query = ""
+				m.filter()
+				break
 			}
+			m.cancelled = true
+			return m, tea.Quit
 
-		case "down", "j":
-			if m.cursor < len(m.options)-1 {
-				m.cursor++
+		case "ctrl+t":
+			m.nextTag()
+
+		case "backspace":
+			if m.query != "" {
+				query := []rune(m.query)
+				m.query = string(query[:len(query)-1])
+				m.filter()
 			}
 
+		case "up", "ctrl+p":
+			m.moveCursor(-1)
+
+		case "down", "ctrl+n":
+			m.moveCursor(1)
+
+		case "pgup", "ctrl+u":
+			m.moveCursor(-m.pageSize())
+
+		case "pgdown", "ctrl+d":
+			m.moveCursor(m.pageSize())
+
+		case "home":
+			m.moveCursor(-len(m.matches))
+
+		case "end":
+			m.moveCursor(len(m.matches))
+
 		case "enter":
-			m.selected = m.snippetMap[m.options[m.cursor]]
-			m.done = true
-			return m, tea.Quit
+			if len(m.matches) > 0 {
+				return m.choose()
+			}
+		}
+
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+
+	case tea.MouseMsg:
+		switch {
+		case msg.Button == tea.MouseButtonWheelUp:
+			m.moveCursor(-1)
+		case msg.Button == tea.MouseButtonWheelDown:
+			m.moveCursor(1)
+		case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress:
+			// Clicking an option selects it, or toggles it in multi mode
+			if i, ok := m.optionAt(msg.Y); ok {
+				m.cursor = i
+				if m.multi {
+					m.toggle()
+					break
+				}
+				return m.choose()
+			}
 		}
 	}
 
 	return m, nil
 }
 
-// View renders the selector
-func (m selectorModel) View() string {
-	if m.done || m.cancelled {
-		return ""
+// updateEmpty handles keys when there are no options to select: n quits
+// to add a template, Esc cancels.
+func (m selectorModel) updateEmpty(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "n":
+			m.addNew = true
+			return m, tea.Quit
+		case "esc", "ctrl+c", "q":
+			m.cancelled = true
+			return m, tea.Quit
+		}
 	}
+	return m, nil
+}
 
-	var b strings.Builder
+// numberedOption reports whether msg is a digit picking an option, and
+// returns the match it picks, or -1 when fewer options are shown: 1 to 9
+// number the visible options while the filter is empty. Otherwise digits
+// are typed into the filter.
+func (m selectorModel) numberedOption(msg tea.KeyMsg) (int, bool) {
+	if !m.numbered || m.query != "" || msg.Type != tea.KeyRunes || msg.Alt || len(msg.Runes) != 1 {
+		return 0, false
+	}
+	digit := msg.Runes[0]
+	if digit < '1' || digit > '9' {
+		return 0, false
+	}
+	start, end := m.window()
+	if i := start + int(digit-'1'); i < end {
+		return i, true
+	}
+	return -1, true
+}
 
-	b.WriteString(titleStyle.Render("Select a template to execute:"))
-	b.WriteString("\n\n")
+// moveCursor moves the cursor by delta matches, stopping at the first and
+// last.
+func (m *selectorModel) moveCursor(delta int) {
+	m.cursor = max(min(m.cursor+delta, len(m.matches)-1), 0)
+}
 
-	// Show visible options (window of items around cursor)
-	windowSize := 10
-	start := m.cursor - windowSize/2
+// pageSize returns the number of options that fit in the terminal, at
+// least one.
+func (m selectorModel) pageSize() int {
+	if m.height == 0 {
+		return selectorWindowSize
+	}
+	return max(m.height-selectorChrome, 1)
+}
+
+// window returns the range [start, end) of matches shown around the cursor.
+func (m selectorModel) window() (start, end int) {
+	size := m.pageSize()
+	start = m.cursor - size/2
 	if start < 0 {
 		start = 0
 	}
-	end := start + windowSize
-	if end > len(m.options) {
-		end = len(m.options)
-		start = end - windowSize
+	end = start + size
+	if end > len(m.matches) {
+		end = len(m.matches)
+		start = end - size
 		if start < 0 {
 			start = 0
 		}
 	}
+	return start, end
+}
+
+// optionAt returns the match shown on line y of the view.
+func (m selectorModel) optionAt(y int) (int, bool) {
+	start, end := m.window()
+	// The title, filter, and a blank line come first, then the scroll indicator
+	first := 3
+	if start > 0 {
+		first++
+	}
+	i := start + y - first
+	if y < first || i >= end {
+		return 0, false
+	}
+	return i, true
+}
+
+// View renders the selector
+func (m selectorModel) View() string {
+	if m.done || m.cancelled || m.addNew {
+		return ""
+	}
+	if len(m.options) == 0 {
+		return m.viewEmpty()
+	}
+
+	var b strings.Builder
+
+	title := "Select a template to execute"
+	if m.multi {
+		title = fmt.Sprintf("Select templates to execute (%d selected)", len(m.chosen))
+	}
+	if m.tag != "" {
+		title += fmt.Sprintf(" (tag: %s)", m.tag)
+	}
+	title += ":"
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n")
+	b.WriteString(normalStyle.Render("Filter: ") + m.query + selectedStyle.Render("▏"))
+	b.WriteString("\n\n")
+
+	// Show visible options (window of items around cursor)
+	start, end := m.window()
 
 	// Show scroll indicator if needed
 	if start > 0 {
 		b.WriteString(scrollStyle.Render("  ...\n"))
 	}
 
+	if len(m.matches) == 0 {
+		b.WriteString(scrollStyle.Render("  (no matches)") + "\n")
+	}
 	for i := start; i < end; i++ {
+		match := m.matches[i]
+		option := m.options[match.index]
+		prefix, style := "  ", normalStyle
 		if i == m.cursor {
-			b.WriteString(selectedStyle.Render("> " + m.options[i]))
-		} else {
-			b.WriteString(normalStyle.Render("  " + m.options[i]))
+			prefix, style = "> ", selectedStyle
+		}
+		b.WriteString(style.Render(prefix))
+		// Dim numbers for quick selection
+		if m.numbered && m.query == "" {
+			if n := i - start + 1; n <= 9 {
+				b.WriteString(scrollStyle.Render(fmt.Sprintf("%d ", n)))
+			} else {
+				b.WriteString("  ")
+			}
+		}
+		if m.multi {
+			if slices.Contains(m.chosen, match.index) {
+				b.WriteString(style.Render("[x] "))
+			} else {
+				b.WriteString(style.Render("[ ] "))
+			}
+		}
+		// The local badge keeps its own color
+		text, badge := option, ""
+		if strings.HasSuffix(option, localBadge) {
+			text, badge = strings.TrimSuffix(option, localBadge), badgeStyle.Render(localBadge)
+		}
+		b.WriteString(highlightMatches(text, match.positions, style) + badge)
+		if suffix := m.suffixes[option]; suffix != "" {
+			b.WriteString(scrollStyle.Render("  " + suffix))
 		}
 		b.WriteString("\n")
 	}
 
-	// Show scroll indicator if needed
-	if end < len(m.options) {
-		b.WriteString(scrollStyle.Render("  ...\n"))
+	// Show scroll indicator with the position when the list doesn't fit
+	if end < len(m.matches) {
+		b.WriteString(scrollStyle.Render(fmt.Sprintf("  ... item %d/%d", m.cursor+1, len(m.matches))) + "\n")
+	} else if start > 0 {
+		b.WriteString(scrollStyle.Render(fmt.Sprintf("  item %d/%d", m.cursor+1, len(m.matches))) + "\n")
 	}
 
 	b.WriteString("\n")
-	b.WriteString(helpTextStyle.Render("↑/k: Up  ↓/j: Down  Enter: Select  q/Esc: Cancel"))
+	help := "Type: Filter  ↑/↓: Move  PgUp/PgDn: Page  Enter/Click: Select  Esc: Clear filter/Cancel"
+	if m.multi {
+		help = "Type: Filter  ↑/↓: Move  PgUp/PgDn: Page  Space: Toggle  Enter: Run selected  Esc: Clear filter/Cancel"
+	}
+	if m.numbered && m.query == "" {
+		help += "  1-9: Pick"
+	}
+	if len(m.allTags) > 0 {
+		help += "  Ctrl+T: Tag"
+	}
+	b.WriteString(helpTextStyle.Render(help))
 
 	return b.String()
 }
 
-// selectSnippetWithBubbleTea shows an interactive snippet selector using Bubble Tea
-func selectSnippetWithBubbleTea(options []string, snippetMap map[string]string, noColor bool) (string, error) {
-	if len(options) == 0 {
-		return "", fmt.Errorf("no templates found")
+// viewEmpty renders the offer to add a template when there are no options.
+func (m selectorModel) viewEmpty() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(cmp.Or(m.empty, "No templates found.")))
+	b.WriteString("\n\n")
+	b.WriteString(normalStyle.Render("Templates are commands with <placeholders> that are filled in before they run."))
+	b.WriteString("\n")
+	b.WriteString(normalStyle.Render("Press n to create one now and run it, or add them to the config file with 'cs add'."))
+	b.WriteString("\n\n")
+	b.WriteString(helpTextStyle.Render("n: New template  Esc: Exit"))
+	return b.String()
+}
+
+// highlightMatches renders s in style, with the runes at positions in
+// matchStyle instead.
+func highlightMatches(s string, positions []int, style lipgloss.Style) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		matched := slices.Contains(positions, i)
+		j := i + 1
+		for j < len(runes) && slices.Contains(positions, j) == matched {
+			j++
+		}
+		if matched {
+			b.WriteString(matchStyle.Inherit(style).Render(string(runes[i:j])))
+		} else {
+			b.WriteString(style.Render(string(runes[i:j])))
+		}
+		i = j
 	}
+	return b.String()
+}
+
+// selectSnippetWithBubbleTea shows an interactive snippet selector using
+// Bubble Tea, filtered by opts.query and with the cursor on the snippet
+// named opts.initial, if any. tags holds each snippet's tags for the tag
+// filter. With opts.multi, several snippets can be selected.
+func selectSnippetWithBubbleTea(options []string, snippetMap map[string]string, suffixes map[string]string, tags map[string][]string, opts selectOptions) ([]string, error) {
+	model := newSelectorModel(options, snippetMap, suffixes, tags)
+	model.multi = opts.multi
+	model.numbered = config.Settings.Selector.QuickSelects()
+	model.query = opts.query
+	model.filter()
+	model.focus(opts.initial)
+	return runSelector(model, opts.noColor)
+}
+
+// selectNothing shows the selector with no options, explaining why with
+// message and offering to add a template. It returns errAddSnippet when
+// the user asks to.
+func selectNothing(message string, noColor bool) ([]string, error) {
+	model := newSelectorModel(nil, nil, nil, nil)
+	model.empty = message
+	return runSelector(model, noColor)
+}
 
+// runSelector runs the selector model and returns the selected snippets.
+func runSelector(model selectorModel, noColor bool) ([]string, error) {
 	template.SetupColorProfile(noColor)
 
-	model := newSelectorModel(options, snippetMap)
 	p := tea.NewProgram(model,
 		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
 		tea.WithOutput(os.Stderr))
 	finalModel, err := p.Run()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	selector := finalModel.(selectorModel)
 	if selector.cancelled {
-		return "", &UserCancellationError{"user cancelled selection"}
+		return nil, &UserCancellationError{"user cancelled selection"}
+	}
+	if selector.addNew {
+		return nil, errAddSnippet
 	}
 	return selector.selected, nil
 }
@@ -0,0 +1,419 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// TestSelectorModel_Mouse tests wheel scrolling and click-to-select in the
+// snippet selector
+func TestSelectorModel_Mouse(t *testing.T) {
+	var options []string
+	snippetMap := make(map[string]string)
+	for i := range 15 {
+		name := fmt.Sprintf("snippet-%02d", i)
+		options = append(options, name)
+		snippetMap[name] = name
+	}
+	var model tea.Model = newSelectorModel(options, snippetMap, nil, nil)
+	wheelDown := tea.MouseMsg{Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress}
+	for range 8 {
+		model, _ = model.Update(wheelDown)
+	}
+	selector := model.(selectorModel)
+	if selector.cursor != 8 {
+		t.Fatalf("Expected the wheel to move the cursor to 8, got %d", selector.cursor)
+	}
+
+	// The window now starts at snippet-03, after a scroll indicator
+	lines := strings.Split(selector.View(), "\n")
+	y := slices.IndexFunc(lines, func(line string) bool { return strings.Contains(line, "snippet-05") })
+	model, cmd := model.Update(tea.MouseMsg{X: 4, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	selector = model.(selectorModel)
+	if !selector.done || !slices.Equal(selector.selected, []string{"snippet-05"}) || cmd == nil {
+		t.Errorf("Expected clicking snippet-05 to select it, got %q", selector.selected)
+	}
+
+	model, _ = newSelectorModel(options, snippetMap, nil, nil).Update(tea.MouseMsg{Y: 0, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+	if model.(selectorModel).done {
+		t.Errorf("Expected clicking the title to select nothing")
+	}
+}
+
+// TestSelectorModel_Filter tests narrowing the built-in selector by typing
+func TestSelectorModel_Filter(t *testing.T) {
+	config = &models.Config{Snippets: map[string]models.Snippet{
+		"docker-run":       {Description: "Run a container", Tags: []string{"docker"}},
+		"kubectl-get-pods": {Description: "List pods", Tags: []string{"k8s"}},
+		"terraform-plan":   {Description: "Plan changes", Tags: []string{"infra"}},
+	}}
+	t.Cleanup(func() { config = nil })
+	snippets := make(map[string]*models.Snippet)
+	for name, snippet := range config.Snippets {
+		snippets[name] = &snippet
+	}
+	options, byDisplay := buildSnippetOptions(snippets, "")
+	var model tea.Model = newSelectorModel(options, byDisplay, nil, nil)
+	update := func(msgs ...tea.Msg) selectorModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(selectorModel)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	names := func(m selectorModel) []string {
+		var names []string
+		for _, match := range m.matches {
+			names = append(names, byDisplay[m.options[match.index]])
+		}
+		return names
+	}
+
+	tests := []struct {
+		query    string
+		expected []string
+	}{
+		{"", []string{"docker-run", "kubectl-get-pods", "terraform-plan"}},
+		{"kgp", []string{"kubectl-get-pods"}},
+		{"K8S", []string{"kubectl-get-pods"}},
+		{"plan", []string{"terraform-plan"}},
+		{"an", []string{"terraform-plan", "docker-run"}},
+		{"zzz", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			model = newSelectorModel(options, byDisplay, nil, nil)
+			if got := names(update(runes(tt.query))); !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	model = newSelectorModel(options, byDisplay, nil, nil)
+	selector := update(runes("zzz"), tea.KeyMsg{Type: tea.KeyEnter})
+	if selector.done || !strings.Contains(selector.View(), "(no matches)") {
+		t.Errorf("Expected no matches and nothing to select, got:\n%s", selector.View())
+	}
+	selector = update(tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}, runes("pods"))
+	if selector.query != "pods" || len(selector.matches) != 1 {
+		t.Errorf("Expected backspace to edit the query, got %q", selector.query)
+	}
+	selector = update(tea.KeyMsg{Type: tea.KeyEsc})
+	if selector.cancelled || selector.query != "" || len(selector.matches) != 3 {
+		t.Errorf("Expected Esc to clear the filter first")
+	}
+	if selector = update(tea.KeyMsg{Type: tea.KeyEsc}); !selector.cancelled {
+		t.Errorf("Expected Esc without a filter to cancel")
+	}
+
+	model = newSelectorModel(options, byDisplay, nil, nil)
+	selector = update(runes("an"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	if !slices.Equal(selector.selected, []string{"docker-run"}) {
+		t.Errorf("Expected Enter to select from the filtered list, got %q", selector.selected)
+	}
+}
+
+// TestSelectorModel_Tags tests cycling the tag filter with Ctrl+T
+func TestSelectorModel_Tags(t *testing.T) {
+	options := []string{"docker-run", "kubectl-get-pods", "kubectl-logs", "terraform-plan"}
+	snippetMap := make(map[string]string)
+	for _, option := range options {
+		snippetMap[option] = option
+	}
+	tags := map[string][]string{
+		"docker-run":       {"docker"},
+		"kubectl-get-pods": {"k8s"},
+		"kubectl-logs":     {"k8s", "logs"},
+	}
+	var model tea.Model = newSelectorModel(options, snippetMap, nil, tags)
+	ctrlT := tea.KeyMsg{Type: tea.KeyCtrlT}
+	count := func() int { return len(model.(selectorModel).matches) }
+
+	if got := model.(selectorModel).allTags; !slices.Equal(got, []string{"docker", "k8s", "logs"}) {
+		t.Errorf("Expected the tags of all options, got %v", got)
+	}
+
+	model, _ = model.Update(ctrlT)
+	model, _ = model.Update(ctrlT)
+	if selector := model.(selectorModel); selector.tag != "k8s" || count() != 2 {
+		t.Errorf("Expected two k8s snippets, got %d with tag %q", count(), selector.tag)
+	}
+	if view := model.View(); !strings.Contains(view, "(tag: k8s)") {
+		t.Errorf("Expected the tag in the title, got:\n%s", view)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("logs")})
+	if count() != 1 {
+		t.Errorf("Expected the query to narrow the tagged snippets, got %d", count())
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	model, _ = model.Update(ctrlT)
+	model, _ = model.Update(ctrlT)
+	if selector := model.(selectorModel); selector.tag != "" || count() != 4 {
+		t.Errorf("Expected the tag filter to wrap around to everything, got %d with tag %q", count(), selector.tag)
+	}
+
+	// Spellings differing only in case are cycled through as one tag
+	tags = map[string][]string{"docker-run": {"Docker"}, "kubectl-get-pods": {"docker"}, "kubectl-logs": {"docker"}}
+	model = newSelectorModel(options, snippetMap, nil, tags)
+	if got := model.(selectorModel).allTags; !slices.Equal(got, []string{"docker"}) {
+		t.Errorf("Expected one docker tag, got %v", got)
+	}
+	model, _ = model.Update(ctrlT)
+	if count() != 3 {
+		t.Errorf("Expected every spelling to match, got %d", count())
+	}
+}
+
+// TestSelectorModel_Multi tests selecting several snippets with Space
+func TestSelectorModel_Multi(t *testing.T) {
+	options := []string{"a", "b", "c"}
+	snippetMap := map[string]string{"a": "a", "b": "b", "c": "c"}
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	tests := []struct {
+		name     string
+		keys     []tea.KeyMsg
+		expected []string
+	}{
+		{"in the order toggled", []tea.KeyMsg{down, down, space, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp}, space, enter}, []string{"c", "a"}},
+		{"toggled off", []tea.KeyMsg{space, down, space, tea.KeyMsg{Type: tea.KeyUp}, space, enter}, []string{"b"}},
+		{"nothing toggled", []tea.KeyMsg{down, enter}, []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := newSelectorModel(options, snippetMap, nil, nil)
+			model.multi = true
+			var m tea.Model = model
+			for _, key := range tt.keys {
+				m, _ = m.Update(key)
+			}
+			if got := m.(selectorModel).selected; !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	model := newSelectorModel(options, snippetMap, nil, nil)
+	model.multi = true
+	m, _ := model.Update(space)
+	view := m.View()
+	if !strings.Contains(view, "> [x] a") || !strings.Contains(view, "  [ ] b") || !strings.Contains(view, "(1 selected)") {
+		t.Errorf("Expected checkboxes and a count, got:\n%s", view)
+	}
+	if m.(selectorModel).query != "" {
+		t.Errorf("Expected Space not to reach the filter in multi mode")
+	}
+}
+
+// TestSelectorModel_Window tests which options are shown for the terminal
+// height and cursor
+func TestSelectorModel_Window(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    int
+		height     int
+		cursor     int
+		start, end int
+	}{
+		{"height unknown", 30, 0, 0, 0, 10},
+		{"small list", 3, 40, 2, 0, 3},
+		{"cursor at the top", 50, 17, 0, 0, 10},
+		{"cursor in the middle", 50, 17, 20, 15, 25},
+		{"cursor at the bottom", 50, 17, 49, 40, 50},
+		{"terminal shorter than the header", 50, 4, 20, 20, 21},
+		{"no options", 0, 17, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var options []string
+			snippetMap := make(map[string]string)
+			for i := range tt.options {
+				name := fmt.Sprintf("snippet-%02d", i)
+				options = append(options, name)
+				snippetMap[name] = name
+			}
+			model := newSelectorModel(options, snippetMap, nil, nil)
+			model.height = tt.height
+			model.cursor = tt.cursor
+			if start, end := model.window(); start != tt.start || end != tt.end {
+				t.Errorf("Expected [%d, %d), got [%d, %d)", tt.start, tt.end, start, end)
+			}
+		})
+	}
+}
+
+// TestSelectorModel_Paging tests jumping through the selector a page at a
+// time and to either end
+func TestSelectorModel_Paging(t *testing.T) {
+	var options []string
+	snippetMap := make(map[string]string)
+	for i := range 200 {
+		name := fmt.Sprintf("snippet-%03d", i)
+		options = append(options, name)
+		snippetMap[name] = name
+	}
+	var model tea.Model = newSelectorModel(options, snippetMap, nil, nil)
+	update := func(msgs ...tea.Msg) selectorModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(selectorModel)
+	}
+
+	selector := update(tea.WindowSizeMsg{Width: 80, Height: 27})
+	if got := strings.Count(selector.View(), "snippet-"); got != 20 {
+		t.Errorf("Expected the options to fill the terminal, got %d", got)
+	}
+
+	tests := []struct {
+		key      tea.KeyMsg
+		expected int
+	}{
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 20},
+		{tea.KeyMsg{Type: tea.KeyCtrlD}, 40},
+		{tea.KeyMsg{Type: tea.KeyPgUp}, 20},
+		{tea.KeyMsg{Type: tea.KeyCtrlU}, 0},
+		{tea.KeyMsg{Type: tea.KeyCtrlU}, 0},
+		{tea.KeyMsg{Type: tea.KeyEnd}, 199},
+		{tea.KeyMsg{Type: tea.KeyPgDown}, 199},
+		{tea.KeyMsg{Type: tea.KeyHome}, 0},
+	}
+	for _, tt := range tests {
+		if selector = update(tt.key); selector.cursor != tt.expected {
+			t.Errorf("Expected %s to move the cursor to %d, got %d", tt.key, tt.expected, selector.cursor)
+		}
+	}
+
+	selector = update(tea.KeyMsg{Type: tea.KeyPgDown}, tea.KeyMsg{Type: tea.KeyDown})
+	if view := selector.View(); !strings.Contains(view, "... item 22/200") {
+		t.Errorf("Expected the position in the scroll indicator, got:\n%s", view)
+	}
+	selector = update(tea.KeyMsg{Type: tea.KeyEnd})
+	if view := selector.View(); !strings.Contains(view, "item 200/200") {
+		t.Errorf("Expected the position at the end of the list, got:\n%s", view)
+	}
+}
+
+// TestSelectorModel_Numbers tests picking a visible option with a digit
+func TestSelectorModel_Numbers(t *testing.T) {
+	var options []string
+	snippetMap := make(map[string]string)
+	for i := range 30 {
+		name := fmt.Sprintf("snippet-%02d", i)
+		options = append(options, name)
+		snippetMap[name] = name
+	}
+	digit := func(r rune) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}} }
+	newModel := func(numbered bool) tea.Model {
+		model := newSelectorModel(options, snippetMap, nil, nil)
+		model.numbered = numbered
+		return model
+	}
+
+	model := newModel(true)
+	if view := model.View(); !strings.Contains(view, "> 1 snippet-00") || !strings.Contains(view, "  9 snippet-08") || strings.Contains(view, "10 snippet-09") {
+		t.Errorf("Expected the first nine options numbered, got:\n%s", view)
+	}
+	model, _ = model.Update(digit('3'))
+	if selector := model.(selectorModel); !selector.done || !slices.Equal(selector.selected, []string{"snippet-02"}) {
+		t.Errorf("Expected 3 to pick the third option, got %v", selector.selected)
+	}
+
+	// Numbers follow the window, not the list
+	model = newModel(true)
+	for range 12 {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	model, _ = model.Update(digit('1'))
+	if selector := model.(selectorModel); !slices.Equal(selector.selected, []string{"snippet-07"}) {
+		t.Errorf("Expected 1 to pick the first visible option, got %v", selector.selected)
+	}
+
+	// A number with no option shown is ignored
+	short := newSelectorModel(options[:2], snippetMap, nil, nil)
+	short.numbered = true
+	model, _ = short.Update(digit('5'))
+	if selector := model.(selectorModel); selector.done || selector.query != "" {
+		t.Errorf("Expected 5 to do nothing with two options, got %v with query %q", selector.selected, selector.query)
+	}
+
+	// With a filter, digits are typed into it
+	model = newModel(true)
+	model, _ = model.Update(digit('s'))
+	model, _ = model.Update(digit('2'))
+	if selector := model.(selectorModel); selector.done || selector.query != "s2" {
+		t.Errorf("Expected digits to join the filter, got query %q", selector.query)
+	}
+
+	model = newModel(false)
+	model, _ = model.Update(digit('3'))
+	if selector := model.(selectorModel); selector.done || selector.query != "3" {
+		t.Errorf("Expected digits to filter when quick select is off, got query %q", selector.query)
+	}
+}
+
+// TestSelectorModel_Empty tests the offer to add a template when there is
+// nothing to select
+func TestSelectorModel_Empty(t *testing.T) {
+	model := newSelectorModel(nil, nil, nil, nil)
+	model.empty = "No templates found matching tags: k8s."
+	if view := model.View(); !strings.Contains(view, "No templates found matching tags: k8s.") || !strings.Contains(view, "n: New template") {
+		t.Errorf("Expected the offer to add a template, got:\n%s", view)
+	}
+
+	// Keys that would filter or move do nothing
+	updated, cmd := model.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if selector := updated.(selectorModel); selector.done || selector.cancelled || selector.addNew || cmd != nil {
+		t.Errorf("Expected down to do nothing")
+	}
+	updated, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if selector := updated.(selectorModel); selector.done || selector.addNew {
+		t.Errorf("Expected enter to do nothing")
+	}
+
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'n'}})
+	if selector := updated.(selectorModel); !selector.addNew || cmd == nil {
+		t.Errorf("Expected n to quit to add a template")
+	}
+	updated, cmd = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if selector := updated.(selectorModel); !selector.cancelled || selector.addNew || cmd == nil {
+		t.Errorf("Expected esc to cancel")
+	}
+}
+
+// TestSelectorModel_Focus tests that the selector can start on a
+// previously picked snippet, as it does after the form is cancelled
+func TestSelectorModel_Focus(t *testing.T) {
+	options := []string{"docker-run (dr)", "kubectl-get-pods", "terraform-plan"}
+	snippetMap := map[string]string{"docker-run (dr)": "docker-run", "kubectl-get-pods": "kubectl-get-pods", "terraform-plan": "terraform-plan"}
+
+	tests := []struct {
+		name     string
+		initial  string
+		expected int
+	}{
+		{"none", "", 0},
+		{"display name differs", "docker-run", 0},
+		{"later option", "terraform-plan", 2},
+		{"unknown", "removed", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := newSelectorModel(options, snippetMap, nil, nil)
+			model.focus(tt.initial)
+			if model.cursor != tt.expected {
+				t.Errorf("Expected cursor %d, got %d", tt.expected, model.cursor)
+			}
+		})
+	}
+}
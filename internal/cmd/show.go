@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"maps"
+	"os"
 	"slices"
 	"strings"
 
@@ -12,39 +14,112 @@ import (
 
 func newShowCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "show [transforms|types|config]",
+		Use:   "show [transforms|types|unused|config] [name]",
 		Short: "Show configuration components",
 		Long: `Show different configuration components like transform templates, variable types, and configuration summary.
 
 Available subcommands:
-  transforms  - Show all transform templates
-  types       - Show all variable types  
+  transforms  - Show all transform templates, or the named one
+  types       - Show all variable types, or the named one
+  unused      - List transform templates and variable types no variable
+                references; name one of transforms or types to list only those
   config      - Show configuration summary
 
+With --usages and a name, the snippets whose variables reference that
+transform template or variable type are listed instead, with the file each
+was loaded from.
+
 Examples:
-  cs show transforms    # Show all transform templates
-  cs show types         # Show all variable types
-  cs show config        # Show configuration overview`,
-		Args: cobra.ExactArgs(1),
+  cs show transforms                      # Show all transform templates
+  cs show transforms k8s-namespace        # Show one transform template
+  cs show transforms k8s-namespace --usages  # Snippets using it
+  cs show types                           # Show all variable types
+  cs show types port --usages             # Snippets with variables of type port
+  cs show unused                          # Definitions nothing references
+  cs show unused transforms -q            # Just the names, for cleanup scripts
+  cs show config                          # Show configuration overview
+  cs show config --paths                  # Which files were loaded, in order`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: runShow,
 	}
 
+	cmd.Flags().Bool("usages", false, "List the snippets that use the named transform template or variable type")
+	cmd.Flags().BoolP("quiet", "q", false, "With unused, print only the names, one per line")
+	cmd.Flags().Bool("paths", false, "With config, list the files loaded, what each contributed, and what it overwrote")
+
 	return cmd
 }
 
 func runShow(cmd *cobra.Command, args []string) error {
 	subcommand := args[0]
+	var name string
+	if len(args) > 1 {
+		name = args[1]
+	}
+	usages, _ := cmd.Flags().GetBool("usages")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	paths, _ := cmd.Flags().GetBool("paths")
+	if usages && name == "" {
+		return usageErrorf("--usages requires a transform template or variable type name")
+	}
+	if quiet && subcommand != "unused" {
+		return usageErrorf("--quiet only applies to show unused")
+	}
+	if paths && subcommand != "config" {
+		return usageErrorf("--paths only applies to show config")
+	}
 
 	switch subcommand {
 	case "transforms":
+		if name != "" {
+			if _, ok := config.TransformTemplates[name]; !ok {
+				return notFoundError(usageTransform, name, slices.Collect(maps.Keys(config.TransformTemplates)))
+			}
+			if usages {
+				return showUsages(usageTransform, name)
+			}
+			displayTransformTemplate(name)
+			return nil
+		}
 		return showTransforms()
 	case "types":
+		if name != "" {
+			if _, ok := config.VariableTypes[name]; !ok {
+				return notFoundError(usageType, name, slices.Collect(maps.Keys(config.VariableTypes)))
+			}
+			if usages {
+				return showUsages(usageType, name)
+			}
+			displayVariableType(name)
+			return nil
+		}
 		return showTypes()
+	case "unused":
+		if usages {
+			return usageErrorf("--usages does not apply to show unused")
+		}
+		return showUnused(name, quiet)
 	case "config":
+		if name != "" || usages {
+			return usageErrorf("show config takes no name")
+		}
+		if paths {
+			showConfigPaths(os.Stdout, config.Files)
+			return nil
+		}
 		return showConfig()
 	default:
-		return fmt.Errorf("unknown subcommand: %s\nAvailable: transforms, types, config", subcommand)
+		return fmt.Errorf("unknown subcommand: %s\nAvailable: transforms, types, unused, config", subcommand)
+	}
+}
+
+// notFoundError reports a missing transform template or variable type,
+// suggesting similar names among candidates.
+func notFoundError(kind, name string, candidates []string) error {
+	if suggestions := similarNames(name, candidates, maxNameSuggestions); len(suggestions) > 0 {
+		return fmt.Errorf("%s '%s' not found (did you mean: %s?)", kind, name, strings.Join(suggestions, ", "))
 	}
+	return fmt.Errorf("%s '%s' not found", kind, name)
 }
 
 func showTransforms() error {
@@ -60,20 +135,24 @@ func showTransforms() error {
 		if i > 0 {
 			fmt.Println() // Add spacing between templates
 		}
+		displayTransformTemplate(name)
+	}
 
-		template := config.TransformTemplates[name]
-		fmt.Printf("%s:\n", name)
+	return nil
+}
 
-		if template.Description != "" {
-			fmt.Printf("  Description: %s\n", template.Description)
-		}
+// displayTransformTemplate shows the named transform template.
+func displayTransformTemplate(name string) {
+	template := config.TransformTemplates[name]
+	fmt.Printf("%s:\n", name)
 
-		if template.Transform != nil {
-			displayTransform(template.Transform, "  ")
-		}
+	if template.Description != "" {
+		fmt.Printf("  Description: %s\n", template.Description)
 	}
 
-	return nil
+	if template.Transform != nil {
+		displayTransform(template.Transform, "  ")
+	}
 }
 
 func showTypes() error {
@@ -89,29 +168,111 @@ func showTypes() error {
 		if i > 0 {
 			fmt.Println() // Add spacing between types
 		}
+		displayVariableType(name)
+	}
+
+	return nil
+}
 
-		varType := config.VariableTypes[name]
-		fmt.Printf("%s:\n", name)
+// displayVariableType shows the named variable type.
+func displayVariableType(name string) {
+	varType := config.VariableTypes[name]
+	fmt.Printf("%s:\n", name)
 
-		if varType.Description != "" {
-			fmt.Printf("  Description: %s\n", varType.Description)
-		}
+	if varType.Description != "" {
+		fmt.Printf("  Description: %s\n", varType.Description)
+	}
+
+	if varType.DefaultFromEnv != "" {
+		fmt.Printf("  Default From Env: $%s\n", varType.DefaultFromEnv)
+	}
+	if varType.Default != "" {
+		fmt.Printf("  Default: %s\n", varType.Default)
+	}
+
+	if varType.Validation != nil {
+		fmt.Printf("  Validation:\n")
+		displayValidation(varType.Validation, "    ")
+	}
 
-		if varType.Default != "" {
-			fmt.Printf("  Default: %s\n", varType.Default)
+	if varType.Transform != nil {
+		fmt.Printf("  Transform:\n")
+		displayTransform(varType.Transform, "    ")
+	}
+}
+
+const (
+	usageTransform = "transform template"
+	usageType      = "variable type"
+)
+
+// snippetUsage lists the variables of one snippet that reference a
+// transform template or variable type. Snippet is empty for definitions in
+// global_variables.
+type snippetUsage struct {
+	snippet    string
+	variables  []string
+	sourceFile string
+}
+
+// findUsages returns the snippets, in name order, with variables that
+// reference the transform template or variable type name; kind is
+// usageTransform or usageType. Global variables using it come first.
+func findUsages(kind, name string) []snippetUsage {
+	uses := func(v models.Variable) bool {
+		if kind == usageTransform {
+			return v.TransformTemplate == name
 		}
+		return v.Type == name
+	}
 
-		if varType.Validation != nil {
-			fmt.Printf("  Validation:\n")
-			displayValidation(varType.Validation, "    ")
+	var usages []snippetUsage
+	var globals []string
+	for _, global := range slices.Sorted(maps.Keys(config.GlobalVariables)) {
+		if uses(config.GlobalVariables[global]) {
+			globals = append(globals, global)
 		}
+	}
+	if len(globals) > 0 {
+		usages = append(usages, snippetUsage{variables: globals})
+	}
 
-		if varType.Transform != nil {
-			fmt.Printf("  Transform:\n")
-			displayTransform(varType.Transform, "    ")
+	for _, snippetName := range slices.Sorted(maps.Keys(config.Snippets)) {
+		snippet := config.Snippets[snippetName]
+		usage := snippetUsage{snippet: snippetName, sourceFile: snippet.SourceFile}
+		for _, v := range snippet.Variables {
+			if uses(v) {
+				usage.variables = append(usage.variables, v.Name)
+			}
+		}
+		if len(usage.variables) > 0 {
+			usages = append(usages, usage)
 		}
 	}
+	return usages
+}
+
+// showUsages prints the snippets using the transform template or variable
+// type name.
+func showUsages(kind, name string) error {
+	usages := findUsages(kind, name)
+	if len(usages) == 0 {
+		fmt.Printf("No snippets use %s '%s'.\n", kind, name)
+		return nil
+	}
 
+	fmt.Printf("Snippets using %s '%s':\n\n", kind, name)
+	for _, usage := range usages {
+		label := usage.snippet
+		if label == "" {
+			label = "(global variables)"
+		}
+		line := fmt.Sprintf("  %s: %s", label, strings.Join(usage.variables, ", "))
+		if usage.sourceFile != "" {
+			line += fmt.Sprintf(" (%s)", usage.sourceFile)
+		}
+		fmt.Println(line)
+	}
 	return nil
 }
 
@@ -162,6 +323,39 @@ func showConfig() error {
 	return nil
 }
 
+// showConfigPaths writes the config files that were loaded, in load order,
+// with the number of definitions each contributed and the entries it
+// overwrote from earlier files.
+func showConfigPaths(w io.Writer, files []models.ConfigFile) {
+	fmt.Fprintf(w, "Config files (in load order):\n")
+	for i, file := range files {
+		fmt.Fprintf(w, "\n%d. %s (%s)\n", i+1, file.Path, file.Kind)
+
+		var counts []string
+		for _, c := range []struct {
+			n    int
+			what string
+		}{
+			{file.Snippets, "snippet(s)"},
+			{file.TransformTemplates, "transform template(s)"},
+			{file.VariableTypes, "variable type(s)"},
+			{file.GlobalVariables, "global variable(s)"},
+		} {
+			if c.n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", c.n, c.what))
+			}
+		}
+		if len(counts) == 0 {
+			counts = []string{"no definitions"}
+		}
+		fmt.Fprintf(w, "   %s\n", strings.Join(counts, ", "))
+
+		for _, entry := range file.Overwritten {
+			fmt.Fprintf(w, "   Overwrites %s\n", entry)
+		}
+	}
+}
+
 // displayTransform shows transform details with proper formatting
 func displayTransform(transform *models.Transform, indent string) {
 	if transform.EmptyValue != "" {
@@ -181,6 +375,20 @@ func displayTransform(transform *models.Transform, indent string) {
 		}
 	}
 
+	if transform.RegexReplace != nil {
+		fmt.Printf("%sRegex Replace: s/%s/%s/\n", indent, transform.RegexReplace.Pattern, transform.RegexReplace.Replacement)
+	}
+
+	if len(transform.Map) > 0 {
+		fmt.Printf("%sMap:\n", indent)
+		for _, key := range slices.Sorted(maps.Keys(transform.Map)) {
+			fmt.Printf("%s  %s: %s\n", indent, key, transform.Map[key])
+		}
+		if transform.MapDefault != "" {
+			fmt.Printf("%s  (other): %s\n", indent, transform.MapDefault)
+		}
+	}
+
 	if transform.TrueValue != "" {
 		fmt.Printf("%sTrue Value: %s\n", indent, transform.TrueValue)
 	}
@@ -206,14 +414,90 @@ func displayTransform(transform *models.Transform, indent string) {
 // displayValidation shows validation rules with proper formatting
 func displayValidation(validation *models.Validation, indent string) {
 	if len(validation.Enum) > 0 {
-		fmt.Printf("%sAllowed values: %s\n", indent, strings.Join(validation.Enum, ", "))
+		if validation.Multiple {
+			fmt.Printf("%sAllowed values (any of): %s\n", indent, strings.Join(validation.Enum, ", "))
+		} else {
+			fmt.Printf("%sAllowed values: %s\n", indent, strings.Join(validation.Enum, ", "))
+		}
 	}
 
-	if len(validation.Range) == 2 {
-		fmt.Printf("%sRange: %d - %d\n", indent, validation.Range[0], validation.Range[1])
+	if ranges := validation.AllowedRanges(); len(ranges) > 0 {
+		parts := make([]string, len(ranges))
+		for i, r := range ranges {
+			parts[i] = fmt.Sprintf("%g - %g", r[0], r[1])
+		}
+		fmt.Printf("%sRange: %s\n", indent, strings.Join(parts, ", "))
+	}
+
+	if validation.Step != 0 {
+		fmt.Printf("%sStep: %g\n", indent, validation.Step)
 	}
 
 	if validation.Pattern != "" {
 		fmt.Printf("%sPattern: %s\n", indent, validation.Pattern)
 	}
+
+	if validation.NotPattern != "" {
+		fmt.Printf("%sNot pattern: %s\n", indent, validation.NotPattern)
+	}
+
+	if validation.Message != "" {
+		fmt.Printf("%sMessage: %s\n", indent, validation.Message)
+	}
+}
+
+// unusedDefinitions returns, sorted, the transform templates or variable
+// types (by kind, usageTransform or usageType) that no snippet or global
+// variable references.
+func unusedDefinitions(kind string) []string {
+	var names []string
+	if kind == usageTransform {
+		names = slices.Collect(maps.Keys(config.TransformTemplates))
+	} else {
+		names = slices.Collect(maps.Keys(config.VariableTypes))
+	}
+	slices.Sort(names)
+	return slices.DeleteFunc(names, func(name string) bool {
+		return len(findUsages(kind, name)) > 0
+	})
+}
+
+// showUnused prints the unused transform templates and variable types, or
+// only one of the two when only is "transforms" or "types". With quiet
+// only the names are printed.
+func showUnused(only string, quiet bool) error {
+	sections := []struct{ arg, kind, heading string }{
+		{"transforms", usageTransform, "Unused transform templates"},
+		{"types", usageType, "Unused variable types"},
+	}
+	switch only {
+	case "":
+	case "transforms":
+		sections = sections[:1]
+	case "types":
+		sections = sections[1:]
+	default:
+		return usageErrorf("show unused takes transforms or types, not '%s'", only)
+	}
+
+	found := false
+	for _, section := range sections {
+		names := unusedDefinitions(section.kind)
+		if len(names) == 0 {
+			continue
+		}
+		if quiet {
+			fmt.Println(strings.Join(names, "\n"))
+			continue
+		}
+		if found {
+			fmt.Println()
+		}
+		fmt.Printf("%s:\n  - %s\n", section.heading, strings.Join(names, "\n  - "))
+		found = true
+	}
+	if !found && !quiet {
+		fmt.Println("No unused transform templates or variable types.")
+	}
+	return nil
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// TestFindUsages tests the reverse lookup behind cs show --usages
+func TestFindUsages(t *testing.T) {
+	config = &models.Config{
+		GlobalVariables: map[string]models.Variable{
+			"namespace": {TransformTemplate: "ns-flag"},
+		},
+		Snippets: map[string]models.Snippet{
+			"get-pods": {SourceFile: "/etc/cs/k8s.yaml", Variables: []models.Variable{
+				{Name: "ns", TransformTemplate: "ns-flag"},
+				{Name: "port", Type: "port"},
+			}},
+			"logs": {Variables: []models.Variable{
+				{Name: "ns", TransformTemplate: "ns-flag"},
+				{Name: "other", TransformTemplate: "ns-flag"},
+			}},
+			"echo": {Variables: []models.Variable{{Name: "message"}}},
+		},
+	}
+	t.Cleanup(func() { config = nil })
+
+	expected := []snippetUsage{
+		{variables: []string{"namespace"}},
+		{snippet: "get-pods", variables: []string{"ns"}, sourceFile: "/etc/cs/k8s.yaml"},
+		{snippet: "logs", variables: []string{"ns", "other"}},
+	}
+	if got := findUsages(usageTransform, "ns-flag"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	expected = []snippetUsage{{snippet: "get-pods", variables: []string{"port"}, sourceFile: "/etc/cs/k8s.yaml"}}
+	if got := findUsages(usageType, "port"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+	if got := findUsages(usageType, "ns-flag"); len(got) != 0 {
+		t.Errorf("Expected no usages of a type named like the template, got %+v", got)
+	}
+
+	err := notFoundError(usageTransform, "ns-flg", []string{"ns-flag", "port"})
+	if err == nil || err.Error() != "transform template 'ns-flg' not found (did you mean: ns-flag?)" {
+		t.Errorf("Expected a suggestion, got %v", err)
+	}
+}
+
+// TestUnusedDefinitions tests which transform templates and variable types
+// cs show unused reports
+func TestUnusedDefinitions(t *testing.T) {
+	config = &models.Config{
+		TransformTemplates: map[string]models.TransformTemplate{"ns-flag": {}, "old-flag": {}, "global-flag": {}},
+		VariableTypes:      map[string]models.VariableType{"port": {}, "legacy": {}},
+		GlobalVariables: map[string]models.Variable{
+			"namespace": {TransformTemplate: "global-flag"},
+		},
+		Snippets: map[string]models.Snippet{
+			"get-pods": {Variables: []models.Variable{
+				{Name: "ns", TransformTemplate: "ns-flag"},
+				{Name: "port", Type: "port"},
+			}},
+		},
+	}
+	t.Cleanup(func() { config = nil })
+
+	if got := unusedDefinitions(usageTransform); !slices.Equal(got, []string{"old-flag"}) {
+		t.Errorf("Expected [old-flag], got %v", got)
+	}
+	if got := unusedDefinitions(usageType); !slices.Equal(got, []string{"legacy"}) {
+		t.Errorf("Expected [legacy], got %v", got)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func newTagsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tags",
+		Short: "List and manage tags across command templates",
+		Long: `List and manage the tags used across all command templates.
+
+Examples:
+  cs tags list                   # Show each tag with its template count
+  cs tags rename k8s kubernetes  # Rename a tag everywhere (merges if target exists)
+  cs tags remove deprecated      # Strip a tag from every template`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "Show each tag with the number of templates using it",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTagsList(os.Stdout)
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rename <old-tag> <new-tag>",
+		Short: "Rename a tag on every template that carries it",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTagsRename(os.Stdout, args[0], args[1])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove <tag>",
+		Short: "Remove a tag from every template",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runTagsRemove(os.Stdout, args[0])
+		},
+	})
+
+	return cmd
+}
+
+// runTagsList writes each tag to w with the number of snippets carrying it.
+// Spellings that differ only in case count as one tag, as they do for
+// rename, remove, and --tags.
+func runTagsList(w io.Writer) error {
+	var tagLists [][]string
+	for _, snippet := range config.Snippets {
+		tagLists = append(tagLists, snippet.Tags)
+	}
+	names := tagNames(tagLists...)
+
+	counts := make(map[string]int)
+	for _, tags := range tagLists {
+		seen := make(map[string]bool)
+		for _, tag := range tags {
+			name := names[strings.ToLower(tag)]
+			if !seen[name] {
+				seen[name] = true
+				counts[name]++
+			}
+		}
+	}
+
+	if len(counts) == 0 {
+		fmt.Fprintln(w, "No tags found.")
+		return nil
+	}
+
+	tags := slices.Sorted(maps.Keys(counts))
+	width := 0
+	for _, tag := range tags {
+		width = max(width, len(tag))
+	}
+	for _, tag := range tags {
+		fmt.Fprintf(w, "%-*s  %d\n", width, tag, counts[tag])
+	}
+	return nil
+}
+
+func runTagsRename(w io.Writer, oldTag, newTag string) error {
+	newTag = strings.TrimSpace(newTag)
+	if newTag == "" {
+		return fmt.Errorf("new tag cannot be empty")
+	}
+	return rewriteTags(w, oldTag, func(tags []string) []string {
+		var result []string
+		for _, tag := range tags {
+			if strings.EqualFold(tag, oldTag) {
+				tag = newTag
+			}
+			// Merge into an existing tag instead of duplicating it
+			if !slices.ContainsFunc(result, func(t string) bool { return strings.EqualFold(t, tag) }) {
+				result = append(result, tag)
+			}
+		}
+		return result
+	}, fmt.Sprintf("renamed to '%s' on", newTag))
+}
+
+func runTagsRemove(w io.Writer, tag string) error {
+	return rewriteTags(w, tag, func(tags []string) []string {
+		return slices.DeleteFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	}, "removed from")
+}
+
+// rewriteTags applies rewrite to the tags of every snippet carrying tag
+// (case-insensitive), saves the config, and reports what changed to w.
+func rewriteTags(w io.Writer, tag string, rewrite func([]string) []string, action string) error {
+	var touched []string
+	now := time.Now()
+	for _, name := range slices.Sorted(maps.Keys(config.Snippets)) {
+		snippet := config.Snippets[name]
+		if !hasAnyTag(snippet.Tags, []string{tag}) {
+			continue
+		}
+		snippet.Tags = rewrite(slices.Clone(snippet.Tags))
+		snippet.UpdatedAt = now
+		config.Snippets[name] = snippet
+		touched = append(touched, name)
+	}
+
+	if len(touched) == 0 {
+		return fmt.Errorf("no templates are tagged '%s'", tag)
+	}
+
+	if err := saveSnippets(snippetFiles(touched...), touched...); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "✅ Tag '%s' %s %d template(s):\n", tag, action, len(touched))
+	for _, name := range touched {
+		fmt.Fprintf(w, "  - %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const tagsConfig = "snippets:\n" +
+	"  build:\n    command: docker build .\n    tags: [Docker, k8s]\n" +
+	"  run:\n    command: docker run app\n    tags: [docker, DOCKER]\n" +
+	"  push:\n    command: docker push app\n    tags: [docker, kubernetes]\n" +
+	"  apply:\n    command: kubectl apply -f .\n    tags: [K8S]\n" +
+	"  plain:\n    command: echo plain\n"
+
+// TestRunTagsList tests that spellings of a tag differing only in case are
+// counted once per snippet under the most used spelling
+func TestRunTagsList(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": tagsConfig})
+
+	var out bytes.Buffer
+	if err := runTagsList(&out); err != nil {
+		t.Fatalf("runTagsList failed: %v", err)
+	}
+	want := "K8S         2\ndocker      3\nkubernetes  1\n"
+	if out.String() != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, out.String())
+	}
+}
+
+// TestRunTagsRename tests that renaming a tag onto one a snippet already has
+// merges the two instead of duplicating it, whatever the case, and that the
+// result is saved
+func TestRunTagsRename(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": tagsConfig})
+
+	var out bytes.Buffer
+	if err := runTagsRename(&out, "K8s", "kubernetes"); err != nil {
+		t.Fatalf("runTagsRename failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "2 template(s)") {
+		t.Errorf("Expected 2 templates to be reported, got:\n%s", out.String())
+	}
+
+	reloaded, err := loadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	want := map[string][]string{
+		"build": {"Docker", "kubernetes"},
+		"apply": {"kubernetes"},
+		"push":  {"docker", "kubernetes"},
+	}
+	for name, tags := range want {
+		if got := reloaded.Snippets[name].Tags; !slices.Equal(got, tags) {
+			t.Errorf("Expected %s to be tagged %v, got %v", name, tags, got)
+		}
+	}
+
+	if err := runTagsRename(&out, "missing", "other"); err == nil {
+		t.Error("Expected an error renaming a tag no template has")
+	}
+}
+
+// TestRunTagsRemove tests that removing a tag strips every spelling of it
+// and leaves other tags alone
+func TestRunTagsRemove(t *testing.T) {
+	useConfigDir(t, map[string]string{"config.yaml": tagsConfig})
+
+	var out bytes.Buffer
+	if err := runTagsRemove(&out, "docker"); err != nil {
+		t.Fatalf("runTagsRemove failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "3 template(s)") {
+		t.Errorf("Expected 3 templates to be reported, got:\n%s", out.String())
+	}
+
+	reloaded, err := loadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("loadConfig failed: %v", err)
+	}
+	want := map[string][]string{
+		"build": {"k8s"},
+		"run":   nil,
+		"push":  {"kubernetes"},
+		"apply": {"K8S"},
+	}
+	for name, tags := range want {
+		if got := reloaded.Snippets[name].Tags; !slices.Equal(got, tags) {
+			t.Errorf("Expected %s to be tagged %v, got %v", name, tags, got)
+		}
+	}
+
+	if err := runTagsRemove(&out, "docker"); err == nil {
+		t.Error("Expected an error removing a tag no template has any more")
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/samling/command-snippets/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+func newValidateCmd() *cobra.Command {
+	var warningsAsErrors bool
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration for errors",
+		Long: `Check every command template, transform template, and variable type for problems:
+
+- Placeholders in a command with no matching variable
+- Variables that are defined but never used
+- Unknown transform templates or variable types
+- Invalid validation patterns and malformed Go templates
+- Defaults that aren't one of the allowed enum values
+
+Exits non-zero when errors are found, so it can run in CI.
+
+Examples:
+  cs validate                        # Report errors and warnings
+  cs validate --warnings-as-errors   # Fail on warnings too`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			return runValidate(warningsAsErrors)
+		},
+	}
+
+	cmd.Flags().BoolVar(&warningsAsErrors, "warnings-as-errors", false, "Exit non-zero on warnings as well as errors")
+
+	return cmd
+}
+
+func runValidate(warningsAsErrors bool) error {
+	issues := config.Lint()
+
+	var errCount, warnCount int
+	var lastKind, lastName string
+	for _, issue := range issues {
+		if issue.Kind != lastKind || issue.Name != lastName {
+			fmt.Printf("%s '%s':\n", issue.Kind, issue.Name)
+			lastKind, lastName = issue.Kind, issue.Name
+		}
+		fmt.Printf("  %s\n", issue)
+
+		if issue.Severity == models.SeverityError {
+			errCount++
+		} else {
+			warnCount++
+		}
+	}
+
+	if len(issues) == 0 {
+		fmt.Printf("✅ Configuration is valid (%d templates checked)\n", len(config.Snippets))
+		return nil
+	}
+
+	fmt.Printf("\nFound %d error(s) and %d warning(s)\n", errCount, warnCount)
+	if errCount > 0 || (warningsAsErrors && warnCount > 0) {
+		return fmt.Errorf("validation failed")
+	}
+	return nil
+}
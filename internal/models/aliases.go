@@ -0,0 +1,53 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// ResolveSnippetName returns the key in c.Snippets that name refers to:
+// name itself when a snippet is called that, otherwise the snippet listing
+// name among its aliases. Snippets are searched in name order, so the
+// first of two snippets sharing an alias wins.
+func (c *Config) ResolveSnippetName(name string) (string, bool) {
+	if _, ok := c.Snippets[name]; ok {
+		return name, true
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.Snippets)) {
+		if slices.Contains(c.Snippets[key].Aliases, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// AliasConflicts describes each alias that is also the name of another
+// snippet or an alias of one, naming the files both are defined in. The
+// alias then resolves as ResolveSnippetName describes.
+func (c *Config) AliasConflicts() []string {
+	var conflicts []string
+	owners := make(map[string]string)
+	for _, key := range slices.Sorted(maps.Keys(c.Snippets)) {
+		snippet := c.Snippets[key]
+		for _, alias := range snippet.Aliases {
+			if alias == key {
+				continue
+			}
+			if other, ok := c.Snippets[alias]; ok {
+				conflicts = append(conflicts, fmt.Sprintf("alias '%s' of snippet '%s' (%s) is the name of snippet '%s' (%s)",
+					alias, key, snippet.SourceFile, alias, other.SourceFile))
+				continue
+			}
+			if owner, ok := owners[alias]; ok {
+				if owner != key {
+					conflicts = append(conflicts, fmt.Sprintf("alias '%s' of snippet '%s' (%s) is also an alias of snippet '%s' (%s)",
+						alias, key, snippet.SourceFile, owner, c.Snippets[owner].SourceFile))
+				}
+				continue
+			}
+			owners[alias] = key
+		}
+	}
+	return conflicts
+}
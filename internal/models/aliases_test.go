@@ -0,0 +1,58 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestResolveSnippetName tests lookups by name and alias
+func TestResolveSnippetName(t *testing.T) {
+	config := &Config{Snippets: map[string]Snippet{
+		"kubectl-get-pods": {Aliases: []string{"kgp", "pods"}},
+		"pods":             {},
+		"a-snippet":        {Aliases: []string{"shared"}},
+		"b-snippet":        {Aliases: []string{"shared"}},
+	}}
+
+	tests := []struct {
+		name     string
+		expected string
+		found    bool
+	}{
+		{"kubectl-get-pods", "kubectl-get-pods", true},
+		{"kgp", "kubectl-get-pods", true},
+		{"pods", "pods", true}, // a snippet name wins over an alias
+		{"shared", "a-snippet", true},
+		{"missing", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := config.ResolveSnippetName(tt.name)
+			if got != tt.expected || found != tt.found {
+				t.Errorf("Expected (%q, %v), got (%q, %v)", tt.expected, tt.found, got, found)
+			}
+		})
+	}
+}
+
+// TestAliasConflicts tests that clashing aliases are reported with both
+// sources
+func TestAliasConflicts(t *testing.T) {
+	config := &Config{Snippets: map[string]Snippet{
+		"kubectl-get-pods": {Aliases: []string{"kgp", "pods", "kubectl-get-pods"}, SourceFile: "k8s.yaml"},
+		"pods":             {SourceFile: "config.yaml"},
+		"a-snippet":        {Aliases: []string{"shared", "shared"}, SourceFile: "a.yaml"},
+		"b-snippet":        {Aliases: []string{"shared"}, SourceFile: "b.yaml"},
+	}}
+
+	conflicts := config.AliasConflicts()
+	expected := []string{
+		"alias 'shared' of snippet 'b-snippet' (b.yaml) is also an alias of snippet 'a-snippet' (a.yaml)",
+		"alias 'pods' of snippet 'kubectl-get-pods' (k8s.yaml) is the name of snippet 'pods' (config.yaml)",
+	}
+	if !slices.Equal(conflicts, expected) {
+		t.Errorf("Expected conflicts:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(conflicts, "\n"))
+	}
+}
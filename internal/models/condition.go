@@ -0,0 +1,142 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Condition decides whether a variable applies, based on the values of the
+// others. In YAML it is either a Go template rendering true or false
+// (when: '{{eq .tls "true"}}') or a comparison
+// (when: {variable: tls, equals: "true"}).
+type Condition struct {
+	Template string
+	Variable string
+	Equals   string
+
+	tpl    *template.Template
+	tplErr error
+}
+
+// conditionComparison is the mapping form of a Condition in YAML.
+type conditionComparison struct {
+	Variable string `yaml:"variable"`
+	Equals   string `yaml:"equals"`
+}
+
+// UnmarshalYAML accepts either form of a condition.
+func (c *Condition) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		c.Template = node.Value
+		return nil
+	}
+	var cmp conditionComparison
+	if err := node.Decode(&cmp); err != nil {
+		return err
+	}
+	if cmp.Variable == "" {
+		return fmt.Errorf("line %d: when needs a variable to compare", node.Line)
+	}
+	c.Variable, c.Equals = cmp.Variable, cmp.Equals
+	return nil
+}
+
+// MarshalYAML writes the condition back in the form it was read in.
+func (c Condition) MarshalYAML() (any, error) {
+	if c.Template != "" {
+		return c.Template, nil
+	}
+	return conditionComparison{Variable: c.Variable, Equals: c.Equals}, nil
+}
+
+// String returns the condition as written, in the comparison form as
+// variable=value.
+func (c *Condition) String() string {
+	if c.Template != "" {
+		return c.Template
+	}
+	return c.Variable + "=" + c.Equals
+}
+
+// template returns the parsed Template, caching the result.
+func (c *Condition) template() (*template.Template, error) {
+	if c.tpl == nil && c.tplErr == nil {
+		c.tpl, c.tplErr = newTemplate("when", c.Template)
+	}
+	return c.tpl, c.tplErr
+}
+
+// dependencies returns the names of the variables the condition reads.
+func (c *Condition) dependencies() []string {
+	if c.Template == "" {
+		return []string{c.Variable}
+	}
+	tpl, err := c.template()
+	if err != nil {
+		return nil
+	}
+	return templateFields(tpl)
+}
+
+// Holds reports whether the condition is met for values.
+func (c *Condition) Holds(values map[string]string) (bool, error) {
+	if c.Template == "" {
+		return values[c.Variable] == c.Equals, nil
+	}
+	tpl, err := c.template()
+	if err != nil {
+		return false, err
+	}
+	var buf strings.Builder
+	if err := tpl.Execute(&buf, values); err != nil {
+		return false, err
+	}
+	return parseBool(strings.TrimSpace(buf.String())), nil
+}
+
+// conditionOrder returns the variables that have a when condition, each
+// after the conditional variables its condition reads. A cycle between
+// conditions is an error.
+func (s *Snippet) conditionOrder() ([]*Variable, error) {
+	var conditional []*Variable
+	for i := range s.Variables {
+		if s.Variables[i].When != nil {
+			conditional = append(conditional, &s.Variables[i])
+		}
+	}
+	return dependencyOrder(conditional, "when conditions", func(v *Variable) []string {
+		return v.When.dependencies()
+	})
+}
+
+// HiddenVariables returns the names of the variables whose when condition
+// does not hold for values. Hidden variables count as empty when later
+// conditions are evaluated, so hiding a variable also hides those that
+// depend on it being set.
+func (s *Snippet) HiddenVariables(values map[string]string) (map[string]bool, error) {
+	order, err := s.conditionOrder()
+	if err != nil || len(order) == 0 {
+		return nil, err
+	}
+
+	effective := maps.Clone(values)
+	if effective == nil {
+		effective = make(map[string]string)
+	}
+	hidden := make(map[string]bool)
+	for _, v := range order {
+		holds, err := v.When.Holds(effective)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: evaluating when: %w", v.Name, err)
+		}
+		if !holds {
+			hidden[v.Name] = true
+			effective[v.Name] = ""
+		}
+	}
+	return hidden, nil
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestCondition_YAML tests both forms of when and that they round-trip
+func TestCondition_YAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected Condition
+	}{
+		{"template", `when: '{{eq .tls "true"}}'`, Condition{Template: `{{eq .tls "true"}}`}},
+		{"comparison", "when: {variable: tls, equals: \"true\"}", Condition{Variable: "tls", Equals: "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Variable
+			if err := yaml.Unmarshal([]byte(tt.yaml), &v); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if v.When == nil || v.When.Template != tt.expected.Template || v.When.Variable != tt.expected.Variable || v.When.Equals != tt.expected.Equals {
+				t.Fatalf("Expected %+v, got %+v", tt.expected, v.When)
+			}
+
+			out, err := yaml.Marshal(v)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var again Variable
+			if err := yaml.Unmarshal(out, &again); err != nil {
+				t.Fatalf("Unmarshal of marshalled output failed: %v\n%s", err, out)
+			}
+			if *again.When != *v.When {
+				t.Errorf("Expected %+v after a round trip, got %+v", v.When, again.When)
+			}
+		})
+	}
+
+	var v Variable
+	if err := yaml.Unmarshal([]byte("when: {equals: x}"), &v); err == nil {
+		t.Error("Expected an error for a comparison without a variable")
+	}
+}
+
+// TestHiddenVariables tests condition evaluation, including chains
+func TestHiddenVariables(t *testing.T) {
+	snippet := Snippet{
+		Variables: []Variable{
+			{Name: "cert", When: &Condition{Template: `{{eq .tls "true"}}`}},
+			{Name: "tls"},
+			{Name: "key", When: &Condition{Template: `{{ne .cert ""}}`}},
+			{Name: "mode", When: &Condition{Variable: "tls", Equals: "true"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		expected []string
+	}{
+		{"condition holds", map[string]string{"tls": "true", "cert": "c.pem"}, nil},
+		{"condition fails", map[string]string{"tls": "false", "cert": ""}, []string{"cert", "key", "mode"}},
+		{"hidden value counts as empty", map[string]string{"tls": "false", "cert": "c.pem"}, []string{"cert", "key", "mode"}},
+		{"missing values", nil, []string{"cert", "key", "mode"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hidden, err := snippet.HiddenVariables(tt.values)
+			if err != nil {
+				t.Fatalf("HiddenVariables failed: %v", err)
+			}
+			if len(hidden) != len(tt.expected) {
+				t.Fatalf("Expected %v hidden, got %v", tt.expected, hidden)
+			}
+			for _, name := range tt.expected {
+				if !hidden[name] {
+					t.Errorf("Expected %s to be hidden, got %v", name, hidden)
+				}
+			}
+		})
+	}
+}
+
+// TestHiddenVariables_Cycle tests that conditions depending on each other are rejected
+func TestHiddenVariables_Cycle(t *testing.T) {
+	snippet := Snippet{
+		Variables: []Variable{
+			{Name: "a", When: &Condition{Variable: "b", Equals: "x"}},
+			{Name: "b", When: &Condition{Template: `{{.a}}`}},
+		},
+	}
+
+	_, err := snippet.HiddenVariables(nil)
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("Expected a cycle error naming a -> b -> a, got %v", err)
+	}
+}
+
+// TestProcessTemplate_HiddenVariables tests that hidden variables take the
+// empty branch of their transforms
+func TestProcessTemplate_HiddenVariables(t *testing.T) {
+	snippet := Snippet{
+		Command: "curl <insecure> <cert> <url>",
+		Variables: []Variable{
+			{Name: "url"},
+			{Name: "tls", Type: VarTypeBoolean},
+			{Name: "insecure", Type: VarTypeBoolean, DefaultValue: "true", When: &Condition{Variable: "tls", Equals: "true"},
+				Transform: &Transform{TrueValue: "-k", FalseValue: ""}},
+			{Name: "cert", DefaultValue: "ca.pem", When: &Condition{Variable: "tls", Equals: "true"},
+				Transform: &Transform{ValuePattern: "--cacert {{.Value}}", EmptyValue: ""}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		expected string
+	}{
+		{"shown", map[string]string{"url": "x", "tls": "true", "insecure": "true", "cert": "a.pem"}, "curl -k --cacert a.pem x"},
+		{"hidden", map[string]string{"url": "x", "tls": "false", "insecure": "true", "cert": "a.pem"}, "curl   x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snippet.ProcessTemplate(tt.values, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
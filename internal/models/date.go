@@ -0,0 +1,81 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default layouts for date variables without a validation format.
+const (
+	DefaultDateFormat     = "2006-01-02"
+	DefaultDatetimeFormat = time.RFC3339
+)
+
+// now is the clock used to resolve relative dates; tests replace it.
+var now = time.Now
+
+// DateFormat returns the Go layout the variable's values are formatted
+// with: validation.format, else the default for its type.
+func (v *Variable) DateFormat() string {
+	if v.Validation != nil && v.Validation.Format != "" {
+		return v.Validation.Format
+	}
+	if v.Type == VarTypeDatetime {
+		return DefaultDatetimeFormat
+	}
+	return DefaultDateFormat
+}
+
+// isDateType reports whether values of the variable are dates.
+func (v *Variable) isDateType() bool {
+	return v.Type == VarTypeDate || v.Type == VarTypeDatetime
+}
+
+// ResolveDate turns a date variable's value into a timestamp in its format.
+// Values already in the format are returned unchanged; the shorthands now,
+// today, yesterday, and tomorrow, and signed offsets from now such as -1h,
+// +30m, or -2d (d and w count days and weeks) are resolved against the
+// current time.
+func (v *Variable) ResolveDate(value string) (string, error) {
+	layout := v.DateFormat()
+	if _, err := time.Parse(layout, value); err == nil {
+		return value, nil
+	}
+
+	t := now()
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch value {
+	case "now":
+	case "today":
+		t = midnight
+	case "yesterday":
+		t = midnight.AddDate(0, 0, -1)
+	case "tomorrow":
+		t = midnight.AddDate(0, 0, 1)
+	default:
+		offset, ok := parseDateOffset(value)
+		if !ok {
+			return "", fmt.Errorf("variable %s must be a date in the format %s (e.g. %s), or now, today, yesterday, tomorrow, or an offset such as -1h or -2d",
+				v.Name, layout, t.Format(layout))
+		}
+		t = t.Add(offset)
+	}
+	return t.Format(layout), nil
+}
+
+// parseDateOffset parses a signed duration, allowing the d and w units on
+// top of those time.ParseDuration accepts.
+func parseDateOffset(s string) (time.Duration, bool) {
+	if !strings.HasPrefix(s, "-") && !strings.HasPrefix(s, "+") {
+		return 0, false
+	}
+	for unit, size := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, unit)); err == nil && strings.HasSuffix(s, unit) {
+			return time.Duration(n) * size, true
+		}
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixNow pins the clock used for relative dates for the rest of the test.
+func fixNow(t *testing.T, at time.Time) {
+	t.Helper()
+	orig := now
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = orig })
+}
+
+// TestResolveDate tests literal and relative date values
+func TestResolveDate(t *testing.T) {
+	fixNow(t, time.Date(2024, 3, 10, 14, 30, 0, 0, time.UTC))
+
+	tests := []struct {
+		name     string
+		variable Variable
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{"literal date", Variable{Type: VarTypeDate}, "2024-01-31", "2024-01-31", false},
+		{"now", Variable{Type: VarTypeDatetime}, "now", "2024-03-10T14:30:00Z", false},
+		{"today", Variable{Type: VarTypeDatetime}, "today", "2024-03-10T00:00:00Z", false},
+		{"yesterday", Variable{Type: VarTypeDate}, "yesterday", "2024-03-09", false},
+		{"tomorrow", Variable{Type: VarTypeDate}, "tomorrow", "2024-03-11", false},
+		{"hours ago", Variable{Type: VarTypeDatetime}, "-1h", "2024-03-10T13:30:00Z", false},
+		{"minutes ahead", Variable{Type: VarTypeDatetime}, "+90m", "2024-03-10T16:00:00Z", false},
+		{"days ago", Variable{Type: VarTypeDate}, "-2d", "2024-03-08", false},
+		{"weeks ago", Variable{Type: VarTypeDate}, "-1w", "2024-03-03", false},
+		{"custom format", Variable{Type: VarTypeDate, Validation: &Validation{Format: "2006/01/02 15:04"}}, "-30m", "2024/03/10 14:00", false},
+		{"custom literal", Variable{Type: VarTypeDate, Validation: &Validation{Format: "02.01.2006"}}, "31.12.2023", "31.12.2023", false},
+		{"wrong layout", Variable{Type: VarTypeDate}, "03/10/2024", "", true},
+		{"unsigned offset", Variable{Type: VarTypeDate}, "1h", "", true},
+		{"garbage", Variable{Type: VarTypeDate}, "last week", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.variable.Name = "when"
+			got, err := tt.variable.ResolveDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ResolveDate(%q) = %q, expected %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestValidateWithConfig_Date tests that date errors explain the expected layout
+func TestValidateWithConfig_Date(t *testing.T) {
+	fixNow(t, time.Date(2024, 3, 10, 14, 30, 0, 0, time.UTC))
+	variable := Variable{Name: "since", Type: VarTypeDate, Validation: &Validation{Format: "2006-01-02 15:04"}}
+
+	if err := variable.ValidateWithConfig("yesterday", nil); err != nil {
+		t.Errorf("Unexpected error for a relative date: %v", err)
+	}
+	err := variable.ValidateWithConfig("2024-03-10", nil)
+	if err == nil || !strings.Contains(err.Error(), "format 2006-01-02 15:04 (e.g. 2024-03-10 14:30)") {
+		t.Errorf("Expected an error naming the layout, got %v", err)
+	}
+}
+
+// TestProcessTemplate_Date tests that dates are resolved before substitution
+func TestProcessTemplate_Date(t *testing.T) {
+	fixNow(t, time.Date(2024, 3, 10, 14, 30, 0, 0, time.UTC))
+	snippet := &Snippet{
+		Command: "logcli query --from=<from> <to>",
+		Variables: []Variable{
+			{Name: "from", Type: VarTypeDatetime},
+			{Name: "to", Type: VarTypeDatetime, DefaultValue: "now", Transform: &Transform{ValuePattern: "--to={{.Value}}"}},
+		},
+	}
+
+	got, err := snippet.ProcessTemplate(map[string]string{"from": "-1h", "to": "now"}, &Config{})
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	expected := "logcli query --from=2024-03-10T13:30:00Z --to=2024-03-10T14:30:00Z"
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// templateFuncs are the helpers available in value_pattern, compose, and
+// when templates. Every template is parsed with them, so a call to an
+// unknown function fails to parse and is reported by lint.
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"title": title,
+	"trim":  strings.TrimSpace,
+	// replace takes the string last so it can be piped into:
+	// {{.Value | replace "_" "-"}}.
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	// default returns value, or fallback when value is empty:
+	// {{.tag | default "latest"}}.
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+	"quote":      shellQuote,
+	"shellquote": shellQuote,
+}
+
+// newTemplate parses text as a template named name with templateFuncs.
+// Values missing from the data render as empty strings.
+func newTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// title upper-cases the first letter of each space-separated word.
+func title(s string) string {
+	prev := ' '
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(prev) {
+			r = unicode.ToUpper(r)
+		}
+		prev = r
+		return r
+	}, s)
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell, so that it is
+// passed as one word with nothing expanded.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
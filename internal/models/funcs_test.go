@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestTemplateFuncs tests the helpers available in transform templates
+func TestTemplateFuncs(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		value    string
+		expected string
+	}{
+		{"upper", "{{upper .Value}}", "dev", "DEV"},
+		{"lower", "--name={{ lower .Value }}", "MyApp", "--name=myapp"},
+		{"title", "{{title .Value}}", "hello big world", "Hello Big World"},
+		{"trim", "[{{trim .Value}}]", "  x  ", "[x]"},
+		{"replace", `{{.Value | replace "_" "-"}}`, "my_app_name", "my-app-name"},
+		{"quote", "echo {{quote .Value}}", "it's $HOME", `echo 'it'\''s $HOME'`},
+		{"chained", `{{.Value | trim | lower | quote}}`, " ABC ", "'abc'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{
+				Command:   "<v>",
+				Variables: []Variable{{Name: "v", Transform: &Transform{ValuePattern: tt.pattern}}},
+			}
+			got, err := snippet.ProcessTemplate(map[string]string{"v": tt.value}, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestTemplateFuncs_Compose tests helpers in compose and when templates
+func TestTemplateFuncs_Compose(t *testing.T) {
+	snippet := Snippet{
+		Command: "docker run <image> <debug>",
+		Variables: []Variable{
+			{Name: "name"},
+			{Name: "tag"},
+			{Name: "image", Computed: true, Transform: &Transform{Compose: `{{lower .name}}:{{.tag | default "latest"}}`}},
+			{Name: "debug", When: &Condition{Template: `{{eq (lower .tag) "dev"}}`}, Transform: &Transform{ValuePattern: "-e DEBUG={{.Value}}"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		values   map[string]string
+		expected string
+	}{
+		{"default applies", map[string]string{"name": "Nginx", "debug": "1"}, "docker run nginx:latest "},
+		{"when uses helpers", map[string]string{"name": "app", "tag": "DEV", "debug": "1"}, "docker run app:DEV -e DEBUG=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snippet.ProcessTemplate(tt.values, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestTemplateFuncs_Unknown tests that an unknown function is a lint error
+func TestTemplateFuncs_Unknown(t *testing.T) {
+	snippet := Snippet{
+		Command:   "echo <v>",
+		Variables: []Variable{{Name: "v", Transform: &Transform{ValuePattern: "{{shout .Value}}"}}},
+	}
+
+	for _, issue := range snippet.Lint("test", &Config{}) {
+		if issue.Severity == SeverityError && strings.Contains(issue.Message, `function "shout" not defined`) {
+			return
+		}
+	}
+	t.Errorf("Expected a lint error for the unknown function, got %v", snippet.Lint("test", &Config{}))
+}
+
+// TestProcessTemplate_Quote tests quote: true and the shellquote function
+func TestProcessTemplate_Quote(t *testing.T) {
+	tests := []struct {
+		name     string
+		variable Variable
+		value    string
+		expected string
+	}{
+		{"plain", Variable{Quote: true}, "hello world", `echo 'hello world'`},
+		{"single quotes", Variable{Quote: true}, "it's", `echo 'it'\''s'`},
+		{"double quotes", Variable{Quote: true}, `say "hi"`, `echo 'say "hi"'`},
+		{"dollar", Variable{Quote: true}, "$HOME and $(id)", `echo '$HOME and $(id)'`},
+		{"empty stays empty", Variable{Quote: true}, "", "echo "},
+		{"quotes the default", Variable{Quote: true, DefaultValue: "a b"}, "", `echo 'a b'`},
+		{"quotes the pattern output", Variable{Quote: true, Transform: &Transform{ValuePattern: "--msg={{.Value}}"}}, "a b", `echo '--msg=a b'`},
+		{"shellquote function", Variable{Transform: &Transform{ValuePattern: "--msg={{shellquote .Value}}"}}, `it's "$1"`, `echo --msg='it'\''s "$1"'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.variable.Name = "message"
+			snippet := Snippet{Command: "echo <message>", Variables: []Variable{tt.variable}}
+			got, err := snippet.ProcessTemplate(map[string]string{"message": tt.value}, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"slices"
+	"strings"
+)
+
+// WithGlobalVariables returns a copy of the snippet with a definition from
+// config.GlobalVariables appended for each placeholder in its commands,
+// workdir, or hooks that no local variable defines, and for the variables those
+// globals' compose templates and when conditions read. Local variables
+// shadow globals of the same name. The snippet itself is not modified.
+func (s *Snippet) WithGlobalVariables(config *Config) Snippet {
+	merged := *s
+	if config == nil || len(config.GlobalVariables) == 0 {
+		return merged
+	}
+
+	defined := make(map[string]bool, len(s.Variables))
+	for _, v := range s.Variables {
+		defined[v.Name] = true
+	}
+	pending := commandPlaceholders(strings.Join(append(s.Steps(), s.Workdir, s.PreCommand, s.PostCommand), "\n"))
+	var globals []Variable
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		global, ok := config.GlobalVariables[name]
+		if defined[name] || !ok {
+			continue
+		}
+		defined[name] = true
+		global.Name = name
+		globals = append(globals, global)
+		pending = append(pending, global.dependencies(config)...)
+	}
+	if len(globals) > 0 {
+		merged.Variables = append(slices.Clip(slices.Clone(s.Variables)), globals...)
+	}
+	return merged
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestWithGlobalVariables tests which global definitions a snippet picks up
+// and that local definitions shadow them
+func TestWithGlobalVariables(t *testing.T) {
+	config := &Config{GlobalVariables: map[string]Variable{
+		"registry": {DefaultValue: "ghcr.io"},
+		"tag":      {DefaultValue: "latest"},
+		"image":    {Computed: true, Transform: &Transform{Compose: "{{.registry}}/{{.name}}"}},
+		"unused":   {DefaultValue: "x"},
+	}}
+
+	tests := []struct {
+		name     string
+		snippet  Snippet
+		expected []string // merged variable names, in order
+		defaults map[string]string
+	}{
+		{
+			name:     "global fills a missing definition",
+			snippet:  Snippet{Command: "docker pull <registry>/app:<tag>"},
+			expected: []string{"registry", "tag"},
+			defaults: map[string]string{"registry": "ghcr.io", "tag": "latest"},
+		},
+		{
+			name:     "local definition shadows the global",
+			snippet:  Snippet{Command: "docker pull <registry>/app:<tag>", Variables: []Variable{{Name: "tag", DefaultValue: "v1"}}},
+			expected: []string{"tag", "registry"},
+			defaults: map[string]string{"registry": "ghcr.io", "tag": "v1"},
+		},
+		{
+			name:     "dependencies of a global are pulled in",
+			snippet:  Snippet{Command: "docker pull <image>", Variables: []Variable{{Name: "name"}}},
+			expected: []string{"name", "image", "registry"},
+		},
+		{
+			name:     "workdir placeholders count",
+			snippet:  Snippet{Command: "make", Workdir: "~/src/<tag>"},
+			expected: []string{"tag"},
+		},
+		{
+			name:     "escaped placeholders do not",
+			snippet:  Snippet{Command: "echo <<tag>>"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local := len(tt.snippet.Variables)
+			merged := tt.snippet.WithGlobalVariables(config)
+
+			var names []string
+			for _, v := range merged.Variables {
+				names = append(names, v.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected variables %v, got %v", tt.expected, names)
+			}
+			for _, v := range merged.Variables {
+				if want, ok := tt.defaults[v.Name]; ok && v.DefaultValue != want {
+					t.Errorf("Expected %s to default to %s, got %s", v.Name, want, v.DefaultValue)
+				}
+			}
+			if len(tt.snippet.Variables) != local {
+				t.Errorf("Expected the snippet to be left unchanged, got %v", tt.snippet.Variables)
+			}
+		})
+	}
+}
+
+// TestProcessTemplate_GlobalVariables tests rendering with global
+// definitions, including their transforms
+func TestProcessTemplate_GlobalVariables(t *testing.T) {
+	config := &Config{GlobalVariables: map[string]Variable{
+		"registry": {DefaultValue: "ghcr.io", Transform: &Transform{ValuePattern: "{{.Value}}/"}},
+	}}
+	snippet := Snippet{Command: "docker pull <registry>app"}
+
+	merged := snippet.WithGlobalVariables(config)
+	got, err := merged.ProcessTemplate(map[string]string{"registry": "ghcr.io"}, config)
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	if expected := "docker pull ghcr.io/app"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	merged.Variables[0].DefaultValue = "docker.io"
+	if config.GlobalVariables["registry"].DefaultValue != "ghcr.io" {
+		t.Error("Expected the global definition to be left unchanged")
+	}
+}
+
+// TestLint_GlobalVariables tests that placeholders defined globally are
+// not reported, while problems in the globals used are
+func TestLint_GlobalVariables(t *testing.T) {
+	config := &Config{GlobalVariables: map[string]Variable{
+		"registry": {},
+		"broken":   {Validation: &Validation{Pattern: "("}},
+	}}
+
+	if issues := (&Snippet{Command: "docker pull <registry>/app"}).Lint("test", config); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+
+	issues := (&Snippet{Command: "echo <broken>"}).Lint("test", config)
+	if len(issues) != 1 || !strings.Contains(issues[0].Message, "variable 'broken': invalid pattern") {
+		t.Errorf("Expected an invalid pattern error for the global, got %v", issues)
+	}
+}
@@ -0,0 +1,296 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Severity classifies a lint Issue.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a single problem found while linting a config. Kind and Name
+// identify the definition the issue belongs to, e.g. ("snippet", "docker-run").
+type Issue struct {
+	Severity Severity
+	Kind     string
+	Name     string
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+}
+
+// Lint checks every transform template, variable type, and snippet in the
+// config, plus the execution settings, for problems that would otherwise
+// only surface at exec time.
+// Issues are returned grouped by definition, in name order.
+func (c *Config) Lint() []Issue {
+	var issues []Issue
+
+	for _, name := range slices.Sorted(maps.Keys(c.TransformTemplates)) {
+		tmpl := c.TransformTemplates[name]
+		add := issueAdder(&issues, "transform template", name)
+		if tmpl.Transform == nil {
+			add(SeverityError, "no transform defined")
+			continue
+		}
+		lintTransform(tmpl.Transform, "", add)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.VariableTypes)) {
+		varType := c.VariableTypes[name]
+		add := issueAdder(&issues, "variable type", name)
+		if varType.Validation != nil {
+			lintValidation(varType.Validation, "", varType.Default, add)
+		}
+		if varType.Transform != nil {
+			lintTransform(varType.Transform, "", add)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Snippets)) {
+		snippet := c.Snippets[name]
+		issues = append(issues, snippet.Lint(name, c)...)
+	}
+
+	if timeout := c.Settings.Execution.Timeout; timeout != "" {
+		if _, err := parseTimeout(timeout); err != nil {
+			add := issueAdder(&issues, "settings", "execution")
+			add(SeverityError, err.Error())
+		}
+	}
+
+	return issues
+}
+
+// Lint checks a single snippet against config. name is the snippet's key in
+// Config.Snippets and is used to label the returned issues.
+// Global variables the snippet uses are checked as part of it.
+func (s *Snippet) Lint(name string, config *Config) []Issue {
+	merged := s.WithGlobalVariables(config)
+	s = &merged
+	var issues []Issue
+	add := issueAdder(&issues, "snippet", name)
+
+	defined := make(map[string]bool, len(s.Variables))
+	for _, v := range s.Variables {
+		if defined[v.Name] {
+			add(SeverityError, fmt.Sprintf("variable '%s' is defined more than once", v.Name))
+		}
+		defined[v.Name] = true
+	}
+
+	if s.Command != "" && len(s.Commands) > 0 {
+		add(SeverityError, "command and commands are mutually exclusive")
+	}
+	if s.Timeout != "" {
+		if _, err := parseTimeout(s.Timeout); err != nil {
+			add(SeverityError, err.Error())
+		}
+	}
+	switch s.ExecMode {
+	case "", "print", "run", "prompt":
+	default:
+		add(SeverityError, fmt.Sprintf("exec_mode '%s' must be print, run, or prompt", s.ExecMode))
+	}
+
+	used := make(map[string]bool)
+	for _, placeholder := range commandPlaceholders(strings.Join(s.Steps(), "\n")) {
+		used[placeholder] = true
+		if !defined[placeholder] {
+			add(SeverityError, fmt.Sprintf("placeholder <%s> has no matching variable", placeholder))
+		}
+	}
+	for _, field := range []struct{ name, text string }{
+		{"workdir", s.Workdir},
+		{"pre_command", s.PreCommand},
+		{"post_command", s.PostCommand},
+	} {
+		for _, placeholder := range commandPlaceholders(field.text) {
+			used[placeholder] = true
+			if !defined[placeholder] {
+				add(SeverityError, fmt.Sprintf("%s placeholder <%s> has no matching variable", field.name, placeholder))
+			}
+		}
+	}
+
+	for _, v := range s.Variables {
+		prefix := fmt.Sprintf("variable '%s': ", v.Name)
+		if v.Validation != nil && v.Validation.EnumFromMap {
+			if v = v.WithMapEnum(config); len(v.Validation.Enum) == 0 {
+				add(SeverityWarning, fmt.Sprintf("%senum_from_map has no transform map to take values from", prefix))
+			}
+		}
+
+		if v.TransformTemplate != "" {
+			if _, ok := config.TransformTemplates[v.TransformTemplate]; !ok {
+				add(SeverityError, fmt.Sprintf("%sunknown transform template '%s'", prefix, v.TransformTemplate))
+			}
+		}
+		if v.Transform != nil {
+			lintTransform(v.Transform, prefix, add)
+		}
+
+		var varType *VariableType
+		if v.Type != "" && !IsBuiltinType(v.Type) {
+			if t, ok := config.VariableTypes[v.Type]; ok {
+				varType = &t
+			} else {
+				add(SeverityError, fmt.Sprintf("%sunknown type '%s'", prefix, v.Type))
+			}
+		}
+
+		switch {
+		case v.Validation != nil && v.Validation.Multiple:
+			lintValidation(v.Validation, prefix, "", add)
+			for _, item := range v.ListItems(v.DefaultValue) {
+				lintEnumDefault(v.Validation, prefix, item, add)
+			}
+		case v.Validation != nil:
+			lintValidation(v.Validation, prefix, v.DefaultValue, add)
+		case varType != nil && varType.Validation != nil && v.DefaultValue != "":
+			lintEnumDefault(varType.Validation, prefix, v.DefaultValue, add)
+		}
+		if v.DefaultFromCommand != "" && !config.Settings.Execution.AllowDynamicDefaults {
+			add(SeverityWarning, fmt.Sprintf("%sdefault_from_command is ignored unless settings.execution.allow_dynamic_defaults is true", prefix))
+		}
+		if v.ItemValidation != nil {
+			itemPrefix := prefix + "item_validation: "
+			lintValidation(v.ItemValidation, itemPrefix, "", add)
+			for _, item := range v.ListItems(v.DefaultValue) {
+				lintEnumDefault(v.ItemValidation, itemPrefix, item, add)
+			}
+		}
+		if v.isDateType() && v.DefaultValue != "" {
+			if _, err := v.ResolveDate(v.DefaultValue); err != nil {
+				add(SeverityError, fmt.Sprintf("%sdefault '%s' is not a valid date in the format %s", prefix, v.DefaultValue, v.DateFormat()))
+			}
+		}
+
+		for _, c := range []struct {
+			field     string
+			condition *Condition
+		}{{"when", v.When}, {"required_if", v.RequiredIf}} {
+			if c.condition == nil {
+				continue
+			}
+			lintCondition(c.condition, prefix+c.field+": ", defined, add)
+			// Variables that only control visibility or requirement still
+			// count as used.
+			for _, dep := range c.condition.dependencies() {
+				used[dep] = true
+			}
+		}
+
+		// Computed variables can pull other variables in via compose.
+		if transform, err := v.ResolveTransform(config); err == nil && transform != nil {
+			if tpl, err := transform.composeTemplate(); err == nil && tpl != nil {
+				for _, field := range templateFields(tpl) {
+					used[field] = true
+				}
+			}
+		}
+	}
+
+	if _, err := s.OrderedVariables(config); err != nil {
+		add(SeverityError, err.Error())
+	}
+
+	for _, v := range s.Variables {
+		if !used[v.Name] {
+			add(SeverityWarning, fmt.Sprintf("variable '%s' is never used in the command", v.Name))
+		}
+	}
+
+	return issues
+}
+
+// issueAdder returns a closure appending issues for one definition.
+func issueAdder(issues *[]Issue, kind, name string) func(Severity, string) {
+	return func(severity Severity, message string) {
+		*issues = append(*issues, Issue{Severity: severity, Kind: kind, Name: name, Message: message})
+	}
+}
+
+// lintTransform reports Go templates and regexes in t that fail to parse.
+func lintTransform(t *Transform, prefix string, add func(Severity, string)) {
+	if _, err := t.valuePatternTemplate(); err != nil {
+		add(SeverityError, fmt.Sprintf("%sinvalid value_pattern template: %v", prefix, err))
+	}
+	if _, err := t.composeTemplate(); err != nil {
+		add(SeverityError, fmt.Sprintf("%sinvalid compose template: %v", prefix, err))
+	}
+	if t.RegexReplace != nil {
+		if _, err := t.RegexReplace.compiled(); err != nil {
+			add(SeverityError, fmt.Sprintf("%sinvalid regex_replace pattern: %v", prefix, err))
+		}
+	}
+}
+
+// lintCondition reports a when or required_if condition that cannot be
+// parsed or that names a variable the snippet does not define.
+func lintCondition(c *Condition, prefix string, defined map[string]bool, add func(Severity, string)) {
+	if c.Template != "" {
+		if _, err := c.template(); err != nil {
+			add(SeverityError, fmt.Sprintf("%sinvalid template: %v", prefix, err))
+			return
+		}
+	}
+	for _, dep := range c.dependencies() {
+		if !defined[dep] {
+			add(SeverityError, fmt.Sprintf("%sunknown variable '%s'", prefix, dep))
+		}
+	}
+}
+
+// lintValidation reports an uncompilable pattern or not_pattern, a
+// malformed range, and a default outside the enum.
+func lintValidation(v *Validation, prefix, defaultValue string, add func(Severity, string)) {
+	if v.Pattern != "" {
+		if _, err := v.compiledPattern(); err != nil {
+			add(SeverityError, fmt.Sprintf("%sinvalid pattern: %v", prefix, err))
+		}
+	}
+	for _, r := range append([][]float64{v.Range}, v.Ranges...) {
+		switch {
+		case r == nil:
+		case len(r) != 2:
+			add(SeverityError, fmt.Sprintf("%srange %v must be a [min, max] pair", prefix, r))
+		case r[0] > r[1]:
+			add(SeverityError, fmt.Sprintf("%srange %v has min greater than max", prefix, r))
+		}
+	}
+	if v.NotPattern != "" {
+		if _, err := v.compiledNotPattern(); err != nil {
+			add(SeverityError, fmt.Sprintf("%sinvalid not_pattern: %v", prefix, err))
+		}
+	}
+	lintEnumDefault(v, prefix, defaultValue, add)
+}
+
+// lintEnumDefault reports a default value that the enum would reject.
+func lintEnumDefault(v *Validation, prefix, defaultValue string, add func(Severity, string)) {
+	if defaultValue != "" && len(v.Enum) > 0 && !slices.Contains(v.Enum, defaultValue) {
+		add(SeverityError, fmt.Sprintf("%sdefault '%s' is not one of the allowed values", prefix, defaultValue))
+	}
+}
+
+// commandPlaceholders returns the unique placeholder names in command, in
+// first-seen order. Escaped <<name>> placeholders are not included.
+func commandPlaceholders(command string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatch(command, -1) {
+		if m[1] != "" && !slices.Contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,261 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestLint_TestdataIsClean ensures the shared fixtures stay lint-free
+func TestLint_TestdataIsClean(t *testing.T) {
+	config := loadTestConfig(t)
+
+	for _, issue := range config.Lint() {
+		if issue.Severity == SeverityError {
+			t.Errorf("unexpected error in %s '%s': %s", issue.Kind, issue.Name, issue.Message)
+		}
+	}
+}
+
+// TestLint_Snippet tests individual snippet checks
+func TestLint_Snippet(t *testing.T) {
+	config := &Config{
+		TransformTemplates: map[string]TransformTemplate{
+			"ns": {Transform: &Transform{ValuePattern: "-n {{.Value}}"}},
+		},
+		VariableTypes: map[string]VariableType{
+			"level": {Validation: &Validation{Enum: []string{"info", "debug"}}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		snippet  Snippet
+		severity Severity
+		contains string
+	}{
+		{
+			name:     "placeholder without variable",
+			snippet:  Snippet{Command: "echo <missing>"},
+			severity: SeverityError,
+			contains: "placeholder <missing>",
+		},
+		{
+			name:     "command and commands",
+			snippet:  Snippet{Command: "echo hi", Commands: []string{"echo a", "echo b"}},
+			severity: SeverityError,
+			contains: "mutually exclusive",
+		},
+		{
+			name:     "placeholder without variable in a step",
+			snippet:  Snippet{Commands: []string{"echo hi", "echo <missing>"}},
+			severity: SeverityError,
+			contains: "placeholder <missing>",
+		},
+		{
+			name:     "invalid timeout",
+			snippet:  Snippet{Command: "curl example.com", Timeout: "forever"},
+			severity: SeverityError,
+			contains: "invalid duration 'forever'",
+		},
+		{
+			name:     "workdir placeholder without variable",
+			snippet:  Snippet{Command: "terraform plan", Workdir: "~/infra/<env>"},
+			severity: SeverityError,
+			contains: "workdir placeholder <env>",
+		},
+		{
+			name:     "unknown exec_mode",
+			snippet:  Snippet{Command: "true", ExecMode: "always"},
+			severity: SeverityError,
+			contains: "exec_mode 'always' must be print, run, or prompt",
+		},
+		{
+			name:     "hook placeholder without variable",
+			snippet:  Snippet{Command: "kubectl get pods", PreCommand: "kubectl config use-context <ctx>"},
+			severity: SeverityError,
+			contains: "pre_command placeholder <ctx>",
+		},
+		{
+			name: "unused variable",
+			snippet: Snippet{
+				Command:   "echo hi",
+				Variables: []Variable{{Name: "extra"}},
+			},
+			severity: SeverityWarning,
+			contains: "'extra' is never used",
+		},
+		{
+			name: "unknown transform template",
+			snippet: Snippet{
+				Command:   "kubectl get pods <ns>",
+				Variables: []Variable{{Name: "ns", TransformTemplate: "nope"}},
+			},
+			severity: SeverityError,
+			contains: "unknown transform template 'nope'",
+		},
+		{
+			name: "unknown type",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Type: "nope"}},
+			},
+			severity: SeverityError,
+			contains: "unknown type 'nope'",
+		},
+		{
+			name: "invalid pattern",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Validation: &Validation{Pattern: "[a-"}}},
+			},
+			severity: SeverityError,
+			contains: "invalid pattern",
+		},
+		{
+			name: "malformed value pattern",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Transform: &Transform{ValuePattern: "{{ .Value"}}},
+			},
+			severity: SeverityError,
+			contains: "invalid value_pattern",
+		},
+		{
+			name: "malformed compose",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Computed: true, Transform: &Transform{Compose: "{{ if }}"}}},
+			},
+			severity: SeverityError,
+			contains: "invalid compose",
+		},
+		{
+			name: "enum default not in enum",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", DefaultValue: "x", Validation: &Validation{Enum: []string{"a", "b"}}}},
+			},
+			severity: SeverityError,
+			contains: "default 'x'",
+		},
+		{
+			name: "default not in type enum",
+			snippet: Snippet{
+				Command:   "app <lvl>",
+				Variables: []Variable{{Name: "lvl", Type: "level", DefaultValue: "trace"}},
+			},
+			severity: SeverityError,
+			contains: "default 'trace'",
+		},
+		{
+			name: "multiple enum default item not in enum",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", DefaultValue: "a,z", Validation: &Validation{Enum: []string{"a", "b"}, Multiple: true}}},
+			},
+			severity: SeverityError,
+			contains: "default 'z'",
+		},
+		{
+			name: "invalid date default",
+			snippet: Snippet{
+				Command:   "logs --since <since>",
+				Variables: []Variable{{Name: "since", Type: VarTypeDate, DefaultValue: "last week"}},
+			},
+			severity: SeverityError,
+			contains: "not a valid date in the format 2006-01-02",
+		},
+		{
+			name: "malformed range",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Validation: &Validation{Ranges: [][]float64{{10, 1}}}}},
+			},
+			severity: SeverityError,
+			contains: "min greater than max",
+		},
+		{
+			name: "invalid regex_replace",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Transform: &Transform{RegexReplace: &RegexReplace{Pattern: "[a-"}}}},
+			},
+			severity: SeverityError,
+			contains: "variable 'v': invalid regex_replace pattern",
+		},
+		{
+			name: "invalid not_pattern",
+			snippet: Snippet{
+				Command:   "echo <v>",
+				Variables: []Variable{{Name: "v", Validation: &Validation{NotPattern: "("}}},
+			},
+			severity: SeverityError,
+			contains: "invalid not_pattern",
+		},
+		{
+			name: "when on unknown variable",
+			snippet: Snippet{
+				Command:   "curl <cert>",
+				Variables: []Variable{{Name: "cert", When: &Condition{Variable: "tls", Equals: "true"}}},
+			},
+			severity: SeverityError,
+			contains: "when: unknown variable 'tls'",
+		},
+		{
+			name: "when cycle",
+			snippet: Snippet{
+				Command: "echo <a> <b>",
+				Variables: []Variable{
+					{Name: "a", When: &Condition{Variable: "b", Equals: "x"}},
+					{Name: "b", When: &Condition{Template: "{{.a}}"}},
+				},
+			},
+			severity: SeverityError,
+			contains: "variable dependencies form a cycle: a -> b -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := tt.snippet.Lint("test", config)
+			for _, issue := range issues {
+				if issue.Severity == tt.severity && strings.Contains(issue.Message, tt.contains) {
+					return
+				}
+			}
+			t.Errorf("Expected %s containing %q, got %v", tt.severity, tt.contains, issues)
+		})
+	}
+}
+
+// TestLint_ComposeCountsAsUse tests that variables referenced only by a
+// computed variable's compose template aren't reported as unused
+func TestLint_ComposeCountsAsUse(t *testing.T) {
+	snippet := Snippet{
+		Command: "app <ref>",
+		Variables: []Variable{
+			{Name: "remote"},
+			{Name: "branch"},
+			{Name: "ref", Computed: true, Transform: &Transform{
+				Compose: "{{if .remote}}{{.remote}}/{{end}}{{.branch}}",
+			}},
+		},
+	}
+
+	if issues := snippet.Lint("test", &Config{}); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+// TestLint_EscapedPlaceholders tests that <<name>> escapes are not treated
+// as placeholders needing a variable
+func TestLint_EscapedPlaceholders(t *testing.T) {
+	snippet := Snippet{
+		Command:   "echo <<stdin>> | tee <file>",
+		Variables: []Variable{{Name: "file"}},
+	}
+
+	if issues := snippet.Lint("test", &Config{}); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
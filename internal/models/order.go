@@ -0,0 +1,173 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OrderedVariables returns the snippet's variables in the order the form
+// asks for them. Variables with an order come first, lowest first, and the
+// rest follow in declaration order; then each variable is moved after the
+// variables its compose template or when condition reads. A dependency
+// cycle is an error naming the cycle.
+func (s *Snippet) OrderedVariables(config *Config) ([]Variable, error) {
+	variables := make([]*Variable, len(s.Variables))
+	for i := range s.Variables {
+		variables[i] = &s.Variables[i]
+	}
+	slices.SortStableFunc(variables, func(a, b *Variable) int {
+		if (a.Order == 0) != (b.Order == 0) {
+			if a.Order == 0 {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.Order, b.Order)
+	})
+
+	sorted, err := sortByDependencies(variables, config)
+	if err != nil {
+		return nil, err
+	}
+	ordered := make([]Variable, len(sorted))
+	for i, v := range sorted {
+		ordered[i] = *v
+	}
+	return ordered, nil
+}
+
+// resolutionOrder returns the variables in declaration order, except that
+// each comes after the variables its compose template or when condition
+// reads. A dependency cycle is an error.
+func (s *Snippet) resolutionOrder(config *Config) ([]*Variable, error) {
+	variables := make([]*Variable, len(s.Variables))
+	for i := range s.Variables {
+		variables[i] = &s.Variables[i]
+	}
+	return sortByDependencies(variables, config)
+}
+
+// sortByDependencies moves each of variables after those its compose
+// template or when condition reads.
+func sortByDependencies(variables []*Variable, config *Config) ([]*Variable, error) {
+	return dependencyOrder(variables, "variable dependencies", func(v *Variable) []string {
+		return v.dependencies(config)
+	})
+}
+
+// VariableGroup is a run of variables shown under one heading; Name is
+// empty for variables without a group.
+type VariableGroup struct {
+	Name      string
+	Variables []Variable
+}
+
+// GroupVariables splits variables by their group, keeping their order
+// within each. Ungrouped variables come first, then each group in the order
+// it first appears.
+func GroupVariables(variables []Variable) []VariableGroup {
+	groups := []VariableGroup{{}}
+	index := map[string]int{"": 0}
+	for _, v := range variables {
+		i, ok := index[v.Group]
+		if !ok {
+			i = len(groups)
+			index[v.Group] = i
+			groups = append(groups, VariableGroup{Name: v.Group})
+		}
+		groups[i].Variables = append(groups[i].Variables, v)
+	}
+	if len(groups[0].Variables) == 0 {
+		groups = groups[1:]
+	}
+	return groups
+}
+
+// Dependents returns the names of the snippet's variables whose compose
+// template or when condition reads name, directly or through other
+// variables.
+func (s *Snippet) Dependents(name string, config *Config) map[string]bool {
+	dependents := make(map[string]bool)
+	for changed := true; changed; {
+		changed = false
+		for i := range s.Variables {
+			v := &s.Variables[i]
+			if dependents[v.Name] || v.Name == name {
+				continue
+			}
+			for _, dep := range v.dependencies(config) {
+				if dep == name || dependents[dep] {
+					dependents[v.Name] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	return dependents
+}
+
+// dependencies returns the names of the variables v's compose template and
+// when condition read.
+func (v *Variable) dependencies(config *Config) []string {
+	var deps []string
+	if v.When != nil {
+		deps = append(deps, v.When.dependencies()...)
+	}
+	if transform, err := v.ResolveTransform(config); err == nil && transform != nil {
+		if tpl, err := transform.composeTemplate(); err == nil && tpl != nil {
+			deps = append(deps, templateFields(tpl)...)
+		}
+	}
+	return deps
+}
+
+// dependencyOrder sorts variables so that each comes after those of them it
+// depends on, keeping the given order otherwise. Dependencies outside
+// variables are ignored. what names the dependencies in a cycle error.
+func dependencyOrder(variables []*Variable, what string, deps func(*Variable) []string) ([]*Variable, error) {
+	byName := make(map[string]*Variable, len(variables))
+	for _, v := range variables {
+		byName[v.Name] = v
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int)
+	var order []*Variable
+	var path []string
+	var visit func(v *Variable) error
+	visit = func(v *Variable) error {
+		switch state[v.Name] {
+		case visiting:
+			start := slices.Index(path, v.Name)
+			return fmt.Errorf("%s form a cycle: %s -> %s", what, strings.Join(path[start:], " -> "), v.Name)
+		case done:
+			return nil
+		}
+		state[v.Name] = visiting
+		path = append(path, v.Name)
+		for _, dep := range deps(v) {
+			if d, ok := byName[dep]; ok {
+				if err := visit(d); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[v.Name] = done
+		order = append(order, v)
+		return nil
+	}
+
+	for _, v := range variables {
+		if err := visit(v); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
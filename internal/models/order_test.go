@@ -0,0 +1,147 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestOrderedVariables tests explicit order combined with dependency order
+func TestOrderedVariables(t *testing.T) {
+	config := &Config{
+		TransformTemplates: map[string]TransformTemplate{
+			"endpoint": {Transform: &Transform{Compose: "{{.host}}:{{.port}}"}},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		variables []Variable
+		expected  []string
+	}{
+		{
+			name:      "declaration order by default",
+			variables: []Variable{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			expected:  []string{"a", "b", "c"},
+		},
+		{
+			name:      "explicit order comes first",
+			variables: []Variable{{Name: "a"}, {Name: "b", Order: 2}, {Name: "c"}, {Name: "d", Order: 1}},
+			expected:  []string{"d", "b", "a", "c"},
+		},
+		{
+			name: "when dependency moves earlier",
+			variables: []Variable{
+				{Name: "cert", Order: 1, When: &Condition{Variable: "tls", Equals: "true"}},
+				{Name: "url"},
+				{Name: "tls"},
+			},
+			expected: []string{"tls", "cert", "url"},
+		},
+		{
+			name: "compose dependency moves earlier",
+			variables: []Variable{
+				{Name: "endpoint", Computed: true, TransformTemplate: "endpoint"},
+				{Name: "port", Order: 2},
+				{Name: "name", Order: 1},
+				{Name: "host"},
+			},
+			expected: []string{"name", "port", "host", "endpoint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{Variables: tt.variables}
+			ordered, err := snippet.OrderedVariables(config)
+			if err != nil {
+				t.Fatalf("OrderedVariables failed: %v", err)
+			}
+			var names []string
+			for _, v := range ordered {
+				names = append(names, v.Name)
+			}
+			if !slices.Equal(names, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+// TestOrderedVariables_Cycle tests that a dependency cycle is named in the error
+func TestOrderedVariables_Cycle(t *testing.T) {
+	snippet := Snippet{
+		Variables: []Variable{
+			{Name: "a", Computed: true, Transform: &Transform{Compose: "{{.b}}"}},
+			{Name: "b", When: &Condition{Variable: "c", Equals: "x"}},
+			{Name: "c", Computed: true, Transform: &Transform{Compose: "{{.a}}"}},
+		},
+	}
+
+	_, err := snippet.OrderedVariables(&Config{})
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("Expected a cycle error naming a -> b -> c -> a, got %v", err)
+	}
+}
+
+// TestDependents tests direct and indirect dependents, including cycles
+func TestDependents(t *testing.T) {
+	snippet := Snippet{
+		Variables: []Variable{
+			{Name: "host"},
+			{Name: "port"},
+			{Name: "endpoint", Computed: true, Transform: &Transform{Compose: "{{.host}}:{{.port}}"}},
+			{Name: "url", Computed: true, Transform: &Transform{Compose: "https://{{.endpoint}}"}},
+			{Name: "cert", When: &Condition{Variable: "host", Equals: "prod"}},
+			{Name: "a", Computed: true, Transform: &Transform{Compose: "{{.b}}{{.port}}"}},
+			{Name: "b", Computed: true, Transform: &Transform{Compose: "{{.a}}"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"host", []string{"cert", "endpoint", "url"}},
+		{"port", []string{"a", "b", "endpoint", "url"}},
+		{"url", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := slices.Sorted(maps.Keys(snippet.Dependents(tt.name, &Config{})))
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestGroupVariables tests that ungrouped variables lead and groups keep
+// their first-seen order
+func TestGroupVariables(t *testing.T) {
+	variables := []Variable{
+		{Name: "cert", Group: "TLS"},
+		{Name: "url"},
+		{Name: "user", Group: "Auth"},
+		{Name: "key", Group: "TLS"},
+		{Name: "verbose"},
+	}
+
+	var got []string
+	for _, group := range GroupVariables(variables) {
+		names := make([]string, len(group.Variables))
+		for i, v := range group.Variables {
+			names[i] = v.Name
+		}
+		got = append(got, group.Name+":"+strings.Join(names, ","))
+	}
+	if expected := []string{":url,verbose", "TLS:cert,key", "Auth:user"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	if groups := GroupVariables([]Variable{{Name: "a", Group: "G"}}); len(groups) != 1 || groups[0].Name != "G" {
+		t.Errorf("Expected no empty default group, got %+v", groups)
+	}
+}
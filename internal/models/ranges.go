@@ -0,0 +1,82 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML reads a validation, letting range hold either a single
+// [min, max] pair or a list of them; the latter is the same as ranges.
+func (v *Validation) UnmarshalYAML(node *yaml.Node) error {
+	type plain Validation
+
+	var nested *yaml.Node
+	if node.Kind == yaml.MappingNode {
+		stripped := *node
+		stripped.Content = nil
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "range" && value.Kind == yaml.SequenceNode && len(value.Content) > 0 && value.Content[0].Kind == yaml.SequenceNode {
+				nested = value
+				continue
+			}
+			stripped.Content = append(stripped.Content, key, value)
+		}
+		node = &stripped
+	}
+
+	if err := node.Decode((*plain)(v)); err != nil {
+		return err
+	}
+	if nested != nil {
+		var ranges [][]float64
+		if err := nested.Decode(&ranges); err != nil {
+			return err
+		}
+		v.Ranges = append(ranges, v.Ranges...)
+	}
+	return nil
+}
+
+// AllowedRanges returns the [min, max] pairs a numeric value must fall in
+// one of: range followed by ranges. Malformed pairs are left out; lint
+// reports them.
+func (v *Validation) AllowedRanges() [][2]float64 {
+	var ranges [][2]float64
+	for _, r := range append([][]float64{v.Range}, v.Ranges...) {
+		if len(r) == 2 {
+			ranges = append(ranges, [2]float64{r[0], r[1]})
+		}
+	}
+	return ranges
+}
+
+// inRanges reports whether num falls in any of ranges.
+func inRanges(num float64, ranges [][2]float64) bool {
+	return slices.ContainsFunc(ranges, func(r [2]float64) bool {
+		return num >= r[0] && num <= r[1]
+	})
+}
+
+// describeRanges lists ranges compactly for error messages, such as
+// "between 1 and 10" or "80, 443, or 1024-49151".
+func describeRanges(ranges [][2]float64) string {
+	if len(ranges) == 1 && ranges[0][0] != ranges[0][1] {
+		return fmt.Sprintf("between %g and %g", ranges[0][0], ranges[0][1])
+	}
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		if r[0] == r[1] {
+			parts[i] = fmt.Sprintf("%g", r[0])
+		} else {
+			parts[i] = fmt.Sprintf("%g-%g", r[0], r[1])
+		}
+	}
+	if len(parts) <= 2 {
+		return strings.Join(parts, " or ")
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + ", or " + parts[len(parts)-1]
+}
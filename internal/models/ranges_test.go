@@ -0,0 +1,71 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestValidation_RangeYAML tests that old single-range configs still load
+// alongside the multi-range forms
+func TestValidation_RangeYAML(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected [][2]float64
+	}{
+		{"single range", "range: [1, 65535]", [][2]float64{{1, 65535}}},
+		{"nested range", "range: [[80, 80], [443, 443]]", [][2]float64{{80, 80}, {443, 443}}},
+		{"ranges", "ranges: [[80, 80], [1024, 49151]]", [][2]float64{{80, 80}, {1024, 49151}}},
+		{"range and ranges", "range: [1, 10]\nranges: [[20, 30]]", [][2]float64{{1, 10}, {20, 30}}},
+		{"nested range and ranges", "range: [[1, 10]]\nranges: [[20, 30]]", [][2]float64{{1, 10}, {20, 30}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validation
+			if err := yaml.Unmarshal([]byte(tt.yaml+"\npattern: x"), &v); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if got := v.AllowedRanges(); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected ranges %v, got %v", tt.expected, got)
+			}
+			if v.Pattern != "x" {
+				t.Errorf("Expected other fields to load, got pattern %q", v.Pattern)
+			}
+		})
+	}
+}
+
+// TestValidate_Ranges tests that a value in any range is valid
+func TestValidate_Ranges(t *testing.T) {
+	variable := Variable{
+		Name:       "port",
+		Validation: &Validation{Ranges: [][]float64{{80, 80}, {443, 443}, {1024, 49151}}},
+	}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"exact port", "443", ""},
+		{"in range", "8080", ""},
+		{"upper bound", "49151", ""},
+		{"outside", "22", "variable port must be 80, 443, or 1024-49151, got 22"},
+		{"above", "50000", "variable port must be 80, 443, or 1024-49151, got 50000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := variable.Validate(tt.value)
+			switch {
+			case tt.expected == "" && err != nil:
+				t.Errorf("Unexpected error: %v", err)
+			case tt.expected != "" && (err == nil || err.Error() != tt.expected):
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package models
+
+import "slices"
+
+// Resolved returns a copy of the snippet that no longer depends on config:
+// global variables it uses are added as with WithGlobalVariables, transform
+// templates are copied in as inline transforms, and variables of a
+// user-defined type take the type's default and validation where they
+// have none of their own and drop the type. A missing transform template
+// is an error.
+func (s *Snippet) Resolved(config *Config) (Snippet, error) {
+	resolved := s.WithGlobalVariables(config)
+	resolved.Variables = slices.Clone(resolved.Variables)
+	for i := range resolved.Variables {
+		v := &resolved.Variables[i]
+		if v.TransformTemplate != "" {
+			transform, err := v.ResolveTransform(config)
+			if err != nil {
+				return Snippet{}, err
+			}
+			v.Transform, v.TransformTemplate = transform, ""
+		}
+
+		if v.Type == "" || IsBuiltinType(v.Type) || config == nil {
+			continue
+		}
+		varType, ok := config.VariableTypes[v.Type]
+		if !ok {
+			continue
+		}
+		if v.DefaultValue == "" {
+			v.DefaultValue = varType.Default
+			if v.DefaultFromEnv == "" {
+				v.DefaultFromEnv = varType.DefaultFromEnv
+			}
+		}
+		if v.Validation == nil {
+			v.Validation = varType.Validation
+		}
+		v.Type = ""
+	}
+	return resolved, nil
+}
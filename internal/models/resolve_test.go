@@ -0,0 +1,54 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestResolved tests that a resolved snippet carries its globals, type
+// defaults, and transform templates itself, and that the original is left
+// unchanged
+func TestResolved(t *testing.T) {
+	config := &Config{
+		TransformTemplates: map[string]TransformTemplate{
+			"ns-flag": {Transform: &Transform{ValuePattern: "-n {{.Value}}"}},
+		},
+		VariableTypes: map[string]VariableType{
+			"port": {Default: "8080", Validation: &Validation{Range: []float64{1, 65535}}},
+		},
+		GlobalVariables: map[string]Variable{
+			"registry": {DefaultValue: "ghcr.io"},
+		},
+	}
+	snippet := Snippet{
+		Command: "run <registry> <ns> <port> <mode>",
+		Variables: []Variable{
+			{Name: "ns", TransformTemplate: "ns-flag"},
+			{Name: "port", Type: "port"},
+			{Name: "mode", Type: VarTypeBoolean},
+		},
+	}
+
+	resolved, err := snippet.Resolved(config)
+	if err != nil {
+		t.Fatalf("Resolved failed: %v", err)
+	}
+	expected := []Variable{
+		{Name: "ns", Transform: config.TransformTemplates["ns-flag"].Transform},
+		{Name: "port", DefaultValue: "8080", Validation: config.VariableTypes["port"].Validation},
+		{Name: "mode", Type: VarTypeBoolean},
+		{Name: "registry", DefaultValue: "ghcr.io"},
+	}
+	if !reflect.DeepEqual(resolved.Variables, expected) {
+		t.Errorf("Expected variables %+v, got %+v", expected, resolved.Variables)
+	}
+	if snippet.Variables[0].TransformTemplate != "ns-flag" || snippet.Variables[1].Type != "port" {
+		t.Error("Expected the original snippet to be left unchanged")
+	}
+
+	snippet.Variables[0].TransformTemplate = "missing"
+	if _, err := snippet.Resolved(config); err == nil || !strings.Contains(err.Error(), "'missing' not found") {
+		t.Errorf("Expected a missing template error, got %v", err)
+	}
+}
@@ -1,12 +1,19 @@
 package models
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 	"text/template"
+	"text/template/parse"
+	"time"
 )
 
 // SnippetSource represents where a snippet was loaded from
@@ -18,13 +25,33 @@ const (
 )
 
 // Built-in variable type identifiers. User-defined types in
-// Config.VariableTypes use arbitrary strings; these are the two the engine
+// Config.VariableTypes use arbitrary strings; these are the ones the engine
 // treats specially.
 const (
-	VarTypeBoolean = "boolean"
-	VarTypeRegex   = "regex"
+	VarTypeBoolean   = "boolean"
+	VarTypeRegex     = "regex"
+	VarTypeSecret    = "secret"
+	VarTypeMultiline = "multiline"
+	VarTypeFilepath  = "filepath"
+	VarTypeDirpath   = "dirpath"
+	VarTypeDate      = "date"
+	VarTypeDatetime  = "datetime"
+	VarTypeInteger   = "integer"
+	VarTypeFloat     = "float"
+	VarTypeList      = "list"
 )
 
+// IsBuiltinType reports whether name is a variable type handled by the
+// engine itself rather than defined in Config.VariableTypes.
+func IsBuiltinType(name string) bool {
+	switch name {
+	case VarTypeBoolean, VarTypeRegex, VarTypeSecret, VarTypeMultiline, VarTypeFilepath, VarTypeDirpath,
+		VarTypeDate, VarTypeDatetime, VarTypeInteger, VarTypeFloat, VarTypeList:
+		return true
+	}
+	return false
+}
+
 // parseBool returns true for the truthy string forms accepted by snippet
 // boolean variables. Anything else is false (including the empty string).
 func parseBool(s string) bool {
@@ -37,29 +64,97 @@ func parseBool(s string) bool {
 
 // placeholderPattern matches <name> tokens in command templates. Variable
 // names are letters/digits/underscores starting with a letter or underscore.
-var placeholderPattern = regexp.MustCompile(`<([A-Za-z_][A-Za-z0-9_]*)>`)
+// <<name>> escapes a placeholder: it matches with an empty name and stands
+// for the literal text <name>.
+var placeholderPattern = regexp.MustCompile(`<<[A-Za-z_][A-Za-z0-9_]*>>|<([A-Za-z_][A-Za-z0-9_]*)>`)
+
+// isEscapedPlaceholder reports whether a placeholderPattern match is a
+// <<name>> escape rather than a placeholder.
+func isEscapedPlaceholder(match string) bool {
+	return strings.HasPrefix(match, "<<")
+}
 
 // Snippet represents a command template
 type Snippet struct {
-	Name        string        `yaml:"name"`
-	Description string        `yaml:"description"`
-	Command     string        `yaml:"command"`
-	Variables   []Variable    `yaml:"variables,omitempty"`
-	Tags        []string      `yaml:"tags,omitempty"`
-	Source      SnippetSource `yaml:"-"` // Not persisted to YAML, set during loading
+	Name                   string        `yaml:"name"`
+	Description            string        `yaml:"description"`
+	Command                string        `yaml:"command,omitempty"`
+	Commands               []string      `yaml:"commands,omitempty"` // steps run in sequence; replaces command
+	Variables              []Variable    `yaml:"variables,omitempty"`
+	Tags                   []string      `yaml:"tags,omitempty"`
+	Aliases                []string      `yaml:"aliases,omitempty"` // other names the snippet can be invoked by
+	Favorite               bool          `yaml:"favorite,omitempty"`
+	ExpandEnv              bool          `yaml:"expand_env,omitempty"`               // expand $VARS in the rendered command
+	Workdir                string        `yaml:"workdir,omitempty"`                  // directory to run in; may contain <placeholders>
+	PreCommand             string        `yaml:"pre_command,omitempty"`              // run before the command when executed; may contain <placeholders>
+	PostCommand            string        `yaml:"post_command,omitempty"`             // run after the command when executed, with $CS_EXIT_CODE set
+	ContinueOnError        bool          `yaml:"continue_on_error,omitempty"`        // keep running steps after one fails
+	Timeout                string        `yaml:"timeout,omitempty"`                  // overrides settings.execution.timeout, e.g. "30s"
+	ExecMode               string        `yaml:"exec_mode,omitempty"`                // print, run, or prompt when exec is given neither --run nor --prompt
+	Dangerous              bool          `yaml:"dangerous,omitempty"`                // always confirm before running, even with --run
+	ConfirmValues          bool          `yaml:"confirm_values,omitempty"`           // review the values before the form submits
+	ComposeUsesTransformed bool          `yaml:"compose_uses_transformed,omitempty"` // compose sees values after their transforms
+	CollapseWhitespace     *bool         `yaml:"collapse_whitespace,omitempty"`      // overrides settings.output.collapse_whitespace
+	CreatedAt              time.Time     `yaml:"created_at,omitempty"`
+	UpdatedAt              time.Time     `yaml:"updated_at,omitempty"`
+	Source                 SnippetSource `yaml:"-"` // Not persisted to YAML, set during loading
+	SourceFile             string        `yaml:"-"` // File the snippet was loaded from, set during loading
 }
 
 // Variable defines a template variable with advanced behavior
 type Variable struct {
-	Name              string      `yaml:"name"`
-	Description       string      `yaml:"description,omitempty"`
-	DefaultValue      string      `yaml:"default,omitempty"`
-	Required          bool        `yaml:"required,omitempty"`
-	Type              string      `yaml:"type,omitempty"`
-	Transform         *Transform  `yaml:"transform,omitempty"`
-	TransformTemplate string      `yaml:"transform_template,omitempty"`
-	Validation        *Validation `yaml:"validation,omitempty"`
-	Computed          bool        `yaml:"computed,omitempty"`
+	Name               string      `yaml:"name"`
+	Description        string      `yaml:"description,omitempty"`
+	DefaultValue       string      `yaml:"default,omitempty"`
+	DefaultFromEnv     string      `yaml:"default_from_env,omitempty"`     // env var whose value, when set, beats default
+	DefaultFromCommand string      `yaml:"default_from_command,omitempty"` // output is the default; see AllowDynamicDefaults
+	Required           bool        `yaml:"required,omitempty"`
+	Type               string      `yaml:"type,omitempty"`
+	Transform          *Transform  `yaml:"transform,omitempty"`
+	TransformTemplate  string      `yaml:"transform_template,omitempty"`
+	Validation         *Validation `yaml:"validation,omitempty"`
+	Computed           bool        `yaml:"computed,omitempty"`
+	// Separator joins the items of a list variable or multiple-choice enum
+	// (default ",").
+	Separator string `yaml:"separator,omitempty"`
+	// ItemValidation applies to each item of a list variable.
+	ItemValidation *Validation `yaml:"item_validation,omitempty"`
+	// When hides the variable unless the condition holds. Hidden variables
+	// are not prompted for or validated, and render as empty.
+	When *Condition `yaml:"when,omitempty"`
+	// RequiredIf makes the variable required while the condition holds.
+	RequiredIf *Condition `yaml:"required_if,omitempty"`
+	// Order places the variable in the form; variables with an order come
+	// before those without, lowest first.
+	Order int `yaml:"order,omitempty"`
+	// Group names the form section the variable is shown in.
+	Group string `yaml:"group,omitempty"`
+	// Quote single-quotes the text substituted for the variable so the
+	// shell sees it as one word.
+	Quote bool `yaml:"quote,omitempty"`
+	// SourceFile is the file a global variable was loaded from, set during
+	// loading.
+	SourceFile string `yaml:"-"`
+}
+
+// DefaultListSeparator joins list items when a variable sets no separator.
+const DefaultListSeparator = ","
+
+// ListSeparator returns the string that joins the variable's list items.
+func (v *Variable) ListSeparator() string {
+	return cmp.Or(v.Separator, DefaultListSeparator)
+}
+
+// ListItems splits the value of a list variable, or of a multiple-choice
+// enum, into its non-empty items.
+func (v *Variable) ListItems(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, v.ListSeparator()) {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
 
 // Transform defines conditional transformations
@@ -69,6 +164,12 @@ type Transform struct {
 	TrueValue    string `yaml:"true_value,omitempty"`
 	FalseValue   string `yaml:"false_value,omitempty"`
 	Compose      string `yaml:"compose,omitempty"`
+	// RegexReplace rewrites the value before Map and value_pattern.
+	RegexReplace *RegexReplace `yaml:"regex_replace,omitempty"`
+	// Map translates values before value_pattern sees them; values not in
+	// it become MapDefault, or are kept if that is empty.
+	Map        map[string]string `yaml:"map,omitempty"`
+	MapDefault string            `yaml:"map_default,omitempty"`
 
 	composeTpl      *template.Template
 	composeTplErr   error
@@ -83,11 +184,43 @@ func (t *Transform) composeTemplate() (*template.Template, error) {
 		return nil, nil
 	}
 	if t.composeTpl == nil && t.composeTplErr == nil {
-		t.composeTpl, t.composeTplErr = template.New("compose").Parse(t.Compose)
+		t.composeTpl, t.composeTplErr = newTemplate("compose", t.Compose)
 	}
 	return t.composeTpl, t.composeTplErr
 }
 
+// RegexReplace replaces each match of Pattern with Replacement, which may
+// refer to capture groups as $1 or ${name}.
+type RegexReplace struct {
+	Pattern     string `yaml:"pattern"`
+	Replacement string `yaml:"replacement"`
+
+	re    *regexp.Regexp
+	reErr error
+}
+
+// compiled returns the compiled Pattern, caching the result.
+func (r *RegexReplace) compiled() (*regexp.Regexp, error) {
+	if r.re == nil && r.reErr == nil {
+		r.re, r.reErr = regexp.Compile(r.Pattern)
+	}
+	return r.re, r.reErr
+}
+
+// lookup translates value through Map.
+func (t *Transform) lookup(value string) string {
+	if len(t.Map) == 0 {
+		return value
+	}
+	if mapped, ok := t.Map[value]; ok {
+		return mapped
+	}
+	if t.MapDefault != "" {
+		return t.MapDefault
+	}
+	return value
+}
+
 // valuePatternTemplate returns the parsed ValuePattern template, caching the result.
 // Returns (nil, nil) when ValuePattern is empty.
 func (t *Transform) valuePatternTemplate() (*template.Template, error) {
@@ -95,19 +228,98 @@ func (t *Transform) valuePatternTemplate() (*template.Template, error) {
 		return nil, nil
 	}
 	if t.valuePatternTpl == nil && t.valuePatternErr == nil {
-		t.valuePatternTpl, t.valuePatternErr = template.New("transform").Parse(t.ValuePattern)
+		t.valuePatternTpl, t.valuePatternErr = newTemplate("transform", t.ValuePattern)
 	}
 	return t.valuePatternTpl, t.valuePatternErr
 }
 
+// templateFields returns the top-level field names (.name) referenced
+// anywhere in tpl, in first-seen order.
+func templateFields(tpl *template.Template) []string {
+	var fields []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			if len(n.Ident) > 0 && !slices.Contains(fields, n.Ident[0]) {
+				fields = append(fields, n.Ident[0])
+			}
+		}
+	}
+	if tpl != nil && tpl.Tree != nil {
+		walk(tpl.Tree.Root)
+	}
+	return fields
+}
+
 // Validation defines variable validation rules
 type Validation struct {
-	Pattern string   `yaml:"pattern,omitempty"`
-	Enum    []string `yaml:"enum,omitempty"`
-	Range   []int    `yaml:"range,omitempty"`
+	Pattern string    `yaml:"pattern,omitempty"`
+	Enum    []string  `yaml:"enum,omitempty"`
+	Range   []float64 `yaml:"range,omitempty"`
+	// Ranges lists further [min, max] pairs; a value in any of them, or in
+	// Range, is valid.
+	Ranges [][]float64 `yaml:"ranges,omitempty"`
+	// NotPattern rejects values that match it.
+	NotPattern string `yaml:"not_pattern,omitempty"`
+	// Multiple lets an enum variable take several of its options, joined by
+	// the variable's separator.
+	Multiple bool `yaml:"multiple,omitempty"`
+	// Step is how much the form's increment and decrement keys change
+	// integer and float variables by (default 1).
+	Step float64 `yaml:"step,omitempty"`
+	// MustExist requires the value to name an existing path (a directory
+	// for dirpath variables). A leading ~ is expanded for the check.
+	MustExist bool `yaml:"must_exist,omitempty"`
+	// Format is the Go time layout for date and datetime variables.
+	Format string `yaml:"format,omitempty"`
+	// EnumFromMap restricts the value to the keys of the variable's
+	// transform map when Enum is empty.
+	EnumFromMap bool `yaml:"enum_from_map,omitempty"`
+	// Message replaces the default explanation when the value fails the
+	// pattern, not_pattern, enum, or range.
+	Message string `yaml:"message,omitempty"`
 
-	patternRE  *regexp.Regexp
-	patternErr error
+	patternRE     *regexp.Regexp
+	patternErr    error
+	notPatternRE  *regexp.Regexp
+	notPatternErr error
 }
 
 // compiledPattern returns the compiled Pattern regex, caching the result.
@@ -118,10 +330,19 @@ func (v *Validation) compiledPattern() (*regexp.Regexp, error) {
 	return v.patternRE, v.patternErr
 }
 
+// compiledNotPattern returns the compiled NotPattern regex, caching the result.
+func (v *Validation) compiledNotPattern() (*regexp.Regexp, error) {
+	if v.notPatternRE == nil && v.notPatternErr == nil {
+		v.notPatternRE, v.notPatternErr = regexp.Compile(v.NotPattern)
+	}
+	return v.notPatternRE, v.notPatternErr
+}
+
 // TransformTemplate defines a reusable transformation template
 type TransformTemplate struct {
 	Description string     `yaml:"description"`
 	Transform   *Transform `yaml:"transform"`
+	SourceFile  string     `yaml:"-"` // File the template was loaded from, set during loading
 }
 
 // VariableType defines reusable variable configurations
@@ -129,46 +350,501 @@ type VariableType struct {
 	Description string      `yaml:"description"`
 	Validation  *Validation `yaml:"validation,omitempty"`
 	Default     string      `yaml:"default,omitempty"`
-	Transform   *Transform  `yaml:"transform,omitempty"`
+	// DefaultFromEnv names an environment variable used as the default
+	// when it is set, ahead of Default.
+	DefaultFromEnv string     `yaml:"default_from_env,omitempty"`
+	Transform      *Transform `yaml:"transform,omitempty"`
+	SourceFile     string     `yaml:"-"` // File the type was loaded from, set during loading
 }
 
 // Config represents the main configuration file
 type Config struct {
 	TransformTemplates map[string]TransformTemplate `yaml:"transform_templates"`
 	VariableTypes      map[string]VariableType      `yaml:"variable_types"`
+	GlobalVariables    map[string]Variable          `yaml:"global_variables,omitempty"` // used by snippets that don't define them
 	Snippets           map[string]Snippet           `yaml:"snippets"`
 	Settings           Settings                     `yaml:"settings"`
+	Files              []ConfigFile                 `yaml:"-"` // Files merged into the config in load order, set during loading
+}
+
+// ConfigFile records what one config file contributed while loading.
+type ConfigFile struct {
+	Path               string
+	Kind               string // "main", "additional", or "local"
+	Snippets           int
+	TransformTemplates int
+	VariableTypes      int
+	GlobalVariables    int
+	// Overwritten names the entries that replaced ones from earlier files,
+	// such as "variable type 'port'"; snippets also name the file they
+	// replaced.
+	Overwritten []string
 }
 
 // Settings contains global configuration
 type Settings struct {
-	AdditionalConfigs []string       `yaml:"additional_configs,omitempty"`
-	Selector          SelectorConfig `yaml:"selector"`
+	AdditionalConfigs []string          `yaml:"additional_configs,omitempty"`
+	Selector          SelectorConfig    `yaml:"selector"`
+	History           HistoryConfig     `yaml:"history,omitempty"`
+	Execution         ExecutionConfig   `yaml:"execution,omitempty"`
+	Interactive       InteractiveConfig `yaml:"interactive,omitempty"`
+	Output            OutputConfig      `yaml:"output,omitempty"`
+	// StrictPlaceholders makes a placeholder without a matching variable an
+	// error when rendering; otherwise it is only warned about.
+	StrictPlaceholders bool `yaml:"strict_placeholders,omitempty"`
 }
 
+// OutputConfig controls how rendered commands are tidied up.
+type OutputConfig struct {
+	// CollapseWhitespace squeezes the runs of spaces left by empty values
+	// and trims trailing whitespace; see CollapseWhitespace.
+	CollapseWhitespace bool `yaml:"collapse_whitespace,omitempty"`
+}
+
+// InteractiveConfig sets defaults for `cs exec` behavior.
+type InteractiveConfig struct {
+	CopyToClipboard bool `yaml:"copy_to_clipboard"` // default for --copy
+	// ConfirmBeforeExecute makes exec without --run/--prompt behave like --prompt.
+	ConfirmBeforeExecute bool `yaml:"confirm_before_execute"`
+	// ShowFinalCommand echoes the command to stderr before --run executes it.
+	// Unset means true.
+	ShowFinalCommand *bool `yaml:"show_final_command,omitempty"`
+	// PreviewLines caps the lines of the form's command preview until it is
+	// expanded. Zero means DefaultPreviewLines.
+	PreviewLines int `yaml:"preview_lines,omitempty"`
+	// ShowSummary lists every value for review before the form submits.
+	ShowSummary bool `yaml:"show_summary,omitempty"`
+}
+
+// DefaultPreviewLines is the preview_lines used when it is not set.
+const DefaultPreviewLines = 6
+
+// ShowsFinalCommand reports the effective show_final_command setting.
+func (c InteractiveConfig) ShowsFinalCommand() bool {
+	return c.ShowFinalCommand == nil || *c.ShowFinalCommand
+}
+
+// PreviewLineLimit reports the effective preview_lines setting.
+func (c InteractiveConfig) PreviewLineLimit() int {
+	if c.PreviewLines <= 0 {
+		return DefaultPreviewLines
+	}
+	return c.PreviewLines
+}
+
+// ExecutionConfig controls how `--run` and `--prompt` execute commands.
+type ExecutionConfig struct {
+	// Shell defaults to $SHELL, then sh; cmd on Windows.
+	Shell string `yaml:"shell,omitempty"`
+	// ShellArgs precede the command, e.g. ["-Command"] for powershell.
+	// Defaults to the flag the shell uses for a command string: -c, /C for
+	// cmd, or -NoProfile -Command for powershell and pwsh.
+	ShellArgs []string `yaml:"shell_args,omitempty"`
+	// ExpandEnv expands $VARS in every snippet's command.
+	ExpandEnv bool `yaml:"expand_env,omitempty"`
+	// StepSeparator joins the steps of a multi-step snippet when printed.
+	// Defaults to DefaultStepSeparator.
+	StepSeparator string `yaml:"step_separator,omitempty"`
+	// Timeout bounds how long an executed command may run, as a duration
+	// string like "30s". Empty means no timeout.
+	Timeout string `yaml:"timeout,omitempty"`
+	// AllowDynamicDefaults lets variables run their default_from_command.
+	// Off by default, since it runs commands from config files.
+	AllowDynamicDefaults bool `yaml:"allow_dynamic_defaults,omitempty"`
+	// TrustedDirs lists directories whose local .csnippets files may run
+	// default_from_command too.
+	TrustedDirs []string `yaml:"trusted_dirs,omitempty"`
+}
+
+// AllowsDynamicDefaults reports whether the snippet's default_from_command
+// entries may run: allow_dynamic_defaults must be set, and a local snippet
+// must come from one of the trusted_dirs.
+func (c *Config) AllowsDynamicDefaults(s *Snippet) bool {
+	if c == nil || !c.Settings.Execution.AllowDynamicDefaults {
+		return false
+	}
+	if s.Source != SourceLocal {
+		return true
+	}
+	dir, err := filepath.Abs(filepath.Dir(s.SourceFile))
+	if err != nil {
+		return false
+	}
+	for _, trusted := range c.Settings.Execution.TrustedDirs {
+		trusted, err := expandHome(trusted)
+		if err != nil {
+			continue
+		}
+		if trusted, err = filepath.Abs(trusted); err == nil && trusted == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultStepSeparator joins the steps of a multi-step snippet when the
+// command is printed rather than executed.
+const DefaultStepSeparator = " && "
+
+// HistoryConfig controls the execution history used by `cs history`.
+type HistoryConfig struct {
+	Enabled    bool `yaml:"enabled"`
+	MaxEntries int  `yaml:"max_entries,omitempty"` // 0 means DefaultHistoryMaxEntries
+}
+
+// DefaultHistoryMaxEntries caps the history file when max_entries is unset.
+const DefaultHistoryMaxEntries = 1000
+
 type SelectorConfig struct {
 	Command string `yaml:"command"`
 	Options string `yaml:"options"`
+	Sort    string `yaml:"sort,omitempty"` // "name" (default), "usage", or "recent"
+	// InternalSort overrides Sort for the built-in selector: "alpha", "recent", or "usage".
+	InternalSort string `yaml:"internal_sort,omitempty"`
+	// Preview is the command fzf shows the highlighted snippet with; {1} is
+	// the snippet's name. Empty means DefaultSelectorPreview.
+	Preview string `yaml:"preview,omitempty"`
+	// QuickSelect numbers the visible options of the built-in selector so a
+	// digit picks one while the filter is empty. Unset means true.
+	QuickSelect *bool `yaml:"quick_select,omitempty"`
+}
+
+// QuickSelects reports the effective quick_select setting.
+func (c SelectorConfig) QuickSelects() bool {
+	return c.QuickSelect == nil || *c.QuickSelect
 }
 
+// DefaultSelectorPreview is the fzf preview command used when preview is
+// unset.
+const DefaultSelectorPreview = "cs describe --plain {1}"
+
 // ProcessTemplate processes a snippet with variable substitution.
 func (s *Snippet) ProcessTemplate(values map[string]string, config *Config) (string, error) {
+	command, _, err := s.ProcessTemplateDetailed(values, config)
+	return command, err
+}
+
+// MaskSecrets returns a copy of values with the non-empty value of each
+// secret-typed variable replaced by mask. Rendering the copy gives a
+// command that is safe to show or record, whatever the transforms make of
+// the real value.
+func (s *Snippet) MaskSecrets(values map[string]string, mask string) map[string]string {
+	masked := maps.Clone(values)
+	for _, v := range s.Variables {
+		if v.Type == VarTypeSecret && values[v.Name] != "" {
+			masked[v.Name] = mask
+		}
+	}
+	return masked
+}
+
+// ResolvedVariable records how a single variable was rendered: the value it
+// was given and the text substituted for its placeholder.
+type ResolvedVariable struct {
+	Name        string
+	Raw         string
+	Transformed string
+	Computed    bool
+}
+
+// ProcessTemplateDetailed is ProcessTemplate, additionally returning the
+// resolution of every variable in definition order. The steps of a
+// multi-step snippet are joined with the configured step separator.
+func (s *Snippet) ProcessTemplateDetailed(values map[string]string, config *Config) (string, []ResolvedVariable, error) {
+	steps, resolved, err := s.processSteps(values, config)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.Join(steps, StepSeparator(config)), resolved, nil
+}
+
+// ProcessSteps renders each step of the snippet: every entry of commands
+// for a multi-step snippet, otherwise the single command.
+func (s *Snippet) ProcessSteps(values map[string]string, config *Config) ([]string, error) {
+	steps, _, err := s.processSteps(values, config)
+	return steps, err
+}
+
+func (s *Snippet) processSteps(values map[string]string, config *Config) ([]string, []ResolvedVariable, error) {
+	if s.Command != "" && len(s.Commands) > 0 {
+		return nil, nil, fmt.Errorf("snippet sets both command and commands")
+	}
+
+	if config != nil && config.Settings.StrictPlaceholders {
+		if err := s.CheckPlaceholders(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	processed, resolved, err := s.processVariables(values, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	steps := s.Steps()
+	rendered := make([]string, len(steps))
+	for i, step := range steps {
+		rendered[i] = substitutePlaceholders(step, processed)
+		if s.ExpandsEnv(config) {
+			rendered[i] = ExpandEnv(rendered[i])
+		}
+		if s.CollapsesWhitespace(config) {
+			rendered[i] = CollapseWhitespace(rendered[i])
+		}
+	}
+	return rendered, resolved, nil
+}
+
+// processVariables runs every variable through ProcessVariable, returning
+// the substitution for each placeholder and the resolution in definition
+// order.
+func (s *Snippet) processVariables(values map[string]string, config *Config) (map[string]string, []ResolvedVariable, error) {
+	hidden, err := s.HiddenVariables(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(hidden) > 0 {
+		values = maps.Clone(values)
+		for name := range hidden {
+			values[name] = ""
+		}
+	}
+
+	binding, err := s.ComputeValues(values, config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolved := make([]ResolvedVariable, 0, len(s.Variables))
 	processed := make(map[string]string, len(s.Variables))
 	for _, variable := range s.Variables {
-		result, err := s.ProcessVariable(variable, values[variable.Name], values, config)
+		var result string
+		var err error
+		if hidden[variable.Name] {
+			result, err = variable.HiddenValue(config)
+		} else {
+			result, err = s.ProcessVariable(variable, values[variable.Name], binding, config)
+		}
 		if err != nil {
-			return "", fmt.Errorf("processing variable %s: %w", variable.Name, err)
+			return nil, nil, fmt.Errorf("processing variable %s: %w", variable.Name, err)
 		}
 		processed[variable.Name] = result
+		resolved = append(resolved, ResolvedVariable{
+			Name:        variable.Name,
+			Raw:         values[variable.Name],
+			Transformed: result,
+			Computed:    variable.Computed,
+		})
+	}
+	return processed, resolved, nil
+}
+
+// ComputeValues returns values with the result of each computed variable
+// added, for compose templates to read. Computed variables are resolved
+// after those they read, so one can build on another; hidden ones are
+// empty. With ComposeUsesTransformed, the other variables are replaced by
+// their transformed values too. If a transform fails, the values computed
+// so far are returned with the error.
+func (s *Snippet) ComputeValues(values map[string]string, config *Config) (map[string]string, error) {
+	order, err := s.resolutionOrder(config)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := s.HiddenVariables(values)
+	if err != nil {
+		return nil, err
+	}
+
+	computed := maps.Clone(values)
+	if computed == nil {
+		computed = make(map[string]string)
+	}
+	for _, variable := range order {
+		switch {
+		case !variable.Computed && !s.ComposeUsesTransformed:
+		case hidden[variable.Name]:
+			result, err := variable.HiddenValue(config)
+			if err != nil {
+				return computed, fmt.Errorf("processing variable %s: %w", variable.Name, err)
+			}
+			computed[variable.Name] = result
+		default:
+			result, err := s.processVariable(*variable, values[variable.Name], computed, config)
+			if err != nil {
+				return computed, fmt.Errorf("processing variable %s: %w", variable.Name, err)
+			}
+			computed[variable.Name] = result
+		}
+	}
+	return computed, nil
+}
+
+// Steps returns the snippet's command templates: Commands for a multi-step
+// snippet, otherwise the single Command.
+func (s *Snippet) Steps() []string {
+	if len(s.Commands) > 0 {
+		return s.Commands
+	}
+	return []string{s.Command}
+}
+
+// StepSeparator returns settings.execution.step_separator, or
+// DefaultStepSeparator when unset.
+func StepSeparator(config *Config) string {
+	if config != nil && config.Settings.Execution.StepSeparator != "" {
+		return config.Settings.Execution.StepSeparator
 	}
+	return DefaultStepSeparator
+}
 
-	return placeholderPattern.ReplaceAllStringFunc(s.Command, func(match string) string {
+// CheckPlaceholders returns an error listing the placeholders in the
+// snippet's commands that no variable defines, and so would be left in the
+// rendered command as typed. Escaped <<name>> placeholders are not checked.
+func (s *Snippet) CheckPlaceholders() error {
+	defined := make(map[string]bool, len(s.Variables))
+	for _, v := range s.Variables {
+		defined[v.Name] = true
+	}
+	var unresolved []string
+	for _, name := range commandPlaceholders(strings.Join(s.Steps(), "\n")) {
+		if !defined[name] {
+			unresolved = append(unresolved, "<"+name+">")
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("unresolved placeholders: %s", strings.Join(unresolved, ", "))
+	}
+	return nil
+}
+
+// substitutePlaceholders replaces each <name> in text with processed[name],
+// leaving placeholders without a value untouched, and each <<name>> with
+// the literal <name>.
+func substitutePlaceholders(text string, processed map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
 		name := match[1 : len(match)-1]
+		if isEscapedPlaceholder(match) {
+			return name
+		}
 		if val, ok := processed[name]; ok {
 			return val
 		}
 		return match
-	}), nil
+	})
+}
+
+// ResolveWorkdir renders the snippet's workdir with the same variable
+// values as the command, then expands a leading ~ and environment
+// variables. Returns "" when the snippet has no workdir.
+func (s *Snippet) ResolveWorkdir(values map[string]string, config *Config) (string, error) {
+	if s.Workdir == "" {
+		return "", nil
+	}
+
+	processed, _, err := s.processVariables(values, config)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := expandHome(ExpandEnv(substitutePlaceholders(s.Workdir, processed)))
+	if err != nil {
+		return "", fmt.Errorf("expanding workdir: %w", err)
+	}
+	return dir, nil
+}
+
+// ResolveHooks renders the snippet's pre_command and post_command with the
+// same variable values as the command. Either is "" when not set.
+func (s *Snippet) ResolveHooks(values map[string]string, config *Config) (pre, post string, err error) {
+	if s.PreCommand == "" && s.PostCommand == "" {
+		return "", "", nil
+	}
+
+	processed, _, err := s.processVariables(values, config)
+	if err != nil {
+		return "", "", err
+	}
+
+	render := func(hook string) string {
+		hook = substitutePlaceholders(hook, processed)
+		if s.ExpandsEnv(config) {
+			hook = ExpandEnv(hook)
+		}
+		return hook
+	}
+	return render(s.PreCommand), render(s.PostCommand), nil
+}
+
+// expandHome replaces a leading ~ in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// ResolveTimeout returns how long the snippet's command may run when
+// executed: its own timeout, else settings.execution.timeout. Zero means no
+// timeout.
+func (s *Snippet) ResolveTimeout(config *Config) (time.Duration, error) {
+	if s.Timeout != "" {
+		return parseTimeout(s.Timeout)
+	}
+	if config != nil && config.Settings.Execution.Timeout != "" {
+		d, err := parseTimeout(config.Settings.Execution.Timeout)
+		if err != nil {
+			return 0, fmt.Errorf("settings.execution.%w", err)
+		}
+		return d, nil
+	}
+	return 0, nil
+}
+
+// parseTimeout parses a timeout duration string, rejecting negative values.
+func parseTimeout(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("timeout: invalid duration '%s' (expected e.g. 30s or 5m)", value)
+	}
+	return d, nil
+}
+
+// ExpandsEnv reports whether environment variables are expanded in this
+// snippet's rendered command, via its expand_env field or the global
+// settings.execution.expand_env.
+func (s *Snippet) ExpandsEnv(config *Config) bool {
+	return s.ExpandEnv || (config != nil && config.Settings.Execution.ExpandEnv)
+}
+
+// CollapsesWhitespace reports whether whitespace is collapsed in the
+// snippet's rendered command: its collapse_whitespace field if set,
+// otherwise settings.output.collapse_whitespace.
+func (s *Snippet) CollapsesWhitespace(config *Config) bool {
+	if s.CollapseWhitespace != nil {
+		return *s.CollapseWhitespace
+	}
+	return config != nil && config.Settings.Output.CollapseWhitespace
+}
+
+// ShowsSummary reports whether the form shows the values for review before
+// submitting: confirm_values, or settings.interactive.show_summary.
+func (s *Snippet) ShowsSummary(config *Config) bool {
+	return s.ConfirmValues || (config != nil && config.Settings.Interactive.ShowSummary)
+}
+
+// ExpandEnv replaces $VAR and ${VAR} with environment values, like
+// os.ExpandEnv, except that $$ produces a literal dollar sign.
+func ExpandEnv(s string) string {
+	return os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		return os.Getenv(name)
+	})
 }
 
 // ResolveTransform returns the Transform that applies to this variable, either
@@ -187,9 +863,64 @@ func (v *Variable) ResolveTransform(config *Config) (*Transform, error) {
 	return v.Transform, nil
 }
 
+// ResolveDefault returns the value a variable starts out with: its
+// default_from_env variable when set and non-empty, then its default, then
+// the same two from its variable type.
+func (v *Variable) ResolveDefault(config *Config) string {
+	if value := lookupEnv(v.DefaultFromEnv); value != "" {
+		return value
+	}
+	if v.DefaultValue != "" {
+		return v.DefaultValue
+	}
+	if config == nil || v.Type == "" {
+		return ""
+	}
+	varType, ok := config.VariableTypes[v.Type]
+	if !ok {
+		return ""
+	}
+	if value := lookupEnv(varType.DefaultFromEnv); value != "" {
+		return value
+	}
+	return varType.Default
+}
+
+// lookupEnv returns the value of the named environment variable, or "" when
+// name is empty.
+func lookupEnv(name string) string {
+	if name == "" {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// HiddenValue is what a hidden variable renders as: the empty (or false)
+// branch of its transform, without falling back to a default.
+func (v *Variable) HiddenValue(config *Config) (string, error) {
+	transform, err := v.ResolveTransform(config)
+	if err != nil || transform == nil {
+		return "", err
+	}
+	if v.Type == VarTypeBoolean {
+		return transform.FalseValue, nil
+	}
+	return transform.EmptyValue, nil
+}
+
 // ProcessVariable applies the variable's transform (if any) to value, using
-// allValues as the binding for compose templates.
+// allValues as the binding for compose templates, and quotes the result for
+// the shell if the variable asks for it.
 func (s *Snippet) ProcessVariable(variable Variable, value string, allValues map[string]string, config *Config) (string, error) {
+	result, err := s.processVariable(variable, value, allValues, config)
+	if err != nil || !variable.Quote || result == "" {
+		return result, err
+	}
+	return shellQuote(result), nil
+}
+
+// processVariable is ProcessVariable without quoting.
+func (s *Snippet) processVariable(variable Variable, value string, allValues map[string]string, config *Config) (string, error) {
 	transform, err := variable.ResolveTransform(config)
 	if err != nil {
 		return "", err
@@ -207,6 +938,13 @@ func (s *Snippet) ProcessVariable(variable Variable, value string, allValues map
 		return buf.String(), nil
 	}
 
+	// Dates are resolved to a timestamp before any transform sees them.
+	if variable.isDateType() && value != "" {
+		if value, err = variable.ResolveDate(value); err != nil {
+			return "", err
+		}
+	}
+
 	if transform != nil {
 		if variable.Type == VarTypeBoolean {
 			if parseBool(value) {
@@ -218,13 +956,27 @@ func (s *Snippet) ProcessVariable(variable Variable, value string, allValues map
 		if value == "" && transform.EmptyValue != "" {
 			return transform.EmptyValue, nil
 		}
+		if value != "" && transform.RegexReplace != nil {
+			re, err := transform.RegexReplace.compiled()
+			if err != nil {
+				return "", fmt.Errorf("invalid regex_replace pattern: %w", err)
+			}
+			value = re.ReplaceAllString(value, transform.RegexReplace.Replacement)
+		}
+		if value != "" {
+			value = transform.lookup(value)
+		}
 		if value != "" && transform.ValuePattern != "" {
 			tmpl, err := transform.valuePatternTemplate()
 			if err != nil {
 				return "", err
 			}
 			var buf strings.Builder
-			if err := tmpl.Execute(&buf, map[string]string{"Value": value}); err != nil {
+			values := []string{value}
+			if variable.Type == VarTypeList {
+				values = variable.ListItems(value)
+			}
+			if err := tmpl.Execute(&buf, map[string]any{"Value": value, "Values": values}); err != nil {
 				return "", err
 			}
 			return buf.String(), nil
@@ -232,7 +984,11 @@ func (s *Snippet) ProcessVariable(variable Variable, value string, allValues map
 	}
 
 	if value == "" {
-		return variable.DefaultValue, nil
+		defaultValue := variable.ResolveDefault(config)
+		if variable.isDateType() && defaultValue != "" {
+			return variable.ResolveDate(defaultValue)
+		}
+		return defaultValue, nil
 	}
 	return value, nil
 }
@@ -247,24 +1003,42 @@ func (v *Variable) Validate(value string) error {
 		return nil
 	}
 
+	// Negative pattern validation applies on top of every other rule
+	if v.Validation.NotPattern != "" && value != "" {
+		re, err := v.Validation.compiledNotPattern()
+		if err != nil {
+			return fmt.Errorf("variable %s has invalid not_pattern: %w", v.Name, err)
+		}
+		if re.MatchString(value) {
+			return v.validationError(fmt.Errorf("variable %s must not match the pattern %s", v.Name, v.Validation.NotPattern))
+		}
+	}
+
 	// Enum validation
+	if len(v.Validation.Enum) > 0 && v.Validation.Multiple {
+		for _, item := range v.ListItems(value) {
+			if !slices.Contains(v.Validation.Enum, item) {
+				return v.validationError(fmt.Errorf("variable %s: %q is not one of: %s", v.Name, item, strings.Join(v.Validation.Enum, ", ")))
+			}
+		}
+		return nil
+	}
 	if len(v.Validation.Enum) > 0 {
 		if slices.Contains(v.Validation.Enum, value) {
 			return nil
 		}
-		return fmt.Errorf("variable %s must be one of: %s", v.Name, strings.Join(v.Validation.Enum, ", "))
+		return v.validationError(fmt.Errorf("variable %s must be one of: %s", v.Name, strings.Join(v.Validation.Enum, ", ")))
 	}
 
 	// Range validation (for numeric types like ports)
-	if len(v.Validation.Range) == 2 && value != "" {
-		num, err := strconv.Atoi(value)
+	if ranges := v.Validation.AllowedRanges(); len(ranges) > 0 && value != "" {
+		num, err := v.parseNumber(value)
 		if err != nil {
-			return fmt.Errorf("variable %s must be a valid number", v.Name)
+			return err
 		}
 
-		lo, hi := v.Validation.Range[0], v.Validation.Range[1]
-		if num < lo || num > hi {
-			return fmt.Errorf("variable %s must be between %d and %d", v.Name, lo, hi)
+		if !inRanges(num, ranges) {
+			return v.validationError(fmt.Errorf("variable %s must be %s, got %s", v.Name, describeRanges(ranges), value))
 		}
 	}
 
@@ -275,15 +1049,103 @@ func (v *Variable) Validate(value string) error {
 			return fmt.Errorf("variable %s has invalid pattern: %w", v.Name, err)
 		}
 		if !re.MatchString(value) {
-			return fmt.Errorf("variable %s does not match required format", v.Name)
+			return v.validationError(fmt.Errorf("variable %s must match the pattern %s", v.Name, v.Validation.Pattern))
+		}
+	}
+
+	// Existence validation (paths)
+	if v.Validation.MustExist && value != "" {
+		if err := v.checkPathExists(value); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// validationError returns err, a value failing the variable's validation,
+// with its explanation replaced by the validation's message if it has one.
+func (v *Variable) validationError(err error) error {
+	if v.Validation.Message != "" {
+		return fmt.Errorf("variable %s: %s", v.Name, v.Validation.Message)
+	}
+	return err
+}
+
+// IsRequired reports whether the variable needs a value given the values
+// of the others: it is required, or its required_if condition holds.
+func (v *Variable) IsRequired(values map[string]string) (bool, error) {
+	if v.Required || v.RequiredIf == nil {
+		return v.Required, nil
+	}
+	holds, err := v.RequiredIf.Holds(values)
+	if err != nil {
+		return false, fmt.Errorf("variable %s: evaluating required_if: %w", v.Name, err)
+	}
+	return holds, nil
+}
+
+// IsNumeric reports whether the variable is an integer or float.
+func (v *Variable) IsNumeric() bool {
+	return v.Type == VarTypeInteger || v.Type == VarTypeFloat
+}
+
+// parseNumber parses value as a float for float variables and as a whole
+// number otherwise, so that range checks on other types keep requiring
+// integers.
+func (v *Variable) parseNumber(value string) (float64, error) {
+	if v.Type == VarTypeFloat {
+		num, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+			return 0, fmt.Errorf("variable %s must be a number", v.Name)
+		}
+		return num, nil
+	}
+	num, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("variable %s must be a valid number", v.Name)
+	}
+	return float64(num), nil
+}
+
+// checkPathExists reports an error unless value names an existing path, or
+// an existing directory for dirpath variables.
+func (v *Variable) checkPathExists(value string) error {
+	path, err := expandHome(value)
+	if err != nil {
+		return fmt.Errorf("variable %s: %w", v.Name, err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("variable %s: %s does not exist", v.Name, value)
+	}
+	if v.Type == VarTypeDirpath && !info.IsDir() {
+		return fmt.Errorf("variable %s: %s is not a directory", v.Name, value)
+	}
+	return nil
+}
+
+// WithMapEnum returns the variable with its enum filled in from the keys of
+// its transform map, in sorted order, when validation asks for it.
+func (v Variable) WithMapEnum(config *Config) Variable {
+	if v.Validation == nil || !v.Validation.EnumFromMap || len(v.Validation.Enum) > 0 {
+		return v
+	}
+	transform, err := v.ResolveTransform(config)
+	if err != nil || transform == nil || len(transform.Map) == 0 {
+		return v
+	}
+	validation := *v.Validation
+	validation.Enum = slices.Sorted(maps.Keys(transform.Map))
+	v.Validation = &validation
+	return v
+}
+
 // ValidateWithConfig checks validation criteria using config context (for type-based validation)
 func (v *Variable) ValidateWithConfig(value string, config *Config) error {
+	resolved := v.WithMapEnum(config)
+	v = &resolved
+
 	// First run standard validation
 	if err := v.Validate(value); err != nil {
 		return err
@@ -302,6 +1164,29 @@ func (v *Variable) ValidateWithConfig(value string, config *Config) error {
 		return nil
 	}
 
+	// Date types must be in their format or a relative shorthand
+	if v.isDateType() {
+		_, err := v.ResolveDate(value)
+		return err
+	}
+
+	// Numeric types must parse as their kind of number
+	if v.IsNumeric() {
+		_, err := v.parseNumber(value)
+		return err
+	}
+
+	// Each list item must pass the item validation
+	if v.Type == VarTypeList && v.ItemValidation != nil {
+		item := Variable{Name: v.Name, Validation: v.ItemValidation}
+		for _, value := range v.ListItems(value) {
+			if err := item.Validate(value); err != nil {
+				return fmt.Errorf("item %q: %w", value, err)
+			}
+		}
+		return nil
+	}
+
 	// Type-based validation using variable_types from config
 	if v.Type != "" && config != nil {
 		if varType, exists := config.VariableTypes[v.Type]; exists {
@@ -310,6 +1195,7 @@ func (v *Variable) ValidateWithConfig(value string, config *Config) error {
 				tempVar := Variable{
 					Name:       v.Name,
 					Type:       v.Type,
+					Separator:  v.Separator,
 					Validation: varType.Validation,
 				}
 				return tempVar.Validate(value)
@@ -3,7 +3,11 @@ package models
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -322,6 +326,105 @@ func TestProcessTemplate_EmptyValueTransform(t *testing.T) {
 	}
 }
 
+// TestProcessTemplate_Map tests value translation tables
+func TestProcessTemplate_Map(t *testing.T) {
+	clusters := map[string]string{"prod": "prod-east-1", "dev": "dev-west-2"}
+
+	tests := []struct {
+		name      string
+		transform Transform
+		value     string
+		expected  string
+	}{
+		{"mapped", Transform{Map: clusters}, "prod", "deploy prod-east-1"},
+		{"mapped into pattern", Transform{Map: clusters, ValuePattern: "--cluster={{.Value}}"}, "dev", "deploy --cluster=dev-west-2"},
+		{"unmatched kept", Transform{Map: clusters, ValuePattern: "--cluster={{.Value}}"}, "qa", "deploy --cluster=qa"},
+		{"unmatched default", Transform{Map: clusters, MapDefault: "local", ValuePattern: "--cluster={{.Value}}"}, "qa", "deploy --cluster=local"},
+		{"empty value", Transform{Map: clusters, MapDefault: "local", EmptyValue: "--all"}, "", "deploy --all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{
+				Command:   "deploy <env>",
+				Variables: []Variable{{Name: "env", Transform: &tt.transform}},
+			}
+			got, err := snippet.ProcessTemplate(map[string]string{"env": tt.value}, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestProcessTemplate_RegexReplace tests regex rewriting before value_pattern
+func TestProcessTemplate_RegexReplace(t *testing.T) {
+	tests := []struct {
+		name      string
+		transform Transform
+		value     string
+		expected  string
+	}{
+		{"strip prefix", Transform{RegexReplace: &RegexReplace{Pattern: `^v`, Replacement: ""}}, "v1.2.3", "tag 1.2.3"},
+		{"dots to dashes", Transform{RegexReplace: &RegexReplace{Pattern: `\.`, Replacement: "-"}, ValuePattern: "--name=app-{{.Value}}"}, "1.2.3", "tag --name=app-1-2-3"},
+		{"capture groups", Transform{RegexReplace: &RegexReplace{Pattern: `^v?(\d+)\.(\d+)\..*$`, Replacement: "$1.$2"}}, "v1.24.3", "tag 1.24"},
+		{"named group", Transform{RegexReplace: &RegexReplace{Pattern: `(?P<major>\d+)\..*`, Replacement: "${major}x"}}, "3.1", "tag 3x"},
+		{"no match passes through", Transform{RegexReplace: &RegexReplace{Pattern: `^v`, Replacement: ""}}, "1.2.3", "tag 1.2.3"},
+		{"before map", Transform{RegexReplace: &RegexReplace{Pattern: `^v`, Replacement: ""}, Map: map[string]string{"1": "one"}}, "v1", "tag one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{
+				Command:   "tag <version>",
+				Variables: []Variable{{Name: "version", Transform: &tt.transform}},
+			}
+			got, err := snippet.ProcessTemplate(map[string]string{"version": tt.value}, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	snippet := Snippet{
+		Command:   "tag <version>",
+		Variables: []Variable{{Name: "version", Transform: &Transform{RegexReplace: &RegexReplace{Pattern: "("}}}},
+	}
+	_, err := snippet.ProcessTemplate(map[string]string{"version": "1"}, &Config{})
+	if err == nil || !strings.Contains(err.Error(), "processing variable version: invalid regex_replace pattern") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+}
+
+// TestValidateWithConfig_EnumFromMap tests enums derived from a transform map
+func TestValidateWithConfig_EnumFromMap(t *testing.T) {
+	config := &Config{
+		TransformTemplates: map[string]TransformTemplate{
+			"cluster": {Transform: &Transform{Map: map[string]string{"prod": "prod-east-1", "dev": "dev-west-2"}}},
+		},
+	}
+	variable := Variable{Name: "env", TransformTemplate: "cluster", Validation: &Validation{EnumFromMap: true}}
+
+	if got := variable.WithMapEnum(config).Validation.Enum; !slices.Equal(got, []string{"dev", "prod"}) {
+		t.Errorf("Expected the sorted map keys as the enum, got %v", got)
+	}
+	if err := variable.ValidateWithConfig("prod", config); err != nil {
+		t.Errorf("Expected a map key to be valid, got %v", err)
+	}
+	if err := variable.ValidateWithConfig("qa", config); err == nil || !strings.Contains(err.Error(), "must be one of: dev, prod") {
+		t.Errorf("Expected a value outside the map to be rejected, got %v", err)
+	}
+	if variable.Validation.Enum != nil {
+		t.Errorf("Expected the variable's own validation to be left alone, got %v", variable.Validation.Enum)
+	}
+}
+
 // TestProcessTemplate_ComputedSimple tests simple computed variables
 func TestProcessTemplate_ComputedSimple(t *testing.T) {
 	config := loadTestConfig(t)
@@ -363,6 +466,48 @@ func TestProcessTemplate_ComputedSimple(t *testing.T) {
 	}
 }
 
+// TestProcessTemplateDetailed tests per-variable resolution results
+func TestProcessTemplateDetailed(t *testing.T) {
+	config := loadTestConfig(t)
+	snippet := config.Snippets["snippet-with-computed-simple"]
+
+	command, resolved, err := snippet.ProcessTemplateDetailed(map[string]string{
+		"resource_type": "pod",
+		"resource_name": "my-pod",
+	}, config)
+	if err != nil {
+		t.Fatalf("ProcessTemplateDetailed failed: %v", err)
+	}
+	if command != "app pod/my-pod" {
+		t.Errorf("Expected %q, got %q", "app pod/my-pod", command)
+	}
+
+	expected := []ResolvedVariable{
+		{Name: "resource_type", Raw: "pod", Transformed: "pod"},
+		{Name: "resource_name", Raw: "my-pod", Transformed: "my-pod"},
+		{Name: "resource", Raw: "", Transformed: "pod/my-pod", Computed: true},
+	}
+	if len(resolved) != len(expected) {
+		t.Fatalf("Expected %d resolved variables, got %d", len(expected), len(resolved))
+	}
+	for i, want := range expected {
+		if resolved[i] != want {
+			t.Errorf("Variable %d: expected %+v, got %+v", i, want, resolved[i])
+		}
+	}
+
+	t.Run("transformed differs from raw", func(t *testing.T) {
+		snippet := config.Snippets["snippet-with-boolean"]
+		_, resolved, err := snippet.ProcessTemplateDetailed(map[string]string{"verbose": "true", "debug": "false"}, config)
+		if err != nil {
+			t.Fatalf("ProcessTemplateDetailed failed: %v", err)
+		}
+		if resolved[0].Raw != "true" || resolved[0].Transformed != "--verbose" {
+			t.Errorf("Expected verbose true -> --verbose, got %+v", resolved[0])
+		}
+	})
+}
+
 // TestProcessTemplate_ComputedConditional tests conditional computed variables
 func TestProcessTemplate_ComputedConditional(t *testing.T) {
 	config := loadTestConfig(t)
@@ -521,6 +666,72 @@ func TestProcessTemplate_ComplexComputed(t *testing.T) {
 	}
 }
 
+// TestProcessTemplate_ChainedComputed tests computed variables that build
+// on other computed variables, whatever order they are declared in
+func TestProcessTemplate_ChainedComputed(t *testing.T) {
+	snippet := Snippet{
+		Command: "docker run <ref> <label>",
+		Variables: []Variable{
+			{Name: "label", Computed: true, Transform: &Transform{Compose: "--label=src={{.ref}}"}},
+			{Name: "ref", Computed: true, Transform: &Transform{Compose: "{{.image}}:{{.tag}}"}},
+			{Name: "image", Computed: true, Quote: true, Transform: &Transform{Compose: "{{.registry}}/{{.name}}"}},
+			{Name: "registry"},
+			{Name: "name"},
+			{Name: "tag"},
+		},
+	}
+
+	values := map[string]string{"registry": "ghcr.io", "name": "app", "tag": "v1"}
+	got, err := snippet.ProcessTemplate(values, &Config{})
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	if expected := "docker run ghcr.io/app:v1 --label=src=ghcr.io/app:v1"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	snippet.Variables[2].Transform = &Transform{Compose: "{{.label}}"}
+	_, err = snippet.ProcessTemplate(values, &Config{})
+	if err == nil || !strings.Contains(err.Error(), "variable dependencies form a cycle: label -> ref -> image -> label") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+}
+
+// TestProcessTemplate_ComposeUsesTransformed tests that compose sees raw
+// values by default and transformed ones when the snippet opts in
+func TestProcessTemplate_ComposeUsesTransformed(t *testing.T) {
+	tests := []struct {
+		name        string
+		transformed bool
+		namespace   string
+		expected    string
+	}{
+		{"raw by default", false, "all", "kubectl get pods # all"},
+		{"transformed", true, "all", "kubectl get pods # -A"},
+		{"transformed pattern", true, "web", "kubectl get pods # -n web"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{
+				Command:                "kubectl get pods # <summary>",
+				ComposeUsesTransformed: tt.transformed,
+				Variables: []Variable{
+					{Name: "summary", Computed: true, Transform: &Transform{Compose: "{{.namespace}}"}},
+					{Name: "namespace", DefaultValue: "default", Transform: &Transform{Map: map[string]string{"all": "-A"}, ValuePattern: `{{if eq .Value "-A"}}-A{{else}}-n {{.Value}}{{end}}`}},
+				},
+			}
+			got, err := snippet.ProcessTemplate(map[string]string{"namespace": tt.namespace}, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 // TestValidate_Required tests required field validation
 func TestValidate_Required(t *testing.T) {
 	variable := Variable{
@@ -539,6 +750,36 @@ func TestValidate_Required(t *testing.T) {
 	}
 }
 
+// TestIsRequired tests required and required_if
+func TestIsRequired(t *testing.T) {
+	prod := &Condition{Variable: "environment", Equals: "prod"}
+
+	tests := []struct {
+		name     string
+		variable Variable
+		values   map[string]string
+		expected bool
+	}{
+		{"optional", Variable{}, nil, false},
+		{"required", Variable{Required: true}, nil, true},
+		{"required_if holds", Variable{RequiredIf: prod}, map[string]string{"environment": "prod"}, true},
+		{"required_if fails", Variable{RequiredIf: prod}, map[string]string{"environment": "dev"}, false},
+		{"required_if template", Variable{RequiredIf: &Condition{Template: `{{ne .environment "dev"}}`}}, map[string]string{"environment": "qa"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.variable.IsRequired(tt.values)
+			if err != nil {
+				t.Fatalf("IsRequired failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("IsRequired() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 // TestValidate_Enum tests enum validation
 func TestValidate_Enum(t *testing.T) {
 	variable := Variable{
@@ -575,7 +816,7 @@ func TestValidate_Range(t *testing.T) {
 	variable := Variable{
 		Name: "port",
 		Validation: &Validation{
-			Range: []int{1, 65535},
+			Range: []float64{1, 65535},
 		},
 	}
 
@@ -637,6 +878,292 @@ func TestValidate_Pattern(t *testing.T) {
 	}
 }
 
+// TestValidate_NotPattern tests negative pattern validation alongside other rules
+func TestValidate_NotPattern(t *testing.T) {
+	tests := []struct {
+		name       string
+		validation Validation
+		value      string
+		wantError  bool
+	}{
+		{"no match", Validation{NotPattern: `\s|^-`}, "my-app", false},
+		{"contains space", Validation{NotPattern: `\s|^-`}, "my app", true},
+		{"leading dash", Validation{NotPattern: `\s|^-`}, "-rf", true},
+		{"empty value", Validation{NotPattern: `.*`}, "", false},
+		{"with pattern", Validation{Pattern: `^[a-z-]+$`, NotPattern: `^-`}, "-app", true},
+		{"pattern still applies", Validation{Pattern: `^[a-z-]+$`, NotPattern: `^-`}, "App", true},
+		{"with enum", Validation{Enum: []string{"ok", "-bad"}, NotPattern: `^-`}, "-bad", true},
+		{"with range", Validation{Range: []float64{-10, 10}, NotPattern: `^-`}, "-5", true},
+		{"invalid regex", Validation{NotPattern: `[`}, "x", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variable := Variable{Name: "v", Validation: &tt.validation}
+			err := variable.Validate(tt.value)
+			if (err != nil) != tt.wantError {
+				t.Errorf("Validate() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+// TestValidate_Messages tests the default and custom explanations for
+// pattern, enum, and range failures
+func TestValidate_Messages(t *testing.T) {
+	config := &Config{
+		VariableTypes: map[string]VariableType{
+			"semver": {Validation: &Validation{Pattern: `^\d+\.\d+\.\d+$`, Message: "must look like 1.2.3"}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		variable Variable
+		value    string
+		expected string
+	}{
+		{"default pattern", Variable{Validation: &Validation{Pattern: `^\d+$`}}, "x", `variable v must match the pattern ^\d+$`},
+		{"default enum", Variable{Validation: &Validation{Enum: []string{"a", "b"}}}, "c", "variable v must be one of: a, b"},
+		{"default range", Variable{Validation: &Validation{Range: []float64{1, 10}}}, "11", "variable v must be between 1 and 10, got 11"},
+		{"custom pattern", Variable{Validation: &Validation{Pattern: `^\d+$`, Message: "digits only"}}, "x", "variable v: digits only"},
+		{"custom enum", Variable{Validation: &Validation{Enum: []string{"a"}, Message: "pick a"}}, "c", "variable v: pick a"},
+		{"custom range", Variable{Validation: &Validation{Range: []float64{1, 10}, Message: "1 to 10"}}, "0", "variable v: 1 to 10"},
+		{"default not_pattern", Variable{Validation: &Validation{NotPattern: `\s`}}, "a b", `variable v must not match the pattern \s`},
+		{"custom not_pattern", Variable{Validation: &Validation{NotPattern: `\s`, Message: "no spaces"}}, "a b", "variable v: no spaces"},
+		{"custom type message", Variable{Type: "semver"}, "1.2", "variable v: must look like 1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.variable.Name = "v"
+			err := tt.variable.ValidateWithConfig(tt.value, config)
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+// TestValidateWithConfig_Numeric tests integer and float parsing, including negatives
+func TestValidateWithConfig_Numeric(t *testing.T) {
+	tests := []struct {
+		name      string
+		variable  Variable
+		value     string
+		wantError bool
+	}{
+		{"integer", Variable{Type: VarTypeInteger}, "42", false},
+		{"negative integer", Variable{Type: VarTypeInteger}, "-7", false},
+		{"integer rejects decimal", Variable{Type: VarTypeInteger}, "1.5", true},
+		{"integer rejects text", Variable{Type: VarTypeInteger}, "ten", true},
+		{"float", Variable{Type: VarTypeFloat}, "0.25", false},
+		{"negative float", Variable{Type: VarTypeFloat}, "-0.25", false},
+		{"float rejects text", Variable{Type: VarTypeFloat}, "1.2.3", true},
+		{"float rejects NaN", Variable{Type: VarTypeFloat}, "NaN", true},
+		{"negative float in range", Variable{Type: VarTypeFloat, Validation: &Validation{Range: []float64{-1, 1}}}, "-0.5", false},
+		{"float below range", Variable{Type: VarTypeFloat, Validation: &Validation{Range: []float64{-1, 1}}}, "-1.5", true},
+		{"negative integer in range", Variable{Type: VarTypeInteger, Validation: &Validation{Range: []float64{-10, 0}}}, "-3", false},
+		{"empty", Variable{Type: VarTypeInteger}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.variable.Name = "n"
+			err := tt.variable.ValidateWithConfig(tt.value, nil)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ValidateWithConfig(%q) error = %v, wantError %v", tt.value, err, tt.wantError)
+			}
+		})
+	}
+}
+
+// TestProcessTemplate_List tests list splitting, item validation, and the Values template field
+func TestProcessTemplate_List(t *testing.T) {
+	snippet := &Snippet{
+		Command: "kubectl get pods <labels> <ports>",
+		Variables: []Variable{
+			{
+				Name:           "labels",
+				Type:           VarTypeList,
+				ItemValidation: &Validation{Pattern: "^[a-z]+=[a-z]+$"},
+				Transform:      &Transform{ValuePattern: "-l {{.Value}}"},
+			},
+			{
+				Name:           "ports",
+				Type:           VarTypeList,
+				Separator:      " ",
+				ItemValidation: &Validation{Enum: []string{"80", "443", "8080"}},
+				Transform:      &Transform{ValuePattern: "{{range .Values}}-p {{.}} {{end}}"},
+			},
+		},
+	}
+
+	got, err := snippet.ProcessTemplate(map[string]string{"labels": "app=web,env=prod", "ports": "80 443"}, &Config{})
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	expected := "kubectl get pods -l app=web,env=prod -p 80 -p 443 "
+	if got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	if items := snippet.Variables[0].ListItems("a=b,,c=d,"); !reflect.DeepEqual(items, []string{"a=b", "c=d"}) {
+		t.Errorf("Expected empty items to be dropped, got %v", items)
+	}
+
+	tests := []struct {
+		variable  Variable
+		value     string
+		wantError bool
+	}{
+		{snippet.Variables[0], "app=web,env=prod", false},
+		{snippet.Variables[0], "app=web,nope", true},
+		{snippet.Variables[1], "80 8080", false},
+		{snippet.Variables[1], "80 22", true},
+	}
+	for _, tt := range tests {
+		err := tt.variable.ValidateWithConfig(tt.value, nil)
+		if (err != nil) != tt.wantError {
+			t.Errorf("ValidateWithConfig(%q) error = %v, wantError %v", tt.value, err, tt.wantError)
+		}
+	}
+}
+
+// TestValidate_MultipleEnum tests validation of multiple-choice enums
+func TestValidate_MultipleEnum(t *testing.T) {
+	variable := Variable{
+		Name:       "caps",
+		Separator:  "|",
+		Validation: &Validation{Enum: []string{"NET_ADMIN", "SYS_TIME"}, Multiple: true},
+	}
+
+	tests := []struct {
+		name      string
+		value     string
+		wantError bool
+	}{
+		{"single", "NET_ADMIN", false},
+		{"several", "NET_ADMIN|SYS_TIME", false},
+		{"none", "", false},
+		{"unknown item", "NET_ADMIN|CHOWN", true},
+		{"wrong separator", "NET_ADMIN,SYS_TIME", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := variable.Validate(tt.value)
+			if (err != nil) != tt.wantError {
+				t.Errorf("Validate(%q) error = %v, wantError %v", tt.value, err, tt.wantError)
+			}
+		})
+	}
+
+	// A truthy-looking value must not pick up boolean transform semantics
+	snippet := &Snippet{
+		Command: "app <flags>",
+		Variables: []Variable{{
+			Name:       "flags",
+			Validation: &Validation{Enum: []string{"true", "false"}, Multiple: true},
+			Transform:  &Transform{TrueValue: "--yes", ValuePattern: "--flags={{.Value}}"},
+		}},
+	}
+	got, err := snippet.ProcessTemplate(map[string]string{"flags": "true,false"}, &Config{})
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	if got != "app --flags=true,false" {
+		t.Errorf("Expected the joined value, got %q", got)
+	}
+}
+
+// TestResolveDefault tests default_from_env with the env var set, empty, and unset
+func TestResolveDefault(t *testing.T) {
+	config := &Config{VariableTypes: map[string]VariableType{
+		"namespace": {Default: "default", DefaultFromEnv: "CS_TEST_TYPE_NS"},
+	}}
+
+	tests := []struct {
+		name     string
+		variable Variable
+		env      map[string]string
+		expected string
+	}{
+		{"env set", Variable{DefaultFromEnv: "CS_TEST_NS", DefaultValue: "static"}, map[string]string{"CS_TEST_NS": "from-env"}, "from-env"},
+		{"env empty", Variable{DefaultFromEnv: "CS_TEST_NS", DefaultValue: "static"}, map[string]string{"CS_TEST_NS": ""}, "static"},
+		{"env unset", Variable{DefaultFromEnv: "CS_TEST_NS", DefaultValue: "static"}, nil, "static"},
+		{"env unset without default", Variable{DefaultFromEnv: "CS_TEST_NS"}, nil, ""},
+		{"type env set", Variable{Type: "namespace"}, map[string]string{"CS_TEST_TYPE_NS": "kube-system"}, "kube-system"},
+		{"type env unset", Variable{Type: "namespace"}, nil, "default"},
+		{"variable default beats type env", Variable{Type: "namespace", DefaultValue: "prod"}, map[string]string{"CS_TEST_TYPE_NS": "kube-system"}, "prod"},
+		{"variable env beats type env", Variable{Type: "namespace", DefaultFromEnv: "CS_TEST_NS"}, map[string]string{"CS_TEST_NS": "mine", "CS_TEST_TYPE_NS": "theirs"}, "mine"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, k := range []string{"CS_TEST_NS", "CS_TEST_TYPE_NS"} {
+				t.Setenv(k, "") // restores the variable afterwards
+				os.Unsetenv(k)
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := tt.variable.ResolveDefault(config); got != tt.expected {
+				t.Errorf("ResolveDefault() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	// ProcessTemplate falls back to the same default for empty values
+	t.Setenv("CS_TEST_NS", "from-env")
+	snippet := &Snippet{
+		Command:   "kubectl get pods -n <namespace>",
+		Variables: []Variable{{Name: "namespace", DefaultFromEnv: "CS_TEST_NS", DefaultValue: "default"}},
+	}
+	got, err := snippet.ProcessTemplate(map[string]string{}, config)
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	if got != "kubectl get pods -n from-env" {
+		t.Errorf("Expected the env default, got %q", got)
+	}
+}
+
+// TestValidate_MustExist tests existence validation for path variables
+func TestValidate_MustExist(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "values.yaml")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", dir)
+
+	tests := []struct {
+		name      string
+		varType   string
+		value     string
+		wantError bool
+	}{
+		{"existing file", VarTypeFilepath, file, false},
+		{"existing dir as filepath", VarTypeFilepath, dir, false},
+		{"missing file", VarTypeFilepath, filepath.Join(dir, "missing.yaml"), true},
+		{"existing dir", VarTypeDirpath, dir, false},
+		{"file as dirpath", VarTypeDirpath, file, true},
+		{"home relative", VarTypeFilepath, "~/values.yaml", false},
+		{"empty value", VarTypeFilepath, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variable := Variable{Name: "path", Type: tt.varType, Validation: &Validation{MustExist: true}}
+			err := variable.Validate(tt.value)
+			if (err != nil) != tt.wantError {
+				t.Errorf("Validate(%q) error = %v, wantError %v", tt.value, err, tt.wantError)
+			}
+		})
+	}
+}
+
 // TestValidateWithConfig_TypeValidation tests type-based validation
 func TestValidateWithConfig_TypeValidation(t *testing.T) {
 	config := loadTestConfig(t)
@@ -838,3 +1365,271 @@ func TestProcessTemplate_RegexType(t *testing.T) {
 		})
 	}
 }
+
+// TestProcessTemplate_ExpandEnv tests environment variable expansion in commands
+func TestProcessTemplate_ExpandEnv(t *testing.T) {
+	t.Setenv("CS_TEST_KUBECONFIG", "/home/me/.kube/config")
+
+	snippet := Snippet{
+		Command: "kubectl --kubeconfig $CS_TEST_KUBECONFIG -n <namespace> get pods; echo ${CS_TEST_KUBECONFIG} $$HOME",
+		Variables: []Variable{
+			{Name: "namespace"},
+		},
+	}
+	values := map[string]string{"namespace": "default"}
+
+	tests := []struct {
+		name      string
+		snippet   bool
+		setting   bool
+		expected  string
+		unchanged bool
+	}{
+		{name: "disabled", unchanged: true},
+		{name: "snippet opt-in", snippet: true},
+		{name: "global setting", setting: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := snippet
+			s.ExpandEnv = tt.snippet
+			config := &Config{Settings: Settings{Execution: ExecutionConfig{ExpandEnv: tt.setting}}}
+
+			result, err := s.ProcessTemplate(values, config)
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+
+			expected := "kubectl --kubeconfig /home/me/.kube/config -n default get pods; echo /home/me/.kube/config $HOME"
+			if tt.unchanged {
+				expected = "kubectl --kubeconfig $CS_TEST_KUBECONFIG -n default get pods; echo ${CS_TEST_KUBECONFIG} $$HOME"
+			}
+			if result != expected {
+				t.Errorf("Expected %q, got %q", expected, result)
+			}
+		})
+	}
+}
+
+// TestResolveWorkdir tests rendering of the snippet working directory
+func TestResolveWorkdir(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	t.Setenv("CS_TEST_INFRA", "/srv/infra")
+
+	snippet := Snippet{
+		Command: "terraform plan",
+		Variables: []Variable{
+			{Name: "env", DefaultValue: "dev"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		workdir  string
+		values   map[string]string
+		expected string
+	}{
+		{name: "unset", expected: ""},
+		{name: "placeholder", workdir: "/srv/<env>", values: map[string]string{"env": "prod"}, expected: "/srv/prod"},
+		{name: "placeholder default", workdir: "/srv/<env>", expected: "/srv/dev"},
+		{name: "home", workdir: "~/infra/<env>", values: map[string]string{"env": "prod"}, expected: filepath.Join(home, "infra", "prod")},
+		{name: "environment", workdir: "$CS_TEST_INFRA/<env>", values: map[string]string{"env": "prod"}, expected: "/srv/infra/prod"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := snippet
+			s.Workdir = tt.workdir
+
+			result, err := s.ResolveWorkdir(tt.values, nil)
+			if err != nil {
+				t.Fatalf("ResolveWorkdir failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestProcessTemplate_MultiStep tests snippets with a list of commands
+func TestProcessTemplate_MultiStep(t *testing.T) {
+	snippet := Snippet{
+		Commands: []string{
+			"kubectl config use-context <ctx>",
+			"kubectl get pods -n <ns>",
+		},
+		Variables: []Variable{
+			{Name: "ctx"},
+			{Name: "ns", DefaultValue: "default"},
+		},
+	}
+	values := map[string]string{"ctx": "prod"}
+
+	steps, err := snippet.ProcessSteps(values, nil)
+	if err != nil {
+		t.Fatalf("ProcessSteps failed: %v", err)
+	}
+	expectedSteps := []string{"kubectl config use-context prod", "kubectl get pods -n default"}
+	if len(steps) != len(expectedSteps) {
+		t.Fatalf("Expected %d steps, got %v", len(expectedSteps), steps)
+	}
+	for i := range steps {
+		if steps[i] != expectedSteps[i] {
+			t.Errorf("Step %d: expected %q, got %q", i, expectedSteps[i], steps[i])
+		}
+	}
+
+	tests := []struct {
+		name      string
+		separator string
+		expected  string
+	}{
+		{name: "default separator", expected: "kubectl config use-context prod && kubectl get pods -n default"},
+		{name: "custom separator", separator: "; ", expected: "kubectl config use-context prod; kubectl get pods -n default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Settings: Settings{Execution: ExecutionConfig{StepSeparator: tt.separator}}}
+			result, err := snippet.ProcessTemplate(values, config)
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("command and commands", func(t *testing.T) {
+		s := snippet
+		s.Command = "echo hi"
+		if _, err := s.ProcessTemplate(values, nil); err == nil {
+			t.Error("Expected error when both command and commands are set")
+		}
+	})
+}
+
+// TestResolveTimeout tests the per-snippet and global execution timeout
+func TestResolveTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		snippet  string
+		setting  string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{name: "no timeout"},
+		{name: "global setting", setting: "30s", expected: 30 * time.Second},
+		{name: "snippet overrides setting", snippet: "2m", setting: "30s", expected: 2 * time.Minute},
+		{name: "invalid snippet timeout", snippet: "soon", wantErr: true},
+		{name: "negative setting", setting: "-1s", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Snippet{Command: "true", Timeout: tt.snippet}
+			config := &Config{Settings: Settings{Execution: ExecutionConfig{Timeout: tt.setting}}}
+
+			timeout, err := s.ResolveTimeout(config)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %s", timeout)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveTimeout failed: %v", err)
+			}
+			if timeout != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, timeout)
+			}
+		})
+	}
+}
+
+// TestProcessTemplate_EscapedPlaceholders tests that <<name>> renders as a
+// literal <name> even when a variable of that name exists
+func TestProcessTemplate_EscapedPlaceholders(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{"escape without variable", "echo '<<html>>' > <file>", "echo '<html>' > out.txt"},
+		{"escape with variable", "echo <<file>> <file>", "echo <file> out.txt"},
+		{"unclosed escape", "echo <<file> x", "echo <out.txt x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{Command: tt.command, Variables: []Variable{{Name: "file"}}}
+			got, err := snippet.ProcessTemplate(map[string]string{"file": "out.txt"}, &Config{})
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestProcessTemplate_StrictPlaceholders tests that placeholders without a
+// variable fail rendering only under strict_placeholders
+func TestProcessTemplate_StrictPlaceholders(t *testing.T) {
+	snippet := Snippet{
+		Command:   "kubectl get pods -n <namspace> <<label>> <pod> <ns>",
+		Variables: []Variable{{Name: "pod"}},
+	}
+	values := map[string]string{"pod": "web"}
+
+	if err := snippet.CheckPlaceholders(); err == nil || err.Error() != "unresolved placeholders: <namspace>, <ns>" {
+		t.Errorf("Expected <namspace> and <ns> to be reported, got %v", err)
+	}
+
+	got, err := snippet.ProcessTemplate(values, &Config{})
+	if err != nil {
+		t.Fatalf("ProcessTemplate failed: %v", err)
+	}
+	if expected := "kubectl get pods -n <namspace> <label> web <ns>"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+
+	strict := &Config{Settings: Settings{StrictPlaceholders: true}}
+	if _, err := snippet.ProcessTemplate(values, strict); err == nil || !strings.Contains(err.Error(), "<namspace>") {
+		t.Errorf("Expected an unresolved placeholder error, got %v", err)
+	}
+	snippet.Variables = append(snippet.Variables, Variable{Name: "namspace"}, Variable{Name: "ns"})
+	if _, err := snippet.ProcessTemplate(values, strict); err != nil {
+		t.Errorf("Expected no error once every placeholder is defined, got %v", err)
+	}
+}
+
+// TestResolveHooks tests that hooks are rendered with the command's values
+func TestResolveHooks(t *testing.T) {
+	snippet := Snippet{
+		Command:     "kubectl get pods",
+		PreCommand:  "kubectl config use-context <ctx>",
+		PostCommand: "notify-send done",
+		Variables:   []Variable{{Name: "ctx", DefaultValue: "dev"}},
+	}
+
+	pre, post, err := snippet.ResolveHooks(map[string]string{"ctx": "prod"}, &Config{})
+	if err != nil {
+		t.Fatalf("ResolveHooks failed: %v", err)
+	}
+	if pre != "kubectl config use-context prod" || post != "notify-send done" {
+		t.Errorf("Unexpected hooks: %q, %q", pre, post)
+	}
+
+	snippet.PreCommand, snippet.PostCommand = "", ""
+	if pre, post, err := snippet.ResolveHooks(nil, &Config{}); err != nil || pre != "" || post != "" {
+		t.Errorf("Expected no hooks, got %q, %q, %v", pre, post, err)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import "strings"
+
+// CollapseWhitespace squeezes each run of spaces and tabs in command to a
+// single space and trims whitespace at the end of every line, leaving
+// quoted strings and backslash-escaped characters as they are.
+func CollapseWhitespace(command string) string {
+	var c WhitespaceCollapser
+	return c.Collapse(command)
+}
+
+// WhitespaceCollapser is CollapseWhitespace for a command written in
+// pieces, such as the styled parts of the form preview; quoting carries
+// over from one piece to the next. A space is only written once the next
+// character shows it is not trailing.
+type WhitespaceCollapser struct {
+	quote   rune // the open quote character, or 0
+	escaped bool // the previous character was an unquoted backslash
+	space   bool // whitespace is pending
+}
+
+// Collapse returns the next piece of the command with its whitespace
+// collapsed.
+func (c *WhitespaceCollapser) Collapse(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case c.escaped:
+			c.escaped = false
+		case c.quote != 0:
+			if r == c.quote {
+				c.quote = 0
+			} else if r == '\\' && c.quote == '"' {
+				c.escaped = true
+			}
+		case r == ' ' || r == '\t':
+			c.space = true
+			continue
+		case r == '\n':
+			c.space = false
+		default:
+			if r == '\\' {
+				c.escaped = true
+			} else if r == '\'' || r == '"' {
+				c.quote = r
+			}
+		}
+		if c.space {
+			b.WriteByte(' ')
+			c.space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+package models
+
+import "testing"
+
+// TestCollapseWhitespace tests that runs of spaces are squeezed outside
+// quotes only
+func TestCollapseWhitespace(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"double spaces", "docker run -d  nginx", "docker run -d nginx"},
+		{"trailing", "app  ", "app"},
+		{"tabs", "a\t\t b", "a b"},
+		{"single quotes", "echo 'a   b'  c", "echo 'a   b' c"},
+		{"double quotes", `echo "a   b"  c`, `echo "a   b" c`},
+		{"escaped quote in double quotes", `echo "a \"  b"  c`, `echo "a \"  b" c`},
+		{"escaped space", `touch a\  b`, `touch a\  b`},
+		{"lines", "a  \\\n  b  \nc", "a \\\n b\nc"},
+		{"unclosed quote", "echo 'a   b", "echo 'a   b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CollapseWhitespace(tt.input); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestWhitespaceCollapser tests that quoting carries across pieces
+func TestWhitespaceCollapser(t *testing.T) {
+	var c WhitespaceCollapser
+	var got string
+	for _, piece := range []string{"echo '", "a  ", "  b' ", " ", "", "c  "} {
+		got += c.Collapse(piece)
+	}
+	if expected := "echo 'a    b' c"; got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+// TestProcessTemplate_CollapseWhitespace tests the setting and the
+// per-snippet override
+func TestProcessTemplate_CollapseWhitespace(t *testing.T) {
+	on, off := true, false
+	tests := []struct {
+		name     string
+		setting  bool
+		snippet  *bool
+		expected string
+	}{
+		{"off by default", false, nil, "docker run  nginx 'a  b' "},
+		{"setting", true, nil, "docker run nginx 'a  b'"},
+		{"snippet enables", false, &on, "docker run nginx 'a  b'"},
+		{"snippet disables", true, &off, "docker run  nginx 'a  b' "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := Snippet{
+				Command:            "docker run <detach> nginx 'a  b' <args>",
+				Variables:          []Variable{{Name: "detach"}, {Name: "args"}},
+				CollapseWhitespace: tt.snippet,
+			}
+			config := &Config{Settings: Settings{Output: OutputConfig{CollapseWhitespace: tt.setting}}}
+			got, err := snippet.ProcessTemplate(map[string]string{}, config)
+			if err != nil {
+				t.Fatalf("ProcessTemplate failed: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
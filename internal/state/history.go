@@ -0,0 +1,153 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// HistoryFile is the name of the execution history file within Dir.
+const HistoryFile = "history.jsonl"
+
+// HistoryEntry is one rendered snippet invocation.
+type HistoryEntry struct {
+	Snippet string            `json:"snippet"`
+	Command string            `json:"command"`
+	Values  map[string]string `json:"values,omitempty"`
+	Mode    string            `json:"mode"`
+	Time    time.Time         `json:"time"`
+}
+
+// HistoryPath returns the default location of the history file.
+func HistoryPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, HistoryFile), nil
+}
+
+// LoadHistory reads every entry in the history file at path, oldest first.
+// A missing file yields no entries; lines that fail to parse are skipped.
+func LoadHistory(path string) ([]HistoryEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// AppendHistory appends entry to the history file at path. When the file
+// then holds more than maxEntries entries, the oldest are dropped with an
+// atomic rewrite. maxEntries <= 0 disables the cap.
+func AppendHistory(path string, entry HistoryEntry, maxEntries int) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if maxEntries <= 0 {
+		return nil
+	}
+	entries, err := LoadHistory(path)
+	if err != nil || len(entries) <= maxEntries {
+		return err
+	}
+
+	return writeHistory(path, entries[len(entries)-maxEntries:])
+}
+
+// RenameHistory rewrites the history file at path so the entries of
+// oldName belong to newName. Entries already recorded for newName are
+// dropped, since the snippet they were for has been replaced. The file is
+// left alone when no entry is affected.
+func RenameHistory(path, oldName, newName string) error {
+	entries, err := LoadHistory(path)
+	if err != nil {
+		return err
+	}
+	changed := false
+	entries = slices.DeleteFunc(entries, func(e HistoryEntry) bool {
+		if e.Snippet == newName {
+			changed = true
+			return true
+		}
+		return false
+	})
+	for i := range entries {
+		if entries[i].Snippet == oldName {
+			entries[i].Snippet = newName
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return writeHistory(path, entries)
+}
+
+// writeHistory atomically replaces the history file at path with entries.
+func writeHistory(path string, entries []HistoryEntry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return writeFileAtomic(path, buf.Bytes())
+}
+
+// RecentValues returns, for each variable of the named snippet, the
+// distinct non-empty values recorded in entries, most recent first and at
+// most limit of them. entries are oldest first, as LoadHistory returns them.
+func RecentValues(entries []HistoryEntry, snippet string, limit int) map[string][]string {
+	recent := make(map[string][]string)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Snippet != snippet {
+			continue
+		}
+		for name, value := range entries[i].Values {
+			if value != "" && len(recent[name]) < limit && !slices.Contains(recent[name], value) {
+				recent[name] = append(recent[name], value)
+			}
+		}
+	}
+	return recent
+}
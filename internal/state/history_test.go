@@ -0,0 +1,135 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestHistory_AppendAndCap tests appending entries and trimming to the cap
+func TestHistory_AppendAndCap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFile)
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := range 5 {
+		entry := HistoryEntry{
+			Snippet: "snippet",
+			Command: "echo " + string(rune('a'+i)),
+			Values:  map[string]string{"n": string(rune('a' + i))},
+			Mode:    "print",
+			Time:    base.Add(time.Duration(i) * time.Minute),
+		}
+		if err := AppendHistory(path, entry, 3); err != nil {
+			t.Fatalf("AppendHistory failed: %v", err)
+		}
+	}
+
+	entries, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries after cap, got %d", len(entries))
+	}
+	if entries[0].Command != "echo c" || entries[2].Command != "echo e" {
+		t.Errorf("Expected oldest entries dropped, got %q..%q", entries[0].Command, entries[2].Command)
+	}
+	if entries[2].Values["n"] != "e" {
+		t.Errorf("Expected values preserved, got %v", entries[2].Values)
+	}
+}
+
+// TestRenameHistory tests that entries move to the new name, replacing
+// those recorded for it, and that an unaffected file is not rewritten
+func TestRenameHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFile)
+	for _, name := range []string{"old", "taken", "other", "old"} {
+		if err := AppendHistory(path, HistoryEntry{Snippet: name, Command: "echo " + name}, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RenameHistory(path, "old", "taken"); err != nil {
+		t.Fatalf("RenameHistory failed: %v", err)
+	}
+	entries, err := LoadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Snippet+": "+e.Command)
+	}
+	expected := []string{"taken: echo old", "other: echo other", "taken: echo old"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+
+	// A rewrite would replace the file, and its mode with it
+	if err := os.Chmod(path, 0400); err != nil {
+		t.Fatal(err)
+	}
+	if err := RenameHistory(path, "missing", "new"); err != nil {
+		t.Fatalf("RenameHistory failed: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode() != 0400 {
+		t.Errorf("Expected the file to be left alone, got %v (%v)", info, err)
+	}
+
+	if err := RenameHistory(filepath.Join(t.TempDir(), HistoryFile), "old", "new"); err != nil {
+		t.Errorf("Expected a missing file to be fine, got %v", err)
+	}
+}
+
+// TestHistory_SkipsCorruptLines tests that unparseable lines are ignored
+func TestHistory_SkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HistoryFile)
+	content := `{"snippet":"one","command":"echo 1","mode":"print","time":"2025-01-01T00:00:00Z"}
+not json at all
+{"snippet":"two","command":"echo 2","mode":"run","time":"2025-01-01T00:01:00Z"}
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("LoadHistory failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Snippet != "two" {
+		t.Errorf("Expected the two valid entries, got %+v", entries)
+	}
+}
+
+// TestHistory_MissingFile tests that a missing history file is empty, not an error
+func TestHistory_MissingFile(t *testing.T) {
+	entries, err := LoadHistory(filepath.Join(t.TempDir(), HistoryFile))
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no entries and no error, got %v, %v", entries, err)
+	}
+}
+
+// TestRecentValues tests that values are distinct, newest first, capped,
+// and limited to the snippet
+func TestRecentValues(t *testing.T) {
+	entries := []HistoryEntry{
+		{Snippet: "pods", Values: map[string]string{"ns": "default", "ctx": "dev"}},
+		{Snippet: "logs", Values: map[string]string{"ns": "other"}},
+		{Snippet: "pods", Values: map[string]string{"ns": "kube-system", "ctx": ""}},
+		{Snippet: "pods", Values: map[string]string{"ns": "default"}},
+		{Snippet: "pods", Values: map[string]string{"ns": "payments"}},
+	}
+
+	expected := map[string][]string{"ns": {"payments", "default", "kube-system"}, "ctx": {"dev"}}
+	if got := RecentValues(entries, "pods", 5); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if got := RecentValues(entries, "pods", 2)["ns"]; !reflect.DeepEqual(got, []string{"payments", "default"}) {
+		t.Errorf("Expected the two most recent values, got %v", got)
+	}
+	if got := RecentValues(entries, "missing", 5); len(got) != 0 {
+		t.Errorf("Expected nothing for an unknown snippet, got %v", got)
+	}
+}
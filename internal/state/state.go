@@ -0,0 +1,45 @@
+// Package state persists small bits of per-user runtime data, such as
+// snippet usage, outside the user's configuration files.
+package state
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Dir returns the directory cs keeps state files in:
+// $XDG_STATE_HOME/cs, falling back to ~/.local/state/cs.
+func Dir() (string, error) {
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "cs"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "cs"), nil
+}
+
+// writeFileAtomic writes data to a temp file in the same directory as path
+// and renames it into place, so readers never observe a partial write.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
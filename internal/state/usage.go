@@ -0,0 +1,88 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UsageFile is the name of the usage state file within Dir.
+const UsageFile = "usage.yaml"
+
+// UsageEntry records how often and when a snippet was last executed.
+type UsageEntry struct {
+	Count    int       `yaml:"count"`
+	LastUsed time.Time `yaml:"last_used"`
+}
+
+// Usage maps snippet names to their execution statistics.
+type Usage struct {
+	Snippets map[string]UsageEntry `yaml:"snippets"`
+}
+
+// UsagePath returns the default location of the usage state file.
+func UsagePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, UsageFile), nil
+}
+
+// LoadUsage reads the usage file at path. A missing file yields empty
+// usage. A file that can't be parsed also yields empty usage, together with
+// an error describing the problem, so callers can warn and carry on.
+func LoadUsage(path string) (*Usage, error) {
+	usage := &Usage{Snippets: make(map[string]UsageEntry)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return usage, nil
+	}
+	if err != nil {
+		return usage, err
+	}
+
+	var loaded Usage
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		return usage, fmt.Errorf("usage file %s is corrupt, starting fresh: %w", path, err)
+	}
+	if loaded.Snippets != nil {
+		usage.Snippets = loaded.Snippets
+	}
+	return usage, nil
+}
+
+// Record counts one execution of the named snippet at the given time.
+func (u *Usage) Record(name string, at time.Time) {
+	entry := u.Snippets[name]
+	entry.Count++
+	entry.LastUsed = at
+	u.Snippets[name] = entry
+}
+
+// Rename moves the usage recorded for oldName to newName, replacing any
+// recorded for newName. It reports whether anything changed.
+func (u *Usage) Rename(oldName, newName string) bool {
+	entry, ok := u.Snippets[oldName]
+	_, replaced := u.Snippets[newName]
+	delete(u.Snippets, oldName)
+	delete(u.Snippets, newName)
+	if ok {
+		u.Snippets[newName] = entry
+	}
+	return ok || replaced
+}
+
+// Save atomically writes the usage file to path.
+func (u *Usage) Save(path string) error {
+	data, err := yaml.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
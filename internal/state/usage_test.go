@@ -0,0 +1,120 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestUsage_RecordAndReload tests that recorded usage survives a save/load cycle
+func TestUsage_RecordAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", UsageFile)
+
+	usage, err := LoadUsage(path)
+	if err != nil {
+		t.Fatalf("LoadUsage on missing file failed: %v", err)
+	}
+	if len(usage.Snippets) != 0 {
+		t.Fatalf("Expected empty usage, got %v", usage.Snippets)
+	}
+
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	usage.Record("kubectl-get-pods", first)
+	usage.Record("kubectl-get-pods", second)
+	usage.Record("docker-ps", first)
+
+	if err := usage.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded, err := LoadUsage(path)
+	if err != nil {
+		t.Fatalf("LoadUsage failed: %v", err)
+	}
+
+	entry := reloaded.Snippets["kubectl-get-pods"]
+	if entry.Count != 2 {
+		t.Errorf("Expected count 2, got %d", entry.Count)
+	}
+	if !entry.LastUsed.Equal(second) {
+		t.Errorf("Expected last used %v, got %v", second, entry.LastUsed)
+	}
+	if reloaded.Snippets["docker-ps"].Count != 1 {
+		t.Errorf("Expected docker-ps count 1, got %d", reloaded.Snippets["docker-ps"].Count)
+	}
+
+	// No temp files should be left behind by the atomic write
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the usage file in state dir, got %d entries", len(entries))
+	}
+}
+
+// TestUsage_Rename tests moving usage to a new name, replacing what was
+// recorded there
+func TestUsage_Rename(t *testing.T) {
+	at := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	usage := &Usage{Snippets: map[string]UsageEntry{"old": {Count: 3, LastUsed: at}, "taken": {Count: 1}}}
+
+	if !usage.Rename("old", "taken") {
+		t.Error("Expected the rename to change usage")
+	}
+	if _, ok := usage.Snippets["old"]; ok {
+		t.Error("Expected old to be gone")
+	}
+	if entry := usage.Snippets["taken"]; entry.Count != 3 || !entry.LastUsed.Equal(at) {
+		t.Errorf("Expected old's usage under taken, got %+v", entry)
+	}
+
+	if usage.Rename("never-used", "other") {
+		t.Error("Expected renaming an unused snippet to change nothing")
+	}
+	if !usage.Rename("never-used", "taken") {
+		t.Error("Expected replacing a used snippet to change usage")
+	}
+	if len(usage.Snippets) != 0 {
+		t.Errorf("Expected no usage left, got %v", usage.Snippets)
+	}
+}
+
+// TestUsage_CorruptFile tests that a corrupt usage file starts fresh with an error
+func TestUsage_CorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), UsageFile)
+	if err := os.WriteFile(path, []byte("snippets: [not: a map"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	usage, err := LoadUsage(path)
+	if err == nil {
+		t.Error("Expected an error for a corrupt usage file")
+	}
+	if usage == nil || usage.Snippets == nil {
+		t.Fatal("Expected usable empty usage after corruption")
+	}
+
+	usage.Record("recovered", time.Now())
+	if err := usage.Save(path); err != nil {
+		t.Fatalf("Save after corruption failed: %v", err)
+	}
+	if _, err := LoadUsage(path); err != nil {
+		t.Errorf("Expected clean reload after save, got %v", err)
+	}
+}
+
+// TestDir_XDGStateHome tests that XDG_STATE_HOME is honored
+func TestDir_XDGStateHome(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/xdg-state")
+
+	dir, err := Dir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != filepath.Join("/tmp/xdg-state", "cs") {
+		t.Errorf("Expected XDG state dir, got %q", dir)
+	}
+}
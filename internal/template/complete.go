@@ -0,0 +1,58 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// completePath returns the filesystem entries that complete prefix, in
+// directory order, with directories suffixed by a slash. A leading ~ is
+// resolved against home for the lookup but kept in the candidates, so they
+// can be inserted as typed. Hidden entries are only offered when the
+// partial name starts with a dot.
+func completePath(prefix string, dirsOnly bool, home string) []string {
+	if prefix == "~" {
+		return []string{"~/"}
+	}
+
+	dirPart, base := "", prefix
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dirPart, base = prefix[:i+1], prefix[i+1:]
+	}
+
+	lookup := dirPart
+	switch {
+	case lookup == "":
+		lookup = "."
+	case strings.HasPrefix(lookup, "~/"):
+		lookup = filepath.Join(home, lookup[1:])
+	}
+
+	entries, err := os.ReadDir(lookup)
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(lookup, name)); err == nil {
+				isDir = info.IsDir()
+			}
+		}
+		if dirsOnly && !isDir {
+			continue
+		}
+		if isDir {
+			name += "/"
+		}
+		matches = append(matches, dirPart+name)
+	}
+	return matches
+}
@@ -0,0 +1,103 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/samling/command-snippets/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newPathFixture creates a directory tree for completion tests and returns
+// its root.
+func newPathFixture(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	for _, dir := range []string{"configs", "charts", ".hidden"} {
+		if err := os.Mkdir(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, file := range []string{"config.yaml", "configs/app.yaml", ".env"} {
+		if err := os.WriteFile(filepath.Join(root, file), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+// TestCompletePath tests filesystem completion of path prefixes
+func TestCompletePath(t *testing.T) {
+	root := newPathFixture(t)
+
+	tests := []struct {
+		name     string
+		prefix   string
+		dirsOnly bool
+		expected []string
+	}{
+		{"files and dirs", root + "/con", false, []string{root + "/config.yaml", root + "/configs/"}},
+		{"dirs only", root + "/c", true, []string{root + "/charts/", root + "/configs/"}},
+		{"directory contents", root + "/configs/", false, []string{root + "/configs/app.yaml"}},
+		{"hidden skipped", root + "/", true, []string{root + "/charts/", root + "/configs/"}},
+		{"hidden with dot", root + "/.", false, []string{root + "/.env", root + "/.hidden/"}},
+		{"home kept as typed", "~/con", false, []string{"~/config.yaml", "~/configs/"}},
+		{"bare tilde", "~", false, []string{"~/"}},
+		{"no match", root + "/missing", false, nil},
+		{"missing directory", root + "/nope/x", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := completePath(tt.prefix, tt.dirsOnly, root)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("completePath(%q) = %v, expected %v", tt.prefix, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormModel_PathCompletion tests that Tab cycles path completions before moving on
+func TestFormModel_PathCompletion(t *testing.T) {
+	root := newPathFixture(t)
+	snippet := &models.Snippet{
+		Command: "kubectl apply -f <file> -n <namespace>",
+		Variables: []models.Variable{
+			{Name: "file", Type: models.VarTypeFilepath},
+			{Name: "namespace"},
+		},
+	}
+	model := newFormModel(snippet, map[string]string{"file": "~/con"}, nil, nil)
+	model.homeDir = root
+
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+	steps := []struct {
+		key   tea.KeyMsg
+		value string
+		focus int
+	}{
+		{tab, "~/config.yaml", 0},
+		{tab, "~/configs/", 0},
+		{tab, "~/config.yaml", 0},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s/")}, "~/config.yamls/", 0},
+		{tea.KeyMsg{Type: tea.KeyCtrlX}, "", 0},
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("~/configs/a")}, "~/configs/a", 0},
+		{tab, "~/configs/app.yaml", 0},
+		{tab, "~/configs/app.yaml", 1},
+	}
+
+	var m tea.Model = model
+	for i, step := range steps {
+		m, _ = m.Update(step.key)
+		form := m.(formModel)
+		if got := form.fields[0].value; got != step.value {
+			t.Errorf("step %d: expected value %q, got %q", i, step.value, got)
+		}
+		if form.focusIndex != step.focus {
+			t.Errorf("step %d: expected focus %d, got %d", i, step.focus, form.focusIndex)
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package template
 
 import (
+	"errors"
 	"os"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// ErrConfirmCancelled is returned when the user dismisses the confirmation
+// before executing (Ctrl+C / Esc) instead of answering it.
+var ErrConfirmCancelled = errors.New("execution cancelled")
+
 // confirmModel represents a simple yes/no confirmation dialog
 type confirmModel struct {
 	message   string
@@ -57,7 +62,8 @@ func (m confirmModel) View() string {
 	return m.message + " [y/n]: "
 }
 
-// promptForConfirmation shows a yes/no confirmation dialog
+// promptForConfirmation shows a yes/no confirmation dialog, returning
+// ErrConfirmCancelled when it is dismissed.
 func promptForConfirmation(message string, noColor bool) (bool, error) {
 	SetupColorProfile(noColor)
 
@@ -72,8 +78,7 @@ func promptForConfirmation(message string, noColor bool) (bool, error) {
 
 	confirm := finalModel.(confirmModel)
 	if confirm.cancelled {
-		// Exit silently on cancellation
-		os.Exit(0)
+		return false, ErrConfirmCancelled
 	}
 
 	return confirm.confirmed, nil
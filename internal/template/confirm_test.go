@@ -0,0 +1,35 @@
+package template
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// TestConfirmModel_Update tests that answering records the choice and that
+// Esc and Ctrl+C cancel instead of declining
+func TestConfirmModel_Update(t *testing.T) {
+	tests := []struct {
+		name                 string
+		key                  tea.KeyMsg
+		confirmed, cancelled bool
+	}{
+		{"yes", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")}, true, false},
+		{"no", tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")}, false, false},
+		{"esc", tea.KeyMsg{Type: tea.KeyEsc}, false, true},
+		{"ctrl+c", tea.KeyMsg{Type: tea.KeyCtrlC}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, cmd := confirmModel{}.Update(tt.key)
+			m := updated.(confirmModel)
+			if !m.done || cmd == nil {
+				t.Fatal("Expected the answer to end the prompt")
+			}
+			if m.confirmed != tt.confirmed || m.cancelled != tt.cancelled {
+				t.Errorf("Expected confirmed=%v cancelled=%v, got confirmed=%v cancelled=%v",
+					tt.confirmed, tt.cancelled, m.confirmed, m.cancelled)
+			}
+		})
+	}
+}
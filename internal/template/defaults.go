@@ -0,0 +1,81 @@
+package template
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// dynamicDefaultTimeout bounds each default_from_command, which runs before
+// the form can open.
+var dynamicDefaultTimeout = 5 * time.Second
+
+// withDynamicDefaults returns snippet with the default of each variable that
+// has a default_from_command, and no preset, replaced by the command's
+// trimmed output. Commands only run when the config allows dynamic defaults
+// for the snippet. A command that fails or prints nothing keeps the static
+// default and is reported as a warning on stderr.
+func (p *Processor) withDynamicDefaults(snippet *models.Snippet, presetValues map[string]string) *models.Snippet {
+	if !p.config.AllowsDynamicDefaults(snippet) {
+		return snippet
+	}
+
+	var variables []models.Variable
+	for i, variable := range snippet.Variables {
+		if variable.DefaultFromCommand == "" || variable.Computed {
+			continue
+		}
+		if _, ok := presetValues[variable.Name]; ok {
+			continue
+		}
+		output, err := p.runDefaultCommand(variable.DefaultFromCommand)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: default_from_command for %s failed: %v\n", variable.Name, err)
+			continue
+		}
+		if variables == nil {
+			variables = append([]models.Variable(nil), snippet.Variables...)
+		}
+		variables[i].DefaultValue = output
+	}
+	if variables == nil {
+		return snippet
+	}
+
+	copied := *snippet
+	copied.Variables = variables
+	return &copied
+}
+
+// runDefaultCommand runs command through the configured shell and returns
+// its trimmed stdout, which must not be empty.
+func (p *Processor) runDefaultCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dynamicDefaultTimeout)
+	defer cancel()
+
+	cmd := p.shellCommandContext(ctx, command)
+	killProcessGroupOnCancel(cmd)
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out after %s", dynamicDefaultTimeout)
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+
+	output := strings.TrimSpace(string(out))
+	if output == "" {
+		return "", errors.New("no output")
+	}
+	return output, nil
+}
@@ -0,0 +1,61 @@
+package template
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// TestWithDynamicDefaults tests default_from_command resolution and its gating
+func TestWithDynamicDefaults(t *testing.T) {
+	orig := dynamicDefaultTimeout
+	dynamicDefaultTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { dynamicDefaultTimeout = orig })
+
+	localDir := t.TempDir()
+	newSnippet := func(source models.SnippetSource) *models.Snippet {
+		return &models.Snippet{
+			Command:    "kubectl --context <context> -n <namespace> <extra>",
+			Source:     source,
+			SourceFile: filepath.Join(localDir, ".csnippets"),
+			Variables: []models.Variable{
+				{Name: "context", DefaultValue: "static", DefaultFromCommand: "printf '  kind-dev\\n'"},
+				{Name: "namespace", DefaultValue: "default", DefaultFromCommand: "echo oops >&2; exit 3"},
+				{Name: "extra", DefaultValue: "none", DefaultFromCommand: "sleep 5"},
+			},
+		}
+	}
+	execution := models.ExecutionConfig{Shell: "sh", AllowDynamicDefaults: true}
+
+	tests := []struct {
+		name     string
+		settings models.ExecutionConfig
+		source   models.SnippetSource
+		presets  map[string]string
+		expected []string
+	}{
+		{"allowed", execution, models.SourceGlobal, nil, []string{"kind-dev", "default", "none"}},
+		{"preset skips command", execution, models.SourceGlobal, map[string]string{"context": "prod"}, []string{"static", "default", "none"}},
+		{"disabled", models.ExecutionConfig{Shell: "sh"}, models.SourceGlobal, nil, []string{"static", "default", "none"}},
+		{"untrusted local", execution, models.SourceLocal, nil, []string{"static", "default", "none"}},
+		{"trusted local", models.ExecutionConfig{Shell: "sh", AllowDynamicDefaults: true, TrustedDirs: []string{localDir}}, models.SourceLocal, nil, []string{"kind-dev", "default", "none"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewProcessor(&models.Config{Settings: models.Settings{Execution: tt.settings}})
+			snippet := newSnippet(tt.source)
+			got := processor.withDynamicDefaults(snippet, tt.presets)
+			for i, want := range tt.expected {
+				if got.Variables[i].DefaultValue != want {
+					t.Errorf("%s: expected default %q, got %q", got.Variables[i].Name, want, got.Variables[i].DefaultValue)
+				}
+			}
+			if snippet.Variables[0].DefaultValue != "static" {
+				t.Error("Expected the original snippet to be left unchanged")
+			}
+		})
+	}
+}
@@ -3,15 +3,20 @@ package template
 import (
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/samling/command-snippets/internal/models"
 	"github.com/samling/command-snippets/internal/regex"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/cellbuf"
 	"golang.org/x/term"
 )
 
@@ -20,8 +25,9 @@ import (
 var ErrUserCancelled = errors.New("user cancelled")
 
 // placeholderPattern matches <name> tokens used by the snippet command
-// template — must stay in sync with models.placeholderPattern.
-var placeholderPattern = regexp.MustCompile(`<([A-Za-z_][A-Za-z0-9_]*)>`)
+// template — must stay in sync with models.placeholderPattern, including
+// the <<name>> escape for a literal <name>.
+var placeholderPattern = regexp.MustCompile(`<<[A-Za-z_][A-Za-z0-9_]*>>|<([A-Za-z_][A-Za-z0-9_]*)>`)
 
 // wrapLines takes a slice of lines and wraps any that exceed the given width
 func wrapLines(lines []string, maxWidth int) []string {
@@ -103,16 +109,382 @@ var (
 
 	filledVarStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("120")) // Green for filled variables
+
+	focusedVarStyle = lipgloss.NewStyle().
+			Underline(true).
+			Background(lipgloss.Color("237")) // Focused variable in the preview
+
+	dependentVarStyle = lipgloss.NewStyle().
+				Underline(true) // Computed variables built from the focused one
+
+	groupHeaderStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("214")). // Orange section headers
+				Bold(true).
+				Underline(true)
+
+	chipStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("86")).  // Cyan text
+			Background(lipgloss.Color("237")). // Dark gray chip
+			Padding(0, 1)
 )
 
+// secretMask stands in for each character of a secret value, and
+// secretPreview for the whole value in the command preview.
+const (
+	secretMask    = "•"
+	secretPreview = "••••"
+)
+
+// requiredMarker follows the label of a field that needs a value, in
+// errorStyle.
+const requiredMarker = "*"
+
 // formField represents a single field in the form
 type formField struct {
 	variable     models.Variable
 	value        string
-	cursorPos    int // Current cursor position in the value string
+	cursorPos    int // Byte offset of the cursor in value, always on a rune boundary
 	errorMessage string
 	enumIndex    int      // For enum fields, tracks the selected option index
 	enumOptions  []string // For enum/boolean fields, the available options
+
+	completions     []string // For path fields, the matches Tab cycles through
+	completionIndex int      // Index of the completion currently inserted
+
+	items []string // For list fields, the items added so far; value holds the next one
+
+	selected []bool // For multiple-choice enums, which options are checked
+
+	filter    string // For long enums, the text narrowing the options
+	filtering bool   // Keys go to the filter; enumIndex is the highlighted match
+
+	history    []string // For text fields, values entered in earlier runs, most recent first
+	suggesting bool     // A suggestion is highlighted and Tab takes it
+	suggestion int      // Index of the highlighted suggestion
+
+	undo, redo []fieldSnapshot // Edit history of text fields, most recent last
+	typing     bool            // The last edit was typing, so more typing joins it
+}
+
+// redoKey redoes an undone edit in a text field. It is Alt+Z because most
+// terminals send Ctrl+Shift+Z as Ctrl+Z; see isCtrlShiftZ.
+var redoKey = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}, Alt: true}
+
+// ctrlShiftZ holds the CSI sequences, minus the leading ESC [, in which
+// terminals with the kitty keyboard protocol or xterm's modifyOtherKeys
+// report Ctrl+Shift+Z.
+var ctrlShiftZ = []string{"122;6u", "90;6u", "27;6;90~", "27;6;122~"}
+
+// isCtrlShiftZ reports whether msg is Ctrl+Shift+Z. Only terminals that
+// report modified keys as CSI sequences tell it apart from Ctrl+Z, and
+// Bubble Tea passes those sequences on unparsed, so they are recognized by
+// the string form it gives them. Elsewhere Ctrl+Shift+Z arrives as Ctrl+Z
+// and undoes, which is why Alt+Z redoes too.
+func isCtrlShiftZ(msg tea.Msg) bool {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		return false
+	}
+	s, ok := msg.(fmt.Stringer)
+	if !ok {
+		return false
+	}
+	for _, seq := range ctrlShiftZ {
+		if s.String() == fmt.Sprintf("?CSI%+v?", []byte(seq)) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxFieldHistory caps the undo entries kept per field.
+const maxFieldHistory = 50
+
+// fieldSnapshot is the editable state of a text field at one point in its
+// edit history.
+type fieldSnapshot struct {
+	value     string
+	cursorPos int
+	items     []string
+}
+
+// snapshot returns the field's current editable state.
+func (f formField) snapshot() fieldSnapshot {
+	return fieldSnapshot{value: f.value, cursorPos: f.cursorPos, items: slices.Clone(f.items)}
+}
+
+// restore puts the field back in the state s.
+func (f *formField) restore(s fieldSnapshot) {
+	f.value, f.cursorPos, f.items = s.value, s.cursorPos, slices.Clone(s.items)
+}
+
+// recordEdit adds before, the state prior to an edit, to the undo history
+// and forgets what could be redone. Consecutive typing is undone as one
+// edit.
+func (f *formField) recordEdit(before fieldSnapshot, typing bool) {
+	if !typing || !f.typing {
+		f.undo = append(f.undo, before)
+		if len(f.undo) > maxFieldHistory {
+			f.undo = slices.Delete(f.undo, 0, len(f.undo)-maxFieldHistory)
+		}
+	}
+	f.typing = typing
+	f.redo = nil
+}
+
+// undoEdit reverts the last edit, reporting false when there is none.
+func (f *formField) undoEdit() bool {
+	if len(f.undo) == 0 {
+		return false
+	}
+	f.redo = append(f.redo, f.snapshot())
+	f.restore(f.undo[len(f.undo)-1])
+	f.undo = f.undo[:len(f.undo)-1]
+	f.typing = false
+	return true
+}
+
+// redoEdit reapplies the last undone edit, reporting false when there is
+// none.
+func (f *formField) redoEdit() bool {
+	if len(f.redo) == 0 {
+		return false
+	}
+	f.undo = append(f.undo, f.snapshot())
+	f.restore(f.redo[len(f.redo)-1])
+	f.redo = f.redo[:len(f.redo)-1]
+	f.typing = false
+	return true
+}
+
+// maxShownSuggestions caps the earlier values listed under a text field.
+const maxShownSuggestions = 5
+
+// suggestions returns the earlier values to offer for a text field: those
+// starting with what has been typed, other than the value itself.
+func (f formField) suggestions() []string {
+	if len(f.enumOptions) > 0 || f.variable.Type == models.VarTypeSecret || f.variable.Type == models.VarTypeMultiline {
+		return nil
+	}
+	var matches []string
+	for _, value := range f.history {
+		if value != f.value && strings.HasPrefix(strings.ToLower(value), strings.ToLower(f.value)) {
+			matches = append(matches, value)
+		}
+		if len(matches) == maxShownSuggestions {
+			break
+		}
+	}
+	return matches
+}
+
+// suggestionKey handles msg for a text field with suggestions, reporting
+// whether it was consumed. Ctrl+N highlights the first suggestion and then
+// moves down the list, Ctrl+P moves back up and off it, Tab takes the
+// highlighted suggestion and Esc leaves the list. Other keys leave it and
+// are handled as usual.
+func (f *formField) suggestionKey(msg tea.KeyMsg) bool {
+	suggestions := f.suggestions()
+	if len(suggestions) == 0 {
+		f.suggesting = false
+		return false
+	}
+	switch msg.String() {
+	case "ctrl+n":
+		if f.suggesting {
+			f.suggestion = min(f.suggestion+1, len(suggestions)-1)
+		} else {
+			f.suggesting, f.suggestion = true, 0
+		}
+		return true
+	case "ctrl+p", "tab", "esc":
+		if !f.suggesting {
+			return false
+		}
+	default:
+		f.suggesting = false
+		return false
+	}
+
+	switch msg.String() {
+	case "ctrl+p":
+		f.suggestion--
+		f.suggesting = f.suggestion >= 0
+	case "tab":
+		before := f.snapshot()
+		f.value = suggestions[min(f.suggestion, len(suggestions)-1)]
+		f.cursorPos = len(f.value)
+		f.recordEdit(before, false)
+		f.suggesting = false
+	case "esc":
+		f.suggesting = false
+	}
+	return true
+}
+
+// enumFilterThreshold is the number of options above which a single-choice
+// enum is shown as a vertical list that typing filters.
+const enumFilterThreshold = 6
+
+// enumListHeight is the number of options the vertical list shows at once.
+const enumListHeight = 8
+
+// isFilterable reports whether the field is a single-choice enum with too
+// many options to show on one line.
+func (f formField) isFilterable() bool {
+	return !f.isMultiSelect() && len(f.enumOptions) > enumFilterThreshold
+}
+
+// enumMatches returns the indices of the options matching the filter, those
+// containing it ahead of those only matching it fuzzily, each in enum order.
+func (f formField) enumMatches() []int {
+	var contains, fuzzy []int
+	filter := strings.ToLower(f.filter)
+	for i, option := range f.enumOptions {
+		option = strings.ToLower(option)
+		switch {
+		case strings.Contains(option, filter):
+			contains = append(contains, i)
+		case fuzzyMatch(filter, option):
+			fuzzy = append(fuzzy, i)
+		}
+	}
+	return append(contains, fuzzy...)
+}
+
+// fuzzyMatch reports whether the runes of pattern appear in s in order.
+func fuzzyMatch(pattern, s string) bool {
+	for _, r := range pattern {
+		i := strings.IndexRune(s, r)
+		if i < 0 {
+			return false
+		}
+		s = s[i+utf8.RuneLen(r):]
+	}
+	return true
+}
+
+// filterKey handles msg for a filterable enum, reporting whether it was
+// consumed. Typing starts or extends the filter and highlights the best
+// match; while filtering, up and down move through the matches, Enter
+// accepts the highlighted one and Esc abandons the filter. Any other key
+// ends filtering and is handled as usual.
+func (f *formField) filterKey(msg tea.KeyMsg) bool {
+	if (msg.Type == tea.KeyRunes && !msg.Alt) || msg.Type == tea.KeySpace {
+		if msg.Type == tea.KeySpace {
+			f.filter += " "
+		} else {
+			f.filter += string(msg.Runes)
+		}
+		f.filtering = true
+		if matches := f.enumMatches(); len(matches) > 0 {
+			f.enumIndex = matches[0]
+		}
+		return true
+	}
+	if !f.filtering {
+		return false
+	}
+
+	matches := f.enumMatches()
+	current := slices.Index(matches, f.enumIndex)
+	switch msg.String() {
+	case "backspace":
+		if f.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(f.filter)
+			f.filter = f.filter[:len(f.filter)-size]
+			if matches := f.enumMatches(); len(matches) > 0 {
+				f.enumIndex = matches[0]
+			}
+		}
+	case "up":
+		if current > 0 {
+			f.enumIndex = matches[current-1]
+		}
+	case "down":
+		if current >= 0 && current < len(matches)-1 {
+			f.enumIndex = matches[current+1]
+		}
+	case "enter":
+		if current >= 0 {
+			f.value = f.enumOptions[f.enumIndex]
+		}
+		f.endFilter()
+	case "esc":
+		f.endFilter()
+	default:
+		f.endFilter()
+		return false
+	}
+	return true
+}
+
+// pickOption selects option i of an enum field: a multiple-choice enum
+// toggles it, other enums take it as their value.
+func (f *formField) pickOption(i int) {
+	f.enumIndex = i
+	if f.isMultiSelect() {
+		f.toggleOption()
+		return
+	}
+	f.value = f.enumOptions[i]
+	if f.filtering {
+		f.endFilter()
+	}
+}
+
+// endFilter leaves filtering, highlighting the field's value again.
+func (f *formField) endFilter() {
+	f.filter, f.filtering = "", false
+	f.enumIndex = max(slices.Index(f.enumOptions, f.value), 0)
+}
+
+// isMultiSelect reports whether the field is a multiple-choice enum, where
+// enumIndex is the highlighted option rather than the value.
+func (f formField) isMultiSelect() bool {
+	return f.selected != nil
+}
+
+// toggleOption checks or unchecks the highlighted option of a multiple-choice
+// enum.
+func (f *formField) toggleOption() {
+	f.selected[f.enumIndex] = !f.selected[f.enumIndex]
+	f.joinSelected()
+}
+
+// joinSelected sets a multiple-choice enum's value to its checked options,
+// in enum order.
+func (f *formField) joinSelected() {
+	var checked []string
+	for i, option := range f.enumOptions {
+		if f.selected[i] {
+			checked = append(checked, option)
+		}
+	}
+	f.value = strings.Join(checked, f.variable.ListSeparator())
+}
+
+// fullValue returns the field's value as submitted: for list fields the
+// items, including one still being typed, joined by the separator.
+func (f formField) fullValue() string {
+	if f.variable.Type != models.VarTypeList {
+		return f.value
+	}
+	items := f.items
+	if f.value != "" {
+		items = append(slices.Clip(items), f.value)
+	}
+	return strings.Join(items, f.variable.ListSeparator())
+}
+
+// addItem moves the typed text of a list field into its items. It reports
+// false when there is nothing to add.
+func (f *formField) addItem() bool {
+	if f.value == "" {
+		return false
+	}
+	f.items = append(f.items, f.value)
+	f.value, f.cursorPos = "", 0
+	return true
 }
 
 // formModel represents the state of the form
@@ -120,25 +492,83 @@ type formModel struct {
 	snippet           *models.Snippet
 	fields            []formField
 	focusIndex        int
-	done              bool
-	cancelled         bool
+	state             formState
 	config            *models.Config
 	width             int
 	height            int
-	showRegexPane     bool // Whether to show regex explanation pane
-	regexPaneScrollUp int  // Number of lines scrolled up in regex pane
+	showRegexPane     bool   // Whether to show regex explanation pane
+	regexPaneScrollUp int    // Number of lines scrolled up in regex pane
+	revealSecret      bool   // Whether the focused secret field shows plaintext
+	previewExpanded   bool   // Whether a long command preview is shown in full
+	homeDir           string // Resolves ~ when completing path fields
+
+	layout *formLayout // Where the last View put each field, for mouse clicks
 }
 
-// newFormModel creates a new form model for the given snippet
-func newFormModel(snippet *models.Snippet, presetValues map[string]string, config *models.Config) formModel {
+// formState is the stage the form is at.
+type formState int
+
+const (
+	formEditing   formState = iota // Fields are being filled in
+	formSummary                    // Values are shown for review before submitting
+	formDone                       // The values were accepted
+	formCancelled                  // The user cancelled
+)
+
+// formLayout records where View rendered each field, in lines of the view,
+// so mouse events can be mapped back to fields and options. It is shared
+// between copies of the model.
+type formLayout struct {
+	lines  int // Lines in the view
+	offset int // Field lines scrolled out of view above, when the form is too tall
+	paneX  int // Column the regex pane starts at, 0 when it is not shown
+	fields []fieldArea
+}
+
+// fieldArea is the lines [top, bottom) a field occupies in the view and the
+// positions of the enum options shown in it.
+type fieldArea struct {
+	field       int
+	top, bottom int
+	options     []optionArea
+}
+
+// optionArea is the columns [start, end) on line of the view where an enum
+// option is shown.
+type optionArea struct {
+	index      int
+	line       int
+	start, end int
+}
+
+// newFormModel creates a form model for the snippet, with fields in
+// variable order, grouped by section. fieldErrors maps variable names to
+// an error shown from the start, such as a preset that failed validation;
+// the first such field is focused.
+func newFormModel(snippet *models.Snippet, presetValues map[string]string, fieldErrors map[string]string, config *models.Config) formModel {
 	var fields []formField
+	focusIndex := -1
+	homeDir, _ := os.UserHomeDir()
 
-	for _, variable := range snippet.Variables {
+	// A dependency cycle is reported before the form opens; fall back to
+	// declaration order regardless.
+	ordered, err := snippet.OrderedVariables(config)
+	if err != nil {
+		ordered = snippet.Variables
+	}
+	// Fields are navigated in the order they are shown, group by group
+	var variables []models.Variable
+	for _, group := range models.GroupVariables(ordered) {
+		variables = append(variables, group.Variables...)
+	}
+
+	for _, variable := range variables {
 		if variable.Computed {
 			continue // Skip computed variables
 		}
+		variable = variable.WithMapEnum(config)
 
-		defaultValue := variable.DefaultValue
+		defaultValue := variable.ResolveDefault(config)
 
 		field := formField{
 			variable:  variable,
@@ -156,6 +586,9 @@ func newFormModel(snippet *models.Snippet, presetValues map[string]string, confi
 			}
 		} else if variable.Validation != nil && len(variable.Validation.Enum) > 0 {
 			field.enumOptions = variable.Validation.Enum
+			if variable.Validation.Multiple {
+				field.selected = make([]bool, len(field.enumOptions))
+			}
 		}
 
 		// Ensure cursor position is valid
@@ -171,8 +604,17 @@ func newFormModel(snippet *models.Snippet, presetValues map[string]string, confi
 			}
 		}
 
-		// For fields with enum options, set the initial index based on value
-		if len(field.enumOptions) > 0 {
+		// For multiple-choice enums, check the options named in the value
+		if field.isMultiSelect() {
+			for _, item := range variable.ListItems(field.value) {
+				if i := slices.Index(field.enumOptions, item); i >= 0 {
+					field.selected[i] = true
+				}
+			}
+			// Anything not in the enum is dropped from the value
+			field.joinSelected()
+		} else if len(field.enumOptions) > 0 {
+			// For fields with enum options, set the initial index based on value
 			for i, option := range field.enumOptions {
 				if option == field.value {
 					field.enumIndex = i
@@ -185,16 +627,41 @@ func newFormModel(snippet *models.Snippet, presetValues map[string]string, confi
 			}
 		}
 
+		if msg, ok := fieldErrors[variable.Name]; ok {
+			// An enum field falls back to a valid option, so name the
+			// rejected value.
+			if preset, ok := presetValues[variable.Name]; ok && preset != field.value {
+				msg = fmt.Sprintf("preset %q rejected: %s", preset, msg)
+			}
+			field.errorMessage = msg
+			if focusIndex < 0 {
+				focusIndex = len(fields)
+			}
+		}
+
+		// List fields show their items as chips and edit a new one
+		if variable.Type == models.VarTypeList {
+			field.items = variable.ListItems(field.value)
+			field.value, field.cursorPos = "", 0
+		}
+
 		fields = append(fields, field)
 	}
 
-	return formModel{
+	m := formModel{
 		snippet:       snippet,
 		fields:        fields,
-		focusIndex:    0,
+		focusIndex:    max(focusIndex, 0),
 		config:        config,
 		showRegexPane: true, // Show regex pane by default
+		homeDir:       homeDir,
+		layout:        &formLayout{},
+	}
+	// Start on a field the user can see
+	if len(fields) > 0 && m.hiddenFields()[fields[m.focusIndex].variable.Name] {
+		m.focusIndex = m.nextVisible(m.focusIndex, 1)
 	}
+	return m
 }
 
 // Init initializes the model
@@ -204,6 +671,10 @@ func (m formModel) Init() tea.Cmd {
 
 // Update handles messages and updates the model
 func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if isCtrlShiftZ(msg) {
+		msg = redoKey
+	}
+
 	// Safety check: this shouldn't happen anymore since we skip the form for no variables
 	// but keep it for defensive programming
 	if len(m.fields) == 0 {
@@ -211,10 +682,10 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyMsg:
 			switch msg.String() {
 			case "enter":
-				m.done = true
+				m.state = formDone
 				return m, tea.Quit
 			case "ctrl+c", "esc":
-				m.cancelled = true
+				m.state = formCancelled
 				return m, tea.Quit
 			}
 		case tea.WindowSizeMsg:
@@ -236,9 +707,19 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
+	case tea.MouseMsg:
+		if m.state == formSummary {
+			return m, nil
+		}
+		return m.updateMouse(msg)
+
 	case tea.KeyMsg:
+		if m.state == formSummary {
+			return m.updateSummary(msg)
+		}
 		currentField := &m.fields[m.focusIndex]
 		isEnum := len(currentField.enumOptions) > 0
+		previousValue := currentField.value
 
 		// Safety check: ensure cursor position is valid for current field
 		if !isEnum {
@@ -249,39 +730,61 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		// Handle bracketed paste - it comes through as "[" + content + "]"
+		before := currentField.snapshot()
+		typed := false
+
 		keyStr := msg.String()
 
-		// Check if this is bracketed paste content
-		if !isEnum && strings.HasPrefix(keyStr, "[") && strings.HasSuffix(keyStr, "]") && len(keyStr) > 2 {
-			// This is bracketed paste - extract the content between brackets
-			pastedContent := keyStr[1 : len(keyStr)-1]
+		// Ctrl+Z undoes the last edit of a text field and Ctrl+Shift+Z (or
+		// Alt+Z, see isCtrlShiftZ) redoes it
+		if !isEnum && (keyStr == "ctrl+z" || keyStr == redoKey.String()) {
+			changed := currentField.undoEdit
+			if keyStr == redoKey.String() {
+				changed = currentField.redoEdit
+			}
+			if changed() {
+				currentField.validateNumber(m.config)
+				m.regexPaneScrollUp = 0
+			}
+			return m, nil
+		}
+
+		// Any key but Tab ends path completion
+		if keyStr != "tab" {
+			currentField.completions = nil
+		}
+
+		// Pasted text arrives as one message of runes, marked Paste when the
+		// terminal supports bracketed paste; without it several runes may
+		// still arrive at once. Either way the text is inserted at the cursor.
+		if !isEnum && msg.Type == tea.KeyRunes && !msg.Alt && (msg.Paste || len(msg.Runes) > 1) {
+			pastedContent := normalizePaste(string(msg.Runes), currentField.variable.Type == models.VarTypeMultiline)
+			if !acceptsText(currentField.variable, pastedContent) {
+				return m, nil
+			}
 			// Insert at cursor position
 			currentField.value = currentField.value[:currentField.cursorPos] + pastedContent + currentField.value[currentField.cursorPos:]
 			currentField.cursorPos += len(pastedContent)
+			currentField.recordEdit(before, false)
+			currentField.validateNumber(m.config)
 			// Reset scroll when pasting
 			m.regexPaneScrollUp = 0
 			return m, nil
 		}
 
-		// Check for regular multi-character paste (without brackets)
-		if !isEnum && len(keyStr) > 1 && !strings.HasPrefix(keyStr, "ctrl+") &&
-			!strings.HasPrefix(keyStr, "alt+") && !strings.HasPrefix(keyStr, "shift+") &&
-			keyStr != "tab" && keyStr != "enter" && keyStr != "backspace" &&
-			keyStr != "up" && keyStr != "down" && keyStr != "left" && keyStr != "right" &&
-			keyStr != "esc" && keyStr != "home" && keyStr != "end" {
-			// This is likely pasted content without brackets
-			// Insert at cursor position
-			currentField.value = currentField.value[:currentField.cursorPos] + keyStr + currentField.value[currentField.cursorPos:]
-			currentField.cursorPos += len(keyStr)
-			// Reset scroll when pasting
-			m.regexPaneScrollUp = 0
+		// Long enums take typing as a filter over their options
+		if currentField.isFilterable() && currentField.filterKey(msg) {
+			return m, nil
+		}
+		// Text fields offer values entered in earlier runs
+		if currentField.suggestionKey(msg) {
+			currentField.validateNumber(m.config)
 			return m, nil
 		}
 
 		switch msg.String() {
 		case "ctrl+c", "esc":
-			m.cancelled = true
+			m.state = formCancelled
 			return m, tea.Quit
 
 		case "ctrl+r":
@@ -289,56 +792,47 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.showRegexPane = !m.showRegexPane
 			m.regexPaneScrollUp = 0 // Reset scroll when toggling
 
+		case "ctrl+up", "ctrl+down":
+			// Increment or decrement numeric fields
+			if currentField.variable.IsNumeric() {
+				if keyStr == "ctrl+up" {
+					currentField.stepNumber(1)
+				} else {
+					currentField.stepNumber(-1)
+				}
+			}
+
+		case "ctrl+p":
+			// Expand or collapse a long command preview
+			m.previewExpanded = !m.previewExpanded
+
+		case "ctrl+t":
+			// Toggle plaintext for the focused secret field
+			if currentField.variable.Type == models.VarTypeSecret {
+				m.revealSecret = !m.revealSecret
+			}
+
 		case "ctrl+u":
 			// Scroll regex pane up (show earlier content)
 			if currentField.variable.Type == models.VarTypeRegex && currentField.value != "" && m.showRegexPane {
-				m.regexPaneScrollUp -= 5
-				if m.regexPaneScrollUp < 0 {
-					m.regexPaneScrollUp = 0
-				}
+				m.scrollRegexPaneUp()
 				return m, nil // Consume the event to prevent default scrolling
 			}
 
 		case "ctrl+d":
 			// Scroll regex pane down (show later content)
 			if currentField.variable.Type == models.VarTypeRegex && currentField.value != "" && m.showRegexPane && m.height > 0 && m.width >= 100 {
-				// Calculate max scroll to prevent scrolling past content
-				// Must use same calculation as View()
-				formWidth := int(float64(m.width) * 0.6)
-				if formWidth < 60 {
-					formWidth = 60
-				}
-				explanationWidth := m.width - formWidth - 2
-
-				explanation := regex.ExplainRegexPattern(currentField.value)
-				rawLines := strings.Split(strings.TrimRight(explanation, "\n"), "\n")
-				explanationLines := wrapLines(rawLines, explanationWidth-4)
-
-				maxContentLines := m.height - 5 // Must match View() calculation
-				if maxContentLines < 5 {
-					maxContentLines = 5
-				}
-				maxScroll := len(explanationLines) - maxContentLines
-				if maxScroll < 0 {
-					maxScroll = 0
-				}
-
-				// Only increment if we're not already at max
-				if m.regexPaneScrollUp < maxScroll {
-					m.regexPaneScrollUp += 5
-					if m.regexPaneScrollUp > maxScroll {
-						m.regexPaneScrollUp = maxScroll
-					}
-				}
+				m.scrollRegexPaneDown()
 				return m, nil // Consume the event to prevent default scrolling
 			}
 
 		case "tab", "down":
-			// Move to next field, wrap around to top
-			m.focusIndex++
-			if m.focusIndex >= len(m.fields) {
-				m.focusIndex = 0
+			// Tab completes path fields while there is something to complete
+			if keyStr == "tab" && isPathField(currentField.variable) && currentField.cycleCompletion(m.homeDir) {
+				break
 			}
+			// Move to next visible field, wrap around to top
+			m.focusIndex = m.nextVisible(m.focusIndex, 1)
 			// Set cursor to end of new field's value
 			newField := &m.fields[m.focusIndex]
 			if len(newField.enumOptions) == 0 {
@@ -348,15 +842,13 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					newField.cursorPos = 0
 				}
 			}
-			// Reset scroll when changing fields
+			// Reset scroll and re-mask secrets when changing fields
 			m.regexPaneScrollUp = 0
+			m.revealSecret = false
 
 		case "shift+tab", "up":
-			// Move to previous field, wrap around to bottom
-			m.focusIndex--
-			if m.focusIndex < 0 {
-				m.focusIndex = len(m.fields) - 1
-			}
+			// Move to previous visible field, wrap around to bottom
+			m.focusIndex = m.nextVisible(m.focusIndex, -1)
 			// Set cursor to end of new field's value
 			newField := &m.fields[m.focusIndex]
 			if len(newField.enumOptions) == 0 {
@@ -366,20 +858,23 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					newField.cursorPos = 0
 				}
 			}
-			// Reset scroll when changing fields
+			// Reset scroll and re-mask secrets when changing fields
 			m.regexPaneScrollUp = 0
+			m.revealSecret = false
 
 		case "left":
 			if isEnum {
 				// For enum fields, cycle to previous option
 				if currentField.enumIndex > 0 {
 					currentField.enumIndex--
-					currentField.value = currentField.enumOptions[currentField.enumIndex]
+					if !currentField.isMultiSelect() {
+						currentField.value = currentField.enumOptions[currentField.enumIndex]
+					}
 				}
 			} else {
 				// For text fields, move cursor left
 				if currentField.cursorPos > 0 {
-					currentField.cursorPos--
+					currentField.cursorPos = runeBefore(currentField.value, currentField.cursorPos)
 				}
 			}
 
@@ -388,44 +883,94 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// For enum fields, cycle to next option
 				if currentField.enumIndex < len(currentField.enumOptions)-1 {
 					currentField.enumIndex++
-					currentField.value = currentField.enumOptions[currentField.enumIndex]
+					if !currentField.isMultiSelect() {
+						currentField.value = currentField.enumOptions[currentField.enumIndex]
+					}
 				}
 			} else {
 				// For text fields, move cursor right
 				if currentField.cursorPos < len(currentField.value) {
-					currentField.cursorPos++
+					currentField.cursorPos = runeAfter(currentField.value, currentField.cursorPos)
 				}
 			}
 
-		case "enter":
-			// Submit form if on last field, otherwise move to next
-			if m.focusIndex == len(m.fields)-1 {
-				// Validate all fields before submitting
-				allValid := true
+		case "enter", "ctrl+s":
+			// Ctrl+S skips ahead to the next required field still empty;
+			// with none left it moves on like Enter
+			if keyStr == "ctrl+s" {
+				if next := m.nextRequiredEmpty(); next >= 0 {
+					m.focusField(next)
+					break
+				}
+			}
+			// Enter starts a new line in multiline fields
+			if msg.String() == "enter" && currentField.variable.Type == models.VarTypeMultiline {
+				currentField.value = currentField.value[:currentField.cursorPos] + "\n" + currentField.value[currentField.cursorPos:]
+				currentField.cursorPos++
+				break
+			}
+			// Enter adds the typed item to a list field; with nothing typed it moves on
+			if msg.String() == "enter" && currentField.variable.Type == models.VarTypeList && currentField.addItem() {
+				break
+			}
+			// Submit form if on last visible field, otherwise move to next
+			next := m.nextVisible(m.focusIndex, 1)
+			if next <= m.focusIndex {
+				// Validate all visible fields before submitting
+				hidden := m.hiddenFields()
+				values := m.getValues()
+				firstInvalid := -1
 				for i := range m.fields {
-					if err := m.fields[i].variable.ValidateWithConfig(m.fields[i].value, m.config); err != nil {
+					variable := m.fields[i].variable
+					if hidden[variable.Name] {
+						m.fields[i].errorMessage = ""
+						continue
+					}
+					if err := variable.ValidateWithConfig(m.fields[i].fullValue(), m.config); err != nil {
 						m.fields[i].errorMessage = err.Error()
-						allValid = false
+					} else if m.fields[i].fullValue() == "" && isRequired(variable, values) {
+						m.fields[i].errorMessage = fmt.Sprintf("variable %s is required when %s", variable.Name, variable.RequiredIf)
 					} else {
 						m.fields[i].errorMessage = ""
+						continue
+					}
+					if firstInvalid < 0 {
+						firstInvalid = i
 					}
 				}
 
-				if allValid {
-					m.done = true
+				if firstInvalid < 0 {
+					// Some snippets want the values reviewed first
+					if m.snippet != nil && m.snippet.ShowsSummary(m.config) {
+						m.state = formSummary
+						return m, nil
+					}
+					m.state = formDone
 					return m, tea.Quit
 				}
+				// Take the user to the first field to fix
+				m.focusField(firstInvalid)
 			} else {
 				// Move to next field
-				m.focusIndex++
+				m.focusIndex = next
+				m.revealSecret = false
 			}
 
 		case "backspace":
+			// In an empty list field, take the last item back for editing
+			if currentField.value == "" && len(currentField.items) > 0 {
+				last := len(currentField.items) - 1
+				currentField.value = currentField.items[last]
+				currentField.cursorPos = len(currentField.value)
+				currentField.items = currentField.items[:last]
+				break
+			}
 			// Only allow backspace for non-enum fields
 			if !isEnum && currentField.cursorPos > 0 {
 				// Delete character before cursor
-				currentField.value = currentField.value[:currentField.cursorPos-1] + currentField.value[currentField.cursorPos:]
-				currentField.cursorPos--
+				start := runeBefore(currentField.value, currentField.cursorPos)
+				currentField.value = currentField.value[:start] + currentField.value[currentField.cursorPos:]
+				currentField.cursorPos = start
 				// Reset scroll when modifying content
 				m.regexPaneScrollUp = 0
 			}
@@ -433,11 +978,32 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "delete":
 			// Delete character at cursor position
 			if !isEnum && currentField.cursorPos < len(currentField.value) {
-				currentField.value = currentField.value[:currentField.cursorPos] + currentField.value[currentField.cursorPos+1:]
+				currentField.value = currentField.value[:currentField.cursorPos] + currentField.value[runeAfter(currentField.value, currentField.cursorPos):]
+				// Reset scroll when modifying content
+				m.regexPaneScrollUp = 0
+			}
+
+		case "alt+d", "ctrl+delete":
+			// Delete word after cursor
+			if !isEnum && currentField.cursorPos < len(currentField.value) {
+				wordEnd := wordEndAfter(currentField.value, currentField.cursorPos)
+				currentField.value = currentField.value[:currentField.cursorPos] + currentField.value[wordEnd:]
 				// Reset scroll when modifying content
 				m.regexPaneScrollUp = 0
 			}
 
+		case "alt+left", "ctrl+left":
+			// Move cursor to the start of the previous word
+			if !isEnum {
+				currentField.cursorPos = wordStartBefore(currentField.value, currentField.cursorPos)
+			}
+
+		case "alt+right", "ctrl+right":
+			// Move cursor to the end of the next word
+			if !isEnum {
+				currentField.cursorPos = wordEndAfter(currentField.value, currentField.cursorPos)
+			}
+
 		case "home", "ctrl+a":
 			// Move cursor to beginning of field
 			if !isEnum {
@@ -455,6 +1021,7 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if !isEnum {
 				currentField.value = ""
 				currentField.cursorPos = 0
+				currentField.items = nil
 				// Reset scroll when modifying content
 				m.regexPaneScrollUp = 0
 			}
@@ -467,17 +1034,10 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.regexPaneScrollUp = 0
 			}
 
-		case "ctrl+w":
+		case "ctrl+w", "alt+backspace":
 			// Delete word before cursor
 			if !isEnum && currentField.cursorPos > 0 {
-				// Find start of word
-				wordStart := currentField.cursorPos - 1
-				for wordStart > 0 && currentField.value[wordStart] == ' ' {
-					wordStart--
-				}
-				for wordStart > 0 && currentField.value[wordStart-1] != ' ' {
-					wordStart--
-				}
+				wordStart := wordStartBefore(currentField.value, currentField.cursorPos)
 				currentField.value = currentField.value[:wordStart] + currentField.value[currentField.cursorPos:]
 				currentField.cursorPos = wordStart
 				// Reset scroll when modifying content
@@ -485,20 +1045,127 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 		default:
+			// Space toggles the highlighted option of a multiple-choice enum
+			if keyStr == " " && currentField.isMultiSelect() {
+				currentField.toggleOption()
+				break
+			}
+			// Typing the separator in a list field adds the item
+			if currentField.variable.Type == models.VarTypeList && keyStr == currentField.variable.ListSeparator() && currentField.addItem() {
+				break
+			}
 			// Allow single character typing for non-enum fields
-			if !isEnum && len(msg.String()) == 1 {
+			if !isEnum && utf8.RuneCountInString(keyStr) == 1 && acceptsText(currentField.variable, keyStr) {
 				// Insert character at cursor position
-				currentField.value = currentField.value[:currentField.cursorPos] + msg.String() + currentField.value[currentField.cursorPos:]
-				currentField.cursorPos++
+				currentField.value = currentField.value[:currentField.cursorPos] + keyStr + currentField.value[currentField.cursorPos:]
+				currentField.cursorPos += len(keyStr)
+				typed = true
 				// Reset scroll when typing
 				m.regexPaneScrollUp = 0
 			}
 		}
+
+		// Edits of text fields can be undone; any other key ends a run of
+		// typing
+		if !isEnum && (currentField.value != before.value || !slices.Equal(currentField.items, before.items)) {
+			currentField.recordEdit(before, typed)
+		} else {
+			currentField.typing = false
+		}
+
+		// Numeric fields are validated live as they change
+		if currentField.value != previousValue {
+			currentField.validateNumber(m.config)
+		}
 	}
 
 	return m, nil
 }
 
+// scrollRegexPaneUp scrolls the regex explanation pane towards its start.
+func (m *formModel) scrollRegexPaneUp() {
+	m.regexPaneScrollUp -= 5
+	if m.regexPaneScrollUp < 0 {
+		m.regexPaneScrollUp = 0
+	}
+}
+
+// scrollRegexPaneDown scrolls the regex explanation pane towards its end,
+// stopping once the last line is shown.
+func (m *formModel) scrollRegexPaneDown() {
+	// Calculate max scroll to prevent scrolling past content
+	// Must use same calculation as View()
+	formWidth := int(float64(m.width) * 0.6)
+	if formWidth < 60 {
+		formWidth = 60
+	}
+	explanationWidth := m.width - formWidth - 2
+
+	explanation := regex.ExplainRegexPattern(m.fields[m.focusIndex].value)
+	rawLines := strings.Split(strings.TrimRight(explanation, "\n"), "\n")
+	explanationLines := wrapLines(rawLines, explanationWidth-4)
+
+	maxContentLines := m.height - 5 // Must match View() calculation
+	if maxContentLines < 5 {
+		maxContentLines = 5
+	}
+	maxScroll := len(explanationLines) - maxContentLines
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+
+	// Only increment if we're not already at max
+	if m.regexPaneScrollUp < maxScroll {
+		m.regexPaneScrollUp += 5
+		if m.regexPaneScrollUp > maxScroll {
+			m.regexPaneScrollUp = maxScroll
+		}
+	}
+}
+
+// updateMouse handles clicks and the scroll wheel. A click focuses the field
+// under it and picks the enum option it lands on; the wheel scrolls the
+// regex pane when over it. Positions are looked up in the layout recorded
+// by the last View.
+func (m formModel) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	layout := m.layout
+	if layout == nil {
+		return m, nil
+	}
+	// Views taller than the terminal lose their top lines
+	y := msg.Y
+	if m.height > 0 && layout.lines > m.height {
+		y += layout.lines - m.height
+	}
+	overPane := layout.paneX > 0 && msg.X >= layout.paneX
+
+	switch {
+	case msg.Button == tea.MouseButtonWheelUp && overPane:
+		m.scrollRegexPaneUp()
+	case msg.Button == tea.MouseButtonWheelDown && overPane:
+		m.scrollRegexPaneDown()
+	case msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress && !overPane:
+		for _, area := range layout.fields {
+			if y < area.top || y >= area.bottom {
+				continue
+			}
+			if area.field != m.focusIndex {
+				if previous := &m.fields[m.focusIndex]; previous.filtering {
+					previous.endFilter()
+				}
+				m.focusField(area.field)
+			}
+			for _, option := range area.options {
+				if option.line == y && msg.X >= option.start && msg.X < option.end {
+					m.fields[area.field].pickOption(option.index)
+				}
+			}
+			break
+		}
+	}
+	return m, nil
+}
+
 // previewVariable applies the variable's transform for display in the
 // command preview. Errors are swallowed and surface as either the raw value
 // or its default — this is a best-effort live preview, not the canonical
@@ -506,31 +1173,56 @@ func (m formModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m formModel) previewVariable(variable models.Variable, value string, allValues map[string]string) string {
 	if m.snippet == nil {
 		if value == "" {
-			return variable.DefaultValue
+			return variable.ResolveDefault(m.config)
 		}
 		return value
 	}
 	result, err := m.snippet.ProcessVariable(variable, value, allValues, m.config)
 	if err != nil {
 		if value == "" {
-			return variable.DefaultValue
+			return variable.ResolveDefault(m.config)
 		}
 		return value
 	}
 	return result
 }
 
-// renderCommandPreview generates a preview of the command with current values
-func (m formModel) renderCommandPreview() string {
+// replacePlaceholders is placeholderPattern.ReplaceAllStringFunc, with the
+// text between placeholders passed through literal. A <<name>> escape is
+// passed through literal as <name> instead of going to repl.
+func replacePlaceholders(command string, literal func(string) string, repl func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringIndex(command, -1) {
+		b.WriteString(literal(command[last:loc[0]]))
+		if match := command[loc[0]:loc[1]]; strings.HasPrefix(match, "<<") {
+			b.WriteString(literal(match[1 : len(match)-1]))
+		} else {
+			b.WriteString(repl(match))
+		}
+		last = loc[1]
+	}
+	b.WriteString(literal(command[last:]))
+	return b.String()
+}
+
+// renderCommandPreview generates a preview of the command with current
+// values, wrapped to width when it is known.
+func (m formModel) renderCommandPreview(width int) string {
 	if m.snippet == nil {
 		return ""
 	}
 
-	valueMap := make(map[string]string, len(m.fields))
+	valueMap := m.getValues()
 	filledMap := make(map[string]bool, len(m.fields))
 	for _, field := range m.fields {
-		valueMap[field.variable.Name] = field.value
-		filledMap[field.variable.Name] = field.value != ""
+		filledMap[field.variable.Name] = valueMap[field.variable.Name] != ""
+	}
+	hidden := m.hiddenFields()
+	// Compose templates see computed values as ProcessTemplate resolves them
+	binding, _ := m.snippet.ComputeValues(valueMap, m.config)
+	if binding == nil {
+		binding = valueMap
 	}
 
 	varByName := make(map[string]*models.Variable, len(m.snippet.Variables))
@@ -539,11 +1231,52 @@ func (m formModel) renderCommandPreview() string {
 		varByName[v.Name] = v
 	}
 
-	result := placeholderPattern.ReplaceAllStringFunc(m.snippet.Command, func(match string) string {
+	// With expand_env the literal text and substituted values are expanded
+	// separately so the styling survives; the result matches the final
+	// command except where a value completes a variable name in the text.
+	expand := func(s string) string { return s }
+	if m.snippet.ExpandsEnv(m.config) {
+		expand = models.ExpandEnv
+	}
+	// With collapse_whitespace each piece goes through the step's collapser
+	// before it is styled, in order, so the result matches the final command.
+	var collapser *models.WhitespaceCollapser
+	collapse := func(s string) string {
+		if collapser == nil {
+			return s
+		}
+		return collapser.Collapse(s)
+	}
+	literal := func(s string) string { return collapse(expand(s)) }
+
+	// The focused variable is highlighted wherever it appears, and the
+	// computed variables built from it more subtly
+	var focused string
+	if m.focusIndex < len(m.fields) {
+		focused = m.fields[m.focusIndex].variable.Name
+	}
+	dependents := m.snippet.Dependents(focused, m.config)
+
+	renderVariable := func(match string) string {
 		name := match[1 : len(match)-1]
 		variable, ok := varByName[name]
 		if !ok {
-			return match
+			return collapse(match)
+		}
+		render := func(style lipgloss.Style, s string) string {
+			switch {
+			case name == focused:
+				style = style.Inherit(focusedVarStyle)
+			case dependents[name] && variable.Computed:
+				style = style.Inherit(dependentVarStyle)
+			}
+			return style.Render(collapse(s))
+		}
+
+		// Hidden variables take the empty branch of their transform
+		if hidden[name] {
+			value, _ := variable.HiddenValue(m.config)
+			return render(filledVarStyle, expand(value))
 		}
 
 		rawValue := ""
@@ -552,35 +1285,152 @@ func (m formModel) renderCommandPreview() string {
 			rawValue = valueMap[name]
 			isFilled = filledMap[name]
 		}
-		transformedValue := m.previewVariable(*variable, rawValue, valueMap)
+		transformedValue := expand(m.previewVariable(*variable, rawValue, binding))
 
 		switch {
+		case variable.Type == models.VarTypeSecret && transformedValue != "":
+			return render(filledVarStyle, secretPreview)
+		case variable.Type == models.VarTypeMultiline && strings.Contains(transformedValue, "\n"):
+			first, _, _ := strings.Cut(transformedValue, "\n")
+			return render(filledVarStyle, fmt.Sprintf("%s…(+%d lines)", first, strings.Count(transformedValue, "\n")))
 		case variable.Computed:
 			if transformedValue != "" {
-				return filledVarStyle.Render(transformedValue)
+				return render(filledVarStyle, transformedValue)
 			}
-			return unfilledVarStyle.Render(match)
+			return render(unfilledVarStyle, match)
 		case transformedValue != "":
-			return filledVarStyle.Render(transformedValue)
+			return render(filledVarStyle, transformedValue)
 		case isFilled && rawValue != "":
 			return ""
 		default:
-			return unfilledVarStyle.Render(match)
+			return render(unfilledVarStyle, match)
+		}
+	}
+
+	// Each step of a multi-step snippet goes on its own line.
+	steps := m.snippet.Steps()
+	lines := make([]string, len(steps))
+	for i, step := range steps {
+		if m.snippet.CollapsesWhitespace(m.config) {
+			collapser = &models.WhitespaceCollapser{}
+		}
+		lines[i] = replacePlaceholders(step, literal, renderVariable)
+	}
+
+	// Wrap on word boundaries, carrying styles over line breaks, then keep
+	// to the configured number of lines unless expanded
+	preview := strings.Join(lines, "\n")
+	if width > 0 {
+		preview = cellbuf.Wrap(preview, width, "")
+	}
+	lines = strings.Split(preview, "\n")
+	limit := models.DefaultPreviewLines
+	if m.config != nil {
+		limit = m.config.Settings.Interactive.PreviewLineLimit()
+	}
+	var indicator string
+	if len(lines) > limit {
+		if m.previewExpanded {
+			indicator = "Ctrl+P: Collapse preview"
+		} else {
+			indicator = fmt.Sprintf("… %d more lines  Ctrl+P: Expand preview", len(lines)-limit)
+			lines = lines[:limit]
 		}
-	})
+	}
 
 	var b strings.Builder
 	b.WriteString(commandPreviewTitleStyle.Render("Command Preview:"))
 	b.WriteString("\n")
-	b.WriteString(result)
+	b.WriteString(strings.Join(lines, "\n"))
+	if indicator != "" {
+		b.WriteString("\n" + helpStyle.Render(indicator))
+	}
 
 	return commandPreviewStyle.Render(b.String())
 }
 
+// updateSummary handles keys on the review screen: Enter accepts the
+// values, e goes back to editing them, and Esc cancels.
+func (m formModel) updateSummary(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		m.state = formDone
+		return m, tea.Quit
+	case "e":
+		m.state = formEditing
+	case "ctrl+c", "esc":
+		m.state = formCancelled
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// renderSummary lists each visible variable with its value after the
+// transform, then the final command, for review before submitting. Secret
+// values are masked in both.
+func (m formModel) renderSummary() string {
+	var b strings.Builder
+	b.WriteString(commandPreviewTitleStyle.Render("Review Values:"))
+	b.WriteString("\n\n")
+
+	// Secrets are masked before rendering, so neither the values nor the
+	// command can show them.
+	values := m.snippet.MaskSecrets(m.getValues(), secretPreview)
+	command, resolved, err := m.snippet.ProcessTemplateDetailed(values, m.config)
+	if err != nil {
+		b.WriteString(errorStyle.Render("Error: "+err.Error()) + "\n\n")
+	} else {
+		secret := make(map[string]bool)
+		for _, v := range m.snippet.Variables {
+			secret[v.Name] = v.Type == models.VarTypeSecret
+		}
+		hidden := m.hiddenFields()
+		nameWidth := 0
+		for _, r := range resolved {
+			if !hidden[r.Name] {
+				nameWidth = max(nameWidth, len(r.Name))
+			}
+		}
+
+		for _, r := range resolved {
+			if hidden[r.Name] {
+				continue
+			}
+			value := filledVarStyle.Render(r.Transformed)
+			switch {
+			case r.Transformed == "":
+				value = helpStyle.Render("(empty)")
+			case secret[r.Name]:
+				value = filledVarStyle.Render(secretPreview)
+			}
+			if r.Computed {
+				value += helpStyle.Render(" (computed)")
+			}
+			fmt.Fprintf(&b, "  %s  →  %s\n", labelStyle.Render(fmt.Sprintf("%-*s", nameWidth, r.Name)), value)
+		}
+
+		if m.width > 0 {
+			command = cellbuf.Wrap(command, m.width, "")
+		}
+		b.WriteString("\n" + commandPreviewTitleStyle.Render("Command:") + "\n")
+		b.WriteString(commandPreviewStyle.Render(command) + "\n")
+	}
+
+	helpText := helpStyle.Render("Enter: Accept  e: Edit  Esc: Cancel")
+	if m.width > 0 {
+		helpText = lipgloss.NewStyle().Width(m.width).Render(helpText)
+	}
+	b.WriteString(helpText)
+	return b.String()
+}
+
 // View renders the form
 func (m formModel) View() string {
-	if m.done || m.cancelled {
+	switch m.state {
+	case formDone, formCancelled:
 		return ""
+	case formSummary:
+		return m.renderSummary()
 	}
 
 	// Safety check: this shouldn't happen anymore since we skip the form for no variables
@@ -625,7 +1475,7 @@ func (m formModel) View() string {
 	var formBuilder strings.Builder
 
 	// Add command preview at the top
-	commandPreview := m.renderCommandPreview()
+	commandPreview := m.renderCommandPreview(formWidth)
 	if commandPreview != "" {
 		if formWidth > 0 {
 			commandPreview = lipgloss.NewStyle().Width(formWidth).Render(commandPreview)
@@ -634,10 +1484,24 @@ func (m formModel) View() string {
 		formBuilder.WriteString("\n")
 	}
 
-	// Render each field
+	// Render each field, leaving out those hidden by their when condition
+	var areas []fieldArea
+	fieldsStart := strings.Count(formBuilder.String(), "\n")
+	hidden := m.hiddenFields()
+	values := m.getValues()
+	group := ""
 	for i := range m.fields {
 		// Use index to get field to ensure we can modify it if needed
 		field := &m.fields[i]
+		if hidden[field.variable.Name] {
+			continue
+		}
+
+		// Start each group with its header, which is not focusable
+		if field.variable.Group != group {
+			group = field.variable.Group
+			formBuilder.WriteString("\n" + groupHeaderStyle.Render(group) + "\n")
+		}
 
 		// Safety check: ensure cursor position is valid
 		if len(field.enumOptions) == 0 && field.cursorPos > len(field.value) {
@@ -646,31 +1510,39 @@ func (m formModel) View() string {
 		if field.cursorPos < 0 {
 			field.cursorPos = 0
 		}
-		// Field label
-		label := field.variable.Name
+		// Field label, marked while the field is required
+		var marker, description string
+		if isRequired(field.variable, values) {
+			marker = errorStyle.Render(requiredMarker)
+		}
 		if field.variable.Description != "" {
-			label = fmt.Sprintf("%s (%s)", field.variable.Name, field.variable.Description)
+			// Long descriptions are shown in full in the help block
+			description = fmt.Sprintf(" (%s)", truncate(field.variable.Description, helpDescriptionLength))
 		}
 
 		// Focus indicator and label styling
-		var linePrefix string
-		var styledLabel string
+		linePrefix := "  "
+		style := labelStyle
 		if i == m.focusIndex {
 			linePrefix = focusedStyle.Render("> ")
-			styledLabel = focusedStyle.Render(label + ":")
-		} else {
-			linePrefix = "  "
-			styledLabel = labelStyle.Render(label + ":")
+			style = focusedStyle
 		}
+		styledLabel := style.Render(field.variable.Name) + marker + style.Render(description+":")
 
 		// Check if this is an enum field
 		isEnum := len(field.enumOptions) > 0
 
 		// Field value with appropriate display
 		var displayValue string
-		if isEnum {
+		var options []string // Enum options shown in the line, for mouse clicks
+		if field.isMultiSelect() {
+			options = renderCheckboxes(*field, i == m.focusIndex)
+			displayValue = strings.Join(options, " ")
+		} else if field.isFilterable() {
+			// Long enums show their value here and their options in a list below
+			displayValue = selectedEnumStyle.Render("<" + field.value + ">")
+		} else if isEnum {
 			// For enum fields, show all options horizontally with selection brackets
-			var options []string
 			for idx, opt := range field.enumOptions {
 				if idx == field.enumIndex {
 					// Current selection shown with angle brackets and color
@@ -681,6 +1553,10 @@ func (m formModel) View() string {
 				}
 			}
 			displayValue = strings.Join(options, " ")
+		} else if field.variable.Type == models.VarTypeMultiline {
+			displayValue = renderMultiline(field.value, field.cursorPos, i == m.focusIndex)
+		} else if field.variable.Type == models.VarTypeSecret && !(i == m.focusIndex && m.revealSecret) {
+			displayValue = renderMasked(field.value, field.cursorPos, i == m.focusIndex)
 		} else {
 			// For text fields, show the value with cursor indicator when focused
 			if i == m.focusIndex {
@@ -693,37 +1569,35 @@ func (m formModel) View() string {
 				} else if field.cursorPos >= len(field.value) {
 					// Cursor at end - add block cursor after text
 					displayValue = field.value + cursorStyle.Render(" ")
-				} else if field.cursorPos < 0 {
-					// Safety check: invalid cursor position
-					field.cursorPos = 0
-					if len(field.value) > 0 {
-						displayValue = cursorStyle.Render(string(field.value[0])) + field.value[1:]
-					} else {
-						displayValue = cursorStyle.Render(" ")
-					}
 				} else {
 					// Cursor in middle or at beginning - highlight the character at cursor position
-					if field.cursorPos == 0 {
-						// Cursor at beginning
-						displayValue = cursorStyle.Render(string(field.value[0]))
-						if len(field.value) > 1 {
-							displayValue += field.value[1:]
-						}
-					} else {
-						// Cursor in middle
-						displayValue = field.value[:field.cursorPos] +
-							cursorStyle.Render(string(field.value[field.cursorPos])) +
-							field.value[field.cursorPos+1:]
-					}
+					pos := max(field.cursorPos, 0)
+					end := runeAfter(field.value, pos)
+					displayValue = field.value[:pos] +
+						cursorStyle.Render(field.value[pos:end]) +
+						field.value[end:]
 				}
 			} else {
 				// Not focused, just show value
 				displayValue = field.value
 			}
 		}
+		if len(field.items) > 0 {
+			displayValue = renderChips(field.items) + displayValue
+		}
 
 		// Build the line with wrapping
 		line := fmt.Sprintf("%s%s %s", linePrefix, styledLabel, displayValue)
+		area := fieldArea{field: i, top: strings.Count(formBuilder.String(), "\n")}
+		// Options can be clicked while the line is not wrapped
+		if formWidth <= 0 || lipgloss.Width(line) <= formWidth {
+			x := lipgloss.Width(linePrefix + styledLabel + " ")
+			for idx, opt := range options {
+				width := lipgloss.Width(opt)
+				area.options = append(area.options, optionArea{index: idx, line: area.top, start: x, end: x + width})
+				x += width + 1
+			}
+		}
 
 		// Apply width constraint for proper wrapping (formWidth is either split width or full width)
 		if formWidth > 0 {
@@ -733,6 +1607,19 @@ func (m formModel) View() string {
 			formBuilder.WriteString(line)
 		}
 		formBuilder.WriteString("\n")
+		if i == m.focusIndex && field.isFilterable() {
+			list, rows := renderEnumList(*field, formWidth)
+			listTop := strings.Count(formBuilder.String(), "\n")
+			for row, idx := range rows {
+				if idx >= 0 {
+					area.options = append(area.options, optionArea{index: idx, line: listTop + row, start: 0, end: max(formWidth, lipgloss.Width(list))})
+				}
+			}
+			formBuilder.WriteString(list)
+		}
+		if i == m.focusIndex {
+			formBuilder.WriteString(renderSuggestions(*field, formWidth))
+		}
 
 		// Add error message if present
 		if field.errorMessage != "" {
@@ -743,15 +1630,35 @@ func (m formModel) View() string {
 			formBuilder.WriteString(errorLine)
 			formBuilder.WriteString("\n")
 		}
+
+		if i == m.focusIndex {
+			formBuilder.WriteString(renderFieldHelp(m.fieldHelp(*field), formWidth))
+		}
+		area.bottom = strings.Count(formBuilder.String(), "\n")
+		areas = append(areas, area)
 	}
+	fieldsEnd := strings.Count(formBuilder.String(), "\n")
 
 	// Add instructions at the bottom of the form
 	formBuilder.WriteString("\n")
+	if remaining := m.requiredRemaining(); remaining > 0 {
+		noun := "fields"
+		if remaining == 1 {
+			noun = "field"
+		}
+		formBuilder.WriteString(errorStyle.Render(fmt.Sprintf("%d required %s remaining", remaining, noun)) + "\n")
+	}
 	// Show different help text based on current field type
 	var helpText string
 	if len(m.fields) > 0 && m.focusIndex >= 0 && m.focusIndex < len(m.fields) {
 		currentField := m.fields[m.focusIndex]
-		if len(currentField.enumOptions) > 0 {
+		if currentField.isMultiSelect() {
+			helpText = helpStyle.Render("Tab/↑↓: Navigate  ←→: Move  Space: Toggle  Enter: Submit  Esc: Cancel")
+		} else if currentField.filtering {
+			helpText = helpStyle.Render("Type: Filter  ↑↓: Move  Enter: Choose  Esc: Clear filter")
+		} else if currentField.isFilterable() {
+			helpText = helpStyle.Render("Tab/↑↓: Navigate  Type: Filter  ←→: Select  Enter: Submit  Esc: Cancel")
+		} else if len(currentField.enumOptions) > 0 {
 			helpText = helpStyle.Render("Tab/↑↓: Navigate  ←→: Select  Enter: Submit  Esc: Cancel")
 		} else if currentField.variable.Type == models.VarTypeRegex {
 			// Show regex-specific help
@@ -760,8 +1667,24 @@ func (m formModel) View() string {
 				paneStatus = "off"
 			}
 			helpText = helpStyle.Render(fmt.Sprintf("Tab/↑↓: Navigate  Ctrl+X: Clear  Ctrl+R: Pane(%s)  Ctrl+U/D: Scroll  Enter: Submit  Esc: Cancel", paneStatus))
+		} else if currentField.variable.Type == models.VarTypeMultiline {
+			helpText = helpStyle.Render("Tab: Next  Ctrl+S: Next required  ←→: Move cursor  Enter: New line  Ctrl+X: Clear  Esc: Cancel")
+		} else if currentField.variable.Type == models.VarTypeList {
+			helpText = helpStyle.Render("Tab/↑↓: Navigate  Enter: Add item  Backspace: Edit last item  Ctrl+X: Clear  Esc: Cancel")
+		} else if currentField.variable.IsNumeric() {
+			helpText = helpStyle.Render("Tab/↑↓: Navigate  Ctrl+↑↓: Increment/Decrement  ←→: Move cursor  Ctrl+X: Clear  Enter: Submit  Esc: Cancel")
+		} else if currentField.variable.Type == models.VarTypeDate || currentField.variable.Type == models.VarTypeDatetime {
+			helpText = helpStyle.Render(fmt.Sprintf("Tab/↑↓: Navigate  Format: %s, now, yesterday, -1h, -2d  Enter: Submit  Esc: Cancel", currentField.variable.DateFormat()))
+		} else if isPathField(currentField.variable) {
+			helpText = helpStyle.Render("Tab: Complete/Next  ↑↓: Navigate  ←→: Move cursor  Ctrl+X: Clear  Enter: Submit  Esc: Cancel")
+		} else if currentField.variable.Type == models.VarTypeSecret {
+			revealAction := "Reveal"
+			if m.revealSecret {
+				revealAction = "Hide"
+			}
+			helpText = helpStyle.Render(fmt.Sprintf("Tab/↑↓: Navigate  ←→: Move cursor  Ctrl+X: Clear  Ctrl+T: %s  Enter: Submit  Esc: Cancel", revealAction))
 		} else {
-			helpText = helpStyle.Render("Tab/↑↓: Navigate  ←→: Move cursor  Home/End: Jump  Ctrl+X: Clear  Enter: Submit  Esc: Cancel")
+			helpText = helpStyle.Render("Tab/↑↓: Navigate  ←→: Move cursor  Alt+←→: Word  Alt+Bksp/Alt+D: Delete word  Home/End: Jump  Ctrl+X: Clear  Ctrl+Z/Ctrl+Shift+Z: Undo/Redo  Enter: Submit  Esc: Cancel")
 		}
 	} else {
 		// No fields - just show basic help
@@ -772,7 +1695,7 @@ func (m formModel) View() string {
 	}
 	formBuilder.WriteString(helpText)
 
-	formContent := formBuilder.String()
+	formContent, offset := m.scrollFields(formBuilder.String(), fieldsStart, fieldsEnd, areas)
 
 	// If we have a regex explanation and should show the pane, render it in a side pane
 	if showPane && regexExplanation != "" {
@@ -871,23 +1794,578 @@ func (m formModel) View() string {
 			Render(paneContent)
 
 		// Join form and explanation horizontally
-		return lipgloss.JoinHorizontal(lipgloss.Top, formContent, explanationContent)
+		view := lipgloss.JoinHorizontal(lipgloss.Top, formContent, explanationContent)
+		m.recordLayout(view, areas, offset, lipgloss.Width(formContent))
+		return view
 	}
 
+	m.recordLayout(formContent, areas, offset, 0)
 	return formContent
 }
 
-// getValues returns the form values as a map
+// scrollFields fits content, the rendered form, to the terminal height by
+// showing only a window of the field lines [start, end), between "more"
+// indicators, with the command preview above and the help below left in
+// place. The window starts from the previous offset and moves just enough to
+// show the focused field. areas are moved to where their fields end up;
+// fields scrolled out of view lose their area. It returns the windowed
+// content and the offset used.
+func (m formModel) scrollFields(content string, start, end int, areas []fieldArea) (string, int) {
+	lines := strings.Split(content, "\n")
+	if m.height <= 0 || len(lines) <= m.height || end <= start {
+		return content, 0
+	}
+	// Always show the focused field; on a terminal too short for even that,
+	// the top of the preview is lost instead
+	available := max(m.height-(len(lines)-(end-start))-2, 1)
+
+	offset := 0
+	if m.layout != nil {
+		offset = m.layout.offset
+	}
+	for _, area := range areas {
+		if area.field != m.focusIndex {
+			continue
+		}
+		top, bottom := area.top-start, area.bottom-start
+		if bottom > offset+available {
+			offset = bottom - available
+		}
+		if top < offset || bottom-top > available {
+			offset = top
+		}
+	}
+	offset = max(min(offset, end-start-available), 0)
+
+	indicator := func(text string, shown bool) string {
+		if !shown {
+			return ""
+		}
+		return helpStyle.Render(text)
+	}
+	windowed := slices.Concat(
+		lines[:start],
+		[]string{indicator("  ↑ more", offset > 0)},
+		lines[start+offset:start+offset+available],
+		[]string{indicator("  ↓ more", offset+available < end-start)},
+		lines[end:],
+	)
+
+	// Shift the clickable areas to the lines their fields are shown on
+	shift := start + 1 - (start + offset)
+	first, last := start+1, start+1+available
+	for i := range areas {
+		area := &areas[i]
+		area.top = max(area.top+shift, first)
+		area.bottom = min(area.bottom+shift, last)
+		var options []optionArea
+		for _, option := range area.options {
+			if option.line += shift; option.line >= first && option.line < last {
+				options = append(options, option)
+			}
+		}
+		area.options = options
+	}
+	return strings.Join(windowed, "\n"), offset
+}
+
+// recordLayout saves where view put each field for the next mouse event,
+// and the offset the fields were scrolled to for the next View.
+func (m formModel) recordLayout(view string, areas []fieldArea, offset, paneX int) {
+	if m.layout != nil {
+		*m.layout = formLayout{lines: strings.Count(view, "\n") + 1, offset: offset, paneX: paneX, fields: areas}
+	}
+}
+
+// isRequired reports whether variable is required given values. A
+// required_if condition that cannot be evaluated does not require it; lint
+// reports it.
+func isRequired(variable models.Variable, values map[string]string) bool {
+	required, _ := variable.IsRequired(values)
+	return required
+}
+
+// normalizePaste prepares pasted text for a field: Windows line endings
+// become newlines, and for single-line fields trailing newlines are dropped
+// and the rest become spaces.
+func normalizePaste(text string, multiline bool) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	if multiline {
+		return text
+	}
+	return strings.ReplaceAll(strings.TrimRight(text, "\n"), "\n", " ")
+}
+
+// acceptsText reports whether text may be typed or pasted into a field for
+// variable. Numeric fields only take digits, signs, and (for floats) a
+// decimal point.
+func acceptsText(variable models.Variable, text string) bool {
+	allowed := ""
+	switch variable.Type {
+	case models.VarTypeInteger:
+		allowed = "0123456789+-"
+	case models.VarTypeFloat:
+		allowed = "0123456789+-."
+	default:
+		return true
+	}
+	return strings.Trim(text, allowed) == ""
+}
+
+// validateNumber updates a numeric field's error for its current value, so
+// that range and format errors show while typing.
+func (f *formField) validateNumber(config *models.Config) {
+	if !f.variable.IsNumeric() {
+		return
+	}
+	f.errorMessage = ""
+	if err := f.variable.ValidateWithConfig(f.value, config); err != nil {
+		f.errorMessage = err.Error()
+	}
+}
+
+// stepNumber moves a numeric field's value by delta steps, starting from
+// zero when it is empty or invalid and staying within the ranges. The result
+// keeps as many decimals as the step or the previous value.
+func (f *formField) stepNumber(delta float64) {
+	step := 1.0
+	validation := f.variable.Validation
+	if validation != nil && validation.Step > 0 {
+		step = validation.Step
+	}
+	if f.variable.Type == models.VarTypeInteger {
+		step = max(math.Round(step), 1)
+	}
+
+	num, _ := strconv.ParseFloat(f.value, 64)
+	num += delta * step
+	if validation != nil {
+		num = snapToRanges(num, validation.AllowedRanges(), delta > 0)
+	}
+
+	decimals := max(decimalPlaces(strconv.FormatFloat(step, 'f', -1, 64)), decimalPlaces(f.value))
+	f.value = strconv.FormatFloat(num, 'f', decimals, 64)
+	f.cursorPos = len(f.value)
+}
+
+// snapToRanges returns num if it is in one of ranges, else the nearest
+// bound of a range in the direction of travel (up or down), else the
+// nearest bound overall.
+func snapToRanges(num float64, ranges [][2]float64, up bool) float64 {
+	if len(ranges) == 0 {
+		return num
+	}
+	best, found := 0.0, false
+	nearest := ranges[0][0]
+	for _, r := range ranges {
+		if num >= r[0] && num <= r[1] {
+			return num
+		}
+		for _, bound := range r {
+			if math.Abs(bound-num) < math.Abs(nearest-num) {
+				nearest = bound
+			}
+		}
+		switch {
+		case up && r[0] > num && (!found || r[0] < best):
+			best, found = r[0], true
+		case !up && r[1] < num && (!found || r[1] > best):
+			best, found = r[1], true
+		}
+	}
+	if found {
+		return best
+	}
+	return nearest
+}
+
+// decimalPlaces counts the digits after the decimal point in a number.
+func decimalPlaces(s string) int {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// isPathField reports whether variable gets filesystem completion.
+func isPathField(variable models.Variable) bool {
+	return variable.Type == models.VarTypeFilepath || variable.Type == models.VarTypeDirpath
+}
+
+// cycleCompletion completes a path field's value on Tab. With several
+// matches pending, Tab cycles through them; otherwise it gathers the matches
+// for the current value, so Tab on a completed directory descends into it.
+// It reports false when there is nothing to complete and Tab should move on.
+func (f *formField) cycleCompletion(homeDir string) bool {
+	if len(f.completions) > 1 {
+		f.completionIndex = (f.completionIndex + 1) % len(f.completions)
+	} else {
+		if f.value == "" {
+			return false
+		}
+		matches := completePath(f.value, f.variable.Type == models.VarTypeDirpath, homeDir)
+		if len(matches) == 0 || (len(matches) == 1 && matches[0] == f.value) {
+			f.completions = nil
+			return false
+		}
+		f.completions, f.completionIndex = matches, 0
+	}
+	f.value = f.completions[f.completionIndex]
+	f.cursorPos = len(f.value)
+	return true
+}
+
+// renderCheckboxes renders a multiple-choice enum's options as checkboxes,
+// with angle brackets around the highlighted option when focused.
+func renderCheckboxes(field formField, focused bool) []string {
+	options := make([]string, len(field.enumOptions))
+	for i, opt := range field.enumOptions {
+		box := "[ ] "
+		style := unselectedEnumStyle
+		if field.selected[i] {
+			box = "[x] "
+			style = selectedEnumStyle
+		}
+		if focused && i == field.enumIndex {
+			options[i] = style.Render("<" + box + opt + ">")
+		} else {
+			options[i] = style.Render(" " + box + opt + " ")
+		}
+	}
+	return options
+}
+
+// helpDescriptionLength is the longest description shown in full in a
+// field's label; longer ones are cut there and shown in the help block.
+const helpDescriptionLength = 40
+
+// fieldHelp returns the lines of the help block shown under the focused
+// field: its full description, its type's description, its validation
+// constraints, and its default. It returns nil when the label already says
+// everything, i.e. the description is short and the type has none.
+func (m formModel) fieldHelp(field formField) []string {
+	variable := field.variable
+	var varType models.VariableType
+	if m.config != nil && variable.Type != "" {
+		varType = m.config.VariableTypes[variable.Type]
+	}
+	if utf8.RuneCountInString(variable.Description) <= helpDescriptionLength && varType.Description == "" {
+		return nil
+	}
+
+	var lines []string
+	if variable.Description != "" {
+		lines = append(lines, variable.Description)
+	}
+	if varType.Description != "" {
+		lines = append(lines, fmt.Sprintf("%s: %s", variable.Type, varType.Description))
+	}
+	validation := variable.Validation
+	if validation == nil {
+		validation = varType.Validation
+	}
+	if validation != nil {
+		if len(validation.Enum) > 0 {
+			lines = append(lines, "Allowed: "+strings.Join(validation.Enum, ", "))
+		}
+		if ranges := validation.AllowedRanges(); len(ranges) > 0 {
+			parts := make([]string, len(ranges))
+			for i, r := range ranges {
+				parts[i] = fmt.Sprintf("%g to %g", r[0], r[1])
+			}
+			lines = append(lines, "Range: "+strings.Join(parts, ", "))
+		}
+		if validation.Pattern != "" {
+			lines = append(lines, "Pattern: "+validation.Pattern)
+		}
+		if validation.NotPattern != "" {
+			lines = append(lines, "Must not match: "+validation.NotPattern)
+		}
+	}
+	if def := variable.ResolveDefault(m.config); def != "" && variable.Type != models.VarTypeSecret {
+		lines = append(lines, "Default: "+def)
+	}
+	return lines
+}
+
+// renderFieldHelp renders help lines dimmed and indented under a field,
+// wrapped to width when it is known.
+func renderFieldHelp(lines []string, width int) string {
+	var b strings.Builder
+	style := helpStyle
+	if width > 4 {
+		style = style.Width(width - 4)
+	}
+	for _, line := range lines {
+		for _, wrapped := range strings.Split(style.Render(line), "\n") {
+			b.WriteString("    " + wrapped + "\n")
+		}
+	}
+	return b.String()
+}
+
+// renderEnumList renders a filterable enum's filter and a window of its
+// matching options, one per line, with the highlighted option marked.
+// Options are cut to fit width when it is known. rows gives the option
+// shown on each line, or -1.
+func renderEnumList(field formField, width int) (list string, rows []int) {
+	var b strings.Builder
+	filter := field.filter
+	if !field.filtering {
+		filter = "type to filter"
+	}
+	b.WriteString("    " + helpStyle.Render("/ "+filter) + "\n")
+	rows = append(rows, -1)
+
+	matches := field.enumMatches()
+	if len(matches) == 0 {
+		b.WriteString("    " + helpStyle.Render("(no matches)") + "\n")
+		return b.String(), append(rows, -1)
+	}
+	current := max(slices.Index(matches, field.enumIndex), 0)
+	start := max(min(current-enumListHeight/2, len(matches)-enumListHeight), 0)
+	end := min(start+enumListHeight, len(matches))
+
+	if start > 0 {
+		b.WriteString("    " + helpStyle.Render("...") + "\n")
+		rows = append(rows, -1)
+	}
+	for i := start; i < end; i++ {
+		option := field.enumOptions[matches[i]]
+		if width > 0 {
+			option = truncate(option, width-6)
+		}
+		if i == current {
+			b.WriteString("  " + selectedEnumStyle.Render("> "+option) + "\n")
+		} else {
+			b.WriteString("    " + unselectedEnumStyle.Render(option) + "\n")
+		}
+		rows = append(rows, matches[i])
+	}
+	if end < len(matches) {
+		b.WriteString("    " + helpStyle.Render("...") + "\n")
+		rows = append(rows, -1)
+	}
+	return b.String(), rows
+}
+
+// renderSuggestions lists a focused text field's earlier values under it,
+// dimmed, with the highlighted one marked.
+func renderSuggestions(field formField, width int) string {
+	suggestions := field.suggestions()
+	if len(suggestions) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("    " + helpStyle.Render("Recent (Ctrl+N/Ctrl+P, Tab to use):") + "\n")
+	for i, value := range suggestions {
+		if width > 0 {
+			value = truncate(value, width-6)
+		}
+		if field.suggesting && i == field.suggestion {
+			b.WriteString("  " + selectedEnumStyle.Render("> "+value) + "\n")
+		} else {
+			b.WriteString("    " + helpStyle.Render(value) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// truncate shortens s to at most width runes, ending it with an ellipsis
+// when cut.
+func truncate(s string, width int) string {
+	if width < 1 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
+
+// renderChips renders list items as chips ahead of the item being typed.
+func renderChips(items []string) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(chipStyle.Render(item))
+		b.WriteString(" ")
+	}
+	return b.String()
+}
+
+// renderMasked renders a secret value as one mask character per rune of
+// value, with the block cursor at cursorPos when focused.
+func renderMasked(value string, cursorPos int, focused bool) string {
+	total := utf8.RuneCountInString(value)
+	if !focused {
+		return strings.Repeat(secretMask, total)
+	}
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+	cursorPos = min(max(cursorPos, 0), len(value))
+	if cursorPos == len(value) {
+		return strings.Repeat(secretMask, total) + cursorStyle.Render(" ")
+	}
+	before := utf8.RuneCountInString(value[:cursorPos])
+	return strings.Repeat(secretMask, before) +
+		cursorStyle.Render(secretMask) +
+		strings.Repeat(secretMask, total-before-1)
+}
+
+// runeBefore returns the byte offset of the rune that ends at offset pos in
+// s, for moving the cursor or deleting backwards.
+func runeBefore(s string, pos int) int {
+	_, size := utf8.DecodeLastRuneInString(s[:pos])
+	return pos - size
+}
+
+// runeAfter returns the byte offset just past the rune starting at offset
+// pos in s, or len(s) at the end.
+func runeAfter(s string, pos int) int {
+	_, size := utf8.DecodeRuneInString(s[pos:])
+	return pos + size
+}
+
+// wordSeparators end a word for word-wise movement and deletion. Besides
+// spaces they split paths, image references, and flags such as
+// --name=value.
+const wordSeparators = " /:=-"
+
+// wordStartBefore returns the offset of the start of the word before pos in
+// s, skipping any separators just before pos.
+func wordStartBefore(s string, pos int) int {
+	return strings.LastIndexAny(strings.TrimRight(s[:pos], wordSeparators), wordSeparators) + 1
+}
+
+// wordEndAfter returns the offset just past the end of the word after pos
+// in s, skipping any separators just after pos.
+func wordEndAfter(s string, pos int) int {
+	rest := strings.TrimLeft(s[pos:], wordSeparators)
+	end := strings.IndexAny(rest, wordSeparators)
+	if end < 0 {
+		return len(s)
+	}
+	return len(s) - len(rest) + end
+}
+
+// multilineIndent lines up the continuation lines of a multiline field.
+const multilineIndent = "    "
+
+// renderMultiline renders a multiline value with each line after the first
+// indented, and the block cursor at cursorPos when focused. A cursor on a
+// newline is drawn as a space at the end of its line.
+func renderMultiline(value string, cursorPos int, focused bool) string {
+	if focused {
+		cursorStyle := lipgloss.NewStyle().Reverse(true)
+		cursorPos = min(max(cursorPos, 0), len(value))
+		switch {
+		case cursorPos == len(value):
+			value += cursorStyle.Render(" ")
+		case value[cursorPos] == '\n':
+			value = value[:cursorPos] + cursorStyle.Render(" ") + value[cursorPos:]
+		default:
+			end := runeAfter(value, cursorPos)
+			value = value[:cursorPos] + cursorStyle.Render(value[cursorPos:end]) + value[end:]
+		}
+	}
+	return strings.ReplaceAll(value, "\n", "\n"+multilineIndent)
+}
+
+// getValues returns the form values as a map. Fields hidden by their when
+// condition are empty.
 func (m formModel) getValues() map[string]string {
+	values := m.fieldValues()
+	for name := range m.hiddenFields() {
+		values[name] = ""
+	}
+	return values
+}
+
+// fieldValues returns what is entered in each field, hidden or not.
+func (m formModel) fieldValues() map[string]string {
 	values := make(map[string]string)
 	for _, field := range m.fields {
-		values[field.variable.Name] = field.value
+		values[field.variable.Name] = field.fullValue()
 	}
 	return values
 }
 
-// promptForVariablesWithBubbleTea shows a Bubble Tea form for all variables
-func promptForVariablesWithBubbleTea(snippet *models.Snippet, presetValues map[string]string, config *models.Config, noColor bool) (map[string]string, error) {
+// hiddenFields returns the names of the fields whose when condition does
+// not hold for the current values. A condition that cannot be evaluated
+// hides nothing; lint reports it.
+func (m formModel) hiddenFields() map[string]bool {
+	if m.snippet == nil {
+		return nil
+	}
+	hidden, _ := m.snippet.HiddenVariables(m.fieldValues())
+	return hidden
+}
+
+// setSuggestions gives each field the values entered for its variable in
+// earlier runs, most recent first.
+func (m *formModel) setSuggestions(suggestions map[string][]string) {
+	for i := range m.fields {
+		m.fields[i].history = suggestions[m.fields[i].variable.Name]
+	}
+}
+
+// requiredRemaining counts the visible fields that are required but still
+// empty.
+func (m formModel) requiredRemaining() int {
+	hidden := m.hiddenFields()
+	values := m.getValues()
+	count := 0
+	for _, field := range m.fields {
+		if !hidden[field.variable.Name] && field.fullValue() == "" && isRequired(field.variable, values) {
+			count++
+		}
+	}
+	return count
+}
+
+// nextRequiredEmpty returns the index of the next visible field after the
+// focused one, wrapping around, that is required but still empty, or -1
+// when there is none.
+func (m formModel) nextRequiredEmpty() int {
+	hidden := m.hiddenFields()
+	values := m.getValues()
+	for step := 1; step < len(m.fields); step++ {
+		i := (m.focusIndex + step) % len(m.fields)
+		field := m.fields[i]
+		if !hidden[field.variable.Name] && field.fullValue() == "" && isRequired(field.variable, values) {
+			return i
+		}
+	}
+	return -1
+}
+
+// focusField moves focus to field i with the cursor at the end of its value,
+// resetting the regex pane scroll and re-masking secrets.
+func (m *formModel) focusField(i int) {
+	m.focusIndex = i
+	if field := &m.fields[i]; len(field.enumOptions) == 0 {
+		field.cursorPos = len(field.value)
+	}
+	m.regexPaneScrollUp = 0
+	m.revealSecret = false
+}
+
+// nextVisible returns the index of the next field in direction step (1 or
+// -1) from index that is not hidden, wrapping around. With no other visible
+// field it returns index.
+func (m formModel) nextVisible(index, step int) int {
+	hidden := m.hiddenFields()
+	for range m.fields {
+		index = (index + step + len(m.fields)) % len(m.fields)
+		if !hidden[m.fields[index].variable.Name] {
+			return index
+		}
+	}
+	return index
+}
+
+// promptForVariablesWithBubbleTea shows a Bubble Tea form for all variables,
+// with fieldErrors shown on their fields when it opens and suggestions
+// offered on text fields.
+func promptForVariablesWithBubbleTea(snippet *models.Snippet, presetValues map[string]string, fieldErrors map[string]string, suggestions map[string][]string, config *models.Config, noColor bool) (map[string]string, error) {
 	// Check if there are any non-computed variables that need user input
 	hasUserVariables := false
 	for _, variable := range snippet.Variables {
@@ -902,6 +2380,10 @@ func promptForVariablesWithBubbleTea(snippet *models.Snippet, presetValues map[s
 		return make(map[string]string), nil
 	}
 
+	if _, err := snippet.OrderedVariables(config); err != nil {
+		return nil, err
+	}
+
 	SetupColorProfile(noColor)
 
 	// Get terminal width for wrapping
@@ -911,13 +2393,15 @@ func promptForVariablesWithBubbleTea(snippet *models.Snippet, presetValues map[s
 	}
 
 	// Create the form model
-	model := newFormModel(snippet, presetValues, config)
+	model := newFormModel(snippet, presetValues, fieldErrors, config)
 	model.width = width
+	model.setSuggestions(suggestions)
 
 	// Run the Bubble Tea program with alternate screen for better UX
 	// Use stderr for the TUI so stdout can be captured for the command output
 	p := tea.NewProgram(model,
 		tea.WithAltScreen(),
+		tea.WithMouseCellMotion(),
 		tea.WithOutput(os.Stderr))
 	finalModel, err := p.Run()
 	if err != nil {
@@ -926,7 +2410,7 @@ func promptForVariablesWithBubbleTea(snippet *models.Snippet, presetValues map[s
 
 	// Check if cancelled
 	form := finalModel.(formModel)
-	if form.cancelled {
+	if form.state == formCancelled {
 		return nil, ErrUserCancelled
 	}
 
@@ -0,0 +1,1424 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/samling/command-snippets/internal/models"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// TestNewFormModel_PresetErrors tests that invalid presets are flagged as soon as the form opens
+func TestNewFormModel_PresetErrors(t *testing.T) {
+	config := loadTestConfig(t)
+	snippet := &models.Snippet{
+		Command: "app --name <name> --log-level <log_level> --mode <mode>",
+		Variables: []models.Variable{
+			{Name: "name"},
+			{Name: "log_level", Type: "test_log_level"},
+			{Name: "mode", Validation: &models.Validation{Enum: []string{"fast", "safe"}}},
+		},
+	}
+	presets := map[string]string{"name": "web", "log_level": "loud", "mode": "reckless"}
+
+	model := newFormModel(snippet, presets, presetErrors(snippet, presets, config), config)
+
+	if model.focusIndex != 1 {
+		t.Errorf("Expected the first invalid field (log_level) to be focused, got index %d", model.focusIndex)
+	}
+	if model.fields[0].errorMessage != "" {
+		t.Errorf("Expected no error for a valid preset, got %q", model.fields[0].errorMessage)
+	}
+
+	view := model.View()
+	for _, want := range []string{
+		"variable log_level must be one of",
+		`preset "reckless" rejected: variable mode must be one of: fast, safe`,
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected first view to contain %q, got:\n%s", want, view)
+		}
+	}
+}
+
+// TestNewFormModel_NoPresetErrors tests that a form without errors focuses the first field
+func TestNewFormModel_NoPresetErrors(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "echo <a> <b>",
+		Variables: []models.Variable{{Name: "a"}, {Name: "b"}},
+	}
+
+	model := newFormModel(snippet, map[string]string{"b": "x"}, nil, nil)
+	if model.focusIndex != 0 {
+		t.Errorf("Expected focus on the first field, got index %d", model.focusIndex)
+	}
+	if strings.Contains(model.View(), "[Error:") {
+		t.Error("Expected no errors in the first view")
+	}
+}
+
+// TestFormModel_Secret tests that secret fields are masked in the form and preview
+func TestFormModel_Secret(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "login --user <user> --token <token>",
+		Variables: []models.Variable{
+			{Name: "token", Type: models.VarTypeSecret, Validation: &models.Validation{Pattern: "^[a-z0-9]+$"}},
+			{Name: "user"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+
+	if view := model.View(); !strings.Contains(view, "<token>") {
+		t.Errorf("Expected the unfilled placeholder in the preview, got:\n%s", view)
+	}
+
+	for _, r := range "s3cr3t" {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	view := model.View()
+	if strings.Contains(view, "s3cr3t") {
+		t.Errorf("Expected the secret to be masked, got:\n%s", view)
+	}
+	if !strings.Contains(view, "--token "+secretPreview) || !strings.Contains(view, strings.Repeat(secretMask, 6)) {
+		t.Errorf("Expected masked value in field and preview, got:\n%s", view)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if view := model.View(); !strings.Contains(view, "s3cr3t") || strings.Contains(view, "--token s3cr3t") {
+		t.Errorf("Expected ctrl+t to reveal the field but not the preview, got:\n%s", view)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if view := model.View(); strings.Contains(view, "s3cr3t") {
+		t.Errorf("Expected the secret to be masked again after leaving the field, got:\n%s", view)
+	}
+
+	// Validation runs against the real value
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'!'}})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	form := model.(formModel)
+	if form.state == formDone || form.fields[0].errorMessage == "" {
+		t.Errorf("Expected the invalid secret to block submission")
+	}
+	if got := form.getValues()["token"]; got != "s3cr3t!" {
+		t.Errorf("Expected the real value to be kept, got %q", got)
+	}
+}
+
+// TestFormModel_Multiline tests that multiline fields take newlines and preview their first line
+func TestFormModel_Multiline(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "git commit -m <message> <flags>",
+		Variables: []models.Variable{
+			{Name: "message", Type: models.VarTypeMultiline},
+			{Name: "flags"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("Fix it")},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyEnter},
+		{Type: tea.KeyRunes, Runes: []rune("Details")},
+	} {
+		model, _ = model.Update(key)
+	}
+	form := model.(formModel)
+	if form.focusIndex != 0 {
+		t.Fatalf("Expected enter to stay in the multiline field, focus moved to %d", form.focusIndex)
+	}
+	if got := form.fields[0].value; got != "Fix it\n\nDetails" {
+		t.Errorf("Expected newlines in the value, got %q", got)
+	}
+	if view := form.View(); !strings.Contains(view, "git commit -m Fix it…(+2 lines)") {
+		t.Errorf("Expected the first line and a line count in the preview, got:\n%s", view)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if form := model.(formModel); form.focusIndex != 1 {
+		t.Errorf("Expected ctrl+s to move to the next field, got focus %d", form.focusIndex)
+	}
+}
+
+// TestFormModel_DatePreview tests that relative dates show resolved in the preview
+func TestFormModel_DatePreview(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "journalctl --since <since>",
+		Variables: []models.Variable{
+			{Name: "since", Type: models.VarTypeDate, Validation: &models.Validation{Format: "2006"}},
+		},
+	}
+	model := newFormModel(snippet, map[string]string{"since": "now"}, nil, nil)
+
+	view := model.View()
+	if want := "journalctl --since " + time.Now().Format("2006"); !strings.Contains(view, want) {
+		t.Errorf("Expected preview to contain %q, got:\n%s", want, view)
+	}
+	if !strings.Contains(view, "Format: 2006") {
+		t.Errorf("Expected the layout in the help text, got:\n%s", view)
+	}
+}
+
+// TestFormModel_Numeric tests numeric input filtering, stepping, and live range validation
+func TestFormModel_Numeric(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "app --replicas <replicas> --ratio <ratio>",
+		Variables: []models.Variable{
+			{Name: "replicas", Type: models.VarTypeInteger, Validation: &models.Validation{Range: []float64{0, 10}}},
+			{Name: "ratio", Type: models.VarTypeFloat, DefaultValue: "0.5", Validation: &models.Validation{Step: 0.1}},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	up, down := tea.KeyMsg{Type: tea.KeyCtrlUp}, tea.KeyMsg{Type: tea.KeyCtrlDown}
+
+	form := update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'1'}}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	if got := form.fields[0].value; got != "1" {
+		t.Errorf("Expected non-numeric input to be rejected, got %q", got)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'2'}})
+	if !strings.Contains(form.fields[0].errorMessage, "between 0 and 10") {
+		t.Errorf("Expected a live range error, got %q", form.fields[0].errorMessage)
+	}
+
+	form = update(down)
+	if got := form.fields[0].value; got != "10" {
+		t.Errorf("Expected decrement to clamp into range, got %q", got)
+	}
+	if form.fields[0].errorMessage != "" {
+		t.Errorf("Expected the error to clear, got %q", form.fields[0].errorMessage)
+	}
+
+	form = update(up)
+	if got := form.fields[0].value; got != "10" {
+		t.Errorf("Expected increment to stop at the range maximum, got %q", got)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyTab}, up, up, up)
+	if got := form.fields[1].value; got != "0.8" {
+		t.Errorf("Expected float steps of 0.1 without rounding noise, got %q", got)
+	}
+	form = update(tea.KeyMsg{Type: tea.KeyCtrlX}, down)
+	if got := form.fields[1].value; got != "-0.1" {
+		t.Errorf("Expected stepping from empty to start at zero, got %q", got)
+	}
+}
+
+// TestFormModel_List tests adding list items as chips and submitting them joined
+func TestFormModel_List(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "kubectl get pods -l <labels>",
+		Variables: []models.Variable{
+			{Name: "labels", Type: models.VarTypeList, ItemValidation: &models.Validation{Pattern: "^[a-z]+=[a-z]+$"}},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"labels": "app=web"}, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	form := update(runes("e"), runes("n"), runes("v"), runes("="), runes("x"), enter)
+	if form.state == formDone || !reflect.DeepEqual(form.fields[0].items, []string{"app=web", "env=x"}) {
+		t.Fatalf("Expected enter to add an item, got items %v", form.fields[0].items)
+	}
+	if view := form.View(); !strings.Contains(view, "kubectl get pods -l app=web,env=x") {
+		t.Errorf("Expected the joined items in the preview, got:\n%s", view)
+	}
+
+	form = update(runes("t"), runes("i"), runes("e"), runes("r"), runes(","))
+	if got := form.fields[0].fullValue(); got != "app=web,env=x,tier" {
+		t.Errorf("Expected the separator to add an item, got %q", got)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyBackspace})
+	if got := form.fields[0].value; got != "ti" {
+		t.Errorf("Expected backspace to take the last item back for editing, got %q", got)
+	}
+
+	form = update(enter, enter)
+	if form.state == formDone || !strings.Contains(form.fields[0].errorMessage, `item "ti"`) {
+		t.Errorf("Expected item validation to block submission, got %q", form.fields[0].errorMessage)
+	}
+}
+
+// TestFormModel_MultiSelect tests toggling options of a multiple-choice enum
+func TestFormModel_MultiSelect(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "docker run --cap-add=<caps> <image>",
+		Variables: []models.Variable{
+			{Name: "caps", Validation: &models.Validation{Enum: []string{"NET_ADMIN", "SYS_TIME", "true"}, Multiple: true}},
+			{Name: "image"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"caps": "SYS_TIME,BOGUS"}, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+
+	form := update()
+	if got := form.fields[0].value; got != "SYS_TIME" {
+		t.Errorf("Expected options outside the enum to be dropped, got %q", got)
+	}
+
+	form = update(space, right, right, space)
+	if got := form.fields[0].value; got != "NET_ADMIN,SYS_TIME,true" {
+		t.Errorf("Expected checked options in enum order, got %q", got)
+	}
+	if view := form.View(); !strings.Contains(view, "--cap-add=NET_ADMIN,SYS_TIME,true") || !strings.Contains(view, "[x] NET_ADMIN") {
+		t.Errorf("Expected the preview and checkboxes to follow toggles, got:\n%s", view)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyLeft}, space)
+	if got := form.fields[0].value; got != "NET_ADMIN,true" {
+		t.Errorf("Expected moving the highlight not to change the value, got %q", got)
+	}
+}
+
+// TestFormModel_When tests that fields follow their when condition as the
+// controlling field changes
+func TestFormModel_When(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "curl <cert> <url>",
+		Variables: []models.Variable{
+			{Name: "tls", Type: models.VarTypeBoolean},
+			{Name: "cert", Required: true, When: &models.Condition{Variable: "tls", Equals: "true"},
+				Transform: &models.Transform{ValuePattern: "--cacert {{.Value}}"}},
+			{Name: "url"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"cert": "ca.pem"}, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	form := update(tab)
+	if form.fields[form.focusIndex].variable.Name != "url" {
+		t.Errorf("Expected Tab to skip the hidden cert field, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+	if view := form.View(); strings.Contains(view, "cert:") || strings.Contains(view, "--cacert") {
+		t.Errorf("Expected cert to be hidden, got:\n%s", view)
+	}
+	if got := form.getValues()["cert"]; got != "" {
+		t.Errorf("Expected a hidden field to have no value, got %q", got)
+	}
+
+	form = update(tab, tea.KeyMsg{Type: tea.KeyRight}, tab)
+	if form.fields[form.focusIndex].variable.Name != "cert" {
+		t.Errorf("Expected Tab to reach cert once tls is true, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+	if view := form.View(); !strings.Contains(view, "--cacert ca.pem") {
+		t.Errorf("Expected cert in the preview once shown, got:\n%s", view)
+	}
+
+	// A required field only blocks submitting while it is shown
+	form = update(tea.KeyMsg{Type: tea.KeyCtrlX}, tab, tea.KeyMsg{Type: tea.KeyEnter})
+	if form.state == formDone || form.fields[1].errorMessage == "" || form.focusIndex != 1 {
+		t.Errorf("Expected the empty required cert to block submitting and take focus")
+	}
+	form = update(tea.KeyMsg{Type: tea.KeyShiftTab}, tea.KeyMsg{Type: tea.KeyLeft}, tab, tea.KeyMsg{Type: tea.KeyEnter})
+	if form.state != formDone {
+		t.Errorf("Expected the form to submit with cert hidden, errors: %q", form.fields[1].errorMessage)
+	}
+}
+
+// TestNewFormModel_Order tests that fields follow explicit order and
+// dependencies rather than declaration order
+func TestNewFormModel_Order(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "curl <cert> <url>",
+		Variables: []models.Variable{
+			{Name: "cert", When: &models.Condition{Variable: "tls", Equals: "true"}},
+			{Name: "url", Order: 1},
+			{Name: "tls", Type: models.VarTypeBoolean},
+		},
+	}
+
+	form := newFormModel(snippet, nil, nil, nil)
+	var names []string
+	for _, field := range form.fields {
+		names = append(names, field.variable.Name)
+	}
+	if expected := []string{"url", "tls", "cert"}; !slices.Equal(names, expected) {
+		t.Errorf("Expected fields %v, got %v", expected, names)
+	}
+}
+
+// TestSnapToRanges tests that stepping skips the gaps between ranges
+func TestSnapToRanges(t *testing.T) {
+	ranges := [][2]float64{{80, 80}, {443, 443}, {1024, 49151}}
+
+	tests := []struct {
+		name     string
+		num      float64
+		up       bool
+		expected float64
+	}{
+		{"inside a range", 2000, true, 2000},
+		{"up into the next range", 81, true, 443},
+		{"down into the previous range", 442, false, 80},
+		{"up past the last range", 49152, true, 49151},
+		{"down past the first range", 79, false, 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := snapToRanges(tt.num, ranges, tt.up); got != tt.expected {
+				t.Errorf("snapToRanges(%g) = %g, expected %g", tt.num, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestFormModel_RequiredIf tests that the required marker and the submit
+// check follow the controlling field
+func TestFormModel_RequiredIf(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "deploy <environment> <image_tag>",
+		Variables: []models.Variable{
+			{Name: "environment", Validation: &models.Validation{Enum: []string{"dev", "prod"}}},
+			{Name: "image_tag", RequiredIf: &models.Condition{Variable: "environment", Equals: "prod"}},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+
+	form := update()
+	if view := form.View(); strings.Contains(view, "image_tag*") {
+		t.Errorf("Expected no required marker for dev, got:\n%s", view)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyRight})
+	if view := form.View(); !strings.Contains(view, "image_tag*") {
+		t.Errorf("Expected a required marker for prod, got:\n%s", view)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	if form.state == formDone || !strings.Contains(form.fields[1].errorMessage, "required when environment=prod") {
+		t.Errorf("Expected submitting without image_tag to fail, error %q", form.fields[1].errorMessage)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyLeft}, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter})
+	if form.state != formDone {
+		t.Errorf("Expected the form to submit for dev, error %q", form.fields[1].errorMessage)
+	}
+}
+
+// TestFormModel_Groups tests that fields are navigated and shown group by
+// group, with a header before each named group
+func TestFormModel_Groups(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "curl <cert> <user> <url>",
+		Variables: []models.Variable{
+			{Name: "cert", Group: "TLS"},
+			{Name: "user", Group: "Auth"},
+			{Name: "url"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+
+	var focused []string
+	for range snippet.Variables {
+		form := model.(formModel)
+		focused = append(focused, form.fields[form.focusIndex].variable.Name)
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	}
+	if expected := []string{"url", "cert", "user"}; !slices.Equal(focused, expected) {
+		t.Errorf("Expected Tab to visit %v, got %v", expected, focused)
+	}
+
+	view := model.(formModel).View()
+	url, tls, cert, auth := strings.Index(view, "url:"), strings.Index(view, "TLS"), strings.Index(view, "cert:"), strings.Index(view, "Auth")
+	if url < 0 || !(url < tls && tls < cert && cert < auth) {
+		t.Errorf("Expected ungrouped fields, then each header before its fields, got:\n%s", view)
+	}
+}
+
+// TestFormModel_QuotePreview tests that the preview shows quoted values as they will run
+func TestFormModel_QuotePreview(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "echo <message>",
+		Variables: []models.Variable{{Name: "message", Quote: true}},
+	}
+	form := newFormModel(snippet, map[string]string{"message": "it's $HOME"}, nil, nil)
+
+	if view := form.View(); !strings.Contains(view, `echo 'it'\''s $HOME'`) {
+		t.Errorf("Expected the quoted value in the preview, got:\n%s", view)
+	}
+}
+
+// TestFormModel_EscapedPlaceholderPreview tests that <<name>> escapes show
+// as a literal <name> in the preview
+func TestFormModel_EscapedPlaceholderPreview(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "echo <<file>> > <file>",
+		Variables: []models.Variable{{Name: "file"}},
+	}
+	form := newFormModel(snippet, map[string]string{"file": "out.txt"}, nil, nil)
+
+	if view := form.View(); !strings.Contains(view, "echo <file> > out.txt") {
+		t.Errorf("Expected the escape as a literal in the preview, got:\n%s", view)
+	}
+}
+
+// TestFormModel_CollapseWhitespacePreview tests that the preview collapses
+// whitespace like the rendered command
+func TestFormModel_CollapseWhitespacePreview(t *testing.T) {
+	collapse := true
+	snippet := &models.Snippet{
+		Command:            "docker run <detach> <image>  'a  b'",
+		Variables:          []models.Variable{{Name: "detach", Type: models.VarTypeBoolean, Transform: &models.Transform{TrueValue: "-d"}}, {Name: "image"}},
+		CollapseWhitespace: &collapse,
+	}
+	form := newFormModel(snippet, map[string]string{"detach": "false", "image": "nginx"}, nil, nil)
+
+	if view := form.View(); !strings.Contains(view, "docker run nginx 'a  b'") {
+		t.Errorf("Expected collapsed whitespace in the preview, got:\n%s", view)
+	}
+}
+
+// TestFormModel_MapPreview tests that map keys become the options and the
+// preview shows the translated value
+func TestFormModel_MapPreview(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "deploy <env>",
+		Variables: []models.Variable{{
+			Name:       "env",
+			Validation: &models.Validation{EnumFromMap: true},
+			Transform:  &models.Transform{Map: map[string]string{"prod": "prod-east-1", "dev": "dev-west-2"}, ValuePattern: "--cluster={{.Value}}"},
+		}},
+	}
+	form := newFormModel(snippet, map[string]string{"env": "prod"}, nil, nil)
+
+	if got := form.fields[0].enumOptions; !slices.Equal(got, []string{"dev", "prod"}) {
+		t.Errorf("Expected the map keys as options, got %v", got)
+	}
+	if view := form.View(); !strings.Contains(view, "deploy --cluster=prod-east-1") {
+		t.Errorf("Expected the mapped value in the preview, got:\n%s", view)
+	}
+}
+
+// TestFormModel_ChainedComputedPreview tests that the preview resolves
+// computed variables built on other computed variables
+func TestFormModel_ChainedComputedPreview(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "docker pull <ref>",
+		Variables: []models.Variable{
+			{Name: "ref", Computed: true, Transform: &models.Transform{Compose: "{{.image}}:{{.tag}}"}},
+			{Name: "image", Computed: true, Transform: &models.Transform{Compose: "{{.registry}}/{{.name}}"}},
+			{Name: "registry"},
+			{Name: "name"},
+			{Name: "tag"},
+		},
+	}
+	form := newFormModel(snippet, map[string]string{"registry": "ghcr.io", "name": "app", "tag": "v1"}, nil, nil)
+
+	if view := form.View(); !strings.Contains(view, "docker pull ghcr.io/app:v1") {
+		t.Errorf("Expected the chained value in the preview, got:\n%s", view)
+	}
+}
+
+// TestFormModel_ComposeUsesTransformedPreview tests that the preview
+// composes from transformed values like ProcessTemplate does
+func TestFormModel_ComposeUsesTransformedPreview(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:                "echo <summary>",
+		ComposeUsesTransformed: true,
+		Variables: []models.Variable{
+			{Name: "summary", Computed: true, Transform: &models.Transform{Compose: "[{{.namespace}}]"}},
+			{Name: "namespace", Transform: &models.Transform{ValuePattern: "-n {{.Value}}"}},
+		},
+	}
+	form := newFormModel(snippet, map[string]string{"namespace": "web"}, nil, nil)
+
+	if view := form.View(); !strings.Contains(view, "echo [-n web]") {
+		t.Errorf("Expected the transformed value in the preview, got:\n%s", view)
+	}
+}
+
+// TestFormModel_MultiByteEditing tests that typing, moving, and deleting
+// work on whole characters for non-ASCII input
+func TestFormModel_MultiByteEditing(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "echo <message>",
+		Variables: []models.Variable{{Name: "message"}},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+
+	tests := []struct {
+		name      string
+		keys      []tea.KeyMsg
+		value     string
+		cursorPos int
+	}{
+		{"typing", []tea.KeyMsg{runes("c"), runes("a"), runes("f"), runes("é")}, "café", len("café")},
+		{"left over a multi-byte rune", []tea.KeyMsg{left}, "café", len("caf")},
+		{"insert before it", []tea.KeyMsg{runes("日")}, "caf日é", len("caf日")},
+		{"backspace removes the whole rune", []tea.KeyMsg{backspace}, "café", len("caf")},
+		{"right over it", []tea.KeyMsg{right}, "café", len("café")},
+		{"emoji", []tea.KeyMsg{runes(" 👍🏽")}, "café 👍🏽", len("café 👍🏽")},
+		{"delete at cursor", []tea.KeyMsg{left, left, {Type: tea.KeyDelete}}, "café 🏽", len("café ")},
+		{"word delete", []tea.KeyMsg{{Type: tea.KeyEnd}, {Type: tea.KeyCtrlW}}, "café ", len("café ")},
+		{"backspace to empty", []tea.KeyMsg{backspace, backspace, backspace, backspace, backspace, backspace}, "", 0},
+	}
+
+	for _, tt := range tests {
+		form := update(tt.keys...)
+		field := form.fields[0]
+		if field.value != tt.value || field.cursorPos != tt.cursorPos {
+			t.Fatalf("%s: expected %q with cursor at %d, got %q with cursor at %d", tt.name, tt.value, tt.cursorPos, field.value, field.cursorPos)
+		}
+		if !utf8.ValidString(form.View()) {
+			t.Fatalf("%s: view is not valid UTF-8", tt.name)
+		}
+	}
+}
+
+// TestRenderMasked_MultiByte tests that secrets are masked per character
+func TestRenderMasked_MultiByte(t *testing.T) {
+	if got := renderMasked("pässwörd", 0, false); got != strings.Repeat(secretMask, 8) {
+		t.Errorf("Expected 8 mask characters, got %q", got)
+	}
+}
+
+// TestFormModel_WordKeys tests word-wise movement and deletion, with path
+// and flag punctuation as word separators
+func TestFormModel_WordKeys(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "docker pull <image>",
+		Variables: []models.Variable{{Name: "image"}},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"image": "ghcr.io/acme/web-app:v1"}, nil, nil)
+	update := func(keys ...tea.KeyMsg) formField {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel).fields[0]
+	}
+	altLeft := tea.KeyMsg{Type: tea.KeyLeft, Alt: true}
+	altRight := tea.KeyMsg{Type: tea.KeyRight, Alt: true}
+	ctrlLeft := tea.KeyMsg{Type: tea.KeyCtrlLeft}
+
+	tests := []struct {
+		name      string
+		keys      []tea.KeyMsg
+		value     string
+		cursorPos int
+	}{
+		{"alt+left", []tea.KeyMsg{altLeft}, "ghcr.io/acme/web-app:v1", len("ghcr.io/acme/web-app:")},
+		{"ctrl+left", []tea.KeyMsg{ctrlLeft, ctrlLeft}, "ghcr.io/acme/web-app:v1", len("ghcr.io/acme/")},
+		{"alt+right", []tea.KeyMsg{altRight}, "ghcr.io/acme/web-app:v1", len("ghcr.io/acme/web")},
+		{"alt+d", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("d"), Alt: true}}, "ghcr.io/acme/web:v1", len("ghcr.io/acme/web")},
+		{"alt+backspace", []tea.KeyMsg{{Type: tea.KeyBackspace, Alt: true}}, "ghcr.io/acme/:v1", len("ghcr.io/acme/")},
+		{"ctrl+w", []tea.KeyMsg{{Type: tea.KeyCtrlW}}, "ghcr.io/:v1", len("ghcr.io/")},
+		{"alt+left at start", []tea.KeyMsg{{Type: tea.KeyHome}, altLeft}, "ghcr.io/:v1", 0},
+		{"alt+right to end", []tea.KeyMsg{altRight, altRight}, "ghcr.io/:v1", len("ghcr.io/:v1")},
+	}
+
+	for _, tt := range tests {
+		field := update(tt.keys...)
+		if field.value != tt.value || field.cursorPos != tt.cursorPos {
+			t.Fatalf("%s: expected %q with cursor at %d, got %q with cursor at %d", tt.name, tt.value, tt.cursorPos, field.value, field.cursorPos)
+		}
+	}
+}
+
+// TestFormModel_UndoRedo tests the per-field edit history
+func TestFormModel_UndoRedo(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "echo <first> <second>",
+		Variables: []models.Variable{{Name: "first"}, {Name: "second"}},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	typeText := func(s string) []tea.KeyMsg {
+		var keys []tea.KeyMsg
+		for _, r := range s {
+			keys = append(keys, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return keys
+	}
+	undo := tea.KeyMsg{Type: tea.KeyCtrlZ}
+	redo := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'z'}, Alt: true}
+	clear := tea.KeyMsg{Type: tea.KeyCtrlX}
+	left := tea.KeyMsg{Type: tea.KeyLeft}
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	check := func(step string, form formModel, value string, cursorPos int) {
+		t.Helper()
+		field := form.fields[form.focusIndex]
+		if field.value != value || field.cursorPos != cursorPos {
+			t.Fatalf("%s: expected %q with cursor at %d, got %q with cursor at %d", step, value, cursorPos, field.value, field.cursorPos)
+		}
+	}
+
+	form := update(typeText("hello")...)
+	form = update(left, left, clear)
+	check("clear", form, "", 0)
+	form = update(undo)
+	check("undo clear", form, "hello", 3)
+	form = update(redo)
+	check("redo clear", form, "", 0)
+	form = update(undo, undo)
+	check("typing is undone as one edit", form, "", 0)
+	form = update(undo)
+	check("nothing left to undo", form, "", 0)
+
+	form = update(typeText("ab")...)
+	form = update(left)
+	form = update(typeText("c")...)
+	form = update(undo)
+	check("moving the cursor ends the run of typing", form, "ab", 1)
+
+	form = update(tab)
+	form = update(typeText("x")...)
+	form = update(tab, redo)
+	check("history survives switching fields", form, "acb", 2)
+
+	form = update(tab, undo)
+	check("second field keeps its own history", form, "", 0)
+
+	// Ctrl+Shift+Z redoes too where the terminal reports it apart from Ctrl+Z
+	model, _ = model.Update(unknownCSI("122;6u"))
+	check("Ctrl+Shift+Z redoes", model.(formModel), "x", 1)
+}
+
+// unknownCSI stands in for the message Bubble Tea sends for a CSI sequence
+// it doesn't parse, which has this string form.
+type unknownCSI string
+
+func (u unknownCSI) String() string {
+	return fmt.Sprintf("?CSI%+v?", []byte(u))
+}
+
+// TestIsCtrlShiftZ tests recognizing Ctrl+Shift+Z in the encodings of the
+// kitty keyboard protocol and xterm's modifyOtherKeys
+func TestIsCtrlShiftZ(t *testing.T) {
+	for _, seq := range []string{"122;6u", "90;6u", "27;6;90~", "27;6;122~"} {
+		if !isCtrlShiftZ(unknownCSI(seq)) {
+			t.Errorf("Expected %q to be Ctrl+Shift+Z", seq)
+		}
+	}
+	for _, msg := range []tea.Msg{unknownCSI("122;5u"), tea.KeyMsg{Type: tea.KeyCtrlZ}, redoKey} {
+		if isCtrlShiftZ(msg) {
+			t.Errorf("Expected %v not to be Ctrl+Shift+Z", msg)
+		}
+	}
+}
+
+// TestFormField_HistoryCap tests that the undo history is bounded
+func TestFormField_HistoryCap(t *testing.T) {
+	var field formField
+	for i := range maxFieldHistory + 10 {
+		field.recordEdit(fieldSnapshot{value: strconv.Itoa(i)}, false)
+	}
+	if len(field.undo) != maxFieldHistory || field.undo[0].value != "10" {
+		t.Errorf("Expected the oldest entries to be dropped, got %d entries starting at %q", len(field.undo), field.undo[0].value)
+	}
+}
+
+// TestFormModel_Paste tests that pasted text is inserted as-is at the cursor,
+// including text that looks like a key name or bracketed paste markers
+func TestFormModel_Paste(t *testing.T) {
+	paste := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s), Paste: true} }
+
+	tests := []struct {
+		name      string
+		varType   string
+		keys      []tea.KeyMsg
+		value     string
+		cursorPos int
+	}{
+		{"regex", "", []tea.KeyMsg{paste("[a-z]+")}, "[a-z]+", len("[a-z]+")},
+		{"brackets", "", []tea.KeyMsg{paste("[x]"), paste("arr[0]")}, "[x]arr[0]", len("[x]arr[0]")},
+		{"key name", "", []tea.KeyMsg{paste("delete"), paste("tab")}, "deletetab", len("deletetab")},
+		{"at the cursor", "", []tea.KeyMsg{paste("ac"), {Type: tea.KeyLeft}, paste("b")}, "abc", len("ab")},
+		{"single line", "", []tea.KeyMsg{paste("one\r\ntwo\nthree\n")}, "one two three", len("one two three")},
+		{"multiline", models.VarTypeMultiline, []tea.KeyMsg{paste("one\r\ntwo\rthree\n")}, "one\ntwo\nthree\n", len("one\ntwo\nthree\n")},
+		{"unbracketed", "", []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune("[a-z]+")}}, "[a-z]+", len("[a-z]+")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := &models.Snippet{
+				Command:   "grep <pattern>",
+				Variables: []models.Variable{{Name: "pattern", Type: tt.varType}},
+			}
+			var model tea.Model = newFormModel(snippet, nil, nil, nil)
+			for _, key := range tt.keys {
+				model, _ = model.Update(key)
+			}
+			field := model.(formModel).fields[0]
+			if field.value != tt.value || field.cursorPos != tt.cursorPos {
+				t.Errorf("Expected %q with cursor at %d, got %q with cursor at %d", tt.value, tt.cursorPos, field.value, field.cursorPos)
+			}
+		})
+	}
+}
+
+// TestFormModel_EnumFilter tests that long enums become a filterable list
+// while short ones keep the one-line selector
+func TestFormModel_EnumFilter(t *testing.T) {
+	contexts := []string{"dev", "staging", "prod-us-east", "prod-eu-west", "prod-ap-south", "kind-local", "minikube", "qa", "pe-sandbox"}
+	newForm := func(options []string) func(msgs ...tea.Msg) formModel {
+		snippet := &models.Snippet{
+			Command:   "kubectl --context <ctx> get pods",
+			Variables: []models.Variable{{Name: "ctx", Validation: &models.Validation{Enum: options}}, {Name: "ns"}},
+		}
+		var model tea.Model = newFormModel(snippet, nil, nil, nil)
+		return func(msgs ...tea.Msg) formModel {
+			for _, msg := range msgs {
+				model, _ = model.Update(msg)
+			}
+			return model.(formModel)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	down := tea.KeyMsg{Type: tea.KeyDown}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+	esc := tea.KeyMsg{Type: tea.KeyEsc}
+	right := tea.KeyMsg{Type: tea.KeyRight}
+
+	t.Run("small enum", func(t *testing.T) {
+		update := newForm(contexts[:3])
+		form := update(runes("p"), right, tea.WindowSizeMsg{Width: 80, Height: 40})
+		if form.fields[0].filtering || form.fields[0].value != "staging" {
+			t.Fatalf("Expected typing to be ignored and right to select, got %q (filtering %v)", form.fields[0].value, form.fields[0].filtering)
+		}
+		view := form.View()
+		if !strings.Contains(view, "<staging>") || !strings.Contains(view, " dev ") || strings.Contains(view, "/ type to filter") {
+			t.Errorf("Expected the options on one line, got:\n%s", view)
+		}
+		if form = update(down); form.focusIndex != 1 {
+			t.Errorf("Expected down to move to the next field, got focus %d", form.focusIndex)
+		}
+	})
+
+	t.Run("large enum", func(t *testing.T) {
+		update := newForm(contexts)
+		form := update(tea.WindowSizeMsg{Width: 80, Height: 40})
+		view := form.View()
+		if !strings.Contains(view, "/ type to filter") || !strings.Contains(view, "> dev") || strings.Contains(view, "pe-sandbox") || !strings.Contains(view, "...") {
+			t.Errorf("Expected a windowed vertical list, got:\n%s", view)
+		}
+
+		form = update(runes("pe"))
+		if !form.fields[0].filtering || form.fields[0].value != "dev" {
+			t.Fatalf("Expected typing to filter without changing the value, got %q", form.fields[0].value)
+		}
+		if got := form.fields[0].enumOptions[form.fields[0].enumIndex]; got != "pe-sandbox" {
+			t.Errorf("Expected the substring match to be highlighted first, got %q", got)
+		}
+		view = form.View()
+		if !strings.Contains(view, "/ pe") || strings.Contains(view, "minikube") || !strings.Contains(view, "prod-eu-west") {
+			t.Errorf("Expected only fuzzy matches to be listed, got:\n%s", view)
+		}
+
+		form = update(down, enter)
+		if form.fields[0].filtering || form.fields[0].filter != "" || form.focusIndex != 0 {
+			t.Fatalf("Expected enter to end filtering on the same field")
+		}
+		if got := form.fields[0].value; got != "prod-us-east" {
+			t.Errorf("Expected the highlighted match to be chosen, got %q", got)
+		}
+
+		form = update(runes("mini"), esc)
+		if form.state == formCancelled || form.fields[0].filtering || form.fields[0].value != "prod-us-east" {
+			t.Errorf("Expected esc to clear the filter and keep the value, got %q (state %v)", form.fields[0].value, form.state)
+		}
+		if got := form.fields[0].enumOptions[form.fields[0].enumIndex]; got != "prod-us-east" {
+			t.Errorf("Expected the value to be highlighted again, got %q", got)
+		}
+
+		form = update(runes("zzz"))
+		if view := form.View(); !strings.Contains(view, "(no matches)") {
+			t.Errorf("Expected a note when nothing matches, got:\n%s", view)
+		}
+		if form = update(enter); form.fields[0].value != "prod-us-east" {
+			t.Errorf("Expected enter without matches to keep the value, got %q", form.fields[0].value)
+		}
+
+		if form = update(down); form.focusIndex != 1 {
+			t.Errorf("Expected down to move to the next field once filtering ends, got focus %d", form.focusIndex)
+		}
+	})
+
+	t.Run("narrow terminal", func(t *testing.T) {
+		long := slices.Clone(contexts)
+		long[0] = "arn:aws:eks:us-east-1:123456789012:cluster/production"
+		update := newForm(long)
+		view := update(tea.WindowSizeMsg{Width: 30, Height: 40}).View()
+		for _, line := range strings.Split(view, "\n") {
+			if w := lipgloss.Width(line); w > 30 {
+				t.Errorf("Expected lines to fit 30 columns, got %d: %q", w, line)
+			}
+		}
+		if !strings.Contains(view, "arn:aws:eks:us-east-1:1…") {
+			t.Errorf("Expected the long option to be cut, got:\n%s", view)
+		}
+	})
+}
+
+// TestFormModel_FieldHelp tests the help block under the focused field
+func TestFormModel_FieldHelp(t *testing.T) {
+	config := &models.Config{VariableTypes: map[string]models.VariableType{
+		"port": {Description: "A TCP port", Validation: &models.Validation{Range: []float64{1, 65535}}, Default: "8080"},
+	}}
+	longDescription := "The namespace to deploy into, which must already exist in the cluster"
+	snippet := &models.Snippet{
+		Command: "deploy <ns> <port> <tag>",
+		Variables: []models.Variable{
+			{Name: "ns", Description: longDescription, Validation: &models.Validation{Pattern: "^[a-z-]+$", Enum: []string{"default", "kube-system"}}},
+			{Name: "port", Type: "port"},
+			{Name: "tag", Description: "Image tag"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, config)
+	update := func(msgs ...tea.Msg) formModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(formModel)
+	}
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	form := update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	view := form.View()
+	for _, want := range []string{longDescription, "Allowed: default, kube-system", "Pattern: ^[a-z-]+$", "ns (The namespace to deploy into, which mus…)"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the view, got:\n%s", want, view)
+		}
+	}
+
+	view = update(tab).View()
+	for _, want := range []string{"port: A TCP port", "Range: 1 to 65535", "Default: 8080"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the view, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, longDescription) {
+		t.Errorf("Expected the help block to follow focus, got:\n%s", view)
+	}
+
+	if view := update(tab).View(); strings.Contains(view, "Default:") || strings.Contains(view, "Range:") {
+		t.Errorf("Expected no help block for a short description, got:\n%s", view)
+	}
+
+	form = update(tab, tea.WindowSizeMsg{Width: 40, Height: 40})
+	view = form.View()
+	for _, line := range strings.Split(view, "\n") {
+		if w := lipgloss.Width(line); w > 40 {
+			t.Errorf("Expected the help to reflow to 40 columns, got %d: %q", w, line)
+		}
+	}
+	if !strings.Contains(view, "must already exist") {
+		t.Errorf("Expected the full description after reflowing, got:\n%s", view)
+	}
+}
+
+// TestFormModel_RequiredFields tests the remaining counter, skipping to
+// required fields, and focusing the first offender on submit
+func TestFormModel_RequiredFields(t *testing.T) {
+	snippet := &models.Snippet{
+		Command: "deploy <app> <replicas> <tag> <note> <region>",
+		Variables: []models.Variable{
+			{Name: "app", Required: true},
+			{Name: "replicas"},
+			{Name: "tag", Required: true},
+			{Name: "note", Type: models.VarTypeMultiline},
+			{Name: "region", Required: true},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"region": "eu"}, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	ctrlS := tea.KeyMsg{Type: tea.KeyCtrlS}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	form := update()
+	view := form.View()
+	if !strings.Contains(view, "2 required fields remaining") || !strings.Contains(view, "app*:") || strings.Contains(view, "replicas*") {
+		t.Errorf("Expected markers on required fields and a count of 2, got:\n%s", view)
+	}
+
+	if form = update(ctrlS); form.fields[form.focusIndex].variable.Name != "tag" {
+		t.Errorf("Expected Ctrl+S to skip to tag, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+	if form = update(ctrlS); form.fields[form.focusIndex].variable.Name != "app" {
+		t.Errorf("Expected Ctrl+S to wrap around to app, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+
+	form = update(runes("web"))
+	if view := form.View(); !strings.Contains(view, "1 required field remaining") {
+		t.Errorf("Expected a count of 1, got:\n%s", view)
+	}
+	if form = update(ctrlS, ctrlS); form.fields[form.focusIndex].variable.Name != "note" {
+		t.Errorf("Expected Ctrl+S on the last required field to move on, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyTab}, enter)
+	if form.state == formDone || form.fields[form.focusIndex].variable.Name != "tag" || form.fields[2].errorMessage == "" {
+		t.Fatalf("Expected submitting to focus the empty tag with an error, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+
+	form = update(runes("v1"))
+	if view := form.View(); strings.Contains(view, "remaining") {
+		t.Errorf("Expected no counter once every required field is set, got:\n%s", view)
+	}
+	if form = update(ctrlS, ctrlS, ctrlS); form.state != formDone {
+		t.Errorf("Expected Ctrl+S to move on and submit with nothing left, focused %s", form.fields[form.focusIndex].variable.Name)
+	}
+}
+
+// TestFormModel_Mouse tests clicking fields and enum options and scrolling
+// the regex pane with the wheel
+func TestFormModel_Mouse(t *testing.T) {
+	contexts := []string{"dev", "staging", "prod-us", "prod-eu", "prod-ap", "kind", "minikube", "qa"}
+	snippet := &models.Snippet{
+		Command: "run <level> <ctx> <caps> <name> <pattern>",
+		Variables: []models.Variable{
+			{Name: "level", Validation: &models.Validation{Enum: []string{"debug", "info", "warn"}}},
+			{Name: "ctx", Validation: &models.Validation{Enum: contexts}},
+			{Name: "caps", Validation: &models.Validation{Enum: []string{"NET_ADMIN", "SYS_TIME"}, Multiple: true}},
+			{Name: "name"},
+			{Name: "pattern", Type: models.VarTypeRegex},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"pattern": `^(\d{3})-(\w+)\s*(?:foo|bar|baz)+[a-z]*$`}, nil, nil)
+	update := func(msgs ...tea.Msg) formModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(formModel)
+	}
+	// click clicks the first line of the view containing text, at text
+	click := func(text string) formModel {
+		t.Helper()
+		for y, line := range strings.Split(model.View(), "\n") {
+			if x := strings.Index(line, text); x >= 0 {
+				return update(tea.MouseMsg{X: lipgloss.Width(line[:x]), Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+			}
+		}
+		t.Fatalf("%q not found in the view:\n%s", text, model.View())
+		return formModel{}
+	}
+	focused := func(form formModel) string { return form.fields[form.focusIndex].variable.Name }
+
+	update(tea.WindowSizeMsg{Width: 80, Height: 60})
+	if form := click("name:"); focused(form) != "name" {
+		t.Errorf("Expected clicking a label to focus its field, focused %s", focused(form))
+	}
+	if form := click(" warn "); focused(form) != "level" || form.fields[0].value != "warn" {
+		t.Errorf("Expected clicking an option to focus and select it, focused %s with %q", focused(form), form.fields[0].value)
+	}
+	if form := click("SYS_TIME"); focused(form) != "caps" || form.fields[2].value != "SYS_TIME" {
+		t.Errorf("Expected clicking a checkbox to toggle it, got %q", form.fields[2].value)
+	}
+	click("ctx:")
+	if form := click("minikube"); focused(form) != "ctx" || form.fields[1].value != "minikube" {
+		t.Errorf("Expected clicking a list option to select it, got %q", form.fields[1].value)
+	}
+
+	form := click("pattern:")
+	form = update(tea.WindowSizeMsg{Width: 120, Height: 12})
+	form.View()
+	paneX := form.layout.paneX
+	if paneX == 0 {
+		t.Fatalf("Expected the regex pane to be shown")
+	}
+	form = update(tea.MouseMsg{X: paneX + 5, Y: 3, Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	if form.regexPaneScrollUp == 0 {
+		t.Errorf("Expected the wheel over the pane to scroll it")
+	}
+	form = update(tea.MouseMsg{X: paneX + 5, Y: 3, Button: tea.MouseButtonWheelUp, Action: tea.MouseActionPress})
+	if form.regexPaneScrollUp != 0 {
+		t.Errorf("Expected the wheel up to scroll back, at %d", form.regexPaneScrollUp)
+	}
+	form = update(tea.MouseMsg{X: 2, Y: 3, Button: tea.MouseButtonWheelDown, Action: tea.MouseActionPress})
+	if form.regexPaneScrollUp != 0 {
+		t.Errorf("Expected the wheel over the form to leave the pane alone")
+	}
+
+	// A view taller than the terminal is shown from its bottom
+	update(tea.WindowSizeMsg{Width: 80, Height: 10})
+	lines := strings.Split(model.View(), "\n")
+	for y, line := range lines {
+		if strings.Contains(line, "name:") {
+			form = update(tea.MouseMsg{X: 4, Y: y - (len(lines) - 10), Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+		}
+	}
+	if focused(form) != "name" {
+		t.Errorf("Expected clicks to account for the cut-off top, focused %s", focused(form))
+	}
+}
+
+// TestFormModel_ScrollFields tests that a form taller than the terminal
+// keeps the focused field and the command preview in view
+func TestFormModel_ScrollFields(t *testing.T) {
+	snippet := &models.Snippet{Command: "echo"}
+	for i := range 15 {
+		name := fmt.Sprintf("var%02d", i)
+		snippet.Command += " <" + name + ">"
+		snippet.Variables = append(snippet.Variables, models.Variable{Name: name})
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	update := func(msgs ...tea.Msg) formModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(formModel)
+	}
+	check := func(step string, form formModel, height int) string {
+		t.Helper()
+		view := form.View()
+		focused := "> " + form.fields[form.focusIndex].variable.Name + ":"
+		if lines := strings.Count(view, "\n") + 1; lines > height {
+			t.Errorf("%s: expected at most %d lines, got %d:\n%s", step, height, lines, view)
+		}
+		if !strings.Contains(view, focused) || !strings.Contains(view, "Command Preview:") || !strings.Contains(view, "Enter: Submit") {
+			t.Errorf("%s: expected the preview, %q, and the help in view, got:\n%s", step, focused, view)
+		}
+		return view
+	}
+
+	form := update(tea.WindowSizeMsg{Width: 80, Height: 14})
+	view := check("start", form, 14)
+	if strings.Contains(view, "↑ more") || !strings.Contains(view, "↓ more") || strings.Contains(view, "var14:") {
+		t.Errorf("Expected only a more-below indicator at the start, got:\n%s", view)
+	}
+
+	for i := range 14 {
+		form = update(tea.KeyMsg{Type: tea.KeyTab})
+		check(fmt.Sprintf("tab %d", i+1), form, 14)
+	}
+	if view := form.View(); !strings.Contains(view, "↑ more") || strings.Contains(view, "↓ more") {
+		t.Errorf("Expected only a more-above indicator at the end, got:\n%s", view)
+	}
+
+	for i := range 3 {
+		form = update(tea.KeyMsg{Type: tea.KeyShiftTab})
+		check(fmt.Sprintf("shift+tab %d", i+1), form, 14)
+	}
+	if view := form.View(); !strings.Contains(view, "var14:") {
+		t.Errorf("Expected moving back within the window not to scroll, got:\n%s", view)
+	}
+
+	form = update(tea.WindowSizeMsg{Width: 80, Height: 12})
+	check("resized", form, 12)
+	if view := update(tea.WindowSizeMsg{Width: 80, Height: 6}).View(); !strings.Contains(view, "> var11:") {
+		t.Errorf("Expected the focused field to stay in view on a tiny terminal, got:\n%s", view)
+	}
+	form = update(tea.WindowSizeMsg{Width: 80, Height: 40})
+	if view := check("enlarged", form, 40); strings.Contains(view, "more") {
+		t.Errorf("Expected no indicators once everything fits, got:\n%s", view)
+	}
+
+	form = update(tea.WindowSizeMsg{Width: 80, Height: 12})
+	for range 3 {
+		form = update(tea.KeyMsg{Type: tea.KeyTab})
+	}
+	check("back at the end", form, 12)
+	for y, line := range strings.Split(form.View(), "\n") {
+		if strings.Contains(line, "var13:") {
+			form = update(tea.MouseMsg{X: 4, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+		}
+	}
+	if got := form.fields[form.focusIndex].variable.Name; got != "var13" {
+		t.Errorf("Expected clicks to follow the scrolled fields, focused %s", got)
+	}
+}
+
+// TestFormModel_LongPreview tests that a long command preview wraps without
+// splitting escape sequences, keeps its colors on each line, and is capped
+// until expanded
+func TestFormModel_LongPreview(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+
+	snippet := &models.Snippet{
+		Command: "kubectl --context <context> --namespace <namespace> get <resource> --selector <selector> --output <output> --sort-by <sort> --field-selector <fields> --show-labels --chunk-size 500",
+		Variables: []models.Variable{
+			{Name: "context"}, {Name: "namespace"}, {Name: "resource"}, {Name: "selector"},
+			{Name: "output"}, {Name: "sort"}, {Name: "fields"},
+		},
+	}
+	presets := map[string]string{
+		"context":   "arn:aws:eks:us-east-1:123456789012:cluster/production-primary",
+		"namespace": "payments-processing",
+		"resource":  "pods",
+		"selector":  "app.kubernetes.io/name=api,app.kubernetes.io/component=backend",
+		"output":    "custom-columns=NAME:.metadata.name,STATUS:.status.phase",
+	}
+	config := &models.Config{Settings: models.Settings{Interactive: models.InteractiveConfig{PreviewLines: 3}}}
+	var model tea.Model = newFormModel(snippet, presets, nil, config)
+	update := func(msgs ...tea.Msg) formModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(formModel)
+	}
+
+	form := update(tea.WindowSizeMsg{Width: 40, Height: 60})
+	preview := form.renderCommandPreview(40)
+	lines := strings.Split(preview, "\n")
+	escape := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	for _, line := range lines {
+		if stripped := escape.ReplaceAllString(line, ""); strings.Contains(stripped, "\x1b") {
+			t.Errorf("Expected no split escape sequences, got %q", line)
+		}
+		if w := lipgloss.Width(line); w > 40 {
+			t.Errorf("Expected lines to fit 40 columns, got %d: %q", w, line)
+		}
+	}
+	if !strings.Contains(preview, "more lines  Ctrl+P: Expand preview") || strings.Contains(preview, "--show-labels") {
+		t.Errorf("Expected the preview to be capped, got:\n%s", preview)
+	}
+	if got := strings.Count(preview, "\n"); got > 6 {
+		t.Errorf("Expected the title, 3 lines, and the indicator, got %d lines:\n%s", got, preview)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	preview = form.renderCommandPreview(40)
+	if !strings.Contains(preview, "--show-labels") || !strings.Contains(preview, "Ctrl+P: Collapse preview") {
+		t.Errorf("Expected Ctrl+P to show the whole preview, got:\n%s", preview)
+	}
+	// A value wrapped onto the next line keeps its color there
+	for _, line := range strings.Split(preview, "\n") {
+		if strings.Contains(line, "cluster/production-primary") && !strings.Contains(line, "\x1b[") {
+			t.Errorf("Expected the wrapped value to be styled on its own line, got %q", line)
+		}
+	}
+
+	if preview := update(tea.KeyMsg{Type: tea.KeyCtrlP}).renderCommandPreview(0); strings.Contains(preview, "Ctrl+P") {
+		t.Errorf("Expected no cap without a known width when the command fits the limit, got:\n%s", preview)
+	}
+}
+
+// TestFormModel_PreviewHighlight tests that the focused variable is
+// highlighted everywhere in the preview and its computed dependents subtly
+func TestFormModel_PreviewHighlight(t *testing.T) {
+	lipgloss.SetColorProfile(termenv.ANSI256)
+	t.Cleanup(func() { lipgloss.SetColorProfile(termenv.Ascii) })
+
+	snippet := &models.Snippet{
+		Command: "curl -H 'Host: <host>' <url> --resolve <host>:443:<ip>",
+		Variables: []models.Variable{
+			{Name: "host"},
+			{Name: "ip"},
+			{Name: "url", Computed: true, Transform: &models.Transform{Compose: "https://{{.host}}/"}},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, map[string]string{"host": "example.com"}, nil, nil)
+	focusedHost := filledVarStyle.Inherit(focusedVarStyle).Render("example.com")
+	plainHost := filledVarStyle.Render("example.com")
+	dependentURL := filledVarStyle.Inherit(dependentVarStyle).Render("https://example.com/")
+
+	preview := model.(formModel).renderCommandPreview(0)
+	if strings.Count(preview, focusedHost) != 2 || !strings.Contains(preview, dependentURL) {
+		t.Errorf("Expected both hosts highlighted and the url marked as dependent, got %q", preview)
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyTab})
+	preview = model.(formModel).renderCommandPreview(0)
+	if strings.Contains(preview, focusedHost) || strings.Count(preview, plainHost) != 2 || strings.Contains(preview, dependentURL) {
+		t.Errorf("Expected the highlight to follow focus to ip, got %q", preview)
+	}
+	if !strings.Contains(preview, unfilledVarStyle.Inherit(focusedVarStyle).Render("<ip>")) {
+		t.Errorf("Expected the unfilled ip placeholder highlighted, got %q", preview)
+	}
+}
+
+// TestFormModel_Suggestions tests offering and taking earlier values
+func TestFormModel_Suggestions(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:   "kubectl -n <namespace> --context <context> get <resource>",
+		Variables: []models.Variable{{Name: "namespace"}, {Name: "context"}, {Name: "resource"}},
+	}
+	form := newFormModel(snippet, nil, nil, nil)
+	form.setSuggestions(map[string][]string{"namespace": {"payments", "kube-system", "kube-public"}, "context": {"dev"}})
+	var model tea.Model = form
+	update := func(msgs ...tea.Msg) formModel {
+		for _, msg := range msgs {
+			model, _ = model.Update(msg)
+		}
+		return model.(formModel)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+	ctrlN := tea.KeyMsg{Type: tea.KeyCtrlN}
+	ctrlP := tea.KeyMsg{Type: tea.KeyCtrlP}
+	tab := tea.KeyMsg{Type: tea.KeyTab}
+
+	if view := update().View(); !strings.Contains(view, "Recent") || !strings.Contains(view, "kube-public") {
+		t.Errorf("Expected the earlier values listed, got:\n%s", view)
+	}
+
+	form = update(runes("kube"))
+	if view := form.View(); strings.Contains(view, "payments") || !strings.Contains(view, "kube-system") {
+		t.Errorf("Expected suggestions to follow what is typed, got:\n%s", view)
+	}
+
+	form = update(ctrlN, ctrlN, ctrlN, ctrlP)
+	if !form.fields[0].suggesting || form.fields[0].suggestion != 0 {
+		t.Fatalf("Expected the first match highlighted, got %d", form.fields[0].suggestion)
+	}
+	if view := form.View(); !strings.Contains(view, "> kube-system") {
+		t.Errorf("Expected the highlighted suggestion marked, got:\n%s", view)
+	}
+
+	form = update(tab)
+	if form.focusIndex != 0 || form.fields[0].value != "kube-system" || form.fields[0].cursorPos != len("kube-system") {
+		t.Fatalf("Expected Tab to take the suggestion and stay, got %q on field %d", form.fields[0].value, form.focusIndex)
+	}
+	if form = update(tea.KeyMsg{Type: tea.KeyCtrlZ}); form.fields[0].value != "kube" {
+		t.Errorf("Expected taking a suggestion to be undoable, got %q", form.fields[0].value)
+	}
+
+	form = update(tab)
+	if form.focusIndex != 1 {
+		t.Errorf("Expected Tab without a highlighted suggestion to move on, focused %d", form.focusIndex)
+	}
+	form = update(ctrlN, ctrlP, ctrlP)
+	if form.fields[1].suggesting || !form.previewExpanded {
+		t.Errorf("Expected Ctrl+P past the top to leave the list and then toggle the preview")
+	}
+
+	form = update(tab)
+	if view := form.View(); strings.Contains(view, "Recent") {
+		t.Errorf("Expected nothing for a field without history, got:\n%s", view)
+	}
+}
+
+// TestFormModel_SummaryShortSecret tests that a secret is masked by what
+// its placeholder renders to, leaving text that happens to match it alone
+func TestFormModel_SummaryShortSecret(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:       "mysql -h db1 -P 3306 <password>",
+		ConfirmValues: true,
+		Variables: []models.Variable{
+			{Name: "password", Type: models.VarTypeSecret, DefaultValue: "1", Transform: &models.Transform{ValuePattern: "--password='{{.Value}}'"}},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	view := model.View()
+	if want := "mysql -h db1 -P 3306 --password='" + secretPreview + "'"; !strings.Contains(view, want) {
+		t.Errorf("Expected %q in the summary, got:\n%s", want, view)
+	}
+}
+
+// TestFormModel_Summary tests the review screen shown before submitting a
+// snippet with confirm_values
+func TestFormModel_Summary(t *testing.T) {
+	snippet := &models.Snippet{
+		Command:       "psql <verbose> -h <host> -W <password>",
+		ConfirmValues: true,
+		Variables: []models.Variable{
+			{Name: "host", DefaultValue: "db1"},
+			{Name: "verbose", Type: models.VarTypeBoolean, Transform: &models.Transform{TrueValue: "-v", FalseValue: ""}},
+			{Name: "password", Type: models.VarTypeSecret, DefaultValue: "hunter2"},
+		},
+	}
+	var model tea.Model = newFormModel(snippet, nil, nil, nil)
+	update := func(keys ...tea.KeyMsg) formModel {
+		for _, key := range keys {
+			model, _ = model.Update(key)
+		}
+		return model.(formModel)
+	}
+	enter := tea.KeyMsg{Type: tea.KeyEnter}
+
+	form := update(enter, enter, enter)
+	if form.state != formSummary {
+		t.Fatalf("Expected the summary after submitting, got state %v", form.state)
+	}
+	view := form.View()
+	for _, want := range []string{"host      →  db1", "verbose   →  (empty)", "psql  -h db1 -W " + secretPreview} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected %q in the summary, got:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "hunter2") {
+		t.Errorf("Expected the secret masked, got:\n%s", view)
+	}
+
+	form = update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if form.state != formEditing || form.fields[0].value != "db1" {
+		t.Fatalf("Expected e to go back to the filled-in form, got state %v", form.state)
+	}
+	if form = update(enter, enter, enter, enter); form.state != formDone {
+		t.Errorf("Expected Enter on the summary to accept, got state %v", form.state)
+	}
+
+	model = newFormModel(snippet, nil, nil, nil)
+	if form = update(enter, enter, enter, tea.KeyMsg{Type: tea.KeyEsc}); form.state != formCancelled {
+		t.Errorf("Expected Esc on the summary to cancel, got state %v", form.state)
+	}
+
+	snippet.ConfirmValues = false
+	model = newFormModel(snippet, nil, nil, &models.Config{Settings: models.Settings{Interactive: models.InteractiveConfig{ShowSummary: true}}})
+	if form = update(enter, enter, enter); form.state != formSummary {
+		t.Errorf("Expected show_summary to turn the summary on, got state %v", form.state)
+	}
+	model = newFormModel(snippet, nil, nil, nil)
+	if form = update(enter, enter, enter); form.state != formDone {
+		t.Errorf("Expected no summary by default, got state %v", form.state)
+	}
+}
@@ -1,10 +1,19 @@
 package template
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/kballard/go-shellquote"
 	"github.com/samling/command-snippets/internal/models"
 )
 
@@ -17,10 +26,79 @@ const (
 	PromptExecute                      // Prompt before executing (original behavior)
 )
 
+// String returns the short name used for the mode in history and output.
+func (m ExecutionMode) String() string {
+	switch m {
+	case PrintOnly:
+		return "print"
+	case AutoExecute:
+		return "run"
+	case PromptExecute:
+		return "prompt"
+	default:
+		return fmt.Sprintf("ExecutionMode(%d)", int(m))
+	}
+}
+
+// ParseExecutionMode is the inverse of ExecutionMode.String.
+func ParseExecutionMode(s string) (ExecutionMode, error) {
+	switch s {
+	case "print":
+		return PrintOnly, nil
+	case "run":
+		return AutoExecute, nil
+	case "prompt":
+		return PromptExecute, nil
+	default:
+		return PrintOnly, fmt.Errorf("unknown execution mode: %s", s)
+	}
+}
+
+// Result describes a rendered snippet: the final command, the values the
+// user supplied for it, the directory it runs in, and how it was handled.
+type Result struct {
+	Command string
+	Values  map[string]string
+	Workdir string // resolved snippet workdir; empty runs in the current directory
+	Mode    ExecutionMode
+	// Steps holds the rendered commands of a multi-step snippet, which are
+	// executed one at a time; Command is then the steps joined for printing.
+	Steps           []string
+	ContinueOnError bool
+	Timeout         time.Duration // limit on execution; zero means none
+	// PreCommand and PostCommand are the snippet's rendered hooks, run
+	// around the command when it is executed; empty when not set.
+	PreCommand  string
+	PostCommand string
+	// Dangerous makes the confirmation for PromptExecute stand out.
+	Dangerous bool
+	// Executed is set by Execute once the command is run, whether or not
+	// it succeeds; it stays false when only printed or when declined.
+	Executed bool
+}
+
+// PrintableCommand returns the command as it should be printed or copied:
+// prefixed with `cd <workdir> && ` when the snippet has a workdir, so it
+// runs in the same place when pasted.
+func (r *Result) PrintableCommand() string {
+	if r.Workdir == "" {
+		return r.Command
+	}
+	return "cd " + shellquote.Join(r.Workdir) + " && " + r.Command
+}
+
 // Processor handles snippet template processing
 type Processor struct {
 	config  *models.Config
 	NoColor bool
+	// NonInteractive resolves variables from presets and defaults only;
+	// the form is never shown.
+	NonInteractive bool
+	// HideCommand suppresses echoing the command before AutoExecute runs it.
+	HideCommand bool
+	// Suggestions are values previously entered for each variable, most
+	// recent first, which the form offers for text fields.
+	Suggestions map[string][]string
 }
 
 // NewProcessor creates a new template processor
@@ -30,46 +108,116 @@ func NewProcessor(config *models.Config) *Processor {
 	}
 }
 
-// ExecuteWithModeAndPresets prompts for variables (skipping preset ones) and handles execution
-func (p *Processor) ExecuteWithModeAndPresets(snippet *models.Snippet, mode ExecutionMode, presetValues map[string]string) error {
+// Render prompts for variables (pre-filling preset ones) and renders the
+// command without printing or executing it. Invalid presets are an error
+// in non-interactive mode; otherwise the form opens with them flagged.
+func (p *Processor) Render(snippet *models.Snippet, presetValues map[string]string) (*Result, error) {
+	snippet = p.withDynamicDefaults(snippet, presetValues)
 	values, err := p.promptForVariablesWithPresets(snippet, presetValues)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	command, err := snippet.ProcessTemplate(values, p.config)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	// With strict_placeholders ProcessTemplate has already failed on these.
+	if err := snippet.CheckPlaceholders(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	workdir, err := snippet.ResolveWorkdir(values, p.config)
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := snippet.ResolveTimeout(p.config)
+	if err != nil {
+		return nil, err
+	}
+	pre, post, err := snippet.ResolveHooks(values, p.config)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Command: command, Values: values, Workdir: workdir, Timeout: timeout, PreCommand: pre, PostCommand: post}
+	if len(snippet.Commands) > 0 {
+		result.Steps, err = snippet.ProcessSteps(values, p.config)
+		if err != nil {
+			return nil, err
+		}
+		result.ContinueOnError = snippet.ContinueOnError
+	}
+	return result, nil
+}
+
+// Execute handles a rendered command according to its mode. PrintOnly does
+// nothing, leaving output to the caller; AutoExecute runs the command and
+// PromptExecute asks for confirmation first. Commands run in the result's
+// workdir, which must exist.
+func (p *Processor) Execute(result *Result) error {
+	command := result.Command
+	if result.Mode != PrintOnly && result.Workdir != "" {
+		if err := checkWorkdir(result.Workdir); err != nil {
+			return err
+		}
 	}
 
-	// Handle execution based on mode
-	switch mode {
+	switch result.Mode {
 	case PrintOnly:
-		// Print just the raw command (perfect for piping)
-		fmt.Print(command)
 		return nil
 
 	case AutoExecute:
 		// Show command with prefix, then execute
-		fmt.Fprintf(os.Stderr, "Command: %s\n", command)
-		return p.executeCommand(command)
+		if !p.HideCommand {
+			fmt.Fprintf(os.Stderr, "Command: %s\n", command)
+		}
+		result.Executed = true
+		return p.executeResult(result)
 
 	case PromptExecute:
 		// Show command with prefix, then ask for confirmation
-		fmt.Fprintf(os.Stderr, "Command: %s\n", command)
+		if result.PreCommand != "" {
+			fmt.Fprintf(os.Stderr, "Pre-command: %s\n", result.PreCommand)
+		}
+		message := "Execute this command?"
+		if result.Dangerous {
+			SetupColorProfile(p.NoColor)
+			fmt.Fprintln(os.Stderr, errorStyle.Render("Command: "+command))
+			message = errorStyle.Render("This snippet is marked dangerous. Execute this command?")
+		} else {
+			fmt.Fprintf(os.Stderr, "Command: %s\n", command)
+		}
+		if result.PostCommand != "" {
+			fmt.Fprintf(os.Stderr, "Post-command: %s\n", result.PostCommand)
+		}
 
-		confirm, err := promptForConfirmation("Execute this command?", p.NoColor)
+		confirm, err := promptForConfirmation(message, p.NoColor)
 		if err != nil {
 			return err
 		}
 		if !confirm {
 			return nil
 		}
-		return p.executeCommand(command)
+		result.Executed = true
+		return p.executeResult(result)
 
 	default:
-		return fmt.Errorf("unknown execution mode: %v", mode)
+		return fmt.Errorf("unknown execution mode: %v", result.Mode)
+	}
+}
+
+// checkWorkdir reports a clear error when dir is missing or not a directory.
+func checkWorkdir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("workdir %s does not exist", dir)
+		}
+		return fmt.Errorf("workdir %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("workdir %s is not a directory", dir)
 	}
+	return nil
 }
 
 // ProcessSnippet processes a snippet with given values (non-interactive)
@@ -79,23 +227,307 @@ func (p *Processor) ProcessSnippet(snippet *models.Snippet, values map[string]st
 
 // promptForVariablesWithPresets interactively prompts for snippet variables, using preset values where available
 func (p *Processor) promptForVariablesWithPresets(snippet *models.Snippet, presetValues map[string]string) (map[string]string, error) {
-	return promptForVariablesWithBubbleTea(snippet, presetValues, p.config, p.NoColor)
+	if p.NonInteractive {
+		if err := validatePresets(snippet, presetValues, p.config); err != nil {
+			return nil, err
+		}
+		return resolveVariablesNonInteractive(snippet, presetValues, p.config)
+	}
+	return promptForVariablesWithBubbleTea(snippet, presetValues, presetErrors(snippet, presetValues, p.config), p.Suggestions, p.config, p.NoColor)
 }
 
-// executeCommand runs the command through the user's shell so quoting,
-// pipes, redirection, and `&&` chains behave as a user would expect.
-func (p *Processor) executeCommand(command string) error {
-	fmt.Fprintf(os.Stderr, "Executing: %s\n", command)
+// validatePresets checks every preset value against its variable's
+// definition before anything is shown or rendered, reporting all invalid
+// values at once. Presets for unknown or computed variables are ignored.
+func validatePresets(snippet *models.Snippet, presetValues map[string]string, config *models.Config) error {
+	errs := presetErrors(snippet, presetValues, config)
+	var invalid []string
+	for _, variable := range snippet.Variables {
+		if msg, ok := errs[variable.Name]; ok {
+			invalid = append(invalid, fmt.Sprintf("%s=%q: %s", variable.Name, presetValues[variable.Name], msg))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid preset values:\n  %s", strings.Join(invalid, "\n  "))
+	}
+	return nil
+}
 
-	shell := os.Getenv("SHELL")
-	if shell == "" {
-		shell = "/bin/sh"
+// presetErrors validates each preset value, returning the error message for
+// every variable whose preset is invalid. Presets for variables hidden by
+// their when condition are not checked.
+func presetErrors(snippet *models.Snippet, presetValues map[string]string, config *models.Config) map[string]string {
+	hidden, _ := snippet.HiddenVariables(initialValues(snippet, presetValues, config))
+	errs := make(map[string]string)
+	for _, variable := range snippet.Variables {
+		value, ok := presetValues[variable.Name]
+		if !ok || variable.Computed || hidden[variable.Name] {
+			continue
+		}
+		if err := variable.ValidateWithConfig(value, config); err != nil {
+			errs[variable.Name] = err.Error()
+		}
+	}
+	return errs
+}
+
+// initialValues fills each non-computed variable from its preset or default,
+// as the form would start out.
+func initialValues(snippet *models.Snippet, presetValues map[string]string, config *models.Config) map[string]string {
+	values := make(map[string]string, len(snippet.Variables))
+	for _, variable := range snippet.Variables {
+		if variable.Computed {
+			continue
+		}
+		value, ok := presetValues[variable.Name]
+		if !ok {
+			value = variable.ResolveDefault(config)
+		}
+		if value == "" && variable.Type == models.VarTypeBoolean {
+			value = "false"
+		}
+		values[variable.Name] = value
+	}
+	return values
+}
+
+// resolveVariablesNonInteractive fills each non-computed variable from its
+// preset or default and validates the result, skipping variables hidden by
+// their when condition. Every missing required variable, including those
+// required by a required_if condition, is reported in a single error.
+func resolveVariablesNonInteractive(snippet *models.Snippet, presetValues map[string]string, config *models.Config) (map[string]string, error) {
+	values := initialValues(snippet, presetValues, config)
+	hidden, err := snippet.HiddenVariables(values)
+	if err != nil {
+		return nil, err
+	}
+	for name := range hidden {
+		values[name] = ""
+	}
+
+	var missing []string
+	var invalid []string
+	for _, variable := range snippet.Variables {
+		if variable.Computed || hidden[variable.Name] {
+			continue
+		}
+		value := values[variable.Name]
+		if value == "" {
+			required, err := variable.IsRequired(values)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case variable.Required:
+				missing = append(missing, variable.Name)
+			case required:
+				missing = append(missing, fmt.Sprintf("%s (required_if %s)", variable.Name, variable.RequiredIf))
+			}
+			continue
+		}
+		if err := variable.ValidateWithConfig(value, config); err != nil {
+			invalid = append(invalid, err.Error())
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing values for required variables: %s (provide them with --set, --values-file, or a default)", strings.Join(missing, ", "))
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid variable values:\n  %s", strings.Join(invalid, "\n  "))
+	}
+	return values, nil
+}
+
+// executeResult runs the result's pre-command, then its command or each of
+// its steps in order, then its post-command. A failing pre-command skips
+// the rest. The post-command always runs, with CS_EXIT_CODE set to the
+// command's exit status; its failure is only reported when the command
+// succeeded.
+func (p *Processor) executeResult(result *Result) error {
+	if result.PreCommand != "" {
+		if err := p.executeCommand(context.Background(), result.PreCommand, result.Workdir, nil); err != nil {
+			return fmt.Errorf("pre_command failed: %w", err)
+		}
+	}
+
+	err := p.executeMain(result)
+	if result.PostCommand == "" {
+		return err
+	}
+
+	code := 0
+	if err != nil {
+		code = 1
+		var exitErr *ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+		}
+	}
+	env := []string{fmt.Sprintf("CS_EXIT_CODE=%d", code)}
+	if postErr := p.executeCommand(context.Background(), result.PostCommand, result.Workdir, env); postErr != nil {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: post_command failed: %v\n", postErr)
+			return err
+		}
+		return fmt.Errorf("post_command failed: %w", postErr)
+	}
+	return err
+}
+
+// executeMain runs the result's command, or each of its steps in order.
+// Steps stop at the first failure unless ContinueOnError is set, in which
+// case the remaining steps still run and the first failure is returned.
+// A timeout covers all steps together.
+func (p *Processor) executeMain(result *Result) error {
+	ctx := context.Background()
+	if result.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, result.Timeout)
+		defer cancel()
+	}
+
+	run := func(command string) error {
+		err := p.executeCommand(ctx, command, result.Workdir, nil)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("command timed out after %s and was killed", result.Timeout)
+		}
+		return err
 	}
 
-	cmd := exec.Command(shell, "-c", command)
+	if len(result.Steps) == 0 {
+		return run(result.Command)
+	}
+
+	var firstErr error
+	for i, step := range result.Steps {
+		err := run(step)
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("step %d of %d failed: %w", i+1, len(result.Steps), err)
+		if !result.ContinueOnError {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// executeCommand runs the command through the configured shell so quoting,
+// pipes, redirection, and `&&` chains behave as a user would expect. A
+// non-empty dir sets the working directory. When ctx has a deadline the
+// command runs in its own process group (see runInProcessGroup), which is
+// killed as a whole once the deadline passes. env is added to the
+// environment the command inherits.
+func (p *Processor) executeCommand(ctx context.Context, command, dir string, env []string) error {
+	if !p.HideCommand {
+		fmt.Fprintf(os.Stderr, "Executing: %s\n", command)
+	}
+
+	cmd := p.shellCommandContext(ctx, command)
+	cmd.Dir = dir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
-	return cmd.Run()
+	run := cmd.Run
+	if _, ok := ctx.Deadline(); ok {
+		run = func() error { return runInProcessGroup(cmd) }
+	}
+	err := run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitError{Code: exitCode(exitErr)}
+	}
+	return err
+}
+
+// ExitError reports that an executed command exited with a non-zero status.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("command exited with status %d", e.Code)
+}
+
+// exitCode returns the child's exit status, or 128+n when it was killed by
+// signal n, as shells report it.
+func exitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return err.ExitCode()
+}
+
+// shellCommand builds `<shell> <shell args> <command>` from the execution
+// settings, falling back to defaults for the running OS (see shellArgv).
+func (p *Processor) shellCommand(command string) *exec.Cmd {
+	return p.shellCommandContext(context.Background(), command)
+}
+
+// shellCommandContext is shellCommand with a context that kills the
+// command when done.
+func (p *Processor) shellCommandContext(ctx context.Context, command string) *exec.Cmd {
+	var settings models.ExecutionConfig
+	if p.config != nil {
+		settings = p.config.Settings.Execution
+	}
+	argv := shellArgv(settings, runtime.GOOS, os.Getenv, command)
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	passCommandLineVerbatim(cmd, argv)
+	return cmd
+}
+
+// shellArgv returns the argv that runs command. The shell is
+// settings.Shell, else $SHELL then sh, or cmd on Windows; its arguments are
+// settings.ShellArgs, else defaultShellArgs. goos and getenv are parameters
+// so every platform can be tested without spawning a shell.
+func shellArgv(settings models.ExecutionConfig, goos string, getenv func(string) string, command string) []string {
+	shell := settings.Shell
+	if shell == "" {
+		if goos == "windows" {
+			shell = "cmd"
+		} else {
+			shell = cmp.Or(getenv("SHELL"), "sh")
+		}
+	}
+
+	args := settings.ShellArgs
+	if args == nil {
+		args = defaultShellArgs(shell)
+	}
+
+	argv := make([]string, 0, len(args)+2)
+	argv = append(argv, shell)
+	argv = append(argv, args...)
+	return append(argv, command)
+}
+
+// defaultShellArgs returns the arguments that make shell run a command
+// string: /C for cmd, -NoProfile -Command for PowerShell, and -c otherwise.
+func defaultShellArgs(shell string) []string {
+	switch shellName(shell) {
+	case "cmd":
+		return []string{"/C"}
+	case "powershell", "pwsh":
+		return []string{"-NoProfile", "-Command"}
+	default:
+		return []string{"-c"}
+	}
+}
+
+// shellName returns the lower-cased base name of shell without an .exe
+// suffix, accepting both slash styles so Windows paths work everywhere.
+func shellName(shell string) string {
+	name := shell[strings.LastIndexAny(shell, `/\`)+1:]
+	return strings.TrimSuffix(strings.ToLower(name), ".exe")
 }
@@ -1,9 +1,12 @@
 package template
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/samling/command-snippets/internal/models"
 	"gopkg.in/yaml.v3"
@@ -739,3 +742,645 @@ func TestProcessSnippet_RegexType(t *testing.T) {
 		})
 	}
 }
+
+// TestShellCommand tests that commands run through a shell
+func TestShellCommand(t *testing.T) {
+	t.Setenv("CS_TEST_GREETING", "hello")
+	processor := NewProcessor(&models.Config{
+		Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+	})
+
+	tests := []struct {
+		name     string
+		command  string
+		expected string
+	}{
+		{
+			name:     "quoted args",
+			command:  `printf '%s|' 'a b' "c  d"`,
+			expected: "a b|c  d|",
+		},
+		{
+			name:     "pipe",
+			command:  `printf 'one\ntwo\nthree\n' | wc -l | tr -d ' '`,
+			expected: "3\n",
+		},
+		{
+			name:     "and chain",
+			command:  `true && echo ok`,
+			expected: "ok\n",
+		},
+		{
+			name:     "environment expansion",
+			command:  `echo "$CS_TEST_GREETING world"`,
+			expected: "hello world\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := processor.shellCommand(tt.command).Output()
+			if err != nil {
+				t.Fatalf("command failed: %v", err)
+			}
+			if string(output) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(output))
+			}
+		})
+	}
+}
+
+// TestShellCommand_ShellSelection tests the shell fallback order
+func TestShellCommand_ShellSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		envShell   string
+		expected   string
+	}{
+		{name: "configured shell wins", configured: "/bin/bash", envShell: "/bin/zsh", expected: "/bin/bash"},
+		{name: "falls back to SHELL", envShell: "/bin/zsh", expected: "/bin/zsh"},
+		{name: "falls back to sh", expected: "sh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SHELL", tt.envShell)
+			processor := NewProcessor(&models.Config{
+				Settings: models.Settings{Execution: models.ExecutionConfig{Shell: tt.configured}},
+			})
+
+			cmd := processor.shellCommand("true")
+			if cmd.Args[0] != tt.expected || cmd.Args[1] != "-c" || cmd.Args[2] != "true" {
+				t.Errorf("Expected [%s -c true], got %v", tt.expected, cmd.Args)
+			}
+		})
+	}
+}
+
+// TestResolveVariablesNonInteractive tests resolving variables without the form
+func TestResolveVariablesNonInteractive(t *testing.T) {
+	config := loadTestConfig(t)
+
+	tests := []struct {
+		name        string
+		snippetID   string
+		presets     map[string]string
+		expected    map[string]string
+		errContains []string
+	}{
+		{
+			name:      "presets and defaults",
+			snippetID: "simple-with-vars",
+			presets:   map[string]string{"message": "Hello"},
+			expected:  map[string]string{"message": "Hello", "name": "World"},
+		},
+		{
+			name:      "preset overrides default",
+			snippetID: "simple-with-vars",
+			presets:   map[string]string{"message": "Hi", "name": "there"},
+			expected:  map[string]string{"message": "Hi", "name": "there"},
+		},
+		{
+			name:        "missing required variable",
+			snippetID:   "simple-with-vars",
+			errContains: []string{"missing values for required variables: message"},
+		},
+		{
+			name:      "boolean defaults to false",
+			snippetID: "snippet-with-boolean",
+			presets:   map[string]string{"verbose": "true"},
+			expected:  map[string]string{"verbose": "true", "debug": "false"},
+		},
+		{
+			name:        "type validation still runs",
+			snippetID:   "snippet-with-range",
+			presets:     map[string]string{"port": "99999"},
+			errContains: []string{"invalid variable values", "port"},
+		},
+		{
+			name:      "computed variables are skipped",
+			snippetID: "snippet-with-computed-simple",
+			presets:   map[string]string{"resource_name": "web"},
+			expected:  map[string]string{"resource_type": "pod", "resource_name": "web"},
+		},
+		{
+			name:      "hidden variables are not required",
+			snippetID: "snippet-with-when",
+			presets:   map[string]string{"url": "example.com"},
+			expected:  map[string]string{"url": "example.com", "tls": "false", "cert": ""},
+		},
+		{
+			name:        "shown variables are required",
+			snippetID:   "snippet-with-when",
+			presets:     map[string]string{"url": "example.com", "tls": "true"},
+			errContains: []string{"missing values for required variables: cert"},
+		},
+		{
+			name:      "required_if not met",
+			snippetID: "snippet-with-required-if",
+			expected:  map[string]string{"environment": "dev", "image_tag": ""},
+		},
+		{
+			name:        "required_if met",
+			snippetID:   "snippet-with-required-if",
+			presets:     map[string]string{"environment": "prod"},
+			errContains: []string{"missing values for required variables: image_tag (required_if environment=prod)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := config.Snippets[tt.snippetID]
+			values, err := resolveVariablesNonInteractive(&snippet, tt.presets, config)
+
+			if len(tt.errContains) > 0 {
+				if err == nil {
+					t.Fatalf("Expected error, got values %v", values)
+				}
+				for _, want := range tt.errContains {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("Expected error to contain %q, got %q", want, err.Error())
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(values) != len(tt.expected) {
+				t.Errorf("Expected %d values, got %v", len(tt.expected), values)
+			}
+			for k, want := range tt.expected {
+				if values[k] != want {
+					t.Errorf("Expected %s=%q, got %q", k, want, values[k])
+				}
+			}
+		})
+	}
+}
+
+// TestValidatePresets tests that preset values are validated before rendering
+func TestValidatePresets(t *testing.T) {
+	config := loadTestConfig(t)
+
+	tests := []struct {
+		name        string
+		snippetID   string
+		presets     map[string]string
+		errContains []string
+	}{
+		{
+			name:      "valid enum",
+			snippetID: "snippet-with-enum",
+			presets:   map[string]string{"log_level": "warn"},
+		},
+		{
+			name:        "invalid enum lists allowed values",
+			snippetID:   "snippet-with-enum",
+			presets:     map[string]string{"log_level": "bogus"},
+			errContains: []string{`log_level="bogus"`, "debug, info, warn, error"},
+		},
+		{
+			name:        "out of range",
+			snippetID:   "snippet-with-range",
+			presets:     map[string]string{"port": "70000"},
+			errContains: []string{"port", "between 1 and 65535"},
+		},
+		{
+			name:        "pattern mismatch",
+			snippetID:   "snippet-with-pattern",
+			presets:     map[string]string{"version": "latest"},
+			errContains: []string{"version", "must match the pattern"},
+		},
+		{
+			name:        "invalid regex type",
+			snippetID:   "snippet-with-regex-type",
+			presets:     map[string]string{"pattern": "([a-z"},
+			errContains: []string{"pattern", "valid regular expression"},
+		},
+		{
+			name:        "every invalid key is reported",
+			snippetID:   "snippet-with-all-features",
+			presets:     map[string]string{"port": "0", "log_level": "loud", "verbose": "true"},
+			errContains: []string{`port="0"`, `log_level="loud"`},
+		},
+		{
+			name:      "unknown keys are ignored",
+			snippetID: "snippet-with-all-features",
+			presets:   map[string]string{"nonexistent": "x"},
+		},
+		{
+			name:      "unset variables are not validated",
+			snippetID: "simple-with-vars",
+			presets:   map[string]string{"name": "there"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet := config.Snippets[tt.snippetID]
+			err := validatePresets(&snippet, tt.presets, config)
+			if len(tt.errContains) == 0 {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected error, got nil")
+			}
+			for _, want := range tt.errContains {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Expected error to contain %q, got %q", want, err.Error())
+				}
+			}
+		})
+	}
+}
+
+// TestPrintableCommand tests the cd prefix for snippets with a workdir
+func TestPrintableCommand(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   Result
+		expected string
+	}{
+		{name: "no workdir", result: Result{Command: "ls"}, expected: "ls"},
+		{name: "workdir", result: Result{Command: "ls", Workdir: "/srv/infra"}, expected: "cd /srv/infra && ls"},
+		{name: "workdir with spaces", result: Result{Command: "ls", Workdir: "/srv/my infra"}, expected: "cd '/srv/my infra' && ls"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.result.PrintableCommand(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestExecute_Workdir tests that commands run in the workdir and that a
+// missing workdir fails before execution
+func TestExecute_Workdir(t *testing.T) {
+	dir := t.TempDir()
+	processor := NewProcessor(&models.Config{
+		Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+	})
+	processor.HideCommand = true
+
+	err := processor.Execute(&Result{Command: "touch ran", Workdir: dir, Mode: AutoExecute})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran")); err != nil {
+		t.Errorf("Expected command to run in %s: %v", dir, err)
+	}
+
+	missing := filepath.Join(dir, "missing")
+	err = processor.Execute(&Result{Command: "touch ran", Workdir: missing, Mode: AutoExecute})
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected missing workdir error, got %v", err)
+	}
+}
+
+// TestExecute_Steps tests sequential execution of multi-step snippets
+func TestExecute_Steps(t *testing.T) {
+	tests := []struct {
+		name            string
+		continueOnError bool
+		wantFiles       []string
+		missingFiles    []string
+	}{
+		{name: "stops on failure", wantFiles: []string{"one"}, missingFiles: []string{"three"}},
+		{name: "continue on error", continueOnError: true, wantFiles: []string{"one", "three"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			processor := NewProcessor(&models.Config{
+				Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+			})
+			processor.HideCommand = true
+
+			err := processor.Execute(&Result{
+				Command:         "touch one && false && touch three",
+				Steps:           []string{"touch one", "false", "touch three"},
+				ContinueOnError: tt.continueOnError,
+				Workdir:         dir,
+				Mode:            AutoExecute,
+			})
+			if err == nil || !strings.Contains(err.Error(), "step 2 of 3 failed") {
+				t.Errorf("Expected step 2 failure, got %v", err)
+			}
+			for _, name := range tt.wantFiles {
+				if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+					t.Errorf("Expected %s to be created: %v", name, err)
+				}
+			}
+			for _, name := range tt.missingFiles {
+				if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+					t.Errorf("Expected %s not to be created", name)
+				}
+			}
+		})
+	}
+}
+
+// TestExecute_Timeout tests that a command running past its timeout is killed
+func TestExecute_Timeout(t *testing.T) {
+	processor := NewProcessor(&models.Config{
+		Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+	})
+	processor.HideCommand = true
+
+	start := time.Now()
+	err := processor.Execute(&Result{Command: "sleep 5; sleep 5", Timeout: 100 * time.Millisecond, Mode: AutoExecute})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected command to be killed promptly, took %s", elapsed)
+	}
+
+	if err := processor.Execute(&Result{Command: "true", Timeout: time.Minute, Mode: AutoExecute}); err != nil {
+		t.Errorf("Expected command within timeout to succeed, got %v", err)
+	}
+}
+
+// TestExecute_ExitCode tests that a failing command reports its exit status
+func TestExecute_ExitCode(t *testing.T) {
+	processor := NewProcessor(&models.Config{
+		Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+	})
+	processor.HideCommand = true
+
+	tests := []struct {
+		name     string
+		result   Result
+		expected int
+	}{
+		{name: "exit status", result: Result{Command: "exit 3"}, expected: 3},
+		{name: "killed by signal", result: Result{Command: "kill -TERM $$"}, expected: 143},
+		{name: "failed step", result: Result{Command: "true && exit 4", Steps: []string{"true", "exit 4"}}, expected: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.result.Mode = AutoExecute
+			err := processor.Execute(&tt.result)
+
+			var exitErr *ExitError
+			if !errors.As(err, &exitErr) {
+				t.Fatalf("Expected *ExitError, got %v", err)
+			}
+			if exitErr.Code != tt.expected {
+				t.Errorf("Expected exit code %d, got %d", tt.expected, exitErr.Code)
+			}
+		})
+	}
+}
+
+// TestExecute_Hooks tests that pre and post commands run around the command
+func TestExecute_Hooks(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    Result
+		wantErr   string
+		wantFiles map[string]string // file name to expected contents
+		missing   []string
+	}{
+		{
+			name:      "both hooks run",
+			result:    Result{PreCommand: "echo pre > log", Command: "echo main >> log", PostCommand: "echo post $CS_EXIT_CODE >> log"},
+			wantFiles: map[string]string{"log": "pre\nmain\npost 0\n"},
+		},
+		{
+			name:    "failing pre-command skips the rest",
+			result:  Result{PreCommand: "false", Command: "touch main", PostCommand: "touch post"},
+			wantErr: "pre_command failed",
+			missing: []string{"main", "post"},
+		},
+		{
+			name:      "post-command sees the exit code",
+			result:    Result{Command: "exit 3", PostCommand: "echo $CS_EXIT_CODE > code"},
+			wantErr:   "status 3",
+			wantFiles: map[string]string{"code": "3\n"},
+		},
+		{
+			name:      "pre-command runs without an exit code",
+			result:    Result{PreCommand: "echo ${CS_EXIT_CODE-unset} > pre", Command: "true"},
+			wantFiles: map[string]string{"pre": "unset\n"},
+		},
+		{
+			name:      "post-command sees a signal exit code",
+			result:    Result{Command: "kill -TERM $$", PostCommand: "echo $CS_EXIT_CODE > code"},
+			wantErr:   "status 143",
+			wantFiles: map[string]string{"code": "143\n"},
+		},
+		{
+			name:      "post-command sees a failed step's exit code",
+			result:    Result{Command: "true && exit 4", Steps: []string{"true", "exit 4", "true"}, PostCommand: "echo $CS_EXIT_CODE > code"},
+			wantErr:   "step 2 of 3 failed",
+			wantFiles: map[string]string{"code": "4\n"},
+		},
+		{
+			name:    "failing post-command",
+			result:  Result{Command: "true", PostCommand: "false"},
+			wantErr: "post_command failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			processor := NewProcessor(&models.Config{
+				Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+			})
+			processor.HideCommand = true
+
+			tt.result.Workdir = dir
+			tt.result.Mode = AutoExecute
+			err := processor.Execute(&tt.result)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+			}
+			for name, expected := range tt.wantFiles {
+				data, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatalf("Expected %s to be written: %v", name, err)
+				}
+				if string(data) != expected {
+					t.Errorf("Expected %s to contain %q, got %q", name, expected, data)
+				}
+			}
+			for _, name := range tt.missing {
+				if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+					t.Errorf("Expected %s not to be created", name)
+				}
+			}
+		})
+	}
+}
+
+// TestRenderExecute tests that executing a rendered snippet runs exactly
+// the commands Render produced, with the hooks and workdir it resolved
+func TestRenderExecute(t *testing.T) {
+	variables := []models.Variable{
+		{Name: "dir", Required: true},
+		{Name: "name", DefaultValue: "world", Transform: &models.Transform{ValuePattern: "--name={{.Value}}"}},
+		{Name: "verbose", Type: "boolean", Transform: &models.Transform{TrueValue: "-v", FalseValue: ""}},
+	}
+	tests := []struct {
+		name    string
+		snippet models.Snippet
+		command string
+		log     string
+	}{
+		{
+			name: "command",
+			snippet: models.Snippet{
+				Command:     "echo 'main <name> <verbose>' >> log",
+				PreCommand:  "echo 'pre <name>' > log",
+				PostCommand: "echo \"post <name> $CS_EXIT_CODE\" >> log",
+			},
+			command: "echo 'main --name=bob -v' >> log",
+			log:     "pre --name=bob\nmain --name=bob -v\npost --name=bob 0\n",
+		},
+		{
+			name: "steps",
+			snippet: models.Snippet{
+				Commands:    []string{"echo 'one <name>' >> log", "echo 'two <verbose>' >> log"},
+				PostCommand: "echo \"post $CS_EXIT_CODE\" >> log",
+			},
+			command: "echo 'one --name=bob' >> log && echo 'two -v' >> log",
+			log:     "one --name=bob\ntwo -v\npost 0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			processor := NewProcessor(&models.Config{
+				Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+			})
+			processor.NonInteractive = true
+			processor.HideCommand = true
+
+			snippet := tt.snippet
+			snippet.Variables = variables
+			snippet.Workdir = "<dir>"
+			result, err := processor.Render(&snippet, map[string]string{"dir": dir, "name": "bob", "verbose": "true"})
+			if err != nil {
+				t.Fatalf("Render failed: %v", err)
+			}
+			if result.Command != tt.command {
+				t.Errorf("Expected command %q, got %q", tt.command, result.Command)
+			}
+			if result.Workdir != dir {
+				t.Errorf("Expected workdir %q, got %q", dir, result.Workdir)
+			}
+
+			result.Mode = AutoExecute
+			if err := processor.Execute(result); err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if !result.Executed {
+				t.Error("Expected the result to be marked executed")
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "log"))
+			if err != nil {
+				t.Fatalf("Expected the commands to run in %s: %v", dir, err)
+			}
+			if string(data) != tt.log {
+				t.Errorf("Expected log %q, got %q", tt.log, data)
+			}
+
+			// Printing the same result runs nothing
+			if err := os.Remove(filepath.Join(dir, "log")); err != nil {
+				t.Fatal(err)
+			}
+			result.Mode, result.Executed = PrintOnly, false
+			if err := processor.Execute(result); err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "log")); err == nil || result.Executed {
+				t.Error("Expected nothing to run in print mode")
+			}
+		})
+	}
+}
+
+// TestShellArgv tests shell argument construction for each platform
+func TestShellArgv(t *testing.T) {
+	env := map[string]string{"SHELL": "/bin/zsh"}
+	getenv := func(name string) string { return env[name] }
+	noEnv := func(string) string { return "" }
+
+	tests := []struct {
+		name     string
+		settings models.ExecutionConfig
+		goos     string
+		getenv   func(string) string
+		expected []string
+	}{
+		{name: "linux uses SHELL", goos: "linux", getenv: getenv, expected: []string{"/bin/zsh", "-c", "echo hi"}},
+		{name: "darwin falls back to sh", goos: "darwin", getenv: noEnv, expected: []string{"sh", "-c", "echo hi"}},
+		{name: "windows defaults to cmd", goos: "windows", getenv: getenv, expected: []string{"cmd", "/C", "echo hi"}},
+		{
+			name:     "powershell gets -Command",
+			settings: models.ExecutionConfig{Shell: "powershell"},
+			goos:     "windows",
+			getenv:   noEnv,
+			expected: []string{"powershell", "-NoProfile", "-Command", "echo hi"},
+		},
+		{
+			name:     "pwsh path on linux",
+			settings: models.ExecutionConfig{Shell: "/usr/bin/pwsh"},
+			goos:     "linux",
+			getenv:   getenv,
+			expected: []string{"/usr/bin/pwsh", "-NoProfile", "-Command", "echo hi"},
+		},
+		{
+			name:     "windows path to cmd.exe",
+			settings: models.ExecutionConfig{Shell: `C:\Windows\System32\CMD.EXE`},
+			goos:     "windows",
+			getenv:   noEnv,
+			expected: []string{`C:\Windows\System32\CMD.EXE`, "/C", "echo hi"},
+		},
+		{
+			name:     "explicit shell args",
+			settings: models.ExecutionConfig{Shell: "powershell", ShellArgs: []string{"-Command"}},
+			goos:     "windows",
+			getenv:   noEnv,
+			expected: []string{"powershell", "-Command", "echo hi"},
+		},
+		{
+			name:     "shell args for SHELL",
+			settings: models.ExecutionConfig{ShellArgs: []string{"-l", "-c"}},
+			goos:     "linux",
+			getenv:   getenv,
+			expected: []string{"/bin/zsh", "-l", "-c", "echo hi"},
+		},
+		{
+			name:     "empty shell args",
+			settings: models.ExecutionConfig{Shell: "/usr/local/bin/runner", ShellArgs: []string{}},
+			goos:     "linux",
+			getenv:   getenv,
+			expected: []string{"/usr/local/bin/runner", "echo hi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shellArgv(tt.settings, tt.goos, tt.getenv, "echo hi")
+			if strings.Join(got, "\x00") != strings.Join(tt.expected, "\x00") {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !unix
+
+package template
+
+import "os/exec"
+
+// killProcessGroupOnCancel is a no-op where process groups are unavailable;
+// cancellation kills only the shell itself.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {}
+
+// runInProcessGroup runs cmd as is where process groups are unavailable.
+func runInProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Run()
+}
@@ -0,0 +1,74 @@
+//go:build unix
+
+package template
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// killProcessGroupOnCancel starts cmd in a new process group and makes
+// context cancellation kill the whole group, so anything the shell spawned
+// dies with it.
+func killProcessGroupOnCancel(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// runInProcessGroup runs cmd with killProcessGroupOnCancel. A new group is
+// left out of the terminal's foreground group, so when cs owns the terminal
+// the group is made the foreground one until cmd exits; Ctrl+C then reaches
+// it and it can read the terminal. SIGINT and SIGTERM sent to cs itself are
+// passed on to the group.
+func runInProcessGroup(cmd *exec.Cmd) error {
+	killProcessGroupOnCancel(cmd)
+
+	if tty, ok := foregroundTerminal(cmd.Stdin); ok {
+		cmd.SysProcAttr.Foreground = true
+		cmd.SysProcAttr.Ctty = tty
+		// Taking the terminal back while in the background raises SIGTTOU,
+		// which would otherwise stop cs.
+		signal.Ignore(syscall.SIGTTOU)
+		defer signal.Reset(syscall.SIGTTOU)
+		defer unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, unix.Getpgrp())
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				_ = syscall.Kill(-cmd.Process.Pid, sig.(syscall.Signal))
+			case <-done:
+				return
+			}
+		}
+	}()
+	return cmd.Wait()
+}
+
+// foregroundTerminal returns the descriptor of stdin when it is a terminal
+// whose foreground process group is cs's own.
+func foregroundTerminal(stdin any) (int, bool) {
+	f, ok := stdin.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	fd := int(f.Fd())
+	pgrp, err := unix.IoctlGetInt(fd, unix.TIOCGPGRP)
+	return fd, err == nil && pgrp == unix.Getpgrp()
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/samling/command-snippets/internal/models"
+)
+
+// TestExecute_TimeoutInterrupted tests that interrupting cs while a command
+// with a timeout runs stops the command and what it spawned, rather than
+// leaving them running in their own process group
+func TestExecute_TimeoutInterrupted(t *testing.T) {
+	processor := NewProcessor(&models.Config{
+		Settings: models.Settings{Execution: models.ExecutionConfig{Shell: "sh"}},
+	})
+	processor.HideCommand = true
+
+	pidFile := filepath.Join(t.TempDir(), "pid")
+	command := `sh -c 'echo $$ > ` + pidFile + `; exec sleep 30'`
+	errs := make(chan error, 1)
+	go func() {
+		errs <- processor.Execute(&Result{Command: command, Timeout: time.Minute, Mode: AutoExecute})
+	}()
+
+	var pid int
+	for deadline := time.Now().Add(5 * time.Second); pid == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Command did not start")
+		}
+		data, _ := os.ReadFile(pidFile)
+		pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+	}
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errs:
+		if err == nil || !strings.Contains(err.Error(), "status 130") {
+			t.Errorf("Expected the command to be interrupted, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the interrupt to stop the command")
+	}
+
+	if running(pid) {
+		t.Errorf("Expected the spawned process %d to be stopped", pid)
+	}
+}
+
+// running reports whether pid is a live process, not counting zombies
+// nothing has reaped yet.
+func running(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return syscall.Kill(pid, 0) == nil
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] != "Z"
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package template
+
+import "os/exec"
+
+// passCommandLineVerbatim is only needed for cmd.exe on Windows; elsewhere
+// arguments are passed to the shell as they are.
+func passCommandLineVerbatim(cmd *exec.Cmd, argv []string) {}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package template
+
+import (
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// passCommandLineVerbatim hands cmd.exe its arguments unescaped. cmd does
+// not follow the quoting rules exec uses to build a command line, so the
+// command string has to reach it as written.
+func passCommandLineVerbatim(cmd *exec.Cmd, argv []string) {
+	if shellName(argv[0]) != "cmd" {
+		return
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		CmdLine: syscall.EscapeArg(argv[0]) + " " + strings.Join(argv[1:], " "),
+	}
+}